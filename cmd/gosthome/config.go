@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	clive "github.com/ASMfreaK/clive2"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/core/registry"
+	"github.com/urfave/cli/v2"
+)
+
+type Config struct {
+	*clive.Command `cli:"usage:'Check configuration files without running them'"`
+
+	Subcommands struct {
+		*Validate
+		*Show
+		*Schema
+	}
+}
+
+type Validate struct {
+	*clive.Command `cli:"usage:'Validate configs, exits with 1 if any of them is invalid'"`
+
+	JSON    bool     `cli:"name:json,usage:'print a json line per config'"`
+	ESPHome bool     `cli:"name:esphome-compat,usage:'accept ESPHome configs and list what gosthome has no use for'"`
+	Config  []string `cli:"usage:'config files to validate',positional"`
+}
+
+// configError is an error of a config in the json output of validate.
+type configError struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// ignoredKey is a key the ESPHome compatibility mode left out in the json
+// output of validate.
+type ignoredKey struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+type configResult struct {
+	Config  string        `json:"config"`
+	Valid   bool          `json:"valid"`
+	Errors  []configError `json:"errors,omitempty"`
+	Ignored []ignoredKey  `json:"ignored,omitempty"`
+}
+
+func newConfigError(path string, err error) configError {
+	var fe *config.FileError
+	if errors.As(err, &fe) {
+		return configError{
+			File:    fe.File,
+			Line:    fe.Line,
+			Column:  fe.Column,
+			Path:    fe.Path,
+			Message: fe.Message(),
+		}
+	}
+	return configError{File: path, Message: err.Error()}
+}
+
+func (v *Validate) Action(ctx *cli.Context) error {
+	if len(v.Config) == 0 {
+		return errors.New("no config to validate")
+	}
+	out := ctx.App.Writer
+	enc := json.NewEncoder(out)
+	invalid := 0
+	for _, path := range v.Config {
+		res := configResult{Config: path, Valid: true}
+		cfg, err := config.LoadConfigFile(path, config.WithESPHomeCompat(v.ESPHome))
+		if err != nil {
+			invalid++
+			res.Valid = false
+			res.Errors = append(res.Errors, newConfigError(path, err))
+		} else {
+			for _, k := range cfg.Ignored {
+				res.Ignored = append(res.Ignored, ignoredKey(k))
+			}
+		}
+		if v.JSON {
+			err = enc.Encode(res)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(out, "%s: invalid\n%s\n", path, err)
+			continue
+		}
+		fmt.Fprintf(out, "%s: valid\n", path)
+		for _, k := range res.Ignored {
+			fmt.Fprintf(out, "  ignored %s (line %d): %s\n", k.Path, k.Line, k.Reason)
+		}
+	}
+	if invalid > 0 {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+type Show struct {
+	*clive.Command `cli:"usage:'Print a config with includes, packages and substitutions resolved and defaults filled in, secrets, passwords and keys are not shown'"`
+
+	ESPHome bool   `cli:"name:esphome-compat,usage:'convert an ESPHome config'"`
+	Config  string `cli:"usage:'config file to show',positional"`
+}
+
+func (s *Show) Action(ctx *cli.Context) error {
+	cfg, err := loadConfig(s.Config, s.ESPHome)
+	if err != nil {
+		return err
+	}
+	data, err := cfg.MarshalRedacted(context.Background())
+	if err != nil {
+		return fmt.Errorf("error marshalling configuration: %w", err)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+type Schema struct {
+	*clive.Command `cli:"usage:'Print the JSON Schema of configs for editors to complete and check them'"`
+}
+
+func (*Schema) Action(ctx *cli.Context) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(config.Schema(registry.DefaultRegistry()))
+}
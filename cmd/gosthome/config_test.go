@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	clive "github.com/ASMfreaK/clive2"
+	"github.com/matryer/is"
+	"github.com/urfave/cli/v2"
+)
+
+func TestValidateJSON(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"good.yaml": `
+gosthome:
+  name: a
+  mac: 00:aa:bb:cc:dd:ee
+`,
+		"broken.yaml": `
+gosthome:
+  name: a
+  mac: 00:aa:bb:cc:dd:ee
+packages:
+  api: api.yaml
+`,
+		"api.yaml": `
+api:
+  port: abc
+`,
+	} {
+		is.NoErr(os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	good, broken := filepath.Join(dir, "good.yaml"), filepath.Join(dir, "broken.yaml")
+
+	out := &bytes.Buffer{}
+	a := clive.Build(&app{})
+	a.Writer = out
+	// the exit code is checked below instead of exiting the test
+	a.ExitErrHandler = func(*cli.Context, error) {}
+	err := a.RunContext(context.Background(), []string{"gosthome", "config", "validate", "--json", good, broken})
+	var ec cli.ExitCoder
+	is.True(errors.As(err, &ec))
+	is.Equal(ec.ExitCode(), 1)
+
+	results := []configResult{}
+	sc := bufio.NewScanner(out)
+	for sc.Scan() {
+		res := configResult{}
+		is.NoErr(json.Unmarshal(sc.Bytes(), &res))
+		results = append(results, res)
+	}
+	is.Equal(len(results), 2) // one json line per config
+	is.Equal(results[0], configResult{Config: good, Valid: true})
+	is.Equal(results[1].Config, broken)
+	is.Equal(results[1].Valid, false)
+	is.Equal(len(results[1].Errors), 1)
+	ce := results[1].Errors[0]
+	is.Equal(ce.File, filepath.Join(dir, "api.yaml"))
+	is.Equal(ce.Line, 3)
+	is.Equal(ce.Column, 9)
+	is.Equal(ce.Path, "$.api.port")
+	is.True(ce.Message != "")
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	clive "github.com/ASMfreaK/clive2"
+	"github.com/gosthome/gosthome/components/api/client"
+	"github.com/gosthome/gosthome/components/health"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/majfault/signal/dispatcher"
+	"github.com/urfave/cli/v2"
+)
+
+type Ctl struct {
+	*clive.Command `cli:"usage:'Control entities of a remote node: list, get <entity>, set <entity> <value>, press <entity>, watch [entity...], health'"`
+
+	Remote `cli:"inline,name:api"`
+	JSON   bool `cli:"name:json,usage:'print output as json lines'"`
+
+	Host string   `cli:"usage:'host[:port] of the node',positional"`
+	Verb string   `cli:"usage:'one of list, get, set, press, watch, health',positional"`
+	Args []string `cli:"usage:'entities and values for the verb',positional,required:false"`
+}
+
+var ErrUnknownEntity = errors.New("unknown entity")
+
+type ctlEntity struct {
+	Domain entity.DomainType `json:"domain"`
+	ID     string            `json:"id"`
+	Name   string            `json:"name"`
+	Key    uint32            `json:"key"`
+	State  any               `json:"state,omitempty"`
+
+	e entity.Entity
+}
+
+func newCtlEntity(dt entity.DomainType, e entity.Entity) ctlEntity {
+	return ctlEntity{
+		Domain: dt,
+		ID:     e.ID(),
+		Name:   e.Name(),
+		Key:    e.HashID(),
+		e:      e,
+	}
+}
+
+// Ref returns the "domain.id" reference used to address the entity.
+func (ce ctlEntity) Ref() string {
+	return ce.Domain.String() + "." + ce.ID
+}
+
+func (ce ctlEntity) withState() ctlEntity {
+	ce.State = entityState(ce.e)
+	return ce
+}
+
+// entityState returns the state of e, nil for the entities without one.
+func entityState(e entity.Entity) any {
+	switch typed := e.(type) {
+	case entity.BinarySensor:
+		return typed.State()
+	case entity.Cover:
+		return typed.State()
+	case entity.Fan:
+		return typed.State()
+	case entity.Light:
+		return typed.State()
+	case entity.Sensor:
+		return typed.State()
+	case entity.Switch:
+		return typed.State()
+	case entity.TextSensor:
+		return typed.State()
+	case entity.Climate:
+		return typed.State()
+	case entity.Number:
+		return typed.State()
+	case entity.Date:
+		return typed.State()
+	case entity.Time:
+		return typed.State()
+	case entity.Datetime:
+		return typed.State()
+	case entity.Text:
+		return typed.State()
+	case entity.Select:
+		return typed.State()
+	case entity.Lock:
+		return typed.State()
+	case entity.Siren:
+		return typed.State()
+	case entity.Valve:
+		return typed.State()
+	case entity.MediaPlayer:
+		return typed.State()
+	case entity.AlarmControlPanel:
+		return typed.State()
+	case entity.Update:
+		return typed.State()
+	}
+	return nil
+}
+
+func (ctl *Ctl) Action(ctx *cli.Context) error {
+	c, err := ctl.Connect(ctx.Context, ctl.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	err = c.ListEntities(ctl.Timeout)
+	if err != nil {
+		return fmt.Errorf("error listing entities: %w", err)
+	}
+	switch ctl.Verb {
+	case "list":
+		return ctl.list(c)
+	case "get":
+		return ctl.get(ctx.Context, c)
+	case "set":
+		return ctl.set(ctx.Context, c)
+	case "press":
+		return ctl.press(ctx.Context, c)
+	case "watch":
+		return ctl.watch(ctx.Context, c)
+	case "health":
+		return ctl.health(ctx.Context, c)
+	}
+	return fmt.Errorf("unknown verb %q, expected one of list, get, set, press, watch, health", ctl.Verb)
+}
+
+func (ctl *Ctl) entities(c *client.Client) (ret []ctlEntity) {
+	for dt, e := range c.AllEntities() {
+		ret = append(ret, newCtlEntity(dt, e))
+	}
+	slices.SortFunc(ret, func(a, b ctlEntity) int {
+		return strings.Compare(a.Ref(), b.Ref())
+	})
+	return
+}
+
+// find looks the entity up by "domain.id" or by a unique object id.
+func (ctl *Ctl) find(c *client.Client, ref string) (ctlEntity, error) {
+	var found []ctlEntity
+	for _, ce := range ctl.entities(c) {
+		if ce.Ref() == ref || ce.ID == ref {
+			found = append(found, ce)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return ctlEntity{}, fmt.Errorf("%w %s", ErrUnknownEntity, ref)
+	case 1:
+		return found[0], nil
+	}
+	return ctlEntity{}, fmt.Errorf("%s is ambiguous, use domain.id", ref)
+}
+
+func (ctl *Ctl) args(n int) error {
+	if len(ctl.Args) != n {
+		return fmt.Errorf("%s expects %d arguments, got %d", ctl.Verb, n, len(ctl.Args))
+	}
+	return nil
+}
+
+func (ctl *Ctl) print(entities ...ctlEntity) error {
+	if ctl.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, ce := range entities {
+			err := enc.Encode(ce)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, ce := range entities {
+		if ce.State != nil {
+			fmt.Fprintf(w, "%s\t%s\t%+v\n", ce.Ref(), ce.Name, ce.State)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\n", ce.Ref(), ce.Name)
+		}
+	}
+	return w.Flush()
+}
+
+func (ctl *Ctl) list(c *client.Client) error {
+	if err := ctl.args(0); err != nil {
+		return err
+	}
+	return ctl.print(ctl.entities(c)...)
+}
+
+// awaitStates subscribes to states and waits until all of the entities got
+// their initial state from the node.
+func (ctl *Ctl) awaitStates(ctx context.Context, c *client.Client, entities []ctlEntity) error {
+	pending := map[uint32]struct{}{}
+	for _, ce := range entities {
+		if ce.withState().State != nil {
+			pending[ce.Key] = struct{}{}
+		}
+	}
+	done := make(chan struct{})
+	updated := make(chan uint32, len(entities))
+	slot := c.States().Connect(dispatcher.Direct(), func(dt entity.DomainType, e entity.Entity) {
+		select {
+		case updated <- e.HashID():
+		case <-done:
+		}
+	})
+	defer c.States().Disconnect(slot)
+	defer close(done)
+	err := c.SubscribeStates()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, ctl.Timeout)
+	defer cancel()
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("error waiting for state: %w", ctx.Err())
+		case key := <-updated:
+			delete(pending, key)
+		}
+	}
+	return nil
+}
+
+func (ctl *Ctl) get(ctx context.Context, c *client.Client) error {
+	if len(ctl.Args) == 0 {
+		return fmt.Errorf("get expects at least one entity")
+	}
+	entities := make([]ctlEntity, 0, len(ctl.Args))
+	for _, ref := range ctl.Args {
+		ce, err := ctl.find(c, ref)
+		if err != nil {
+			return err
+		}
+		entities = append(entities, ce)
+	}
+	err := ctl.awaitStates(ctx, c, entities)
+	if err != nil {
+		return err
+	}
+	for i := range entities {
+		entities[i] = entities[i].withState()
+	}
+	return ctl.print(entities...)
+}
+
+// health prints the setup summary published by the health component.
+func (ctl *Ctl) health(ctx context.Context, c *client.Client) error {
+	if err := ctl.args(0); err != nil {
+		return err
+	}
+	ce, err := ctl.find(c, entity.DomainTypeTextSensor.String()+"."+health.DEFAULT_ID)
+	if err != nil {
+		return fmt.Errorf("node does not expose its health: %w", err)
+	}
+	err = ctl.awaitStates(ctx, c, []ctlEntity{ce})
+	if err != nil {
+		return err
+	}
+	return ctl.print(ce.withState())
+}
+
+func parseOnOff(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "true", "1":
+		return true, nil
+	case "off", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected on or off, got %q", value)
+}
+
+func (ctl *Ctl) set(ctx context.Context, c *client.Client) error {
+	if err := ctl.args(2); err != nil {
+		return err
+	}
+	ce, err := ctl.find(c, ctl.Args[0])
+	if err != nil {
+		return err
+	}
+	value := ctl.Args[1]
+	switch e := ce.e.(type) {
+	case *client.SwitchComponent:
+		state, err := parseOnOff(value)
+		if err != nil {
+			return err
+		}
+		return e.Command(ctx, state)
+	case entity.Number:
+		v, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return fmt.Errorf("invalid number %q: %w", value, err)
+		}
+		return e.SetValue(ctx, float32(v))
+	case entity.Select:
+		if !slices.Contains(e.Values(), value) {
+			return fmt.Errorf("%q is not one of %s", value, strings.Join(e.Values(), ", "))
+		}
+		return e.Command(value)
+	case entity.Light:
+		cmd := entity.LightCommand{}
+		state, err := parseOnOff(value)
+		if err == nil {
+			return e.Command(cmd.SetState(state))
+		}
+		// a bare number sets the brightness of the light
+		v, perr := strconv.ParseFloat(value, 32)
+		if perr != nil || v < 0 || v > 1 {
+			return fmt.Errorf("expected on, off or brightness between 0 and 1, got %q", value)
+		}
+		return e.Command(cmd.SetState(v > 0).SetBrightness(float32(v)))
+	}
+	return fmt.Errorf("%s can not be set", ce.Ref())
+}
+
+func (ctl *Ctl) press(ctx context.Context, c *client.Client) error {
+	if err := ctl.args(1); err != nil {
+		return err
+	}
+	ce, err := ctl.find(c, ctl.Args[0])
+	if err != nil {
+		return err
+	}
+	b, ok := ce.e.(entity.Button)
+	if !ok {
+		return fmt.Errorf("%s is not a button", ce.Ref())
+	}
+	return b.Press(ctx)
+}
+
+func (ctl *Ctl) watch(ctx context.Context, c *client.Client) error {
+	filter := map[uint32]struct{}{}
+	for _, ref := range ctl.Args {
+		ce, err := ctl.find(c, ref)
+		if err != nil {
+			return err
+		}
+		filter[ce.Key] = struct{}{}
+	}
+	slot := c.States().Connect(dispatcher.Direct(), func(dt entity.DomainType, e entity.Entity) {
+		if _, ok := filter[e.HashID()]; len(filter) > 0 && !ok {
+			return
+		}
+		err := ctl.print(newCtlEntity(dt, e).withState())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	})
+	defer c.States().Disconnect(slot)
+	// events have no state, print the type of the fired event instead
+	eslot := c.EventTriggers().Connect(dispatcher.Direct(), func(e *client.EventComponent, eventType string) {
+		if _, ok := filter[e.HashID()]; len(filter) > 0 && !ok {
+			return
+		}
+		ce := newCtlEntity(entity.DomainTypeEvent, e)
+		ce.State = eventType
+		err := ctl.print(ce)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	})
+	defer c.EventTriggers().Disconnect(eslot)
+	err := c.SubscribeStates()
+	if err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	clive "github.com/ASMfreaK/clive2"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/tests"
+	"github.com/matryer/is"
+)
+
+const testPassword = "hunter2"
+
+// startNode runs a node with a password protected api and returns the
+// host:port to reach it.
+func startNode(t *testing.T) (*core.Node, string) {
+	t.Helper()
+	is := is.New(t)
+	port := tests.GetFreePort(t)
+	cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+api:
+    address: "127.0.0.1"
+    port: %d
+    password: %s
+
+switch:
+  - platform: template
+    id: lamp
+    name: Lamp
+    optimistic: true
+  - platform: template
+    id: relay
+    name: Relay
+    optimistic: true
+
+button:
+  - platform: template
+    id: lamp
+    name: Lamp
+  - platform: template
+    id: relay_on
+    name: Relay On
+    on_press:
+      - switch.turn_on: relay
+`, port, testPassword)))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	t.Cleanup(func() { n.Close() })
+	n.Start()
+	return n, fmt.Sprintf("127.0.0.1:%d", port)
+}
+
+// runCLI runs the gosthome command line with args.
+func runCLI(args ...string) error {
+	return clive.Build(&app{}).RunContext(context.Background(), append([]string{"gosthome"}, args...))
+}
+
+func eventually(t *testing.T, what string, f func() bool) {
+	t.Helper()
+	for range 100 {
+		if f() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal(what)
+}
+
+func TestCtlFind(t *testing.T) {
+	is := is.New(t)
+	_, host := startNode(t)
+	ctl := &Ctl{Remote: Remote{Password: testPassword, Timeout: 5 * time.Second}}
+	c, err := ctl.Connect(context.Background(), host)
+	is.NoErr(err)
+	defer c.Close()
+	is.NoErr(c.ListEntities(ctl.Timeout))
+
+	for _, tc := range []struct {
+		name string
+		ref  string
+		key  uint32
+		err  string
+	}{
+		{name: "domain_id", ref: "switch.lamp", key: cid.HashID("lamp")},
+		{name: "unique_id", ref: "relay", key: cid.HashID("relay")},
+		{name: "other_domain", ref: "button.lamp", key: cid.HashID("lamp")},
+		{name: "unknown", ref: "nope", err: "unknown entity nope"},
+		{name: "unknown_domain_id", ref: "switch.nope", err: "unknown entity switch.nope"},
+		{name: "ambiguous", ref: "lamp", err: "lamp is ambiguous, use domain.id"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			ce, err := ctl.find(c, tc.ref)
+			if tc.err != "" {
+				is.True(err != nil)
+				is.Equal(err.Error(), tc.err)
+				is.Equal(errors.Is(err, ErrUnknownEntity), strings.HasPrefix(tc.err, "unknown"))
+				return
+			}
+			is.NoErr(err)
+			is.Equal(ce.Key, tc.key)
+		})
+	}
+}
+
+func TestCtlSetPress(t *testing.T) {
+	n, host := startNode(t)
+	lamp, ok := n.SwitchByKey(cid.HashID("lamp"))
+	if !ok {
+		t.Fatal("no lamp switch")
+	}
+	relay, ok := n.SwitchByKey(cid.HashID("relay"))
+	if !ok {
+		t.Fatal("no relay switch")
+	}
+	for _, tc := range []struct {
+		name string
+		args []string
+		err  string
+		want func() bool
+	}{
+		{name: "set_on", args: []string{"set", "switch.lamp", "on"}, want: func() bool { return lamp.State().State }},
+		{name: "set_off", args: []string{"set", "switch.lamp", "off"}, want: func() bool { return !lamp.State().State }},
+		{name: "set_invalid", args: []string{"set", "switch.lamp", "maybe"}, err: `expected on or off, got "maybe"`},
+		{name: "set_button", args: []string{"set", "button.lamp", "on"}, err: "button.lamp can not be set"},
+		{name: "press", args: []string{"press", "relay_on"}, want: func() bool { return relay.State().State }},
+		{name: "press_switch", args: []string{"press", "switch.lamp"}, err: "switch.lamp is not a button"},
+		{name: "press_unknown", args: []string{"press", "nope"}, err: "unknown entity nope"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			err := runCLI(append([]string{"ctl", "--api-password", testPassword, host}, tc.args...)...)
+			if tc.err != "" {
+				is.True(err != nil)
+				is.Equal(err.Error(), tc.err)
+				return
+			}
+			is.NoErr(err)
+			eventually(t, "the command did not change the state", tc.want)
+		})
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// dumpSignals make run dump the bus queues, there is no spare signal here.
+var dumpSignals []os.Signal
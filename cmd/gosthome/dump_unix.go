@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// dumpSignals make run dump the bus queues.
+var dumpSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	clive "github.com/ASMfreaK/clive2"
+	"github.com/gosthome/gosthome/core/component/logger"
+	"github.com/majfault/signal/dispatcher"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+type Logs struct {
+	*clive.Command `cli:"usage:'Stream logs of a remote node'"`
+
+	Remote  `cli:"inline,name:api"`
+	Level   logger.Level `cli:"usage:'minimal level of the logs to show',default:debug"`
+	NoColor bool         `cli:"usage:'do not colorize the output'"`
+
+	Host string `cli:"usage:'host[:port] of the node',positional"`
+}
+
+var levelColors = map[logger.Level]string{
+	logger.LevelError:       "\033[1;31m",
+	logger.LevelWarn:        "\033[0;33m",
+	logger.LevelInfo:        "\033[0;32m",
+	logger.LevelConfig:      "\033[0;35m",
+	logger.LevelDebug:       "\033[0;36m",
+	logger.LevelVerbose:     "\033[0;37m",
+	logger.LevelVeryVerbose: "\033[0;37m",
+}
+
+const colorReset = "\033[0m"
+
+func (l *Logs) Action(ctx *cli.Context) error {
+	c, err := l.Connect(ctx.Context, l.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	color := !l.NoColor && term.IsTerminal(int(os.Stdout.Fd()))
+	c.Logs().Connect(dispatcher.Direct(), func(level logger.Level, msg []byte) {
+		if level < l.Level {
+			return
+		}
+		// the node may already have colorized the message
+		msg = bytes.TrimRight(msg, "\r\n")
+		if !color {
+			fmt.Fprintf(os.Stdout, "[%s] %s\n", level, stripColors(msg))
+			return
+		}
+		fmt.Fprintf(os.Stdout, "%s[%s]%s %s%s\n", levelColors[level], level, colorReset, msg, colorReset)
+	})
+	err = c.StartLogsLevel(l.Level)
+	if err != nil {
+		return err
+	}
+	<-ctx.Context.Done()
+	return nil
+}
+
+// stripColors removes ANSI escape sequences from msg.
+func stripColors(msg []byte) []byte {
+	ret := make([]byte, 0, len(msg))
+	for i := 0; i < len(msg); i++ {
+		if msg[i] != '\033' {
+			ret = append(ret, msg[i])
+			continue
+		}
+		for i < len(msg) && !(msg[i] >= 'a' && msg[i] <= 'z' || msg[i] >= 'A' && msg[i] <= 'Z') {
+			i++
+		}
+	}
+	return ret
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestStripColors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "nothing to strip", want: "nothing to strip"},
+		{name: "empty", in: "", want: ""},
+		{name: "colored", in: "\033[0;32m[I][app:100]: Running\033[0m", want: "[I][app:100]: Running"},
+		{name: "bold", in: "\033[1;31merror\033[0m done", want: "error done"},
+		{name: "no_params", in: "\033[mreset", want: "reset"},
+		{name: "several", in: "a\033[0;33mb\033[0;36mc\033[0m", want: "abc"},
+		{name: "unterminated", in: "text\033[0;3", want: "text"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(string(stripColors([]byte(tc.in))), tc.want)
+		})
+	}
+}
@@ -6,11 +6,16 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"path"
+	"strings"
 	"syscall"
 
 	clive "github.com/ASMfreaK/clive2"
 	_ "github.com/gosthome/gosthome/components"
+	"github.com/gosthome/gosthome/components/api"
+	"github.com/gosthome/gosthome/components/api/frameshakers"
 	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/config"
 	"github.com/urfave/cli/v2"
 )
@@ -20,7 +25,10 @@ type app struct {
 	Verbose        bool
 	Subcommands    struct {
 		*Run
+		*Logs
+		*Ctl
 		*Util
+		*Config
 	}
 }
 
@@ -45,32 +53,111 @@ func (*app) Version() string {
 type Run struct {
 	*clive.Command `cli:"usage:'Run a configuration'"`
 
-	Config string `cli:"usage:'config file to read',required"`
+	Config       []string `cli:"usage:'config file to read, repeat to run several nodes',required"`
+	Gateway      string   `cli:"usage:'serve the api of all nodes through a single listener on this address'"`
+	GatewayKey   string   `cli:"name:gateway-key,usage:'encrypt the gateway connections with this noise key instead of the key the nodes share'"`
+	GatewayRoute []string `cli:"name:gateway-route,usage:'route the clients with the client info to a node as client=node, the client info may be a glob pattern'"`
+	Watch        bool     `cli:"usage:'reload a config when one of its files changes, SIGHUP reloads all configs'"`
+	ESPHome      bool     `cli:"name:esphome-compat,usage:'accept ESPHome configs, leaving out what gosthome has no use for'"`
 }
 
 func (r *Run) Action(ctx *cli.Context) error {
+	runCtx := ctx.Context
+	if r.Gateway == "" && len(r.Config) > 1 {
+		return fmt.Errorf("running several configs requires --gateway")
+	}
+	if r.Gateway != "" {
+		opts, err := r.gatewayOptions()
+		if err != nil {
+			return err
+		}
+		g := api.NewGateway(runCtx, r.Gateway, opts...)
+		err = g.Start()
+		if err != nil {
+			return fmt.Errorf("error starting api gateway: %w", err)
+		}
+		defer g.Close()
+		runCtx = api.GatewayContext(runCtx, g)
+	}
+	nodes := make([]*core.Node, 0, len(r.Config))
+	defer func() {
+		for _, n := range nodes {
+			err := n.Close()
+			if err != nil {
+				slog.Error("Error stopping node", "err", err)
+			}
+		}
+	}()
+	for _, path := range r.Config {
+		n, err := loadNode(runCtx, path, r.ESPHome)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, n)
+	}
+	for _, n := range nodes {
+		logHealth("Node started", n, n.Start())
+	}
+	rl := newReloader(r.Config, nodes, r.ESPHome)
+	return rl.run(ctx.Context, r.Watch)
+}
+
+// gatewayOptions are the options of the api gateway given on the command
+// line.
+func (r *Run) gatewayOptions() ([]api.GatewayOpt, error) {
+	routes := map[string]string{}
+	for _, route := range r.GatewayRoute {
+		client, node, ok := strings.Cut(route, "=")
+		if !ok || client == "" || node == "" {
+			return nil, fmt.Errorf("gateway route %q is not client=node", route)
+		}
+		if _, err := path.Match(client, ""); err != nil {
+			return nil, fmt.Errorf("gateway route %q: %w", route, err)
+		}
+		routes[client] = node
+	}
+	opts := []api.GatewayOpt{api.WithRoutes(routes)}
+	if r.GatewayKey != "" {
+		psk, err := frameshakers.ParseNoisePSK(r.GatewayKey)
+		if err != nil || !psk.Valid() {
+			return nil, fmt.Errorf("gateway key is not a base64 encoded 32 byte key")
+		}
+		opts = append(opts, api.WithGatewayNoisePSK(psk))
+	}
+	return opts, nil
+}
+
+func logHealth(msg string, n *core.Node, h core.Health) {
+	if h.Status == component.StatusOk {
+		slog.Info(msg, "name", n.Config.Gosthome.Name, "health", h)
+	} else {
+		slog.Warn(msg, "name", n.Config.Gosthome.Name, "health", h)
+	}
+}
 
-	f, err := os.Open(r.Config)
+// loadConfig loads the config in path, esphome lets it be an ESPHome config
+// and logs what was left out of it.
+func loadConfig(path string, esphome bool) (*config.Config, error) {
+	cfg, err := config.LoadConfigFile(path, config.WithESPHomeCompat(esphome))
 	if err != nil {
-		return fmt.Errorf("error reading file %w", err)
+		return nil, fmt.Errorf("error loading configuration from %s: %w", path, err)
 	}
-	cfg, err := config.LoadConfig(f)
+	for _, k := range cfg.Ignored {
+		slog.Warn("Ignored ESPHome config", "file", k.File, "line", k.Line, "path", k.Path, "reason", k.Reason)
+	}
+	return cfg, nil
+}
+
+func loadNode(ctx context.Context, path string, esphome bool) (*core.Node, error) {
+	cfg, err := loadConfig(path, esphome)
 	if err != nil {
-		return fmt.Errorf("error loading configuration from %s: %w", r.Config, err)
+		return nil, err
 	}
-	n, err := core.NewNode(ctx.Context, cfg)
+	n, err := core.NewNode(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("error initalizing node: %w", err)
+		return nil, fmt.Errorf("error initalizing node: %w", err)
 	}
-	defer func() {
-		err := n.Close()
-		if err != nil {
-			slog.Error("Error stopping node", "err", err)
-		}
-	}()
-	n.Start()
-	<-ctx.Context.Done()
-	return nil
+	return n, nil
 }
 
 func main() {
@@ -78,5 +165,6 @@ func main() {
 	err := clive.Build(&app{}).RunContext(ctx, os.Args)
 	if err != nil {
 		println(err.Error())
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gosthome/gosthome/core"
+)
+
+// reloadDebounce collapses the several writes editors do when saving a file.
+const reloadDebounce = 250 * time.Millisecond
+
+// reloader applies changed config files to the running nodes.
+type reloader struct {
+	paths   []string
+	nodes   []*core.Node
+	esphome bool
+	// files are the files each config was last read from, the included
+	// ones and the secrets too.
+	files   [][]string
+	watcher *fsnotify.Watcher
+}
+
+func newReloader(paths []string, nodes []*core.Node, esphome bool) *reloader {
+	rl := &reloader{paths: paths, nodes: nodes, esphome: esphome}
+	for _, n := range nodes {
+		rl.files = append(rl.files, n.Config.Files)
+	}
+	return rl
+}
+
+func (rl *reloader) reload(i int) {
+	path := rl.paths[i]
+	cfg, err := loadConfig(path, rl.esphome)
+	if err != nil {
+		slog.Error("Rejected config, keeping the running one", "path", path, "err", err)
+		return
+	}
+	rl.setFiles(i, cfg.Files)
+	n := rl.nodes[i]
+	keys, err := n.Reload(cfg)
+	if err != nil {
+		slog.Error("Failed to reload config", "path", path, "err", err)
+		return
+	}
+	if len(keys) == 0 {
+		slog.Info("Config is unchanged", "path", path)
+		return
+	}
+	logHealth("Node reloaded", n, n.Health())
+}
+
+// dumpBus writes the queues of the buses of the nodes to stderr.
+func (rl *reloader) dumpBus() {
+	for i, n := range rl.nodes {
+		fmt.Fprintf(os.Stderr, "Bus of %s:\n", rl.paths[i])
+		err := n.Bus.Dump(os.Stderr)
+		if err != nil {
+			slog.Error("Failed to dump bus", "path", rl.paths[i], "err", err)
+		}
+	}
+}
+
+// setFiles records the files config i was read from and watches them.
+func (rl *reloader) setFiles(i int, files []string) {
+	rl.files[i] = files
+	if rl.watcher == nil {
+		return
+	}
+	for _, f := range rl.files[i] {
+		if err := rl.watch(f); err != nil {
+			slog.Error("Failed to watch config file", "path", f, "err", err)
+		}
+	}
+}
+
+// watch watches the directory of path, editors replace files on save.
+func (rl *reloader) watch(path string) error {
+	dir := filepath.Dir(path)
+	if slices.Contains(rl.watcher.WatchList(), dir) {
+		return nil
+	}
+	return rl.watcher.Add(dir)
+}
+
+// run reloads every config on SIGHUP and, when watch is set, a config when
+// one of its files changes until ctx is done. SIGUSR1 dumps the bus queues.
+func (rl *reloader) run(ctx context.Context, watch bool) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	dump := make(chan os.Signal, 1)
+	if len(dumpSignals) > 0 {
+		signal.Notify(dump, dumpSignals...)
+		defer signal.Stop(dump)
+	}
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if watch {
+		w, err := fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("error watching configs: %w", err)
+		}
+		defer w.Close()
+		rl.watcher = w
+		for _, files := range rl.files {
+			for _, path := range files {
+				err = rl.watch(path)
+				if err != nil {
+					return fmt.Errorf("error watching %s: %w", path, err)
+				}
+			}
+		}
+		events, errs = w.Events, w.Errors
+	}
+
+	pending := map[int]struct{}{}
+	debounce := time.NewTimer(0)
+	<-debounce.C
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			slog.Info("Got SIGHUP, reloading configs")
+			for i := range rl.paths {
+				rl.reload(i)
+			}
+		case <-dump:
+			rl.dumpBus()
+		case e := <-events:
+			if !e.Has(fsnotify.Write) && !e.Has(fsnotify.Create) && !e.Has(fsnotify.Rename) {
+				continue
+			}
+			for i, files := range rl.files {
+				if slices.Contains(files, filepath.Clean(e.Name)) {
+					pending[i] = struct{}{}
+					debounce.Reset(reloadDebounce)
+				}
+			}
+		case err := <-errs:
+			slog.Error("Error watching configs", "err", err)
+		case <-debounce.C:
+			for i := range pending {
+				rl.reload(i)
+			}
+			clear(pending)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/gosthome/gosthome/components/api/client"
+	"github.com/gosthome/gosthome/components/api/frameshakers"
+)
+
+const defaultApiPort = 6053
+
+// Remote holds the flags shared by commands talking to a node over the api.
+type Remote struct {
+	Password    string        `cli:"usage:'api password of the node',env:GOSTHOME_API_PASSWORD"`
+	Key         string        `cli:"usage:'noise encryption key of the node',env:GOSTHOME_API_KEY"`
+	Credentials string        `cli:"usage:'credentials file with password and key per host, defaults to gosthome/credentials.yaml in the user config dir',env:GOSTHOME_API_CREDENTIALS"`
+	Timeout     time.Duration `cli:"usage:'timeout for connecting and listing entities',default:10s"`
+}
+
+type remoteCredentials struct {
+	Password string `yaml:"password"`
+	Key      string `yaml:"key"`
+}
+
+func (r *Remote) credentialsPath() (string, bool) {
+	if r.Credentials != "" {
+		return r.Credentials, true
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(dir, "gosthome", "credentials.yaml"), false
+}
+
+func (r *Remote) credentials(host string) (creds remoteCredentials, err error) {
+	path, explicit := r.credentialsPath()
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return creds, nil
+		}
+		return creds, fmt.Errorf("error reading credentials file: %w", err)
+	}
+	all := map[string]remoteCredentials{}
+	err = yaml.Unmarshal(data, &all)
+	if err != nil {
+		return creds, fmt.Errorf("error parsing credentials file %s: %w", path, err)
+	}
+	return all[host], nil
+}
+
+// Connect dials host (optionally with a port) and returns a connected client.
+// Flags and environment take precedence over the credentials file.
+func (r *Remote) Connect(ctx context.Context, host string) (*client.Client, error) {
+	port := uint16(defaultApiPort)
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		pi, err := strconv.ParseUint(p, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q: %w", p, err)
+		}
+		host, port = h, uint16(pi)
+	}
+	creds, err := r.credentials(host)
+	if err != nil {
+		return nil, err
+	}
+	if r.Password != "" {
+		creds.Password = r.Password
+	}
+	if r.Key != "" {
+		creds.Key = r.Key
+	}
+	opts := []client.ClientOpt{}
+	if creds.Password != "" {
+		opts = append(opts, client.WithPassword(creds.Password))
+	}
+	if creds.Key != "" {
+		psk, err := frameshakers.ParseNoisePSK(creds.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid noise key: %w", err)
+		}
+		opts = append(opts, client.WithNoisePSK(psk))
+	}
+	c := client.New(ctx, host, port, opts...)
+	connectCtx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Connect()
+	}()
+	select {
+	case err = <-done:
+	case <-connectCtx.Done():
+		err = connectCtx.Err()
+	}
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("error connecting to %s: %w", host, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRemoteConnectCredentials(t *testing.T) {
+	_, host := startNode(t)
+	const wrong = "wrong"
+	for _, tc := range []struct {
+		name string
+		flag string
+		env  string
+		file string
+		ok   bool
+	}{
+		{name: "file", file: testPassword, ok: true},
+		{name: "env_over_file", env: testPassword, file: wrong, ok: true},
+		{name: "flag_over_env", flag: testPassword, env: wrong, file: wrong, ok: true},
+		{name: "flag_over_file", flag: testPassword, file: wrong, ok: true},
+		{name: "wrong_flag", flag: wrong, env: testPassword, file: testPassword},
+		{name: "wrong_env", env: wrong, file: testPassword},
+		{name: "none"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			creds := filepath.Join(t.TempDir(), "credentials.yaml")
+			content := "{}\n"
+			if tc.file != "" {
+				content = fmt.Sprintf("127.0.0.1:\n  password: %s\n", tc.file)
+			}
+			is.NoErr(os.WriteFile(creds, []byte(content), 0o600))
+			t.Setenv("GOSTHOME_API_CREDENTIALS", creds)
+			t.Setenv("GOSTHOME_API_PASSWORD", tc.env)
+			args := []string{"ctl", "--api-timeout", "2s"}
+			if tc.flag != "" {
+				args = append(args, "--api-password", tc.flag)
+			}
+			err := runCLI(append(args, host, "list")...)
+			if tc.ok {
+				is.NoErr(err)
+			} else {
+				is.True(err != nil)
+			}
+		})
+	}
+}
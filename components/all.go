@@ -7,12 +7,21 @@ import (
 	"context"
 
 	"github.com/gosthome/gosthome/components/api"
+	"github.com/gosthome/gosthome/components/audit"
 	"github.com/gosthome/gosthome/components/binarysensor"
 	"github.com/gosthome/gosthome/components/button"
 	"github.com/gosthome/gosthome/components/demo"
+	"github.com/gosthome/gosthome/components/event"
 	"github.com/gosthome/gosthome/components/file"
+	"github.com/gosthome/gosthome/components/globals"
+	"github.com/gosthome/gosthome/components/health"
+	"github.com/gosthome/gosthome/components/interval"
+	"github.com/gosthome/gosthome/components/number"
 	"github.com/gosthome/gosthome/components/psutil"
+	"github.com/gosthome/gosthome/components/script"
 	"github.com/gosthome/gosthome/components/sensor"
+	"github.com/gosthome/gosthome/components/switchcomp"
+	"github.com/gosthome/gosthome/components/template"
 	"github.com/gosthome/gosthome/components/textsensor"
 	"github.com/gosthome/gosthome/components/uart"
 	"github.com/gosthome/gosthome/components/webserver"
@@ -31,6 +40,17 @@ func (apiComponent) Component(ctx context.Context, cfg component.Config) ([]comp
 	return api.New(ctx, apiCfg)
 }
 
+type auditComponent struct{}
+
+func (auditComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(audit.NewConfig())
+}
+
+func (auditComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	auditCfg := cfg.(*audit.Config)
+	return audit.New(ctx, auditCfg)
+}
+
 type binarysensorComponent struct{}
 
 func (binarysensorComponent) Config() *component.ConfigDecoder {
@@ -64,6 +84,17 @@ func (demoComponent) Component(ctx context.Context, cfg component.Config) ([]com
 	return demo.New(ctx, demoCfg)
 }
 
+type eventComponent struct{}
+
+func (eventComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(event.NewConfig())
+}
+
+func (eventComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	eventCfg := cfg.(*event.Config)
+	return event.New(ctx, eventCfg)
+}
+
 type fileComponent struct{}
 
 func (fileComponent) Config() *component.ConfigDecoder {
@@ -75,6 +106,50 @@ func (fileComponent) Component(ctx context.Context, cfg component.Config) ([]com
 	return file.New(ctx, fileCfg)
 }
 
+type globalsComponent struct{}
+
+func (globalsComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(globals.NewConfig())
+}
+
+func (globalsComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	globalsCfg := cfg.(*globals.Config)
+	return globals.New(ctx, globalsCfg)
+}
+
+type healthComponent struct{}
+
+func (healthComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(health.NewConfig())
+}
+
+func (healthComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	healthCfg := cfg.(*health.Config)
+	return health.New(ctx, healthCfg)
+}
+
+type intervalComponent struct{}
+
+func (intervalComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(interval.NewConfig())
+}
+
+func (intervalComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	intervalCfg := cfg.(*interval.Config)
+	return interval.New(ctx, intervalCfg)
+}
+
+type numberComponent struct{}
+
+func (numberComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(number.NewConfig())
+}
+
+func (numberComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	numberCfg := cfg.(*number.Config)
+	return number.New(ctx, numberCfg)
+}
+
 type psutilComponent struct{}
 
 func (psutilComponent) Config() *component.ConfigDecoder {
@@ -86,6 +161,17 @@ func (psutilComponent) Component(ctx context.Context, cfg component.Config) ([]c
 	return psutil.New(ctx, psutilCfg)
 }
 
+type scriptComponent struct{}
+
+func (scriptComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(script.NewConfig())
+}
+
+func (scriptComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	scriptCfg := cfg.(*script.Config)
+	return script.New(ctx, scriptCfg)
+}
+
 type sensorComponent struct{}
 
 func (sensorComponent) Config() *component.ConfigDecoder {
@@ -97,6 +183,118 @@ func (sensorComponent) Component(ctx context.Context, cfg component.Config) ([]c
 	return sensor.New(ctx, sensorCfg)
 }
 
+type switchcompComponent struct{}
+
+func (switchcompComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(switchcomp.NewConfig())
+}
+
+func (switchcompComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	switchcompCfg := cfg.(*switchcomp.Config)
+	return switchcomp.New(ctx, switchcompCfg)
+}
+
+type templateComponent struct{}
+
+func (templateComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(template.NewConfig())
+}
+
+func (templateComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	templateCfg := cfg.(*template.Config)
+	return template.New(ctx, templateCfg)
+}
+
+type templateBinarySensorEntityComponent struct{}
+
+func (templateBinarySensorEntityComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(template.NewBinarySensorConfig())
+}
+
+func (templateBinarySensorEntityComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	templateBinarySensorCfg := cfg.(*template.BinarySensorConfig)
+	return template.NewBinarySensor(ctx, templateBinarySensorCfg)
+}
+
+func (templateComponent) BinarySensorPlatform() component.Declaration {
+	return &templateBinarySensorEntityComponent{}
+}
+
+type templateButtonEntityComponent struct{}
+
+func (templateButtonEntityComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(template.NewButtonConfig())
+}
+
+func (templateButtonEntityComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	templateButtonCfg := cfg.(*template.ButtonConfig)
+	return template.NewButton(ctx, templateButtonCfg)
+}
+
+func (templateComponent) ButtonPlatform() component.Declaration {
+	return &templateButtonEntityComponent{}
+}
+
+type templateNumberEntityComponent struct{}
+
+func (templateNumberEntityComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(template.NewNumberConfig())
+}
+
+func (templateNumberEntityComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	templateNumberCfg := cfg.(*template.NumberConfig)
+	return template.NewNumber(ctx, templateNumberCfg)
+}
+
+func (templateComponent) NumberPlatform() component.Declaration {
+	return &templateNumberEntityComponent{}
+}
+
+type templateSensorEntityComponent struct{}
+
+func (templateSensorEntityComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(template.NewSensorConfig())
+}
+
+func (templateSensorEntityComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	templateSensorCfg := cfg.(*template.SensorConfig)
+	return template.NewSensor(ctx, templateSensorCfg)
+}
+
+func (templateComponent) SensorPlatform() component.Declaration {
+	return &templateSensorEntityComponent{}
+}
+
+type templateSwitchEntityComponent struct{}
+
+func (templateSwitchEntityComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(template.NewSwitchConfig())
+}
+
+func (templateSwitchEntityComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	templateSwitchCfg := cfg.(*template.SwitchConfig)
+	return template.NewSwitch(ctx, templateSwitchCfg)
+}
+
+func (templateComponent) SwitchPlatform() component.Declaration {
+	return &templateSwitchEntityComponent{}
+}
+
+type templateTextSensorEntityComponent struct{}
+
+func (templateTextSensorEntityComponent) Config() *component.ConfigDecoder {
+	return component.NewConfigDecoder(template.NewTextSensorConfig())
+}
+
+func (templateTextSensorEntityComponent) Component(ctx context.Context, cfg component.Config) ([]component.Component, error) {
+	templateTextSensorCfg := cfg.(*template.TextSensorConfig)
+	return template.NewTextSensor(ctx, templateTextSensorCfg)
+}
+
+func (templateComponent) TextSensorPlatform() component.Declaration {
+	return &templateTextSensorEntityComponent{}
+}
+
 type textsensorComponent struct{}
 
 func (textsensorComponent) Config() *component.ConfigDecoder {
@@ -147,12 +345,21 @@ func (webserverComponent) Component(ctx context.Context, cfg component.Config) (
 
 var (
 	COMPONENT_KEY_API          = "api"
+	COMPONENT_KEY_AUDIT        = "audit"
 	COMPONENT_KEY_BINARYSENSOR = binarysensor.COMPONENT_KEY
 	COMPONENT_KEY_BUTTON       = button.COMPONENT_KEY
 	COMPONENT_KEY_DEMO         = "demo"
+	COMPONENT_KEY_EVENT        = event.COMPONENT_KEY
 	COMPONENT_KEY_FILE         = "file"
+	COMPONENT_KEY_GLOBALS      = globals.COMPONENT_KEY
+	COMPONENT_KEY_HEALTH       = "health"
+	COMPONENT_KEY_INTERVAL     = interval.COMPONENT_KEY
+	COMPONENT_KEY_NUMBER       = number.COMPONENT_KEY
 	COMPONENT_KEY_PSUTIL       = "psutil"
+	COMPONENT_KEY_SCRIPT       = script.COMPONENT_KEY
 	COMPONENT_KEY_SENSOR       = sensor.COMPONENT_KEY
+	COMPONENT_KEY_SWITCHCOMP   = switchcomp.COMPONENT_KEY
+	COMPONENT_KEY_TEMPLATE     = template.COMPONENT_KEY
 	COMPONENT_KEY_TEXTSENSOR   = textsensor.COMPONENT_KEY
 	COMPONENT_KEY_UART         = uart.COMPONENT_KEY
 	COMPONENT_KEY_WEBSERVER    = webserver.COMPONENT_KEY
@@ -160,12 +367,21 @@ var (
 
 var (
 	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_API, apiComponent{})
+	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_AUDIT, auditComponent{})
 	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_BINARYSENSOR, binarysensorComponent{})
 	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_BUTTON, buttonComponent{})
 	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_DEMO, demoComponent{})
+	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_EVENT, eventComponent{})
 	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_FILE, fileComponent{})
+	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_GLOBALS, globalsComponent{})
+	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_HEALTH, healthComponent{})
+	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_INTERVAL, intervalComponent{})
+	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_NUMBER, numberComponent{})
 	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_PSUTIL, psutilComponent{})
+	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_SCRIPT, scriptComponent{})
 	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_SENSOR, sensorComponent{})
+	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_SWITCHCOMP, switchcompComponent{})
+	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_TEMPLATE, templateComponent{})
 	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_TEXTSENSOR, textsensorComponent{})
 	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_UART, uartComponent{})
 	_ = registry.RegisterDefaultComponent(COMPONENT_KEY_WEBSERVER, webserverComponent{})
@@ -9,10 +9,12 @@ import (
 	"net"
 	"reflect"
 	"sync"
+	"sync/atomic"
 
 	"github.com/gosthome/gosthome/components/api/common"
 	ehp "github.com/gosthome/gosthome/components/api/esphomeproto"
 	"github.com/gosthome/gosthome/components/api/frameshakers"
+	"github.com/gosthome/gosthome/components/api/recorder"
 	"github.com/gosthome/gosthome/core/component/logger"
 	"github.com/gosthome/gosthome/core/entity"
 	"github.com/gosthome/gosthome/core/guarded"
@@ -50,7 +52,7 @@ func (s connectState) Error() string {
 }
 
 type Client struct {
-	reg entity.Registry
+	reg atomic.Pointer[entity.Registry]
 
 	dialer   common.Dialer
 	shaker   frameshakers.ClientShaker
@@ -67,10 +69,18 @@ type Client struct {
 
 	stateRead         <-chan error
 	stateWrite        guarded.Value[chan<- error]
-	listEntitiesState guarded.Value[chan<- struct{}]
+	listEntitiesState guarded.Value[*listing]
 	logs              LogsSignal
+	states            StatesSignal
+	triggers          EventTriggersSignal
+	added             EntitiesSignal
+	removed           EntitiesSignal
+	recorder          *recorder.Recorder
 
 	OnClose func()
+	// OnDisconnect is called when the connection ended, Connect can be called
+	// again to reconnect.
+	OnDisconnect func()
 }
 
 type ClientOpt func(*Client)
@@ -95,6 +105,13 @@ func WithNoisePSK(pwd *frameshakers.ConfigNoisePSK) ClientOpt {
 	}
 }
 
+// WithRecorder records the decrypted traffic of the connection.
+func WithRecorder(r *recorder.Recorder) ClientOpt {
+	return func(c *Client) {
+		c.recorder = r
+	}
+}
+
 func New(ctx context.Context, address string, port uint16, opts ...ClientOpt) *Client {
 	ctx, cancel := context.WithCancel(ctx)
 	c := &Client{
@@ -108,34 +125,43 @@ func New(ctx context.Context, address string, port uint16, opts ...ClientOpt) *C
 	for _, o := range opts {
 		o(c)
 	}
-	c.reg.CreateDomain(entity.PublicDomain(&entity.BinarySensorDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.AlarmControlPanelDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.CoverDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.FanDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.LightDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.SensorDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.SwitchDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.TextSensorDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.ServiceDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.CameraDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.ClimateDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.NumberDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.SelectDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.SirenDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.LockDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.ButtonDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.MediaPlayerDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.TextDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.DateDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.TimeDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.EventDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.ValveDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.DatetimeDomain{}))
-	c.reg.CreateDomain(entity.PublicDomain(&entity.UpdateDomain{}))
+	c.reg.Store(newRegistry())
 	return c
 }
 
+func newRegistry() *entity.Registry {
+	reg := &entity.Registry{}
+	reg.CreateDomain(entity.PublicDomain(&entity.BinarySensorDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.AlarmControlPanelDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.CoverDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.FanDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.LightDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.SensorDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.SwitchDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.TextSensorDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.ServiceDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.CameraDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.ClimateDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.NumberDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.SelectDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.SirenDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.LockDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.ButtonDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.MediaPlayerDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.TextDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.DateDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.TimeDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.EventDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.ValveDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.DatetimeDomain{}))
+	reg.CreateDomain(entity.PublicDomain(&entity.UpdateDomain{}))
+	return reg
+}
+
+// Connect connects to the server. It can be called again after the server
+// closed the connection, the entities and signal subscriptions are kept.
 func (c *Client) Connect() error {
+	c.wg.Wait()
 	c.stateWrite.Do(func(ch *chan<- error) {
 		rwc := make(chan error, 1)
 		*ch = rwc
@@ -191,20 +217,26 @@ func (c *Client) handshake() error {
 		defer c.close()
 		r, w := frameshakers.SplitConnection(c.conn.(net.Conn))
 		neerr := c.shaker(c.ctx, r, w, func(sendFrames frameshakers.FrameSenderFunc) (handler frameshakers.FrameSenderFunc, err error) {
+			// sendFrames is set before Connect learns about the handshake
+			// and starts sending
+			handler = c.handleFrames
+			if c.recorder != nil {
+				sendFrames = c.recorder.Sender(sendFrames)
+				handler = c.recorder.Receiver(c.handleFrames)
+			}
+			c.sendFrames = sendFrames
 			c.stateWrite.Do(func(r *chan<- error) {
 				if *r == nil {
 					err = errors.New("wrong connection state")
 					return
 				}
-				slog.Debug("handshake done")
 				*r <- connectStateHandshake
 				slog.Debug("handshake done")
 			})
 			if err != nil {
 				return nil, err
 			}
-			c.sendFrames = sendFrames
-			return c.handleFrames, nil
+			return handler, nil
 		})
 		if neerr != nil {
 			c.stateWrite.Do(func(r *chan<- error) {
@@ -236,6 +268,11 @@ func (c *Client) Close() (err error) {
 		c.OnClose()
 	}
 	c.wg.Wait()
+	c.logs.Close()
+	c.states.Close()
+	c.triggers.Close()
+	c.added.Close()
+	c.removed.Close()
 	errs := []error{}
 	ok := false
 	for {
@@ -266,13 +303,16 @@ func (c *Client) close() error {
 			*r = nil
 		}
 	})
-	c.listEntitiesState.Do(func(state *chan<- struct{}) {
+	c.listEntitiesState.Do(func(state **listing) {
 		if *state != nil {
-			close(*state)
+			close((*state).done)
 			*state = nil
 		}
 	})
-	c.logs.Close()
+	c.entitiesUnavailable()
+	if c.OnDisconnect != nil {
+		c.OnDisconnect()
+	}
 	if c.conn != nil {
 		err := c.conn.Close()
 		if err != nil {
@@ -295,7 +335,7 @@ func (c *Client) sendMessages(msgs ...ehp.EsphomeMessageTyper) error {
 
 func (c *Client) handleFrames(input []frameshakers.Frame) (err error) {
 	defer func() {
-		if err != nil {
+		if err != nil && !errors.Is(err, frameshakers.ErrCloseConnection) {
 			slog.Error("error handling frames", "err", err)
 		}
 	}()
@@ -312,7 +352,7 @@ func (c *Client) handleFrames(input []frameshakers.Frame) (err error) {
 		if src == ehp.APISourceType_SOURCE_CLIENT {
 			return errors.New("unexpected client message")
 		}
-		slog.Info("client recieved", "frame", mt, "msg", msg)
+		slog.Debug("client recieved", "frame", mt, "msg", msg)
 		switch mt {
 		case ehp.MessageTypeHelloResponse:
 			c.stateWrite.Do(func(r *chan<- error) {
@@ -329,9 +369,9 @@ func (c *Client) handleFrames(input []frameshakers.Frame) (err error) {
 			continue
 		case ehp.MessageTypeDeviceInfoResponse:
 		case ehp.MessageTypeDisconnectRequest:
+			slog.Info("Server requested disconnect")
 			c.sendMessages(&ehp.DisconnectResponse{})
-
-			break
+			closing = true
 		case ehp.MessageTypeDisconnectResponse:
 			slog.Error("Server is disconnecting")
 			closing = true
@@ -407,6 +447,9 @@ func (c *Client) handleFrames(input []frameshakers.Frame) (err error) {
 				return err
 			}
 			continue
+		case ehp.MessageTypeEventResponse:
+			c.eventResponse(msg.(*ehp.EventResponse))
+			continue
 		default:
 			slog.Error("Dont know how to handle unknown message", "type", fmt.Sprintf("%T", msg))
 			// SubscribeLogsResponse
@@ -427,7 +470,6 @@ func (c *Client) handleFrames(input []frameshakers.Frame) (err error) {
 			// BluetoothDeviceClearCacheResponse
 			// VoiceAssistantRequest
 			// BluetoothLERawAdvertisementsResponse
-			// EventResponse
 			// VoiceAssistantAnnounceFinished
 			// VoiceAssistantConfigurationResponse
 		}
@@ -438,14 +480,65 @@ func (c *Client) handleFrames(input []frameshakers.Frame) (err error) {
 	return
 }
 
+// Replay feeds the server messages of a recording to the client as if it was
+// connected to the recorded server. Messages sent by the client are dropped.
+// The client is closed after the replay, entities and states stay available.
+func (c *Client) Replay(rp *recorder.Replayer) error {
+	stateRead := make(chan error, 1)
+	c.stateWrite.Do(func(ch *chan<- error) {
+		*ch = stateRead
+	})
+	go func() {
+		for range stateRead {
+		}
+	}()
+	c.sendFrames = func([]frameshakers.Frame) error { return nil }
+	err := c.replay(rp)
+	c.close()
+	return err
+}
+
+func (c *Client) replay(rp *recorder.Replayer) error {
+	for _, e := range rp.Entries() {
+		if err := c.ctx.Err(); err != nil {
+			return err
+		}
+		if e.From == recorder.PeerClient {
+			// entities are only accepted while a listing is in progress
+			if e.Type == ehp.MessageTypeListEntitiesRequest {
+				c.listEntitiesState.Do(func(state **listing) {
+					if *state == nil {
+						*state = newListing(make(chan struct{}))
+					}
+				})
+			}
+			continue
+		}
+		frame, err := e.Frame()
+		if err != nil {
+			return err
+		}
+		err = c.handleFrames([]frameshakers.Frame{frame})
+		if err != nil && !errors.Is(err, frameshakers.ErrCloseConnection) {
+			return fmt.Errorf("error replaying %s: %w", e.Name, err)
+		}
+	}
+	return nil
+}
+
 type (
 	LogsSignal = signal.Signal2[logger.Level, []byte]
 	LogsSlot   = signal.Slot2[logger.Level, []byte]
 )
 
 func (c *Client) StartLogs() error {
+	return c.StartLogsLevel(logger.LevelVerbose)
+}
+
+// StartLogsLevel subscribes to logs of the given level and more severe.
+func (c *Client) StartLogsLevel(level logger.Level) error {
 	return c.sendMessages(&ehp.SubscribeLogsRequest{
-		Level:      ehp.LogLevel_LOG_LEVEL_VERBOSE,
+		Level:      ehp.LogLevel(level.Int()),
 		DumpConfig: true,
 	})
 }
@@ -453,3 +546,50 @@ func (c *Client) StartLogs() error {
 func (c *Client) Logs() *LogsSignal {
 	return &c.logs
 }
+
+type (
+	StatesSignal = signal.Signal2[entity.DomainType, entity.Entity]
+	StatesSlot   = signal.Slot2[entity.DomainType, entity.Entity]
+)
+
+// States emits every entity after its state was updated by the server.
+func (c *Client) States() *StatesSignal {
+	return &c.states
+}
+
+type (
+	EntitiesSignal = signal.Signal2[entity.DomainType, entity.Entity]
+	EntitiesSlot   = signal.Slot2[entity.DomainType, entity.Entity]
+)
+
+// EntitiesAdded emits the entities a finished listing has and the previous
+// listing had not, every entity of the first listing is added.
+func (c *Client) EntitiesAdded() *EntitiesSignal {
+	return &c.added
+}
+
+// EntitiesRemoved emits the entities of the previous listing a finished
+// listing does not have anymore.
+func (c *Client) EntitiesRemoved() *EntitiesSignal {
+	return &c.removed
+}
+
+type (
+	EventTriggersSignal = signal.Signal2[*EventComponent, string]
+	EventTriggersSlot   = signal.Slot2[*EventComponent, string]
+)
+
+// EventTriggers emits the event entity and the event type every time an
+// event entity fires on the server. Events are sent after SubscribeStates.
+func (c *Client) EventTriggers() *EventTriggersSignal {
+	return &c.triggers
+}
+
+func (c *Client) eventResponse(msg *ehp.EventResponse) {
+	ev, ok := c.EventByKey(msg.Key)
+	if !ok {
+		slog.Warn("Client does not know about this Event, did you subscribed to state changes before lising entities?", "key", msg.Key)
+		return
+	}
+	c.triggers.Emit(ev, msg.EventType)
+}
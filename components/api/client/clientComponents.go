@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"reflect"
+	"sync/atomic"
 	"weak"
 
 	ehp "github.com/gosthome/gosthome/components/api/esphomeproto"
@@ -27,6 +29,7 @@ func (b *ComponentBase) Internal() bool {
 
 type state[T any] struct {
 	state       *T
+	available   atomic.Bool
 	stateChange signal.Signal1[T]
 }
 
@@ -38,6 +41,22 @@ func (s *state[T]) State() T {
 	return *s.state
 }
 
+// Available implements entity.WithAvailability. The entity is unavailable
+// until its first state, while the server reports its state missing and
+// after the connection is lost.
+func (s *state[T]) Available() bool {
+	return s.available.Load()
+}
+
+// SetAvailable implements entity.WithAvailability, the next state from the
+// server overrides it.
+func (s *state[T]) SetAvailable(available bool) {
+	s.available.Store(available)
+}
+
+// SetState sets the state reported by the server and emits it on
+// StateChange. SwitchComponent and ClimateComponent shadow it with the
+// command of their entity interface, use their state field there.
 func (s *state[T]) SetState(t T) {
 	s.state = &t
 	s.stateChange.Emit(t)
@@ -47,7 +66,7 @@ func (s *state[T]) StateChange() *signal.Signal1[T] {
 	return &s.stateChange
 }
 
-func (s *state[T]) Close() error {
+func (s *state[T]) Close(ctx context.Context) error {
 	return s.stateChange.Close()
 }
 
@@ -99,7 +118,7 @@ func (b *BinarySensorComponent) Name() string {
 }
 
 // Setup implements entity.BinarySensor.
-func (b *BinarySensorComponent) Setup() {}
+func (b *BinarySensorComponent) Setup(ctx context.Context) error { return nil }
 
 func (b *BinarySensorComponent) UniqueID() string {
 	return b.i.UniqueId
@@ -150,7 +169,7 @@ func (c *CoverComponent) Name() string {
 }
 
 // Setup implements entity.Cover.
-func (c *CoverComponent) Setup() {}
+func (c *CoverComponent) Setup(ctx context.Context) error { return nil }
 
 func (c *CoverComponent) UniqueID() string {
 	return c.i.UniqueId
@@ -196,7 +215,7 @@ func (f *FanComponent) Name() string {
 }
 
 // Setup implements entity.Fan.
-func (f *FanComponent) Setup() {}
+func (f *FanComponent) Setup(ctx context.Context) error { return nil }
 
 func (f *FanComponent) UniqueID() string {
 	return f.i.UniqueId
@@ -297,7 +316,7 @@ func (l *LightComponent) MaxMireds() float32 {
 
 // Close implements entity.Light.
 // Subtle: this method shadows the method (state).Close of LightComponent.state.
-func (l *LightComponent) Close() error {
+func (l *LightComponent) Close(ctx context.Context) error {
 	return nil
 }
 
@@ -341,7 +360,7 @@ func (l *LightComponent) Name() string {
 }
 
 // Setup implements entity.Light.
-func (l *LightComponent) Setup() {}
+func (l *LightComponent) Setup(ctx context.Context) error { return nil }
 
 func (l *LightComponent) UniqueID() string {
 	return l.i.UniqueId
@@ -407,7 +426,7 @@ func (s *SensorComponent) Name() string {
 }
 
 // Setup implements entity.Sensor.
-func (s *SensorComponent) Setup() {}
+func (s *SensorComponent) Setup(ctx context.Context) error { return nil }
 
 func (s *SensorComponent) UniqueID() string {
 	return s.i.UniqueId
@@ -468,12 +487,29 @@ func (s *SwitchComponent) Name() string {
 }
 
 // Setup implements entity.Switch.
-func (s *SwitchComponent) Setup() {}
+func (s *SwitchComponent) Setup(ctx context.Context) error { return nil }
 
 func (s *SwitchComponent) UniqueID() string {
 	return s.i.UniqueId
 }
 
+// SetState implements entity.Switch by sending Command.
+func (s *SwitchComponent) SetState(ctx context.Context, on bool) error {
+	return s.Command(ctx, on)
+}
+
+// Command asks the server to turn the switch on or off.
+func (s *SwitchComponent) Command(ctx context.Context, on bool) error {
+	client := s.c.Value()
+	if client == nil {
+		return ErrClientGone
+	}
+	return client.sendMessages(&ehp.SwitchCommandRequest{
+		Key:   s.i.Key,
+		State: on,
+	})
+}
+
 var _ (entity.Switch) = (*SwitchComponent)(nil)
 
 type TextSensorComponent struct {
@@ -519,7 +555,7 @@ func (t *TextSensorComponent) Name() string {
 }
 
 // Setup implements entity.TextSensor.
-func (t *TextSensorComponent) Setup() {}
+func (t *TextSensorComponent) Setup(ctx context.Context) error { return nil }
 
 func (t *TextSensorComponent) UniqueID() string {
 	return t.i.UniqueId
@@ -559,16 +595,81 @@ func (s *ServiceComponent) Name() string {
 }
 
 // Setup implements entity.Service.
-func (s *ServiceComponent) Setup() {}
+func (s *ServiceComponent) Setup(ctx context.Context) error { return nil }
 
-func (u *ServiceComponent) Close() error {
+func (u *ServiceComponent) Close(ctx context.Context) error {
 	return nil
 }
 
+// Args returns the args of the service in the order they are sent.
+func (s *ServiceComponent) Args() []info.ServicesArgument {
+	return s.i.Args
+}
+
+// Execute executes the service with the args by name, the int and float
+// args may be any number.
+func (s *ServiceComponent) Execute(ctx context.Context, args map[string]any) error {
+	client := s.c.Value()
+	if client == nil {
+		return ErrClientGone
+	}
+	req := &ehp.ExecuteServiceRequest{Key: s.i.Key}
+	for _, a := range s.i.Args {
+		arg, err := serviceArgument(a, args[a.Name])
+		if err != nil {
+			return fmt.Errorf("service %s: %w", s.i.Name, err)
+		}
+		req.Args = append(req.Args, arg)
+	}
+	return client.sendMessages(req)
+}
+
+func serviceArgument(a info.ServicesArgument, v any) (*ehp.ExecuteServiceArgument, error) {
+	ret := &ehp.ExecuteServiceArgument{}
+	ok := false
+	switch a.Type {
+	case "bool":
+		ret.Bool_, ok = v.(bool)
+	case "int":
+		var f float64
+		f, ok = number(v)
+		ret.Int_ = int32(f)
+		ret.LegacyInt = ret.Int_
+	case "float":
+		var f float64
+		f, ok = number(v)
+		ret.Float_ = float32(f)
+	case "string":
+		ret.String_, ok = v.(string)
+	default:
+		return nil, fmt.Errorf("arg %s: %s args are not supported", a.Name, a.Type)
+	}
+	if !ok {
+		return nil, fmt.Errorf("arg %s needs a %s value, got %v", a.Name, a.Type, v)
+	}
+	return ret, nil
+}
+
+func number(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch {
+	case !rv.IsValid():
+		return 0, false
+	case rv.CanInt():
+		return float64(rv.Int()), true
+	case rv.CanUint():
+		return float64(rv.Uint()), true
+	case rv.CanFloat():
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
 var _ (entity.Service) = (*ServiceComponent)(nil)
 
 type CameraComponent struct {
 	ComponentBase
+	state[entity.CameraState]
 
 	i info.Camera
 }
@@ -604,16 +705,12 @@ func (c *CameraComponent) Name() string {
 }
 
 // Setup implements entity.Camera.
-func (c *CameraComponent) Setup() {}
+func (c *CameraComponent) Setup(ctx context.Context) error { return nil }
 
 func (c *CameraComponent) UniqueID() string {
 	return c.i.UniqueId
 }
 
-func (u *CameraComponent) Close() error {
-	return nil
-}
-
 var _ (entity.Camera) = (*CameraComponent)(nil)
 
 type ClimateComponent struct {
@@ -654,12 +751,49 @@ func (c *ClimateComponent) Name() string {
 }
 
 // Setup implements entity.Climate.
-func (c *ClimateComponent) Setup() {}
+func (c *ClimateComponent) Setup(ctx context.Context) error { return nil }
 
 func (c *ClimateComponent) UniqueID() string {
 	return c.i.UniqueId
 }
 
+// SetState implements entity.Climate by sending Command.
+func (c *ClimateComponent) SetState(ctx context.Context, state entity.ClimateState) error {
+	return c.Command(ctx, state)
+}
+
+// Command asks the server to change the settings of the climate to the ones
+// in state.
+func (c *ClimateComponent) Command(ctx context.Context, state entity.ClimateState) error {
+	client := c.c.Value()
+	if client == nil {
+		return ErrClientGone
+	}
+	return client.sendMessages(&ehp.ClimateCommandRequest{
+		Key:                      c.i.Key,
+		HasMode:                  true,
+		Mode:                     ehp.ClimateMode(state.Mode),
+		HasTargetTemperature:     true,
+		TargetTemperature:        state.TargetTemperature,
+		HasTargetTemperatureLow:  true,
+		TargetTemperatureLow:     state.TargetTemperatureLow,
+		HasTargetTemperatureHigh: true,
+		TargetTemperatureHigh:    state.TargetTemperatureHigh,
+		HasFanMode:               true,
+		FanMode:                  ehp.ClimateFanMode(state.FanMode),
+		HasSwingMode:             true,
+		SwingMode:                ehp.ClimateSwingMode(state.SwingMode),
+		HasCustomFanMode:         state.CustomFanMode != "",
+		CustomFanMode:            state.CustomFanMode,
+		HasPreset:                true,
+		Preset:                   ehp.ClimatePreset(state.Preset),
+		HasCustomPreset:          state.CustomPreset != "",
+		CustomPreset:             state.CustomPreset,
+		HasTargetHumidity:        true,
+		TargetHumidity:           state.TargetHumidity,
+	})
+}
+
 var _ (entity.Climate) = (*ClimateComponent)(nil)
 
 type NumberComponent struct {
@@ -710,7 +844,7 @@ func (n *NumberComponent) NumberMode() entity.NumberMode {
 }
 
 // Setup implements entity.Number.
-func (n *NumberComponent) Setup() {}
+func (n *NumberComponent) Setup(ctx context.Context) error { return nil }
 
 func (n *NumberComponent) UniqueID() string {
 	return n.i.UniqueId
@@ -721,6 +855,33 @@ func (n *NumberComponent) UnitOfMeasurement() string {
 	return n.i.UnitOfMeasurement
 }
 
+// MinValue implements entity.Number.
+func (n *NumberComponent) MinValue() float32 {
+	return n.i.MinValue
+}
+
+// MaxValue implements entity.Number.
+func (n *NumberComponent) MaxValue() float32 {
+	return n.i.MaxValue
+}
+
+// Step implements entity.Number.
+func (n *NumberComponent) Step() float32 {
+	return n.i.Step
+}
+
+// SetValue implements entity.Number.
+func (n *NumberComponent) SetValue(ctx context.Context, value float32) error {
+	client := n.c.Value()
+	if client == nil {
+		return ErrClientGone
+	}
+	return client.sendMessages(&ehp.NumberCommandRequest{
+		Key:   n.i.Key,
+		State: value,
+	})
+}
+
 var _ (entity.Number) = (*NumberComponent)(nil)
 
 type SelectComponent struct {
@@ -766,7 +927,7 @@ func (s *SelectComponent) Name() string {
 }
 
 // Setup implements entity.Select.
-func (s *SelectComponent) Setup() {}
+func (s *SelectComponent) Setup(ctx context.Context) error { return nil }
 
 func (s *SelectComponent) UniqueID() string {
 	return s.i.UniqueId
@@ -793,7 +954,8 @@ type SirenComponent struct {
 }
 
 // Setup implements entity.Siren.
-func (s *SirenComponent) Setup() {
+func (s *SirenComponent) Setup(ctx context.Context) error {
+	return nil
 }
 
 // DisabledByDefault implements entity.Siren.
@@ -881,7 +1043,7 @@ func (l *LockComponent) Name() string {
 }
 
 // Setup implements entity.Lock.
-func (l *LockComponent) Setup() {}
+func (l *LockComponent) Setup(ctx context.Context) error { return nil }
 
 func (l *LockComponent) UniqueID() string {
 	return l.i.UniqueId
@@ -942,13 +1104,13 @@ func (b *ButtonComponent) Press(ctx context.Context) error {
 }
 
 // Setup implements entity.Button.
-func (b *ButtonComponent) Setup() {}
+func (b *ButtonComponent) Setup(ctx context.Context) error { return nil }
 
 func (b *ButtonComponent) UniqueID() string {
 	return b.i.UniqueId
 }
 
-func (u *ButtonComponent) Close() error {
+func (u *ButtonComponent) Close(ctx context.Context) error {
 	return nil
 }
 
@@ -992,7 +1154,7 @@ func (m *MediaPlayerComponent) Name() string {
 }
 
 // Setup implements entity.MediaPlayer.
-func (m *MediaPlayerComponent) Setup() {}
+func (m *MediaPlayerComponent) Setup(ctx context.Context) error { return nil }
 
 func (m *MediaPlayerComponent) UniqueID() string {
 	return m.i.UniqueId
@@ -1008,7 +1170,8 @@ type AlarmControlPanelComponent struct {
 }
 
 // Setup implements entity.AlarmControlPanel.
-func (a *AlarmControlPanelComponent) Setup() {
+func (a *AlarmControlPanelComponent) Setup(ctx context.Context) error {
+	return nil
 }
 
 // DisabledByDefault implements entity.AlarmControlPanel.
@@ -1081,7 +1244,7 @@ func (t *TextComponent) Name() string {
 }
 
 // Setup implements entity.Text.
-func (t *TextComponent) Setup() {}
+func (t *TextComponent) Setup(ctx context.Context) error { return nil }
 
 func (t *TextComponent) UniqueID() string {
 	return t.i.UniqueId
@@ -1132,7 +1295,7 @@ func (d *DateComponent) Name() string {
 }
 
 // Setup implements entity.Date.
-func (d *DateComponent) Setup() {}
+func (d *DateComponent) Setup(ctx context.Context) error { return nil }
 
 func (d *DateComponent) UniqueID() string {
 	return d.i.UniqueId
@@ -1178,7 +1341,7 @@ func (t *TimeComponent) Name() string {
 }
 
 // Setup implements entity.Time.
-func (t *TimeComponent) Setup() {}
+func (t *TimeComponent) Setup(ctx context.Context) error { return nil }
 
 func (t *TimeComponent) UniqueID() string {
 	return t.i.UniqueId
@@ -1207,6 +1370,11 @@ func (e *EventComponent) EntityCategory() entity.Category {
 	return e.i.EntityCategory
 }
 
+// EventTypes implements entity.Event.
+func (e *EventComponent) EventTypes() []string {
+	return e.i.EventTypes
+}
+
 // HashID implements entity.Event.
 func (e *EventComponent) HashID() uint32 {
 	return e.i.Key
@@ -1228,13 +1396,13 @@ func (e *EventComponent) Name() string {
 }
 
 // Setup implements entity.Event.
-func (e *EventComponent) Setup() {}
+func (e *EventComponent) Setup(ctx context.Context) error { return nil }
 
 func (e *EventComponent) UniqueID() string {
 	return e.i.UniqueId
 }
 
-func (u *EventComponent) Close() error {
+func (u *EventComponent) Close(ctx context.Context) error {
 	return nil
 }
 
@@ -1283,7 +1451,7 @@ func (v *ValveComponent) Name() string {
 }
 
 // Setup implements entity.Valve.
-func (v *ValveComponent) Setup() {}
+func (v *ValveComponent) Setup(ctx context.Context) error { return nil }
 
 func (v *ValveComponent) UniqueID() string {
 	return v.i.UniqueId
@@ -1329,7 +1497,7 @@ func (d *DatetimeComponent) Name() string {
 }
 
 // Setup implements entity.Datetime.
-func (d *DatetimeComponent) Setup() {}
+func (d *DatetimeComponent) Setup(ctx context.Context) error { return nil }
 
 func (d *DatetimeComponent) UniqueID() string {
 	return d.i.UniqueId
@@ -1380,7 +1548,7 @@ func (u *UpdateComponent) Name() string {
 }
 
 // Setup implements entity.Update.
-func (u *UpdateComponent) Setup() {}
+func (u *UpdateComponent) Setup(ctx context.Context) error { return nil }
 
 func (u *UpdateComponent) UniqueID() string {
 	return u.i.UniqueId
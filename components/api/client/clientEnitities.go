@@ -5,6 +5,7 @@ import (
 	"errors"
 	"iter"
 	"log/slog"
+	"strings"
 	"time"
 	"weak"
 
@@ -16,6 +17,20 @@ import (
 
 var ErrAlreadyInProgress = errors.New("already in progress")
 
+// listing collects the entities of a listing in progress, they replace the
+// known entities once the server is done listing.
+type listing struct {
+	done chan<- struct{}
+	reg  *entity.Registry
+}
+
+func newListing(done chan<- struct{}) *listing {
+	return &listing{
+		done: done,
+		reg:  newRegistry(),
+	}
+}
+
 func (c *Client) SubscribeStates() error {
 	err := c.sendMessages(&ehp.SubscribeStatesRequest{})
 	if err != nil {
@@ -28,13 +43,13 @@ func (c *Client) ListEntities(timeout time.Duration) error {
 	var ctx context.Context
 	var canc context.CancelFunc
 	var listEndChan chan struct{}
-	err := c.listEntitiesState.DoErr(func(state *chan<- struct{}) error {
+	err := c.listEntitiesState.DoErr(func(state **listing) error {
 		if *state != nil {
 			return ErrAlreadyInProgress
 		}
 		ctx, canc = context.WithTimeout(c.ctx, timeout)
 		listEndChan = make(chan struct{})
-		*state = listEndChan
+		*state = newListing(listEndChan)
 		err := c.sendMessages(&ehp.ListEntitiesRequest{})
 		if err != nil {
 			return err
@@ -54,7 +69,33 @@ func (c *Client) ListEntities(timeout time.Duration) error {
 }
 
 func (c *Client) AllEntities() iter.Seq2[entity.DomainType, entity.Entity] {
-	return entity.IterateRegistry(&c.reg)
+	return entity.IterateRegistry(c.reg.Load())
+}
+
+// entitiesListed emits the entities added and removed between the previous
+// and the current listing.
+func (c *Client) entitiesListed(prev, cur *entity.Registry) {
+	type domainKey struct {
+		dt  entity.DomainType
+		key uint32
+	}
+	known := map[domainKey]struct{}{}
+	for dt, ent := range entity.IterateRegistry(prev) {
+		known[domainKey{dt, ent.HashID()}] = struct{}{}
+	}
+	for dt, ent := range entity.IterateRegistry(cur) {
+		k := domainKey{dt, ent.HashID()}
+		if _, ok := known[k]; ok {
+			delete(known, k)
+			continue
+		}
+		c.added.Emit(dt, ent)
+	}
+	for dt, ent := range entity.IterateRegistry(prev) {
+		if _, ok := known[domainKey{dt, ent.HashID()}]; ok {
+			c.removed.Emit(dt, ent)
+		}
+	}
 }
 
 func (c *Client) componentRegistration(err error) error {
@@ -65,15 +106,15 @@ func (c *Client) componentRegistration(err error) error {
 }
 
 func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
-	var ok bool
-	c.listEntitiesState.Do(func(state *chan<- struct{}) { ok = *state == nil })
-	if ok {
+	var l *listing
+	c.listEntitiesState.Do(func(state **listing) { l = *state })
+	if l == nil {
 		slog.Error("Unexpected list entities message", "msg", msg)
 		return nil
 	}
 	switch list := msg.(type) {
 	case *ehp.ListEntitiesBinarySensorResponse:
-		err := c.reg.RegisterBinarySensor(&BinarySensorComponent{
+		err := l.reg.RegisterBinarySensor(&BinarySensorComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -91,7 +132,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesCoverResponse:
-		err := c.reg.RegisterCover(&CoverComponent{
+		err := l.reg.RegisterCover(&CoverComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -112,7 +153,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesFanResponse:
-		err := c.reg.RegisterFan(&FanComponent{
+		err := l.reg.RegisterFan(&FanComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -133,7 +174,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesLightResponse:
-		err := c.reg.RegisterLight(&LightComponent{
+		err := l.reg.RegisterLight(&LightComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -153,7 +194,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesSensorResponse:
-		err := c.reg.RegisterSensor(&SensorComponent{
+		err := l.reg.RegisterSensor(&SensorComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -175,7 +216,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesSwitchResponse:
-		err := c.reg.RegisterSwitch(&SwitchComponent{
+		err := l.reg.RegisterSwitch(&SwitchComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -193,7 +234,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesTextSensorResponse:
-		err := c.reg.RegisterTextSensor(&TextSensorComponent{
+		err := l.reg.RegisterTextSensor(&TextSensorComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -210,18 +251,19 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesServicesResponse:
-		err := c.reg.RegisterService(&ServiceComponent{
+		err := l.reg.RegisterService(&ServiceComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
 			i: info.Services{
 				Name: list.Name,
 				Key:  list.Key,
+				Args: serviceArgs(list.Args),
 			},
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesCameraResponse:
-		err := c.reg.RegisterCamera(&CameraComponent{
+		err := l.reg.RegisterCamera(&CameraComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -237,7 +279,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesClimateResponse:
-		err := c.reg.RegisterClimate(&ClimateComponent{
+		err := l.reg.RegisterClimate(&ClimateComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -273,7 +315,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesNumberResponse:
-		err := c.reg.RegisterNumber(&NumberComponent{
+		err := l.reg.RegisterNumber(&NumberComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -295,7 +337,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesSelectResponse:
-		err := c.reg.RegisterSelect(&SelectComponent{
+		err := l.reg.RegisterSelect(&SelectComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -312,7 +354,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesSirenResponse:
-		c.reg.RegisterSiren(&SirenComponent{
+		l.reg.RegisterSiren(&SirenComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -320,7 +362,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return nil
 	case *ehp.ListEntitiesLockResponse:
-		err := c.reg.RegisterLock(&LockComponent{
+		err := l.reg.RegisterLock(&LockComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -340,7 +382,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesButtonResponse:
-		err := c.reg.RegisterButton(&ButtonComponent{
+		err := l.reg.RegisterButton(&ButtonComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -357,7 +399,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesMediaPlayerResponse:
-		err := c.reg.RegisterMediaPlayer(&MediaPlayerComponent{
+		err := l.reg.RegisterMediaPlayer(&MediaPlayerComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -387,7 +429,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesAlarmControlPanelResponse:
-		err := c.reg.RegisterAlarmControlPanel(&AlarmControlPanelComponent{
+		err := l.reg.RegisterAlarmControlPanel(&AlarmControlPanelComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -406,7 +448,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesTextResponse:
-		err := c.reg.RegisterText(&TextComponent{
+		err := l.reg.RegisterText(&TextComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -426,7 +468,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesDateResponse:
-		err := c.reg.RegisterDate(&DateComponent{
+		err := l.reg.RegisterDate(&DateComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -442,7 +484,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesTimeResponse:
-		err := c.reg.RegisterTime(&TimeComponent{
+		err := l.reg.RegisterTime(&TimeComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -458,7 +500,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesEventResponse:
-		err := c.reg.RegisterEvent(&EventComponent{
+		err := l.reg.RegisterEvent(&EventComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -476,7 +518,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesValveResponse:
-		err := c.reg.RegisterValve(&ValveComponent{
+		err := l.reg.RegisterValve(&ValveComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -496,7 +538,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesDateTimeResponse:
-		err := c.reg.RegisterDatetime(&DatetimeComponent{
+		err := l.reg.RegisterDatetime(&DatetimeComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -512,7 +554,7 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesUpdateResponse:
-		err := c.reg.RegisterUpdate(&UpdateComponent{
+		err := l.reg.RegisterUpdate(&UpdateComponent{
 			ComponentBase: ComponentBase{
 				c: weak.Make(c),
 			},
@@ -529,10 +571,11 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 		})
 		return c.componentRegistration(err)
 	case *ehp.ListEntitiesDoneResponse:
-		c.listEntitiesState.Do(func(state *chan<- struct{}) {
-			close(*state)
+		c.listEntitiesState.Do(func(state **listing) {
 			*state = nil
 		})
+		c.entitiesListed(c.reg.Swap(l.reg), l.reg)
+		close(l.done)
 		return nil
 	default:
 		slog.Error("unexpected message ", "msg", msg)
@@ -540,7 +583,22 @@ func (c *Client) listEntitiesResponse(msg ehp.EsphomeMessageTyper) error {
 	}
 }
 
+// serviceArgs are the args of a listed service, their types are named like
+// in the config, e.g. int.
+func serviceArgs(args []*ehp.ListEntitiesServicesArgument) []info.ServicesArgument {
+	ret := make([]info.ServicesArgument, 0, len(args))
+	for _, a := range args {
+		ret = append(ret, info.ServicesArgument{
+			Name: a.Name,
+			Type: strings.ToLower(strings.TrimPrefix(a.Type.String(), "SERVICE_ARG_TYPE_")),
+		})
+	}
+	return ret
+}
+
 func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
+	var changed entity.Entity
+	var domain entity.DomainType
 	switch state := msg.(type) {
 	case *ehp.BinarySensorStateResponse:
 		comp, ok := c.BinarySensorByKey(state.Key)
@@ -548,6 +606,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this BinarySensor, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeBinarySensor
 		comp.SetState(entity.BinarySensorState{
 			State:   state.State,
 			Missing: state.MissingState,
@@ -558,6 +617,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Cover, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeCover
 		comp.SetState(entity.CoverState{
 			LegacyState: common.Enum[entity.LegacyCoverState](state.LegacyState),
 			Position:    state.Position,
@@ -569,6 +629,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Fan, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeFan
 		comp.SetState(entity.FanState{
 			State:       state.State,
 			Oscillating: state.Oscillating,
@@ -583,6 +644,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Light, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeLight
 		comp.SetState(entity.LightState{
 			State:            state.State,
 			Brightness:       state.Brightness,
@@ -604,6 +666,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Sensor, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeSensor
 		comp.SetState(entity.SensorState{
 			State:        state.State,
 			MissingState: state.MissingState,
@@ -614,7 +677,8 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Switch, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
-		comp.SetState(entity.SwitchState{
+		changed, domain = comp, entity.DomainTypeSwitch
+		comp.state.SetState(entity.SwitchState{
 			State: state.State,
 		})
 	case *ehp.TextSensorStateResponse:
@@ -623,6 +687,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this TextSensor, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeTextSensor
 		comp.SetState(entity.TextSensorState{
 			State:        state.State,
 			MissingState: state.MissingState,
@@ -633,7 +698,8 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Climate, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
-		comp.SetState(entity.ClimateState{
+		changed, domain = comp, entity.DomainTypeClimate
+		comp.state.SetState(entity.ClimateState{
 			Mode:                  common.Enum[entity.ClimateMode](state.Mode),
 			CurrentTemperature:    state.CurrentTemperature,
 			TargetTemperature:     state.TargetTemperature,
@@ -654,6 +720,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Number, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeNumber
 		comp.SetState(entity.NumberState{
 			State:        state.State,
 			MissingState: state.MissingState,
@@ -664,6 +731,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Select, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeSelect
 		comp.SetState(entity.SelectState{
 			State:        state.State,
 			MissingState: state.MissingState,
@@ -674,6 +742,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Siren, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeSiren
 		comp.SetState(entity.SirenState(state.State))
 	case *ehp.LockStateResponse:
 		comp, ok := c.LockByKey(state.Key)
@@ -681,6 +750,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Lock, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeLock
 		comp.SetState(common.Enum[entity.LockState](state.State))
 	case *ehp.MediaPlayerStateResponse:
 		comp, ok := c.MediaPlayerByKey(state.Key)
@@ -688,6 +758,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this MediaPlayer, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeMediaPlayer
 		comp.SetState(entity.MediaPlayerState{
 			State:  common.Enum[entity.MediaPlayingState](state.State),
 			Volume: state.Volume,
@@ -699,6 +770,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this AlarmControlPanel, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeAlarmControlPanel
 		comp.SetState(common.Enum[entity.AlarmControlPanelState](state.State))
 	case *ehp.TextStateResponse:
 		comp, ok := c.TextByKey(state.Key)
@@ -706,6 +778,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Text, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeText
 		comp.SetState(entity.TextState{
 			State:        state.State,
 			MissingState: state.MissingState,
@@ -716,6 +789,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Date, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeDatetimeDate
 		comp.SetState(entity.DateState{
 			MissingState: state.MissingState,
 			Year:         state.Year,
@@ -728,6 +802,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Time, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeDatetimeTime
 		comp.SetState(entity.TimeState{
 			MissingState: state.MissingState,
 			Hour:         state.Hour,
@@ -740,6 +815,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Valve, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeValve
 		comp.SetState(entity.ValveState{
 			Position:         state.Position,
 			CurrentOperation: common.Enum[entity.ValveOperation](state.CurrentOperation),
@@ -750,6 +826,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this DateTime, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeDatetimeDatetime
 		comp.SetState(entity.DatetimeState{
 			MissingState: state.MissingState,
 			EpochSeconds: state.EpochSeconds,
@@ -760,6 +837,7 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			slog.Warn("Client does not know about this Update, did you subscribed to state changes before lising entities?", "key", state.Key)
 			return nil
 		}
+		changed, domain = comp, entity.DomainTypeUpdate
 		comp.SetState(entity.UpdateState{
 			MissingState:   state.MissingState,
 			InProgress:     state.InProgress,
@@ -772,5 +850,23 @@ func (c *Client) stateChangeResponse(msg ehp.EsphomeMessageTyper) error {
 			ReleaseUrl:     state.ReleaseUrl,
 		})
 	}
+	if changed != nil {
+		if a, ok := changed.(entity.WithAvailability); ok {
+			m, ok := msg.(interface{ GetMissingState() bool })
+			a.SetAvailable(!ok || !m.GetMissingState())
+		}
+		c.states.Emit(domain, changed)
+	}
 	return nil
 }
+
+// entitiesUnavailable marks every entity unavailable once the connection is
+// lost.
+func (c *Client) entitiesUnavailable() {
+	for dt, ent := range c.AllEntities() {
+		if a, ok := ent.(entity.WithAvailability); ok && a.Available() {
+			a.SetAvailable(false)
+			c.states.Emit(dt, ent)
+		}
+	}
+}
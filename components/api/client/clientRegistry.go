@@ -1,7 +1,7 @@
 package client
 
 func (c *Client) BinarySensorByKey(key uint32) (ret *BinarySensorComponent, ok bool) {
-	cm, ok := c.reg.BinarySensorByKey(key)
+	cm, ok := c.reg.Load().BinarySensorByKey(key)
 	if !ok {
 		return
 	}
@@ -9,7 +9,7 @@ func (c *Client) BinarySensorByKey(key uint32) (ret *BinarySensorComponent, ok b
 	return
 }
 func (c *Client) CoverByKey(key uint32) (ret *CoverComponent, ok bool) {
-	cm, ok := c.reg.CoverByKey(key)
+	cm, ok := c.reg.Load().CoverByKey(key)
 	if !ok {
 		return
 	}
@@ -17,7 +17,7 @@ func (c *Client) CoverByKey(key uint32) (ret *CoverComponent, ok bool) {
 	return
 }
 func (c *Client) FanByKey(key uint32) (ret *FanComponent, ok bool) {
-	cm, ok := c.reg.FanByKey(key)
+	cm, ok := c.reg.Load().FanByKey(key)
 	if !ok {
 		return
 	}
@@ -25,7 +25,7 @@ func (c *Client) FanByKey(key uint32) (ret *FanComponent, ok bool) {
 	return
 }
 func (c *Client) LightByKey(key uint32) (ret *LightComponent, ok bool) {
-	cm, ok := c.reg.LightByKey(key)
+	cm, ok := c.reg.Load().LightByKey(key)
 	if !ok {
 		return
 	}
@@ -33,7 +33,7 @@ func (c *Client) LightByKey(key uint32) (ret *LightComponent, ok bool) {
 	return
 }
 func (c *Client) SensorByKey(key uint32) (ret *SensorComponent, ok bool) {
-	cm, ok := c.reg.SensorByKey(key)
+	cm, ok := c.reg.Load().SensorByKey(key)
 	if !ok {
 		return
 	}
@@ -41,7 +41,7 @@ func (c *Client) SensorByKey(key uint32) (ret *SensorComponent, ok bool) {
 	return
 }
 func (c *Client) SwitchByKey(key uint32) (ret *SwitchComponent, ok bool) {
-	cm, ok := c.reg.SwitchByKey(key)
+	cm, ok := c.reg.Load().SwitchByKey(key)
 	if !ok {
 		return
 	}
@@ -49,7 +49,7 @@ func (c *Client) SwitchByKey(key uint32) (ret *SwitchComponent, ok bool) {
 	return
 }
 func (c *Client) ButtonByKey(key uint32) (ret *ButtonComponent, ok bool) {
-	cm, ok := c.reg.ButtonByKey(key)
+	cm, ok := c.reg.Load().ButtonByKey(key)
 	if !ok {
 		return
 	}
@@ -57,7 +57,7 @@ func (c *Client) ButtonByKey(key uint32) (ret *ButtonComponent, ok bool) {
 	return
 }
 func (c *Client) TextSensorByKey(key uint32) (ret *TextSensorComponent, ok bool) {
-	cm, ok := c.reg.TextSensorByKey(key)
+	cm, ok := c.reg.Load().TextSensorByKey(key)
 	if !ok {
 		return
 	}
@@ -65,7 +65,7 @@ func (c *Client) TextSensorByKey(key uint32) (ret *TextSensorComponent, ok bool)
 	return
 }
 func (c *Client) ServiceByKey(key uint32) (ret *ServiceComponent, ok bool) {
-	cm, ok := c.reg.ServiceByKey(key)
+	cm, ok := c.reg.Load().ServiceByKey(key)
 	if !ok {
 		return
 	}
@@ -73,7 +73,7 @@ func (c *Client) ServiceByKey(key uint32) (ret *ServiceComponent, ok bool) {
 	return
 }
 func (c *Client) CameraByKey(key uint32) (ret *CameraComponent, ok bool) {
-	cm, ok := c.reg.CameraByKey(key)
+	cm, ok := c.reg.Load().CameraByKey(key)
 	if !ok {
 		return
 	}
@@ -81,7 +81,7 @@ func (c *Client) CameraByKey(key uint32) (ret *CameraComponent, ok bool) {
 	return
 }
 func (c *Client) ClimateByKey(key uint32) (ret *ClimateComponent, ok bool) {
-	cm, ok := c.reg.ClimateByKey(key)
+	cm, ok := c.reg.Load().ClimateByKey(key)
 	if !ok {
 		return
 	}
@@ -89,7 +89,7 @@ func (c *Client) ClimateByKey(key uint32) (ret *ClimateComponent, ok bool) {
 	return
 }
 func (c *Client) NumberByKey(key uint32) (ret *NumberComponent, ok bool) {
-	cm, ok := c.reg.NumberByKey(key)
+	cm, ok := c.reg.Load().NumberByKey(key)
 	if !ok {
 		return
 	}
@@ -97,7 +97,7 @@ func (c *Client) NumberByKey(key uint32) (ret *NumberComponent, ok bool) {
 	return
 }
 func (c *Client) DateByKey(key uint32) (ret *DateComponent, ok bool) {
-	cm, ok := c.reg.DateByKey(key)
+	cm, ok := c.reg.Load().DateByKey(key)
 	if !ok {
 		return
 	}
@@ -105,7 +105,7 @@ func (c *Client) DateByKey(key uint32) (ret *DateComponent, ok bool) {
 	return
 }
 func (c *Client) TimeByKey(key uint32) (ret *TimeComponent, ok bool) {
-	cm, ok := c.reg.TimeByKey(key)
+	cm, ok := c.reg.Load().TimeByKey(key)
 	if !ok {
 		return
 	}
@@ -113,7 +113,7 @@ func (c *Client) TimeByKey(key uint32) (ret *TimeComponent, ok bool) {
 	return
 }
 func (c *Client) DatetimeByKey(key uint32) (ret *DatetimeComponent, ok bool) {
-	cm, ok := c.reg.DatetimeByKey(key)
+	cm, ok := c.reg.Load().DatetimeByKey(key)
 	if !ok {
 		return
 	}
@@ -121,7 +121,7 @@ func (c *Client) DatetimeByKey(key uint32) (ret *DatetimeComponent, ok bool) {
 	return
 }
 func (c *Client) TextByKey(key uint32) (ret *TextComponent, ok bool) {
-	cm, ok := c.reg.TextByKey(key)
+	cm, ok := c.reg.Load().TextByKey(key)
 	if !ok {
 		return
 	}
@@ -129,7 +129,7 @@ func (c *Client) TextByKey(key uint32) (ret *TextComponent, ok bool) {
 	return
 }
 func (c *Client) SelectByKey(key uint32) (ret *SelectComponent, ok bool) {
-	cm, ok := c.reg.SelectByKey(key)
+	cm, ok := c.reg.Load().SelectByKey(key)
 	if !ok {
 		return
 	}
@@ -137,7 +137,7 @@ func (c *Client) SelectByKey(key uint32) (ret *SelectComponent, ok bool) {
 	return
 }
 func (c *Client) SirenByKey(key uint32) (ret *SirenComponent, ok bool) {
-	cm, ok := c.reg.SirenByKey(key)
+	cm, ok := c.reg.Load().SirenByKey(key)
 	if !ok {
 		return
 	}
@@ -145,7 +145,7 @@ func (c *Client) SirenByKey(key uint32) (ret *SirenComponent, ok bool) {
 	return
 }
 func (c *Client) LockByKey(key uint32) (ret *LockComponent, ok bool) {
-	cm, ok := c.reg.LockByKey(key)
+	cm, ok := c.reg.Load().LockByKey(key)
 	if !ok {
 		return
 	}
@@ -153,7 +153,7 @@ func (c *Client) LockByKey(key uint32) (ret *LockComponent, ok bool) {
 	return
 }
 func (c *Client) ValveByKey(key uint32) (ret *ValveComponent, ok bool) {
-	cm, ok := c.reg.ValveByKey(key)
+	cm, ok := c.reg.Load().ValveByKey(key)
 	if !ok {
 		return
 	}
@@ -161,7 +161,7 @@ func (c *Client) ValveByKey(key uint32) (ret *ValveComponent, ok bool) {
 	return
 }
 func (c *Client) MediaPlayerByKey(key uint32) (ret *MediaPlayerComponent, ok bool) {
-	cm, ok := c.reg.MediaPlayerByKey(key)
+	cm, ok := c.reg.Load().MediaPlayerByKey(key)
 	if !ok {
 		return
 	}
@@ -169,7 +169,7 @@ func (c *Client) MediaPlayerByKey(key uint32) (ret *MediaPlayerComponent, ok boo
 	return
 }
 func (c *Client) AlarmControlPanelByKey(key uint32) (ret *AlarmControlPanelComponent, ok bool) {
-	cm, ok := c.reg.AlarmControlPanelByKey(key)
+	cm, ok := c.reg.Load().AlarmControlPanelByKey(key)
 	if !ok {
 		return
 	}
@@ -177,7 +177,7 @@ func (c *Client) AlarmControlPanelByKey(key uint32) (ret *AlarmControlPanelCompo
 	return
 }
 func (c *Client) EventByKey(key uint32) (ret *EventComponent, ok bool) {
-	cm, ok := c.reg.EventByKey(key)
+	cm, ok := c.reg.Load().EventByKey(key)
 	if !ok {
 		return
 	}
@@ -185,7 +185,7 @@ func (c *Client) EventByKey(key uint32) (ret *EventComponent, ok bool) {
 	return
 }
 func (c *Client) UpdateByKey(key uint32) (ret *UpdateComponent, ok bool) {
-	cm, ok := c.reg.UpdateByKey(key)
+	cm, ok := c.reg.Load().UpdateByKey(key)
 	if !ok {
 		return
 	}
@@ -194,7 +194,7 @@ func (c *Client) UpdateByKey(key uint32) (ret *UpdateComponent, ok bool) {
 }
 
 func (c *Client) BinarySensors() (ret []*BinarySensorComponent) {
-	cmps := c.reg.BinarySensors()
+	cmps := c.reg.Load().BinarySensors()
 	ret = make([]*BinarySensorComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*BinarySensorComponent)
@@ -202,7 +202,7 @@ func (c *Client) BinarySensors() (ret []*BinarySensorComponent) {
 	return
 }
 func (c *Client) Covers() (ret []*CoverComponent) {
-	cmps := c.reg.Covers()
+	cmps := c.reg.Load().Covers()
 	ret = make([]*CoverComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*CoverComponent)
@@ -210,7 +210,7 @@ func (c *Client) Covers() (ret []*CoverComponent) {
 	return
 }
 func (c *Client) Fans() (ret []*FanComponent) {
-	cmps := c.reg.Fans()
+	cmps := c.reg.Load().Fans()
 	ret = make([]*FanComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*FanComponent)
@@ -218,7 +218,7 @@ func (c *Client) Fans() (ret []*FanComponent) {
 	return
 }
 func (c *Client) Lights() (ret []*LightComponent) {
-	cmps := c.reg.Lights()
+	cmps := c.reg.Load().Lights()
 	ret = make([]*LightComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*LightComponent)
@@ -226,7 +226,7 @@ func (c *Client) Lights() (ret []*LightComponent) {
 	return
 }
 func (c *Client) Sensors() (ret []*SensorComponent) {
-	cmps := c.reg.Sensors()
+	cmps := c.reg.Load().Sensors()
 	ret = make([]*SensorComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*SensorComponent)
@@ -234,7 +234,7 @@ func (c *Client) Sensors() (ret []*SensorComponent) {
 	return
 }
 func (c *Client) Switches() (ret []*SwitchComponent) {
-	cmps := c.reg.Switches()
+	cmps := c.reg.Load().Switches()
 	ret = make([]*SwitchComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*SwitchComponent)
@@ -242,7 +242,7 @@ func (c *Client) Switches() (ret []*SwitchComponent) {
 	return
 }
 func (c *Client) Buttons() (ret []*ButtonComponent) {
-	cmps := c.reg.Buttons()
+	cmps := c.reg.Load().Buttons()
 	ret = make([]*ButtonComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*ButtonComponent)
@@ -250,7 +250,7 @@ func (c *Client) Buttons() (ret []*ButtonComponent) {
 	return
 }
 func (c *Client) TextSensors() (ret []*TextSensorComponent) {
-	cmps := c.reg.TextSensors()
+	cmps := c.reg.Load().TextSensors()
 	ret = make([]*TextSensorComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*TextSensorComponent)
@@ -258,7 +258,7 @@ func (c *Client) TextSensors() (ret []*TextSensorComponent) {
 	return
 }
 func (c *Client) Services() (ret []*ServiceComponent) {
-	cmps := c.reg.Services()
+	cmps := c.reg.Load().Services()
 	ret = make([]*ServiceComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*ServiceComponent)
@@ -266,7 +266,7 @@ func (c *Client) Services() (ret []*ServiceComponent) {
 	return
 }
 func (c *Client) Cameras() (ret []*CameraComponent) {
-	cmps := c.reg.Cameras()
+	cmps := c.reg.Load().Cameras()
 	ret = make([]*CameraComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*CameraComponent)
@@ -274,7 +274,7 @@ func (c *Client) Cameras() (ret []*CameraComponent) {
 	return
 }
 func (c *Client) Climates() (ret []*ClimateComponent) {
-	cmps := c.reg.Climates()
+	cmps := c.reg.Load().Climates()
 	ret = make([]*ClimateComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*ClimateComponent)
@@ -282,7 +282,7 @@ func (c *Client) Climates() (ret []*ClimateComponent) {
 	return
 }
 func (c *Client) Numbers() (ret []*NumberComponent) {
-	cmps := c.reg.Numbers()
+	cmps := c.reg.Load().Numbers()
 	ret = make([]*NumberComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*NumberComponent)
@@ -290,7 +290,7 @@ func (c *Client) Numbers() (ret []*NumberComponent) {
 	return
 }
 func (c *Client) Dates() (ret []*DateComponent) {
-	cmps := c.reg.Dates()
+	cmps := c.reg.Load().Dates()
 	ret = make([]*DateComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*DateComponent)
@@ -298,7 +298,7 @@ func (c *Client) Dates() (ret []*DateComponent) {
 	return
 }
 func (c *Client) Times() (ret []*TimeComponent) {
-	cmps := c.reg.Times()
+	cmps := c.reg.Load().Times()
 	ret = make([]*TimeComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*TimeComponent)
@@ -306,7 +306,7 @@ func (c *Client) Times() (ret []*TimeComponent) {
 	return
 }
 func (c *Client) Datetimes() (ret []*DatetimeComponent) {
-	cmps := c.reg.Datetimes()
+	cmps := c.reg.Load().Datetimes()
 	ret = make([]*DatetimeComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*DatetimeComponent)
@@ -314,7 +314,7 @@ func (c *Client) Datetimes() (ret []*DatetimeComponent) {
 	return
 }
 func (c *Client) Texts() (ret []*TextComponent) {
-	cmps := c.reg.Texts()
+	cmps := c.reg.Load().Texts()
 	ret = make([]*TextComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*TextComponent)
@@ -322,7 +322,7 @@ func (c *Client) Texts() (ret []*TextComponent) {
 	return
 }
 func (c *Client) Selects() (ret []*SelectComponent) {
-	cmps := c.reg.Selects()
+	cmps := c.reg.Load().Selects()
 	ret = make([]*SelectComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*SelectComponent)
@@ -330,7 +330,7 @@ func (c *Client) Selects() (ret []*SelectComponent) {
 	return
 }
 func (c *Client) Sirens() (ret []*SirenComponent) {
-	cmps := c.reg.Sirens()
+	cmps := c.reg.Load().Sirens()
 	ret = make([]*SirenComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*SirenComponent)
@@ -338,7 +338,7 @@ func (c *Client) Sirens() (ret []*SirenComponent) {
 	return
 }
 func (c *Client) Locks() (ret []*LockComponent) {
-	cmps := c.reg.Locks()
+	cmps := c.reg.Load().Locks()
 	ret = make([]*LockComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*LockComponent)
@@ -346,7 +346,7 @@ func (c *Client) Locks() (ret []*LockComponent) {
 	return
 }
 func (c *Client) Valves() (ret []*ValveComponent) {
-	cmps := c.reg.Valves()
+	cmps := c.reg.Load().Valves()
 	ret = make([]*ValveComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*ValveComponent)
@@ -354,7 +354,7 @@ func (c *Client) Valves() (ret []*ValveComponent) {
 	return
 }
 func (c *Client) MediaPlayers() (ret []*MediaPlayerComponent) {
-	cmps := c.reg.MediaPlayers()
+	cmps := c.reg.Load().MediaPlayers()
 	ret = make([]*MediaPlayerComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*MediaPlayerComponent)
@@ -362,7 +362,7 @@ func (c *Client) MediaPlayers() (ret []*MediaPlayerComponent) {
 	return
 }
 func (c *Client) AlarmControlPanels() (ret []*AlarmControlPanelComponent) {
-	cmps := c.reg.AlarmControlPanels()
+	cmps := c.reg.Load().AlarmControlPanels()
 	ret = make([]*AlarmControlPanelComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*AlarmControlPanelComponent)
@@ -370,7 +370,7 @@ func (c *Client) AlarmControlPanels() (ret []*AlarmControlPanelComponent) {
 	return
 }
 func (c *Client) Events() (ret []*EventComponent) {
-	cmps := c.reg.Events()
+	cmps := c.reg.Load().Events()
 	ret = make([]*EventComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*EventComponent)
@@ -378,7 +378,7 @@ func (c *Client) Events() (ret []*EventComponent) {
 	return
 }
 func (c *Client) Updates() (ret []*UpdateComponent) {
-	cmps := c.reg.Updates()
+	cmps := c.reg.Load().Updates()
 	ret = make([]*UpdateComponent, len(cmps))
 	for i, ec := range cmps {
 		ret[i] = ec.(*UpdateComponent)
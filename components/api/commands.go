@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	ehp "github.com/gosthome/gosthome/components/api/esphomeproto"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/entity"
+)
+
+// rateLimiter enforces the configured command rate limits for all
+// connections of a server.
+type rateLimiter struct {
+	mux    sync.Mutex
+	limits []ConfigRateLimit
+	last   map[rateLimitKey]time.Time
+	now    func() time.Time
+}
+
+type rateLimitKey struct {
+	limit int
+	key   uint32
+}
+
+func newRateLimiter(limits []ConfigRateLimit) *rateLimiter {
+	return &rateLimiter{
+		limits: limits,
+		last:   map[rateLimitKey]time.Time{},
+		now:    time.Now,
+	}
+}
+
+func (l *ConfigRateLimit) matches(dt entity.DomainType, ent entity.Entity, command string) bool {
+	if l.Command != "" && l.Command != command {
+		return false
+	}
+	return l.Entity == ent.ID() || l.Entity == dt.String()+"."+ent.ID()
+}
+
+// allow reports whether command may be sent to ent now. Allowed commands
+// start a new interval for every limit they match.
+func (rl *rateLimiter) allow(dt entity.DomainType, ent entity.Entity, command string) bool {
+	now := rl.now()
+	rl.mux.Lock()
+	defer rl.mux.Unlock()
+	matched := []rateLimitKey{}
+	for i := range rl.limits {
+		l := &rl.limits[i]
+		if !l.matches(dt, ent, command) {
+			continue
+		}
+		k := rateLimitKey{limit: i, key: ent.HashID()}
+		if last, ok := rl.last[k]; ok && now.Sub(last) < l.Interval {
+			return false
+		}
+		matched = append(matched, k)
+	}
+	for _, k := range matched {
+		rl.last[k] = now
+	}
+	return true
+}
+
+func findEntity(reg *entity.Registry, key uint32) (entity.DomainType, entity.Entity, bool) {
+	for dt, ent := range entity.IterateRegistry(reg) {
+		if ent.HashID() == key {
+			return dt, ent, true
+		}
+	}
+	return 0, nil, false
+}
+
+// commandTimeout is how long a command waits for the entity to execute it.
+const commandTimeout = 10 * time.Second
+
+// command calls the service req for the entity with key on behalf of the
+// client unless the command is rate limited. The call is waited for in its
+// own goroutine, the connection keeps handling messages meanwhile. Every
+// command is reported on the bus with a bus.CommandEvent.
+func (c *Connection) command(ctx context.Context, key uint32, req bus.ServiceRequestData) {
+	node := core.GetNode(ctx)
+	ev := &bus.CommandEvent{
+		Time:    time.Now(),
+		Client:  c.clientInfo,
+		Remote:  c.remote,
+		Key:     key,
+		Command: req.ServiceType(),
+		Args:    req,
+	}
+	emit := bus.MakeEventEmitter[bus.CommandEvent](node.Bus).Emit
+	dt, ent, ok := findEntity(node.Registry, key)
+	if !ok {
+		slog.Warn("Command for unknown entity", "key", key, "command", ev.Command, "client", c.clientInfo)
+		ev.Result = bus.CommandResultUnknownEntity
+		emit(ev)
+		return
+	}
+	ev.Domain = dt.String()
+	ev.Entity = ent.ID()
+	if !c.server.limiter.allow(dt, ent, ev.Command) {
+		slog.Warn("Command rate limited", "entity", ev.Entity, "command", ev.Command, "client", c.clientInfo)
+		ev.Result = bus.CommandResultRateLimited
+		emit(ev)
+		return
+	}
+	c.commands.Add(1)
+	go func() {
+		defer c.commands.Done()
+		defer emit(ev)
+		ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+		defer cancel()
+		_, ev.RequestID, ev.Err = bus.CallWithID[bus.ServiceRequestData, any](ctx, node.Bus, req)
+		if errors.Is(ev.Err, bus.ErrNoHandler) {
+			slog.Warn("Command is not supported", "entity", ev.Entity, "command", ev.Command, "client", c.clientInfo)
+			ev.Result = bus.CommandResultUnsupported
+			return
+		}
+		if ev.Err != nil {
+			slog.Warn("Command failed", "entity", ev.Entity, "command", ev.Command, "client", c.clientInfo, "err", ev.Err)
+			ev.Result = bus.CommandResultFailed
+			return
+		}
+		ev.Result = bus.CommandResultOK
+	}()
+}
+
+// commandDomains are the domains of the commands sent as EntityCommand.
+var commandDomains = map[ehp.MessageType]string{
+	ehp.MessageTypeCoverCommandRequest:             "cover",
+	ehp.MessageTypeFanCommandRequest:               "fan",
+	ehp.MessageTypeLightCommandRequest:             "light",
+	ehp.MessageTypeSelectCommandRequest:            "select",
+	ehp.MessageTypeTextCommandRequest:              "text",
+	ehp.MessageTypeSirenCommandRequest:             "siren",
+	ehp.MessageTypeLockCommandRequest:              "lock",
+	ehp.MessageTypeValveCommandRequest:             "valve",
+	ehp.MessageTypeMediaPlayerCommandRequest:       "media_player",
+	ehp.MessageTypeDateCommandRequest:              "date",
+	ehp.MessageTypeTimeCommandRequest:              "time",
+	ehp.MessageTypeDateTimeCommandRequest:          "datetime",
+	ehp.MessageTypeAlarmControlPanelCommandRequest: "alarm_control_panel",
+}
+
+// EntityCommand is a client command for an entity of a domain the api has
+// no request type of, e.g. a cover. A component of the domain handles it
+// with bus.ServiceHandler, without one the command is unsupported.
+type EntityCommand[M ehp.EsphomeMessageTyper] struct {
+	Key     uint32
+	Request M
+}
+
+// ServiceType implements bus.ServiceRequestData, e.g. "cover.command".
+func (e *EntityCommand[M]) ServiceType() string {
+	var m M
+	return commandDomains[m.EsphomeMessageType()] + ".command"
+}
+
+var _ bus.ServiceRequestData = (*EntityCommand[*ehp.CoverCommandRequest])(nil)
+
+// entityCommand sends msg to the entity with key as an EntityCommand.
+func entityCommand[M interface {
+	ehp.EsphomeMessageTyper
+	GetKey() uint32
+}](ctx context.Context, c *Connection, msg M) ([]ehp.EsphomeMessageTyper, error) {
+	c.command(ctx, msg.GetKey(), &EntityCommand[M]{Key: msg.GetKey(), Request: msg})
+	return nil, nil
+}
@@ -3,7 +3,6 @@ package common
 import (
 	"context"
 	"errors"
-	"fmt"
 	"io"
 	"log/slog"
 	"net"
@@ -88,8 +87,8 @@ func (l *WSProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer wc.CloseNow()
-	dc, err := net.Dial("tcp", fmt.Sprintf("%s:%d", addr, port))
-	if addr == "" {
+	dc, err := net.Dial("tcp", net.JoinHostPort(addr, strconv.Itoa(port)))
+	if err != nil {
 		slog.Error("Failed connecting to destination", "addr", addr, "err", err)
 		return
 	}
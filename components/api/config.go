@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/gosthome/gosthome/components/api/frameshakers"
@@ -11,12 +12,18 @@ import (
 )
 
 type Config struct {
-	cid.IDConfig
-	component.ConfigOf[Server, *Server]
-	Address    string           `yaml:"address"`
-	Port       uint16           `yaml:"port"`
-	Password   *cv.Password     `yaml:"password"`
-	Encryption ConfigEncryption `yaml:"encryption"`
+	cid.IDConfig[Server]                `yaml:",inline"`
+	component.ConfigOf[Server, *Server] `yaml:"-"`
+	Address                             string           `yaml:"address"`
+	Port                                uint16           `yaml:"port"`
+	Password                            *cv.Password     `yaml:"password"`
+	Encryption                          ConfigEncryption `yaml:"encryption"`
+	// Record writes the decrypted traffic of all connections to this JSONL file.
+	Record string `yaml:"record"`
+	// RateLimits limit how often clients may send commands to an entity.
+	RateLimits []ConfigRateLimit `yaml:"rate_limits"`
+	// Services are the user services clients can execute.
+	Services []*ServiceConfig `yaml:"services"`
 }
 
 func NewConfig() *Config {
@@ -31,6 +38,8 @@ func (c *Config) ValidateWithContext(ctx context.Context) error {
 		validation.ValidateStructWithContext(
 			ctx, c,
 			validation.Field(&c.Address),
+			validation.Field(&c.RateLimits),
+			validation.Field(&c.Services),
 		),
 	)
 }
@@ -50,3 +59,25 @@ func (c *ConfigEncryption) ValidateWithContext(ctx context.Context) error {
 }
 
 var _ cv.Validatable = (*ConfigEncryption)(nil)
+
+// ConfigRateLimit allows at most one command per Interval to an entity.
+type ConfigRateLimit struct {
+	// Entity is the object id of the entity, optionally prefixed with its
+	// domain: "garage_door" or "button.garage_door".
+	Entity string `yaml:"entity"`
+	// Command limits only this service, like "button.press". All commands
+	// to the entity share the limit when empty.
+	Command  string        `yaml:"command"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (c *ConfigRateLimit) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(
+		ctx, c,
+		validation.Field(&c.Entity, validation.Required),
+		validation.Field(&c.Interval, validation.Required, validation.Min(time.Millisecond)),
+	)
+}
+
+var _ cv.Validatable = (*ConfigRateLimit)(nil)
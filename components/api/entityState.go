@@ -9,7 +9,17 @@ import (
 	"github.com/gosthome/gosthome/core/entity"
 )
 
+// entityState returns the current state of ent, with missing_state set
+// when ent is unavailable.
 func entityState(ent entity.Entity) ehp.EsphomeMessageTyper {
+	r := currentState(ent)
+	if a, ok := ent.(entity.WithAvailability); ok && r != nil && !a.Available() {
+		markMissing(r)
+	}
+	return r
+}
+
+func currentState(ent entity.Entity) ehp.EsphomeMessageTyper {
 	switch typed := ent.(type) {
 	case entity.BinarySensor:
 		state := typed.State()
@@ -236,3 +246,30 @@ func stateResponse(key uint32, astate any) ehp.EsphomeMessageTyper {
 		return nil
 	}
 }
+
+// markMissing sets missing_state on the state responses that have it, the
+// others can not tell an unavailable entity apart.
+func markMissing(r ehp.EsphomeMessageTyper) {
+	switch r := r.(type) {
+	case *ehp.BinarySensorStateResponse:
+		r.MissingState = true
+	case *ehp.SensorStateResponse:
+		r.MissingState = true
+	case *ehp.TextSensorStateResponse:
+		r.MissingState = true
+	case *ehp.NumberStateResponse:
+		r.MissingState = true
+	case *ehp.DateStateResponse:
+		r.MissingState = true
+	case *ehp.TimeStateResponse:
+		r.MissingState = true
+	case *ehp.DateTimeStateResponse:
+		r.MissingState = true
+	case *ehp.TextStateResponse:
+		r.MissingState = true
+	case *ehp.SelectStateResponse:
+		r.MissingState = true
+	case *ehp.UpdateStateResponse:
+		r.MissingState = true
+	}
+}
@@ -470,7 +470,7 @@ func NoiseClient(
 		switch state {
 		case noiseHello:
 			if msgData[0] == 0x1 && msgData[len(msgData)-1] == 0 {
-				serverName = string(msgData[1 : len(msgData)-2])
+				serverName = string(msgData[1 : len(msgData)-1])
 			}
 			state = noiseHandshake
 		case noiseHandshake:
@@ -31,23 +31,9 @@ func readVarUint(r io.Reader) (uint64, error) {
 	}
 }
 
-func PlaintextServer(
-	ctx context.Context,
-	r io.Reader,
-	w io.Writer,
-	framer ServerFramer,
-) (
-	err error,
-) {
+func newPlaintextPacketReader(r io.Reader) func() (int, []byte, error) {
 	msgData := make([]byte, 4096)
-	var handler FramesHandler
-	defer func() {
-		if handler != nil {
-			handler.Close()
-		}
-	}()
-
-	readPacket := func() (int, []byte, error) {
+	return func() (int, []byte, error) {
 		// read hello header
 		h, rerr := readVarUint(r)
 		if rerr != nil {
@@ -67,7 +53,7 @@ func PlaintextServer(
 		if msgLen != 0 {
 			msgData = reserveBuf(msgData, int(msgLen))
 			msgData = msgData[:msgLen]
-			_, rerr = r.Read(msgData)
+			_, rerr = io.ReadFull(r, msgData)
 			if rerr != nil {
 				return 0, nil, rerr
 			}
@@ -76,9 +62,12 @@ func PlaintextServer(
 		}
 		return int(type_), msgData, nil
 	}
+}
+
+func newPlaintextPacketWriter(w io.Writer) func(type_ int, packet []byte) error {
 	writeBufMux := sync.Mutex{}
 	writeBuf := make([]byte, 4096)
-	writePacket := func(type_ int, packet []byte) error {
+	return func(type_ int, packet []byte) error {
 		writeBufMux.Lock()
 		defer writeBufMux.Unlock()
 		maxFrameLen := len(packet) + binary.MaxVarintLen64*2 + 1
@@ -96,6 +85,25 @@ func PlaintextServer(
 		}
 		return nil
 	}
+}
+
+func PlaintextServer(
+	ctx context.Context,
+	r io.Reader,
+	w io.Writer,
+	framer ServerFramer,
+) (
+	err error,
+) {
+	var handler FramesHandler
+	defer func() {
+		if handler != nil {
+			handler.Close()
+		}
+	}()
+
+	readPacket := newPlaintextPacketReader(r)
+	writePacket := newPlaintextPacketWriter(w)
 	for {
 		if ctx.Err() != nil {
 			return ctx.Err()
@@ -146,5 +154,60 @@ func PlaintextClient(
 ) (
 	err error,
 ) {
-	panic("unimplemented")
+	readPacket := newPlaintextPacketReader(r)
+	writePacket := newPlaintextPacketWriter(w)
+	handler, err := framer(func(frames []Frame) error {
+		for _, frame := range frames {
+			werr := writePacket(frame.Type, frame.Data)
+			if werr != nil {
+				return werr
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to init framer %w", err)
+	}
+	type frameOrErr struct {
+		frame Frame
+		err   error
+	}
+	frames := make(chan frameOrErr)
+	go func() {
+		for {
+			type_, data, rerr := readPacket()
+			var f frameOrErr
+			if rerr != nil {
+				f.err = rerr
+			} else {
+				// the reader reuses its buffer
+				f.frame = Frame{Type: type_, Data: append([]byte(nil), data...)}
+			}
+			select {
+			case frames <- f:
+			case <-ctx.Done():
+				return
+			}
+			if rerr != nil {
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case f := <-frames:
+			if f.err != nil {
+				return f.err
+			}
+			err = handler([]Frame{f.frame})
+			if err != nil {
+				if errors.Is(err, ErrCloseConnection) {
+					return nil
+				}
+				return fmt.Errorf("handler errored: %w", err)
+			}
+		}
+	}
 }
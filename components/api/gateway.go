@@ -0,0 +1,296 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"path"
+	"slices"
+	"sync"
+
+	"github.com/gosthome/gosthome/components/api/common"
+	ehp "github.com/gosthome/gosthome/components/api/esphomeproto"
+	"github.com/gosthome/gosthome/components/api/frameshakers"
+	"github.com/gosthome/gosthome/core/guarded"
+)
+
+var ErrNoRoute = errors.New("no node to route the connection to")
+
+// Router picks the name of the node a new gateway connection should be served by.
+type Router func(hello *ehp.HelloRequest, nodes []string) (string, bool)
+
+// Gateway accepts API connections on a single listener and serves each of them
+// with one of the attached Servers, picked from the HelloRequest of the client.
+// The connections are encrypted with the key of the gateway, without one
+// with the encryption key of the attached nodes, which all need the same key.
+type Gateway struct {
+	baseCtx context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	address         string
+	listenerFactory common.ListenerFactory
+	listener        net.Listener
+
+	// servers guards key too, it is the key of the attached nodes unless
+	// keySet.
+	servers guarded.RWValue[map[string]*Server]
+	key     *frameshakers.ConfigNoisePSK
+	keySet  bool
+	routes  map[string]string
+	router  Router
+}
+
+type GatewayOpt func(*Gateway)
+
+func WithGatewayListenerFactory(lf common.ListenerFactory) GatewayOpt {
+	return func(g *Gateway) {
+		g.listenerFactory = lf
+	}
+}
+
+// WithGatewayNoisePSK encrypts all gateway connections with a single key,
+// the keys of the attached nodes are not used.
+func WithGatewayNoisePSK(psk *frameshakers.ConfigNoisePSK) GatewayOpt {
+	return func(g *Gateway) {
+		if !psk.Valid() {
+			return
+		}
+		g.key = psk
+		g.keySet = true
+	}
+}
+
+// WithRoutes maps HelloRequest.ClientInfo of connecting clients to node names,
+// the client infos are path.Match patterns.
+func WithRoutes(routes map[string]string) GatewayOpt {
+	return func(g *Gateway) {
+		maps.Copy(g.routes, routes)
+	}
+}
+
+// WithRouter replaces the default routing by the client info.
+func WithRouter(r Router) GatewayOpt {
+	return func(g *Gateway) {
+		g.router = r
+	}
+}
+
+func NewGateway(ctx context.Context, address string, opts ...GatewayOpt) *Gateway {
+	g := &Gateway{
+		address:         address,
+		listenerFactory: common.ListenTCP,
+		routes:          map[string]string{},
+	}
+	g.baseCtx, g.cancel = context.WithCancel(ctx)
+	g.servers.Write(func(m *map[string]*Server) {
+		*m = map[string]*Server{}
+	})
+	g.router = g.defaultRouter
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+type gatewayCtxKey struct{}
+
+// GatewayContext makes every api Server created with ctx attach to g
+// instead of listening on its own port.
+func GatewayContext(ctx context.Context, g *Gateway) context.Context {
+	return context.WithValue(ctx, gatewayCtxKey{}, g)
+}
+
+func GetGateway(ctx context.Context) *Gateway {
+	v := ctx.Value(gatewayCtxKey{})
+	if v == nil {
+		return nil
+	}
+	g, ok := v.(*Gateway)
+	if !ok {
+		return nil
+	}
+	return g
+}
+
+func (g *Gateway) defaultRouter(hello *ehp.HelloRequest, nodes []string) (string, bool) {
+	if name, ok := g.routes[hello.ClientInfo]; ok {
+		return name, true
+	}
+	for _, pattern := range slices.Sorted(maps.Keys(g.routes)) {
+		if ok, _ := path.Match(pattern, hello.ClientInfo); ok {
+			return g.routes[pattern], true
+		}
+	}
+	if slices.Contains(nodes, hello.ClientInfo) {
+		return hello.ClientInfo, true
+	}
+	if len(nodes) == 1 {
+		return nodes[0], true
+	}
+	return "", false
+}
+
+// Attach makes the node name reachable through the gateway. Without a
+// gateway key the node needs the encryption key of the attached nodes.
+func (g *Gateway) Attach(name string, s *Server) (err error) {
+	key := s.config.Encryption.Key
+	g.servers.Write(func(m *map[string]*Server) {
+		if _, ok := (*m)[name]; ok {
+			err = fmt.Errorf("node %s is already attached to the gateway", name)
+			return
+		}
+		if !g.keySet {
+			if len(*m) == 0 {
+				g.key = key
+			} else if !g.key.Equal(key) {
+				err = fmt.Errorf("node %s does not have the encryption key of the other nodes of the gateway, give the gateway a key", name)
+				return
+			}
+		}
+		(*m)[name] = s
+	})
+	if err == nil {
+		slog.Info("Node attached to api gateway", "node", name, "address", g.address)
+	}
+	return
+}
+
+func (g *Gateway) Detach(name string) {
+	g.servers.Write(func(m *map[string]*Server) {
+		delete(*m, name)
+		if len(*m) == 0 && !g.keySet {
+			g.key = nil
+		}
+	})
+}
+
+// shaker returns the frame shaker of new connections along with its
+// context.
+func (g *Gateway) shaker() (shaker frameshakers.ServerShaker, ctx context.Context) {
+	g.servers.Read(func(*map[string]*Server) {
+		if g.key.Valid() {
+			shaker = frameshakers.NoiseServer
+			ctx = frameshakers.ContextWithValue(g.baseCtx, "noisePSK", g.key)
+			return
+		}
+		shaker, ctx = frameshakers.PlaintextServer, g.baseCtx
+	})
+	return
+}
+
+func (g *Gateway) route(hello *ehp.HelloRequest) (s *Server, err error) {
+	g.servers.Read(func(m *map[string]*Server) {
+		name, ok := g.router(hello, slices.Sorted(maps.Keys(*m)))
+		if !ok {
+			err = fmt.Errorf("%w for client %q", ErrNoRoute, hello.ClientInfo)
+			return
+		}
+		s, ok = (*m)[name]
+		if !ok {
+			err = fmt.Errorf("%w: node %s is not attached", ErrNoRoute, name)
+		}
+	})
+	return
+}
+
+func (g *Gateway) Start() (err error) {
+	g.listener, err = g.listenerFactory(g.baseCtx, g.address)
+	if err != nil {
+		return err
+	}
+	go g.run()
+	return nil
+}
+
+func (g *Gateway) run() {
+	for {
+		nconn, err := g.listener.Accept()
+		if err != nil {
+			slog.Error("api gateway got accept error", "err", err)
+			return
+		}
+		slog.Info("Accepting gateway connection", "from", nconn.RemoteAddr())
+		g.wg.Add(1)
+		go func() {
+			defer g.wg.Done()
+			defer nconn.Close()
+			r, w := frameshakers.SplitConnection(nconn)
+			shaker, ctx := g.shaker()
+			rerr := shaker(ctx, r, w, g.framer(nconn.RemoteAddr().String()))
+			if rerr != nil {
+				slog.Error("handling gateway connection failed", "err", rerr)
+			}
+			slog.Debug("Done serving gateway connection", "from", nconn.RemoteAddr())
+		}()
+	}
+}
+
+func (g *Gateway) framer(remote string) frameshakers.ServerFramer {
+	return func(sendFrames frameshakers.FrameSenderFunc) (handler frameshakers.FramesHandler, err error) {
+		return &gatewayConnection{
+			gateway:    g,
+			sendFrames: sendFrames,
+			remote:     remote,
+		}, nil
+	}
+}
+
+func (g *Gateway) Close() error {
+	if g.listener != nil {
+		g.listener.Close()
+		g.cancel()
+		g.wg.Wait()
+	}
+	return nil
+}
+
+// gatewayConnection waits for the HelloRequest and then hands the connection
+// over to the Server of the routed node.
+type gatewayConnection struct {
+	gateway    *Gateway
+	sendFrames frameshakers.FrameSenderFunc
+	remote     string
+
+	ctx    context.Context
+	target frameshakers.FramesHandler
+}
+
+// Handle implements frameshakers.FramesHandler.
+func (gc *gatewayConnection) Handle(ctx context.Context, input []frameshakers.Frame) ([]frameshakers.Frame, error) {
+	if gc.target == nil {
+		if len(input) == 0 {
+			return nil, nil
+		}
+		mt, msg, err := common.DecodeFrame(input[0])
+		if err != nil {
+			return nil, err
+		}
+		if mt != ehp.MessageTypeHelloRequest {
+			return nil, fmt.Errorf("gateway expected hello request, got message type %d", mt)
+		}
+		s, err := gc.gateway.route(msg.(*ehp.HelloRequest))
+		if err != nil {
+			return nil, err
+		}
+		gc.target, err = s.framer(gc.remote)(gc.sendFrames)
+		if err != nil {
+			return nil, err
+		}
+		gc.ctx = s.baseCtx
+	}
+	return gc.target.Handle(gc.ctx, input)
+}
+
+// Close implements frameshakers.FramesHandler.
+func (gc *gatewayConnection) Close() error {
+	if gc.target == nil {
+		return nil
+	}
+	return gc.target.Close()
+}
+
+var _ frameshakers.FramesHandler = (*gatewayConnection)(nil)
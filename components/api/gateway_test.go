@@ -0,0 +1,25 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gosthome/gosthome/components/api/common"
+	ehp "github.com/gosthome/gosthome/components/api/esphomeproto"
+	"github.com/matryer/is"
+)
+
+func TestGatewayConnectionBeforeRouting(t *testing.T) {
+	is := is.New(t)
+	g := NewGateway(context.Background(), "")
+	gc := &gatewayConnection{gateway: g}
+	out, err := gc.Handle(context.Background(), nil)
+	is.NoErr(err) // nothing to route yet
+	is.Equal(len(out), 0)
+
+	frames, err := common.EncodeFrames([]ehp.EsphomeMessageTyper{&ehp.HelloRequest{ClientInfo: "test"}})
+	is.NoErr(err)
+	_, err = gc.Handle(context.Background(), frames)
+	is.True(err != nil) // no node is attached
+	is.NoErr(gc.Close())
+}
@@ -0,0 +1,209 @@
+// Package recorder writes decoded api traffic to JSONL files and replays it.
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gosthome/gosthome/components/api/common"
+	ehp "github.com/gosthome/gosthome/components/api/esphomeproto"
+	"github.com/gosthome/gosthome/components/api/frameshakers"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Peer is the side of the api connection that sent a message.
+type Peer string
+
+const (
+	PeerClient Peer = "client"
+	PeerServer Peer = "server"
+)
+
+// Other returns the peer on the other end of the connection.
+func (p Peer) Other() Peer {
+	if p == PeerClient {
+		return PeerServer
+	}
+	return PeerClient
+}
+
+// Entry is a single recorded message, one line of a recording.
+type Entry struct {
+	Time time.Time       `json:"time"`
+	From Peer            `json:"from"`
+	Type ehp.MessageType `json:"type"`
+	Name string          `json:"name"`
+	Body json.RawMessage `json:"body"`
+}
+
+// Message decodes the body of the entry.
+func (e *Entry) Message() (ehp.EsphomeMessageTyper, error) {
+	msg := ehp.MessageByType(e.Type)
+	if msg == nil {
+		return nil, fmt.Errorf("unknown message: %d", e.Type)
+	}
+	err := protojson.Unmarshal(e.Body, msg)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s: %w", e.Name, err)
+	}
+	return msg, nil
+}
+
+// Frame encodes the entry back to a plaintext frame.
+func (e *Entry) Frame() (frameshakers.Frame, error) {
+	msg, err := e.Message()
+	if err != nil {
+		return frameshakers.Frame{}, err
+	}
+	frames, err := common.EncodeFrames([]ehp.EsphomeMessageTyper{msg})
+	if err != nil {
+		return frameshakers.Frame{}, err
+	}
+	return frames[0], nil
+}
+
+// NewEntry creates an entry for msg sent by from.
+func NewEntry(t time.Time, from Peer, msg ehp.EsphomeMessageTyper) (Entry, error) {
+	body, err := protojson.Marshal(msg)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{
+		Time: t,
+		From: from,
+		Type: msg.EsphomeMessageType(),
+		Name: string(msg.ProtoReflect().Descriptor().Name()),
+		Body: body,
+	}, nil
+}
+
+// redacted replaces the passwords in recorded connect requests.
+const redacted = "<redacted>"
+
+// Recorder writes the frames passing through a connection after decryption.
+// Passwords are never written. It is safe to share one Recorder between
+// several connections. Frames that are not messages are left out, a recorder
+// that fails to write stops recording, the connections go on either way.
+type Recorder struct {
+	mux  sync.Mutex
+	w    io.Writer
+	enc  *json.Encoder
+	side Peer
+	now  func() time.Time
+	// stopped is set once writing failed.
+	stopped atomic.Bool
+}
+
+// New creates a Recorder for the side of the connection it is attached to.
+func New(w io.Writer, side Peer) *Recorder {
+	return &Recorder{
+		w:    w,
+		enc:  json.NewEncoder(w),
+		side: side,
+		now:  time.Now,
+	}
+}
+
+// Create creates or truncates the file at path and records to it.
+func Create(path string, side Peer) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(f, side), nil
+}
+
+// Record writes frames sent by from. The frames that can not be recorded are
+// logged and skipped, the error is the one of writing.
+func (r *Recorder) Record(from Peer, frames []frameshakers.Frame) error {
+	t := r.now()
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for _, frame := range frames {
+		_, msg, err := common.DecodeFrame(frame)
+		if err != nil {
+			slog.Warn("Skipped recording api frame", "type", frame.Type, "err", err)
+			continue
+		}
+		if cr, ok := msg.(*ehp.ConnectRequest); ok && cr.Password != "" {
+			cr.Password = redacted
+		}
+		e, err := NewEntry(t, from, msg)
+		if err != nil {
+			slog.Warn("Skipped recording api message", "type", frame.Type, "err", err)
+			continue
+		}
+		err = r.enc.Encode(&e)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record records frames of a connection. Recording never fails the
+// connection, the first write error is logged and stops the recording.
+func (r *Recorder) record(from Peer, frames []frameshakers.Frame) {
+	if r.stopped.Load() {
+		return
+	}
+	err := r.Record(from, frames)
+	if err != nil && r.stopped.CompareAndSwap(false, true) {
+		slog.Error("Failed to record api messages, recording stopped", "err", err)
+	}
+}
+
+// Sender records the frames before passing them to send.
+func (r *Recorder) Sender(send frameshakers.FrameSenderFunc) frameshakers.FrameSenderFunc {
+	return func(frames []frameshakers.Frame) error {
+		r.record(r.side, frames)
+		return send(frames)
+	}
+}
+
+// Receiver records the frames before passing them to receive.
+func (r *Recorder) Receiver(receive frameshakers.FrameSenderFunc) frameshakers.FrameSenderFunc {
+	return func(frames []frameshakers.Frame) error {
+		r.record(r.side.Other(), frames)
+		return receive(frames)
+	}
+}
+
+// Handler records the frames handled by h and the frames it responds with.
+func (r *Recorder) Handler(h frameshakers.FramesHandler) frameshakers.FramesHandler {
+	return &recordingHandler{r: r, h: h}
+}
+
+func (r *Recorder) Close() error {
+	if c, ok := r.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+type recordingHandler struct {
+	r *Recorder
+	h frameshakers.FramesHandler
+}
+
+// Handle implements frameshakers.FramesHandler.
+func (rh *recordingHandler) Handle(ctx context.Context, input []frameshakers.Frame) ([]frameshakers.Frame, error) {
+	rh.r.record(rh.r.side.Other(), input)
+	output, err := rh.h.Handle(ctx, input)
+	rh.r.record(rh.r.side, output)
+	return output, err
+}
+
+// Close implements frameshakers.FramesHandler.
+func (rh *recordingHandler) Close() error {
+	return rh.h.Close()
+}
+
+var _ frameshakers.FramesHandler = (*recordingHandler)(nil)
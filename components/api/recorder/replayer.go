@@ -0,0 +1,77 @@
+package recorder
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gosthome/gosthome/components/api/frameshakers"
+)
+
+// Replayer feeds the messages of one peer from a recording to the other side.
+type Replayer struct {
+	entries []Entry
+}
+
+// NewReplayer reads a whole recording from r.
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	rp := &Replayer{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		err := json.Unmarshal(scanner.Bytes(), &e)
+		if err != nil {
+			return nil, fmt.Errorf("error reading recording line %d: %w", line, err)
+		}
+		rp.entries = append(rp.entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+// Open reads the recording at path.
+func Open(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewReplayer(f)
+}
+
+// Entries returns all recorded entries in order.
+func (rp *Replayer) Entries() []Entry {
+	return rp.entries
+}
+
+// Drive passes the messages sent by from to handle one by one, in recorded order.
+func (rp *Replayer) Drive(ctx context.Context, from Peer, handle frameshakers.FrameSenderFunc) error {
+	for _, e := range rp.entries {
+		if e.From != from {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		frame, err := e.Frame()
+		if err != nil {
+			return err
+		}
+		err = handle([]frameshakers.Frame{frame})
+		if err != nil {
+			return fmt.Errorf("error replaying %s: %w", e.Name, err)
+		}
+	}
+	return nil
+}
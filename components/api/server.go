@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -8,12 +9,15 @@ import (
 	"net"
 	"reflect"
 	"sync"
+	"time"
 
 	"maps"
 
 	"github.com/gosthome/gosthome/components/api/common"
 	ehp "github.com/gosthome/gosthome/components/api/esphomeproto"
 	"github.com/gosthome/gosthome/components/api/frameshakers"
+	"github.com/gosthome/gosthome/components/api/recorder"
+	"github.com/gosthome/gosthome/core"
 	"github.com/gosthome/gosthome/core/bus"
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/component/cid"
@@ -26,8 +30,12 @@ type Connection struct {
 	canAuthenticate bool
 	subscribed      bool
 	clientInfo      string
+	remote          string
 	asyncHandlers   asyncHandlers
-	busEvents       []bus.EventSubsciption
+	// commands are the commands waiting for their entity.
+	commands  sync.WaitGroup
+	busEvents []bus.EventSubsciption
+	states    *stateSender
 }
 
 func (c *Connection) SendMessages(msgs []ehp.EsphomeMessageTyper) error {
@@ -111,9 +119,15 @@ func (c *Connection) Handle(ctx context.Context, input []frameshakers.Frame) (re
 }
 
 func (c *Connection) Close() error {
+	c.server.untrack(c)
+	// the sent commands are carried out even if the client left
+	c.commands.Wait()
 	for _, sub := range c.busEvents {
 		sub.Close()
 	}
+	if c.states != nil {
+		c.states.Close()
+	}
 	return c.asyncHandlers.Close()
 }
 
@@ -185,6 +199,19 @@ type Server struct {
 	shaker   frameshakers.ServerShaker
 	handlers safeMessageHandlers
 
+	gateway     *Gateway
+	gatewayName string
+
+	recorder *recorder.Recorder
+	limiter  *rateLimiter
+
+	registrySub bus.EventSubsciption
+	relist      *time.Timer
+
+	liveMux  sync.Mutex
+	conns    map[*Connection]struct{}
+	netConns map[net.Conn]struct{}
+
 	config *Config
 }
 
@@ -193,7 +220,15 @@ func New(ctx context.Context, cfg *Config) (n []component.Component, err error)
 	if err != nil {
 		return nil, err
 	}
-	return []component.Component{s}, nil
+	n = []component.Component{s}
+	if node := core.GetNode(ctx); node != nil && len(cfg.Services) > 0 {
+		services, err := newServices(ctx, node, cfg.Services)
+		if err != nil {
+			return nil, err
+		}
+		n = append(n, services...)
+	}
+	return n, nil
 }
 
 type ServerOpt func(*Server)
@@ -204,13 +239,44 @@ func WithListenerFactory(lf common.ListenerFactory) ServerOpt {
 	}
 }
 
+// WithGateway serves the api through g under the node name instead of
+// listening on the configured address.
+func WithGateway(g *Gateway, name string) ServerOpt {
+	return func(s *Server) {
+		s.gateway = g
+		s.gatewayName = name
+	}
+}
+
+// WithRecorder records the decrypted traffic of every connection.
+func WithRecorder(r *recorder.Recorder) ServerOpt {
+	return func(s *Server) {
+		s.recorder = r
+	}
+}
+
 func NewServer(ctx context.Context, cfg *Config, opts ...ServerOpt) (n *Server, err error) {
 	n = &Server{
 		CID:             cid.NewID(cfg.ID),
 		shaker:          frameshakers.PlaintextServer,
 		handlers:        safeMessageHandlers{m: map[ehp.MessageType]AnyMessageHandler{}},
 		listenerFactory: common.ListenTCP,
+		limiter:         newRateLimiter(cfg.RateLimits),
 		config:          cfg,
+		conns:           map[*Connection]struct{}{},
+		netConns:        map[net.Conn]struct{}{},
+	}
+	if g := GetGateway(ctx); g != nil {
+		n.gateway = g
+		if node := core.GetNode(ctx); node != nil {
+			n.gatewayName = node.Config.Gosthome.Name
+		}
+	}
+	if cfg.Record != "" {
+		n.recorder, err = recorder.Create(cfg.Record, recorder.PeerServer)
+		if err != nil {
+			return nil, fmt.Errorf("error creating api recording: %w", err)
+		}
 	}
 	for _, opt := range opts {
 		opt(n)
@@ -233,31 +299,67 @@ func NewServer(ctx context.Context, cfg *Config, opts ...ServerOpt) (n *Server,
 }
 
 // Setup implements component.Component.
-func (n *Server) Setup() {
+func (n *Server) Setup(ctx context.Context) error {
 	var err error
+	if node := core.GetNode(n.baseCtx); node != nil {
+		n.registrySub = node.HandleEvents(bus.EventHandler(n.registryChanged))
+	}
+	if n.gateway != nil {
+		err = n.gateway.Attach(n.gatewayName, n)
+		if err != nil {
+			n.gateway = nil
+			return fmt.Errorf("failed to attach api to gateway: %w", err)
+		}
+		return nil
+	}
 	n.listener, err = n.listenerFactory(n.baseCtx, fmt.Sprintf("%s:%d", n.config.Address, n.config.Port))
 	if err != nil {
-		slog.Error("Failed to initialize api", "err", err)
-		return
+		return fmt.Errorf("failed to initialize api: %w", err)
 	}
 	go n.run()
+	return nil
+}
+
+// relistDelay collects the registry changes of a reload or a hotplug into a
+// single disconnect.
+const relistDelay = 100 * time.Millisecond
+
+// registryChanged makes the clients list the entities again after entities
+// were added or removed. ESPHome clients re-list when they reconnect.
+func (n *Server) registryChanged(e *bus.RegistryChangedEvent) {
+	n.live(func() {
+		if n.relist != nil {
+			n.relist.Reset(relistDelay)
+			return
+		}
+		n.relist = time.AfterFunc(relistDelay, func() {
+			n.live(func() { n.relist = nil })
+			slog.Info("Entities changed, disconnecting clients")
+			n.disconnectClients()
+		})
+	})
 }
 
 func (n *Server) run() {
 	for {
 		nconn, err := n.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
 		if err != nil {
 			slog.Error("gosthome.Node got accept error", "err", err)
 			return
 		}
 		slog.Info("Accepting connection", "from", nconn.RemoteAddr())
 		n.wg.Add(1)
+		n.live(func() { n.netConns[nconn] = struct{}{} })
 		go func() {
 			defer n.wg.Done()
+			defer n.live(func() { delete(n.netConns, nconn) })
 			defer nconn.Close()
 			r, w := frameshakers.SplitConnection(nconn)
-			rerr := n.shaker(n.baseCtx, r, w, n.connection)
-			if rerr != nil {
+			rerr := n.shaker(n.baseCtx, r, w, n.framer(nconn.RemoteAddr().String()))
+			if rerr != nil && !errors.Is(rerr, frameshakers.ErrCloseConnection) {
 				slog.Error("handling connection failed", "err", rerr)
 			}
 			slog.Debug("Done serving connection", "from", nconn.RemoteAddr())
@@ -265,21 +367,135 @@ func (n *Server) run() {
 	}
 }
 
-func (n *Server) connection(sendFrames frameshakers.FrameSenderFunc) (handler frameshakers.FramesHandler, err error) {
+// framer creates the connections for a client connected from remote.
+func (n *Server) framer(remote string) frameshakers.ServerFramer {
+	return func(sendFrames frameshakers.FrameSenderFunc) (handler frameshakers.FramesHandler, err error) {
+		if n.recorder != nil {
+			return n.recordedConnection(n.recorder, remote, sendFrames), nil
+		}
+		c := &Connection{
+			server: n,
+
+			authenticated: !n.config.Password.Valid(),
+			sendFrames:    sendFrames,
+			remote:        remote,
+		}
+		n.track(c)
+		return c, nil
+	}
+}
+
+func (n *Server) recordedConnection(r *recorder.Recorder, remote string, sendFrames frameshakers.FrameSenderFunc) frameshakers.FramesHandler {
 	c := &Connection{
 		server: n,
 
 		authenticated: !n.config.Password.Valid(),
-		sendFrames:    sendFrames,
+		sendFrames:    r.Sender(sendFrames),
+		remote:        remote,
+	}
+	n.track(c)
+	return r.Handler(c)
+}
+
+// Replay handles the client messages of a recording as if they came from a
+// new connection and returns the transcript of the replayed session.
+func (n *Server) Replay(rp *recorder.Replayer) ([]recorder.Entry, error) {
+	out := bytes.Buffer{}
+	r := recorder.New(&out, recorder.PeerServer)
+	h := n.recordedConnection(r, "replay", func([]frameshakers.Frame) error { return nil })
+	err := rp.Drive(n.baseCtx, recorder.PeerClient, func(frames []frameshakers.Frame) error {
+		_, herr := h.Handle(n.baseCtx, frames)
+		if errors.Is(herr, frameshakers.ErrCloseConnection) {
+			return nil
+		}
+		return herr
+	})
+	cerr := h.Close()
+	if err != nil {
+		return nil, err
 	}
-	return c, nil
+	if cerr != nil {
+		return nil, cerr
+	}
+	transcript, err := recorder.NewReplayer(&out)
+	if err != nil {
+		return nil, err
+	}
+	return transcript.Entries(), nil
+}
+
+func (n *Server) live(f func()) {
+	n.liveMux.Lock()
+	defer n.liveMux.Unlock()
+	f()
+}
+
+func (n *Server) track(c *Connection) {
+	n.live(func() { n.conns[c] = struct{}{} })
+}
+
+func (n *Server) untrack(c *Connection) {
+	n.live(func() { delete(n.conns, c) })
 }
 
-func (n *Server) Close() error {
+// disconnectClients asks every connected client to disconnect.
+func (n *Server) disconnectClients() {
+	conns := []*Connection{}
+	n.live(func() {
+		for c := range n.conns {
+			conns = append(conns, c)
+		}
+	})
+	for _, c := range conns {
+		err := c.SendMessages([]ehp.EsphomeMessageTyper{&ehp.DisconnectRequest{}})
+		if err != nil {
+			slog.Debug("Failed to send disconnect request", "remote", c.remote, "err", err)
+		}
+	}
+}
+
+// dropClients closes the connections of the clients that did not
+// disconnect.
+func (n *Server) dropClients() {
+	n.live(func() {
+		for nconn := range n.netConns {
+			slog.Warn("Dropping client", "remote", nconn.RemoteAddr())
+			nconn.Close()
+		}
+	})
+}
+
+// Close implements component.Component. Clients are sent a DisconnectRequest
+// and are dropped if they did not disconnect before ctx is done.
+func (n *Server) Close(ctx context.Context) error {
+	n.registrySub.Close()
+	n.live(func() {
+		if n.relist != nil {
+			n.relist.Stop()
+		}
+	})
+	if n.recorder != nil {
+		defer n.recorder.Close()
+	}
 	if n.listener != nil {
 		n.listener.Close()
-		n.cancel()
+	}
+	n.disconnectClients()
+	if n.gateway != nil {
+		n.gateway.Detach(n.gatewayName)
+	}
+	done := make(chan struct{})
+	go func() {
 		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		n.cancel()
+	case <-ctx.Done():
+		n.cancel()
+		n.dropClients()
+		<-done
 	}
 	return nil
 }
@@ -44,7 +44,7 @@ func WithAuth(mh MessageHandler) MessageHandler {
 
 var (
 	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.HelloRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Info("Client connected", "clientApiVersionMajor", msg.ApiVersionMajor, "clientApiVersionMinor", msg.ApiVersionMinor, "clientInfo", msg.ClientInfo)
+		slog.Info("Client connected", "remote", c.remote, "clientApiVersionMajor", msg.ApiVersionMajor, "clientApiVersionMinor", msg.ApiVersionMinor, "clientInfo", msg.ClientInfo)
 		c.clientInfo = msg.ClientInfo
 		cfg := core.GetNode(ctx).Config
 		return []ehp.EsphomeMessageTyper{
@@ -76,6 +76,10 @@ var (
 		// option (needs_authentication) = false;
 		return []ehp.EsphomeMessageTyper{&ehp.DisconnectResponse{}}, frameshakers.ErrCloseConnection
 	}))
+	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.DisconnectResponse) ([]ehp.EsphomeMessageTyper, error) {
+		// the client acknowledged a DisconnectRequest sent on shutdown
+		return nil, frameshakers.ErrCloseConnection
+	}))
 	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.PingRequest) ([]ehp.EsphomeMessageTyper, error) {
 
 		// option (needs_setup_connection) = false;
@@ -449,6 +453,7 @@ var (
 					UniqueId:          node.DefaultUniqueId(t, typed),
 					Icon:              typed.Icon(),
 					DeviceClass:       string(typed.DeviceClass()),
+					EventTypes:        typed.EventTypes(),
 				})
 			case entity.Update:
 				ret = append(ret, &ehp.ListEntitiesUpdateResponse{
@@ -462,10 +467,14 @@ var (
 					DeviceClass:       string(typed.DeviceClass()),
 				})
 			case entity.Service:
+				var args []*ehp.ListEntitiesServicesArgument
+				if us, ok := typed.(*UserService); ok {
+					args = us.listArgs()
+				}
 				ret = append(ret, &ehp.ListEntitiesServicesResponse{
 					Key:  typed.HashID(),
 					Name: typed.Name(),
-					Args: nil, // TODO: fill
+					Args: args,
 				})
 			default:
 			}
@@ -475,16 +484,30 @@ var (
 	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.SubscribeStatesRequest) ([]ehp.EsphomeMessageTyper, error) {
 		c.subscribed = true
 		b := bus.Get(ctx)
+		// the handlers only queue the messages, a slow client must not
+		// block the emitters
+		if c.states == nil {
+			c.states = newStateSender(c.SendMessages)
+		}
+		queue := bus.WithName("api " + c.remote)
 		sub := b.HandleEvents(bus.EventHandler(func(t *bus.StateChangeEvent) {
 			r := stateResponse(t.Key, t.NewState)
 			if r != nil {
-				slog.Debug("Sending state change", "key", t.Key, "state", t.NewState, "to", c.clientInfo)
-				err := c.SendMessages([]ehp.EsphomeMessageTyper{r})
-				if err != nil {
-					slog.Error("Failed to send state update", "err", err)
+				if !t.Available {
+					markMissing(r)
 				}
+				slog.Debug("Sending state change", "key", t.Key, "state", t.NewState, "to", c.clientInfo)
+				c.states.State(t.Key, r)
 			}
-		}))
+		}), queue)
+		c.busEvents = append(c.busEvents, sub)
+		sub = b.HandleEvents(bus.EventHandler(func(t *bus.EntityEvent) {
+			slog.Debug("Sending event", "key", t.Key, "type", t.Type, "to", c.clientInfo)
+			c.states.Event(&ehp.EventResponse{
+				Key:       t.Key,
+				EventType: t.Type,
+			})
+		}), queue)
 		c.busEvents = append(c.busEvents, sub)
 		ret := []ehp.EsphomeMessageTyper{}
 		for _, ent := range entity.IterateRegistry(core.GetNode(ctx).Registry) {
@@ -510,23 +533,17 @@ var (
 		return nil, nil
 	}))
 	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.ExecuteServiceRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command execute_service, doing nothing")
-		return nil, nil
-	}))
-	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.CoverCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command cover_command, doing nothing")
-		return nil, nil
-	}))
-	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.FanCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command fan_command, doing nothing")
-		return nil, nil
-	}))
-	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.LightCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command light_command, doing nothing")
+		c.command(ctx, msg.Key, &ExecuteService{
+			Key:  msg.Key,
+			Args: msg.Args,
+		})
 		return nil, nil
 	}))
+	_ = dH(Handler(entityCommand[*ehp.CoverCommandRequest]))
+	_ = dH(Handler(entityCommand[*ehp.FanCommandRequest]))
+	_ = dH(Handler(entityCommand[*ehp.LightCommandRequest]))
 	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.SwitchCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		core.GetNode(ctx).Bus.CallService(&switchcomp.SetState{
+		c.command(ctx, msg.Key, &switchcomp.SetState{
 			Key:   msg.Key,
 			State: msg.State,
 		})
@@ -537,7 +554,7 @@ var (
 		return nil, nil
 	}))
 	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.ClimateCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		core.GetNode(ctx).Bus.CallService(&climate.SetState{
+		c.command(ctx, msg.Key, &climate.SetState{
 			Key:                      msg.Key,
 			HasMode:                  msg.HasMode,
 			Mode:                     entity.ClimateMode(msg.Mode),
@@ -565,54 +582,27 @@ var (
 		return nil, nil
 	}))
 	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.NumberCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		core.GetNode(ctx).Bus.CallService(&number.SetValue{
+		c.command(ctx, msg.Key, &number.SetValue{
 			Key:   msg.Key,
 			State: msg.State,
 		})
 		return nil, nil
 	}))
-	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.SelectCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command select_command, doing nothing")
-		return nil, nil
-	}))
-	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.TextCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command text_command, doing nothing")
-		return nil, nil
-	}))
-	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.SirenCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command siren_command, doing nothing")
-		return nil, nil
-	}))
+	_ = dH(Handler(entityCommand[*ehp.SelectCommandRequest]))
+	_ = dH(Handler(entityCommand[*ehp.TextCommandRequest]))
+	_ = dH(Handler(entityCommand[*ehp.SirenCommandRequest]))
 	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.ButtonCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		core.GetNode(ctx).Bus.CallService(&button.ButtonPress{
+		c.command(ctx, msg.Key, &button.ButtonPress{
 			Key: msg.Key,
 		})
 		return nil, nil
 	}))
-	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.LockCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command lock_command, doing nothing")
-		return nil, nil
-	}))
-	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.ValveCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command valve_command, doing nothing")
-		return nil, nil
-	}))
-	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.MediaPlayerCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command media_player_command, doing nothing")
-		return nil, nil
-	}))
-	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.DateCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command date_command, doing nothing")
-		return nil, nil
-	}))
-	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.TimeCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command time_command, doing nothing")
-		return nil, nil
-	}))
-	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.DateTimeCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command datetime_command, doing nothing")
-		return nil, nil
-	}))
+	_ = dH(Handler(entityCommand[*ehp.LockCommandRequest]))
+	_ = dH(Handler(entityCommand[*ehp.ValveCommandRequest]))
+	_ = dH(Handler(entityCommand[*ehp.MediaPlayerCommandRequest]))
+	_ = dH(Handler(entityCommand[*ehp.DateCommandRequest]))
+	_ = dH(Handler(entityCommand[*ehp.TimeCommandRequest]))
+	_ = dH(Handler(entityCommand[*ehp.DateTimeCommandRequest]))
 	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.SubscribeBluetoothLEAdvertisementsRequest) ([]ehp.EsphomeMessageTyper, error) {
 		slog.Warn("gosthome Node got command subscribe_bluetooth_le_advertisements, doing nothing")
 		return nil, nil
@@ -653,8 +643,5 @@ var (
 		slog.Warn("gosthome Node got command subscribe_voice_assistant, doing nothing")
 		return nil, nil
 	}))
-	_ = dH(Handler(func(ctx context.Context, c *Connection, msg *ehp.AlarmControlPanelCommandRequest) ([]ehp.EsphomeMessageTyper, error) {
-		slog.Warn("gosthome Node got command alarm_control_panel_command, doing nothing")
-		return nil, nil
-	}))
+	_ = dH(Handler(entityCommand[*ehp.AlarmControlPanelCommandRequest]))
 )
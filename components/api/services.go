@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	ehp "github.com/gosthome/gosthome/components/api/esphomeproto"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/lambda"
+)
+
+// serviceArgTypes are the types of the variables of a service.
+var serviceArgTypes = map[string]ehp.ServiceArgType{
+	"bool":   ehp.ServiceArgType_SERVICE_ARG_TYPE_BOOL,
+	"int":    ehp.ServiceArgType_SERVICE_ARG_TYPE_INT,
+	"float":  ehp.ServiceArgType_SERVICE_ARG_TYPE_FLOAT,
+	"string": ehp.ServiceArgType_SERVICE_ARG_TYPE_STRING,
+}
+
+func lambdaType(t ehp.ServiceArgType) lambda.Type {
+	switch t {
+	case ehp.ServiceArgType_SERVICE_ARG_TYPE_BOOL:
+		return lambda.Bool
+	case ehp.ServiceArgType_SERVICE_ARG_TYPE_INT, ehp.ServiceArgType_SERVICE_ARG_TYPE_FLOAT:
+		return lambda.Number
+	case ehp.ServiceArgType_SERVICE_ARG_TYPE_STRING:
+		return lambda.String
+	}
+	return lambda.Invalid
+}
+
+// ServiceConfig is a service clients like Home Assistant call, its actions
+// get the variables as args.
+type ServiceConfig struct {
+	Service string `yaml:"service"`
+	// Variables are the types of the args by name: bool, int, float or
+	// string.
+	Variables map[string]string  `yaml:"variables"`
+	Then      automation.Actions `yaml:"then"`
+}
+
+// serviceConfig decodes the fields of a ServiceConfig.
+type serviceConfig ServiceConfig
+
+// UnmarshalYAML implements yaml.NodeUnmarshalerContext, the lambdas of the
+// actions have the variables.
+func (c *ServiceConfig) UnmarshalYAML(ctx context.Context, node ast.Node) error {
+	dec := ctx.Value(cv.ConfigYAMLDecoderKey{}).(*yaml.Decoder)
+	var values []*ast.MappingValueNode
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		values = n.Values
+	case *ast.MappingValueNode:
+		values = []*ast.MappingValueNode{n}
+	}
+	for _, mv := range values {
+		if mv.Key.GetToken().Value != "variables" {
+			continue
+		}
+		if err := dec.DecodeFromNodeContext(ctx, mv.Value, &c.Variables); err != nil {
+			return err
+		}
+	}
+	vars := lambda.Vars{}
+	for name, typ := range c.Variables {
+		if t, ok := serviceArgTypes[typ]; ok {
+			vars[name] = lambdaType(t)
+		}
+	}
+	return dec.DecodeFromNodeContext(lambda.WithVars(ctx, vars), node, (*serviceConfig)(c))
+}
+
+func (c *ServiceConfig) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, c,
+		validation.Field(&c.Service, validation.Required, cv.String(cv.Name())),
+		validation.Field(&c.Variables, validation.Each(cv.String(cv.OneOf("bool", "int", "float", "string")))),
+		validation.Field(&c.Then, validation.Required),
+	)
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (c *serviceConfig) ValidateWithContext(ctx context.Context) error {
+	return (*ServiceConfig)(c).ValidateWithContext(ctx)
+}
+
+var _ yaml.NodeUnmarshalerContext = (*ServiceConfig)(nil)
+var _ cv.Validatable = (*ServiceConfig)(nil)
+var _ cv.Validatable = (*serviceConfig)(nil)
+
+type serviceArg struct {
+	name string
+	typ  ehp.ServiceArgType
+}
+
+// UserService is a service of the api config, it runs its actions in the
+// background when a client executes it.
+type UserService struct {
+	entity.BaseEntity
+	component.WithInitializationPriorityProcessor
+
+	ctx     context.Context
+	args    []serviceArg
+	actions automation.Actions
+}
+
+func newUserService(ctx context.Context, sc *ServiceConfig) *UserService {
+	s := &UserService{
+		BaseEntity: entity.NewBaseEntity(entity.DomainTypeService, &entity.EntityConfig{
+			ID:   sc.Service,
+			Name: sc.Service,
+		}),
+		ctx:     ctx,
+		actions: sc.Then,
+	}
+	// clients send the args in the order of the list entities response,
+	// which is sorted by name
+	for name, typ := range sc.Variables {
+		s.args = append(s.args, serviceArg{name: name, typ: serviceArgTypes[typ]})
+	}
+	slices.SortFunc(s.args, func(a, b serviceArg) int {
+		return strings.Compare(a.name, b.name)
+	})
+	return s
+}
+
+// Setup implements component.Component.
+func (s *UserService) Setup(ctx context.Context) error {
+	return nil
+}
+
+// Close implements component.Component.
+func (s *UserService) Close(ctx context.Context) error {
+	return nil
+}
+
+func (s *UserService) listArgs() []*ehp.ListEntitiesServicesArgument {
+	ret := make([]*ehp.ListEntitiesServicesArgument, 0, len(s.args))
+	for _, a := range s.args {
+		ret = append(ret, &ehp.ListEntitiesServicesArgument{Name: a.name, Type: a.typ})
+	}
+	return ret
+}
+
+// Execute runs the actions with the args, they are in the order of the
+// list entities response.
+func (s *UserService) Execute(args []*ehp.ExecuteServiceArgument) error {
+	if len(args) != len(s.args) {
+		return fmt.Errorf("service %s takes %d args, got %d", s.ID(), len(s.args), len(args))
+	}
+	vals := automation.Args{}
+	for i, a := range s.args {
+		switch a.typ {
+		case ehp.ServiceArgType_SERVICE_ARG_TYPE_BOOL:
+			vals[a.name] = args[i].Bool_
+		case ehp.ServiceArgType_SERVICE_ARG_TYPE_INT:
+			vals[a.name] = float64(args[i].Int_)
+		case ehp.ServiceArgType_SERVICE_ARG_TYPE_FLOAT:
+			vals[a.name] = float64(args[i].Float_)
+		case ehp.ServiceArgType_SERVICE_ARG_TYPE_STRING:
+			vals[a.name] = args[i].String_
+		}
+	}
+	automation.GetRunner(s.ctx).Run("api service "+s.ID(), s.actions, vals)
+	return nil
+}
+
+var _ entity.Service = (*UserService)(nil)
+
+// ExecuteService runs the user service with the key.
+type ExecuteService struct {
+	Key  uint32
+	Args []*ehp.ExecuteServiceArgument
+}
+
+// ServiceType implements bus.ServiceRequestData.
+func (e *ExecuteService) ServiceType() string {
+	return "api.execute_service"
+}
+
+var _ bus.ServiceRequestData = (*ExecuteService)(nil)
+
+// newServices creates the service domain with the services of the config
+// and handles their calls on the bus.
+func newServices(ctx context.Context, node *core.Node, cfgs []*ServiceConfig) ([]component.Component, error) {
+	domain := &entity.ServiceDomain{}
+	ret := []component.Component{domain}
+	for _, sc := range cfgs {
+		s := newUserService(ctx, sc)
+		if err := domain.Register(s); err != nil {
+			return nil, err
+		}
+		ret = append(ret, s)
+	}
+	if err := node.CreateDomain(entity.PublicDomain(domain)); err != nil {
+		return nil, err
+	}
+	b := node.Bus
+	sub := b.HandleServiceCalls(bus.ServiceHandlerWithRespose(b, func(t *ExecuteService) error {
+		ent, ok := domain.FindByKey(t.Key)
+		if !ok {
+			return bus.ErrNotHandled
+		}
+		s, ok := ent.(*UserService)
+		if !ok {
+			return fmt.Errorf("service %s is not a service of the api config", ent.ID())
+		}
+		return s.Execute(t.Args)
+	}))
+	domain.OnClose(sub.Close)
+	return ret, nil
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ehp "github.com/gosthome/gosthome/components/api/esphomeproto"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/matryer/is"
+)
+
+// recordArgs is an action that sends the args it runs with.
+type recordArgs chan automation.Args
+
+func (r recordArgs) ValidateWithContext(ctx context.Context) error { return nil }
+
+func (r recordArgs) Run(ctx context.Context) error {
+	r <- automation.GetArgs(ctx)
+	return nil
+}
+
+func TestUserServiceArgsOrder(t *testing.T) {
+	is := is.New(t)
+	r := automation.NewRunner(context.Background())
+	defer r.Stop(context.Background())
+	got := make(recordArgs, 1)
+	s := newUserService(automation.Context(context.Background(), r), &ServiceConfig{
+		Service:   "s",
+		Variables: map[string]string{"zone": "string", "amount": "int", "enabled": "bool", "level": "float"},
+		Then:      automation.Actions{{Name: "record", Value: got}},
+	})
+
+	// a client sends the args in the order of the list entities response
+	listed := s.listArgs()
+	names := []string{}
+	args := []*ehp.ExecuteServiceArgument{}
+	for _, a := range listed {
+		names = append(names, a.Name)
+		switch a.Type {
+		case ehp.ServiceArgType_SERVICE_ARG_TYPE_BOOL:
+			args = append(args, &ehp.ExecuteServiceArgument{Bool_: true})
+		case ehp.ServiceArgType_SERVICE_ARG_TYPE_INT:
+			args = append(args, &ehp.ExecuteServiceArgument{Int_: 5})
+		case ehp.ServiceArgType_SERVICE_ARG_TYPE_FLOAT:
+			args = append(args, &ehp.ExecuteServiceArgument{Float_: 0.5})
+		case ehp.ServiceArgType_SERVICE_ARG_TYPE_STRING:
+			args = append(args, &ehp.ExecuteServiceArgument{String_: "kitchen"})
+		}
+	}
+	is.Equal(names, []string{"amount", "enabled", "level", "zone"})
+	is.NoErr(s.Execute(args))
+	select {
+	case vals := <-got:
+		is.Equal(vals, automation.Args{"amount": 5.0, "enabled": true, "level": 0.5, "zone": "kitchen"})
+	case <-time.After(5 * time.Second):
+		t.Fatal("the service did not run")
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"log/slog"
+	"sync"
+
+	ehp "github.com/gosthome/gosthome/components/api/esphomeproto"
+)
+
+// maxPendingEvents bounds the entity events waiting for a slow client.
+const maxPendingEvents = 64
+
+// stateSender sends the state updates and entity events of a subscribed
+// client in the background, so a slow client never blocks the bus. While a
+// state waits to be sent a newer state of the entity replaces it: the client
+// only needs the latest one. Events can not be coalesced, when too many are
+// waiting the client is asked to disconnect and gets the states again once
+// it reconnects.
+type stateSender struct {
+	send func([]ehp.EsphomeMessageTyper) error
+
+	mux        sync.Mutex
+	pending    []ehp.EsphomeMessageTyper
+	stateIndex map[uint32]int
+	events     int
+	overflowed bool
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+func newStateSender(send func([]ehp.EsphomeMessageTyper) error) *stateSender {
+	s := &stateSender{
+		send:       send,
+		stateIndex: map[uint32]int{},
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// State queues the state of the entity with the key, replacing the one
+// still waiting.
+func (s *stateSender) State(key uint32, msg ehp.EsphomeMessageTyper) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.overflowed {
+		return
+	}
+	if i, ok := s.stateIndex[key]; ok {
+		s.pending[i] = msg
+		return
+	}
+	s.stateIndex[key] = len(s.pending)
+	s.pending = append(s.pending, msg)
+	s.signal()
+}
+
+// Event queues an entity event.
+func (s *stateSender) Event(msg ehp.EsphomeMessageTyper) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.overflowed {
+		return
+	}
+	if s.events >= maxPendingEvents {
+		s.overflowed = true
+		s.pending = []ehp.EsphomeMessageTyper{&ehp.DisconnectRequest{}}
+		clear(s.stateIndex)
+		s.signal()
+		return
+	}
+	s.events++
+	s.pending = append(s.pending, msg)
+	s.signal()
+}
+
+func (s *stateSender) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *stateSender) run() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+		}
+		s.mux.Lock()
+		msgs := s.pending
+		s.pending = nil
+		s.events = 0
+		clear(s.stateIndex)
+		overflowed := s.overflowed
+		s.mux.Unlock()
+		if len(msgs) == 0 {
+			continue
+		}
+		if overflowed {
+			slog.Warn("Client is too slow for the entity events, asking it to reconnect")
+		}
+		if err := s.send(msgs); err != nil {
+			slog.Error("Failed to send state updates", "err", err)
+		}
+	}
+}
+
+// Close stops sending, the queued messages are dropped.
+func (s *stateSender) Close() {
+	close(s.done)
+}
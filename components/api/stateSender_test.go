@@ -0,0 +1,76 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	ehp "github.com/gosthome/gosthome/components/api/esphomeproto"
+	"github.com/matryer/is"
+)
+
+// blockedClient is a client that reads the messages when it is released.
+type blockedClient struct {
+	release chan struct{}
+	sent    chan []ehp.EsphomeMessageTyper
+}
+
+func newBlockedClient() *blockedClient {
+	return &blockedClient{release: make(chan struct{}), sent: make(chan []ehp.EsphomeMessageTyper, 10)}
+}
+
+func (b *blockedClient) send(msgs []ehp.EsphomeMessageTyper) error {
+	<-b.release
+	b.sent <- msgs
+	return nil
+}
+
+func (b *blockedClient) next(t *testing.T) []ehp.EsphomeMessageTyper {
+	t.Helper()
+	b.release <- struct{}{}
+	select {
+	case msgs := <-b.sent:
+		return msgs
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing was sent")
+	}
+	return nil
+}
+
+func TestStateSenderCoalesces(t *testing.T) {
+	is := is.New(t)
+	c := newBlockedClient()
+	s := newStateSender(c.send)
+	defer s.Close()
+	s.State(1, &ehp.SwitchStateResponse{Key: 1, State: true})
+	// the sender waits for the client with the first state, the next ones
+	// are queued meanwhile
+	time.Sleep(10 * time.Millisecond)
+	s.State(2, &ehp.SwitchStateResponse{Key: 2, State: true})
+	s.Event(&ehp.EventResponse{Key: 3, EventType: "press"})
+	s.State(1, &ehp.SwitchStateResponse{Key: 1, State: false})
+	s.State(2, &ehp.SwitchStateResponse{Key: 2, State: false})
+	s.Event(&ehp.EventResponse{Key: 3, EventType: "press"})
+	is.Equal(c.next(t), []ehp.EsphomeMessageTyper{&ehp.SwitchStateResponse{Key: 1, State: true}})
+	is.Equal(c.next(t), []ehp.EsphomeMessageTyper{
+		&ehp.SwitchStateResponse{Key: 2, State: false}, // the other entity keeps its update
+		&ehp.EventResponse{Key: 3, EventType: "press"},
+		&ehp.SwitchStateResponse{Key: 1, State: false},
+		&ehp.EventResponse{Key: 3, EventType: "press"}, // events are never dropped
+	})
+}
+
+func TestStateSenderEventOverflow(t *testing.T) {
+	is := is.New(t)
+	c := newBlockedClient()
+	s := newStateSender(c.send)
+	defer s.Close()
+	s.State(1, &ehp.SwitchStateResponse{Key: 1, State: true})
+	time.Sleep(10 * time.Millisecond)
+	for range maxPendingEvents + 1 {
+		s.Event(&ehp.EventResponse{Key: 3, EventType: "press"})
+	}
+	s.State(1, &ehp.SwitchStateResponse{Key: 1, State: false})
+	c.next(t)
+	// the client gets every state again once it reconnects
+	is.Equal(c.next(t), []ehp.EsphomeMessageTyper{&ehp.DisconnectRequest{}})
+}
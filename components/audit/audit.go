@@ -0,0 +1,215 @@
+// Package audit persists the commands clients send to entities.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/component/cid"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/oklog/ulid/v2"
+)
+
+type Config struct {
+	cid.IDConfig[Audit]               `yaml:",inline"`
+	component.ConfigOf[Audit, *Audit] `yaml:"-"`
+	// Path of the JSONL audit log.
+	Path string `yaml:"path"`
+	// MaxSize in bytes after which the log is rotated.
+	MaxSize int64 `yaml:"max_size"`
+	// MaxBackups is the number of rotated logs to keep.
+	MaxBackups int `yaml:"max_backups"`
+}
+
+func NewConfig() *Config {
+	return &Config{
+		Path:       "audit.jsonl",
+		MaxSize:    10 * 1024 * 1024,
+		MaxBackups: 5,
+	}
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (c *Config) ValidateWithContext(ctx context.Context) error {
+	return cv.ValidateEmbedded(
+		c.IDConfig.ValidateWithContext(ctx),
+		validation.ValidateStructWithContext(
+			ctx, c,
+			validation.Field(&c.Path, validation.Required),
+			validation.Field(&c.MaxSize, validation.Min(int64(1024))),
+			validation.Field(&c.MaxBackups, validation.Min(0)),
+		),
+	)
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Record is one line of the audit log.
+type Record struct {
+	Time time.Time `json:"time"`
+	// Event is either "command" or "response".
+	Event     string            `json:"event"`
+	Client    string            `json:"client,omitempty"`
+	Remote    string            `json:"remote,omitempty"`
+	Domain    string            `json:"domain,omitempty"`
+	Entity    string            `json:"entity,omitempty"`
+	Key       uint32            `json:"key,omitempty"`
+	Command   string            `json:"command,omitempty"`
+	Args      any               `json:"args,omitempty"`
+	Result    bus.CommandResult `json:"result,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// pendingTTL is how long a response waits for its command and a command
+// for its response before they are forgotten.
+const pendingTTL = time.Minute
+
+type Audit struct {
+	cid.CID
+	component.WithInitializationPriorityData
+
+	ctx  context.Context
+	cfg  *Config
+	file *rotatingFile
+	enc  *json.Encoder
+	subs []bus.EventSubsciption
+
+	// responses are the service responses not yet matched with a command,
+	// they are usually emitted before their command is reported.
+	responses map[ulid.ULID]pendingResponse
+	// awaited are the audited commands whose response was not emitted yet,
+	// e.g. the ones that timed out.
+	awaited map[ulid.ULID]time.Time
+}
+
+type pendingResponse struct {
+	at time.Time
+	e  *bus.ServiceResponseEvent
+}
+
+func New(ctx context.Context, cfg *Config) ([]component.Component, error) {
+	id := cfg.ID
+	if id == "" {
+		id = "audit"
+	}
+	return []component.Component{&Audit{
+		CID:       cid.NewID(id),
+		ctx:       ctx,
+		cfg:       cfg,
+		responses: map[ulid.ULID]pendingResponse{},
+		awaited:   map[ulid.ULID]time.Time{},
+	}}, nil
+}
+
+// Setup implements component.Component.
+func (a *Audit) Setup(ctx context.Context) error {
+	var err error
+	a.file, err = openRotatingFile(a.cfg.Path, a.cfg.MaxSize, a.cfg.MaxBackups)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log %s: %w", a.cfg.Path, err)
+	}
+	a.enc = json.NewEncoder(a.file)
+	b := core.GetNode(a.ctx).Bus
+	// a single subscription keeps the writes in one goroutine and the
+	// responses ahead of their commands
+	a.subs = append(a.subs,
+		b.HandleEvents(bus.AnyEventHandler(a.handle).Filter(bus.OfType("command", "service_response"))),
+	)
+	return nil
+}
+
+func (a *Audit) handle(e *bus.Event) {
+	now := time.Now()
+	for id, r := range a.responses {
+		if now.Sub(r.at) > pendingTTL {
+			delete(a.responses, id)
+		}
+	}
+	for id, at := range a.awaited {
+		if now.Sub(at) > pendingTTL {
+			delete(a.awaited, id)
+		}
+	}
+	switch ed := e.EventData.(type) {
+	case *bus.CommandEvent:
+		a.command(ed)
+	case *bus.ServiceResponseEvent:
+		if _, ok := a.awaited[ed.RequestID]; ok {
+			delete(a.awaited, ed.RequestID)
+			a.response(ed)
+			return
+		}
+		a.responses[ed.RequestID] = pendingResponse{at: now, e: ed}
+	}
+}
+
+func (a *Audit) write(r *Record) {
+	err := a.enc.Encode(r)
+	if err != nil {
+		slog.Error("Failed to write audit log", "err", err)
+	}
+}
+
+func (a *Audit) command(e *bus.CommandEvent) {
+	r := &Record{
+		Time:    e.Time,
+		Event:   "command",
+		Client:  e.Client,
+		Remote:  e.Remote,
+		Domain:  e.Domain,
+		Entity:  e.Entity,
+		Key:     e.Key,
+		Command: e.Command,
+		Args:    e.Args,
+		Result:  e.Result,
+	}
+	if e.RequestID != nil {
+		r.RequestID = e.RequestID.String()
+	}
+	if e.Err != nil {
+		r.Error = e.Err.Error()
+	}
+	a.write(r)
+	if e.RequestID == nil {
+		return
+	}
+	if resp, ok := a.responses[*e.RequestID]; ok {
+		delete(a.responses, *e.RequestID)
+		a.response(resp.e)
+		return
+	}
+	a.awaited[*e.RequestID] = time.Now()
+}
+
+func (a *Audit) response(e *bus.ServiceResponseEvent) {
+	r := &Record{
+		Time:      time.Now(),
+		Event:     "response",
+		RequestID: e.RequestID.String(),
+	}
+	if err, ok := e.Response.(error); ok && err != nil {
+		r.Error = err.Error()
+	}
+	a.write(r)
+}
+
+// Close implements component.Component.
+func (a *Audit) Close(ctx context.Context) error {
+	for _, sub := range a.subs {
+		sub.Close()
+	}
+	if a.file != nil {
+		return a.file.Close()
+	}
+	return nil
+}
+
+var _ component.Component = (*Audit)(nil)
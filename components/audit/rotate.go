@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// rotatingFile is an append only file that is moved to path.1 once it grows
+// past maxSize. Older files are shifted up to path.<maxBackups>.
+type rotatingFile struct {
+	mux        sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	// f is nil when the file is closed or could not be reopened after a
+	// failed rotation, the next write then reopens it.
+	f      *os.File
+	size   int64
+	closed bool
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	rf := &rotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	err := rf.open()
+	if err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	rf.f = f
+	rf.size = st.Size()
+	return nil
+}
+
+func (rf *rotatingFile) backup(n int) string {
+	return fmt.Sprintf("%s.%d", rf.path, n)
+}
+
+func (rf *rotatingFile) rotate() error {
+	err := rf.shift()
+	if err != nil {
+		// keep appending to the current file, the next write rotates again.
+		// If it cannot be reopened f stays nil and the next write retries.
+		_ = rf.open()
+		return err
+	}
+	return rf.open()
+}
+
+// shift closes the file and moves it and its backups up one place.
+func (rf *rotatingFile) shift() error {
+	err := rf.f.Close()
+	rf.f = nil
+	if err != nil {
+		return err
+	}
+	if rf.maxBackups > 0 {
+		for i := rf.maxBackups - 1; i > 0; i-- {
+			err = os.Rename(rf.backup(i), rf.backup(i+1))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		err = os.Rename(rf.path, rf.backup(1))
+	} else {
+		err = os.Remove(rf.path)
+	}
+	return err
+}
+
+// Write implements io.Writer. A single write is never split between files.
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mux.Lock()
+	defer rf.mux.Unlock()
+	if rf.closed {
+		return 0, os.ErrClosed
+	}
+	if rf.f == nil {
+		err := rf.open()
+		if err != nil {
+			return 0, fmt.Errorf("error reopening %s: %w", rf.path, err)
+		}
+	}
+	if rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		err := rf.rotate()
+		if err != nil {
+			return 0, fmt.Errorf("error rotating %s: %w", rf.path, err)
+		}
+	}
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Close implements io.Closer.
+func (rf *rotatingFile) Close() error {
+	rf.mux.Lock()
+	defer rf.mux.Unlock()
+	rf.closed = true
+	if rf.f == nil {
+		return nil
+	}
+	err := rf.f.Close()
+	rf.f = nil
+	return err
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRotatingFile(t *testing.T) {
+	is := is.New(t)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	rf, err := openRotatingFile(path, 10, 2)
+	is.NoErr(err)
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n", "eeee\n", "ffff\n", "gggg\n"} {
+		_, err = rf.Write([]byte(line))
+		is.NoErr(err)
+	}
+	is.NoErr(rf.Close())
+
+	read := func(p string) string {
+		b, err := os.ReadFile(p)
+		is.NoErr(err)
+		return string(b)
+	}
+	is.Equal(read(path), "gggg\n")
+	is.Equal(read(path+".1"), "eeee\nffff\n")
+	is.Equal(read(path+".2"), "cccc\ndddd\n")
+	_, err = os.Stat(path + ".3")
+	is.True(os.IsNotExist(err))
+
+	// reopening appends to the current file
+	rf, err = openRotatingFile(path, 10, 2)
+	is.NoErr(err)
+	_, err = rf.Write([]byte("hhhh\n"))
+	is.NoErr(err)
+	is.NoErr(rf.Close())
+	is.True(strings.HasPrefix(read(path), "gggg\nhhhh\n"))
+}
+
+func TestRotatingFileRecovers(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+	rf, err := openRotatingFile(path, 10, 1)
+	is.NoErr(err)
+	defer rf.Close()
+	_, err = rf.Write([]byte("aaaa\nbbbb\n"))
+	is.NoErr(err)
+
+	// a directory in place of the backup fails the rotation
+	is.NoErr(os.MkdirAll(filepath.Join(path+".1", "x"), 0o700))
+	_, err = rf.Write([]byte("cccc\n"))
+	is.True(err != nil)
+
+	is.NoErr(os.RemoveAll(path + ".1"))
+	_, err = rf.Write([]byte("dddd\n"))
+	is.NoErr(err)
+	b, err := os.ReadFile(path + ".1")
+	is.NoErr(err)
+	is.Equal(string(b), "aaaa\nbbbb\n")
+	b, err = os.ReadFile(path)
+	is.NoErr(err)
+	is.Equal(string(b), "dddd\n")
+}
@@ -0,0 +1,75 @@
+package binarysensor
+
+import (
+	"context"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/lambda"
+	"github.com/gosthome/gosthome/core/registry"
+)
+
+var (
+	_ = registry.RegisterDefaultCondition("binary_sensor.is_on", func() automation.Condition {
+		return &Condition{on: true}
+	})
+	_ = registry.RegisterDefaultCondition("binary_sensor.is_off", func() automation.Condition {
+		return &Condition{on: false}
+	})
+)
+
+// Condition is true when the binary sensor is on, or off, e.g.
+// binary_sensor.is_on: door. It is false while the state is missing.
+type Condition struct {
+	ID cid.Ref[entity.BinarySensor] `yaml:"id"`
+	on bool
+}
+
+// Shorthand implements automation.Shorthand.
+func (c *Condition) Shorthand() any {
+	return &c.ID
+}
+
+// ValidateWithContext implements automation.Condition.
+func (c *Condition) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, c, validation.Field(&c.ID))
+}
+
+// Check implements automation.Condition.
+func (c *Condition) Check(ctx context.Context) (bool, error) {
+	bs, ok := core.GetNode(ctx).BinarySensorByKey(c.ID.CID().HashID())
+	if !ok {
+		return false, nil
+	}
+	s := bs.State()
+	return !s.Missing && s.State == c.on, nil
+}
+
+var _ automation.Condition = (*Condition)(nil)
+var _ automation.Shorthand = (*Condition)(nil)
+
+var _ = registry.RegisterDefaultObject[entity.BinarySensor](lambda.Object{Fields: map[string]lambda.Field{
+	"state": {Type: lambda.Bool, Get: func(ctx context.Context, id string) (any, error) {
+		st, err := binarySensorState(ctx, id)
+		return st.State, err
+	}},
+	"has_state": {Type: lambda.Bool, Get: func(ctx context.Context, id string) (any, error) {
+		st, err := binarySensorState(ctx, id)
+		return !st.Missing, err
+	}},
+}})
+
+func binarySensorState(ctx context.Context, id string) (entity.BinarySensorState, error) {
+	n := core.GetNode(ctx)
+	if n == nil {
+		return entity.BinarySensorState{Missing: true}, lambda.ErrNotFound
+	}
+	bs, ok := n.BinarySensorByKey(cid.HashID(id))
+	if !ok {
+		return entity.BinarySensorState{Missing: true}, lambda.ErrNotFound
+	}
+	return bs.State(), nil
+}
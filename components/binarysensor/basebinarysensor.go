@@ -2,7 +2,10 @@ package binarysensor
 
 import (
 	"context"
+	"reflect"
 
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/bus"
 	"github.com/gosthome/gosthome/core/component"
 	cv "github.com/gosthome/gosthome/core/configvalidation"
 	"github.com/gosthome/gosthome/core/entity"
@@ -14,10 +17,16 @@ type BaseBinarySensorConfig[T any, PT interface {
 	component.Component
 	entity.BinarySensor
 }] struct {
-	component.ConfigOf[T, PT]
+	component.ConfigOf[T, PT]                                                                      `yaml:"-"`
 	entity.EntityConfig                                                                            `yaml:",inline"`
 	entity.DeviceClassMixinConfig[entity.BinarySensorDeviceClass, *entity.BinarySensorDeviceClass] `yaml:",inline"`
 	entity.IconMixinConfig                                                                         `yaml:",inline"`
+
+	OnPress   automation.Trigger[bool] `yaml:"on_press"`
+	OnRelease automation.Trigger[bool] `yaml:"on_release"`
+	// OnState runs on every change with the state as x, not when the sensor
+	// only becomes unavailable or available again.
+	OnState automation.Trigger[bool] `yaml:"on_state"`
 }
 
 func (bsc *BaseBinarySensorConfig[T, PT]) ValidateWithContext(ctx context.Context) error {
@@ -28,6 +37,11 @@ func (bsc *BaseBinarySensorConfig[T, PT]) ValidateWithContext(ctx context.Contex
 	)
 }
 
+// DeclaredID implements cid.Declarer.
+func (bsc *BaseBinarySensorConfig[T, PT]) DeclaredID() (*string, reflect.Type) {
+	return bsc.EntityID(), reflect.TypeFor[entity.BinarySensor]()
+}
+
 type BaseBinarySensor[T any, PT interface {
 	*T
 	component.Component
@@ -38,6 +52,10 @@ type BaseBinarySensor[T any, PT interface {
 	entity.IconMixin
 	state.State_[entity.BinarySensorState]
 	isStatusBinarySensor bool
+
+	onPress   automation.Actions
+	onRelease automation.Actions
+	onState   automation.Actions
 }
 
 // IsStatusBinarySensor implements entity.BinarySensor.
@@ -57,9 +75,33 @@ func NewBaseBinarySensor[T any, PT interface {
 	ret.BaseEntity = entity.NewBaseEntity(entity.DomainTypeBinarySensor, &cfg.EntityConfig)
 	ret.DeviceClassMixin = entity.NewDeviceClassMixin(&cfg.DeviceClassMixinConfig)
 	ret.IconMixin = entity.NewIconMixin(&cfg.IconMixinConfig)
+	ret.onPress = cfg.OnPress.Actions
+	ret.onRelease = cfg.OnRelease.Actions
+	ret.onState = cfg.OnState.Actions
 	ret.State_, err = state.NewState(ctx, t, entity.BinarySensorState{
 		State:   false,
 		Missing: true,
-	})
+	}, state.FollowAvailability(&ret.BaseEntity))
 	return
 }
+
+// SubscribeTriggers implements automation.Subscriber.
+func (t *BaseBinarySensor[T, PT]) SubscribeTriggers(ctx context.Context) []bus.EventSubsciption {
+	if len(t.onPress)+len(t.onRelease)+len(t.onState) == 0 {
+		return nil
+	}
+	r := automation.GetRunner(ctx)
+	return []bus.EventSubsciption{automation.OnState(ctx, t, func(old, nv entity.BinarySensorState) {
+		if nv.Missing {
+			return
+		}
+		args := automation.Args{"x": nv.State}
+		r.Run(t.ID()+" on_state", t.onState, args)
+		switch {
+		case nv.State && (old.Missing || !old.State):
+			r.Run(t.ID()+" on_press", t.onPress, args)
+		case !nv.State && !old.Missing && old.State:
+			r.Run(t.ID()+" on_release", t.onRelease, args)
+		}
+	})}
+}
@@ -31,7 +31,7 @@ type testBinarySensor struct {
 }
 
 // Close implements component.Component.
-func (t *testBinarySensor) Close() error {
+func (t *testBinarySensor) Close(ctx context.Context) error {
 	return nil
 }
 
@@ -41,7 +41,8 @@ func (t *testBinarySensor) InitializationPriority() component.InitializationPrio
 }
 
 // Setup implements component.Component.
-func (t *testBinarySensor) Setup() {
+func (t *testBinarySensor) Setup(ctx context.Context) error {
+	return nil
 }
 
 func newTestBinarySensor(ctx context.Context, cfg *testBinarySensorConfig) (ret *testBinarySensor, err error) {
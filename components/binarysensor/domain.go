@@ -5,13 +5,14 @@ import (
 	"log/slog"
 
 	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/automation"
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/config"
 	"github.com/gosthome/gosthome/core/entity"
 )
 
 type Config struct {
-	component.ConfigOf[entity.BinarySensorDomain, *entity.BinarySensorDomain]
+	component.ConfigOf[entity.BinarySensorDomain, *entity.BinarySensorDomain] `yaml:"-"`
 	config.PlatformConfig
 }
 
@@ -41,6 +42,7 @@ func New(ctx context.Context, c *Config) ([]component.Component, error) {
 		}
 		for _, c := range comp {
 			domain.Register(c.(entity.BinarySensor))
+			automation.Subscribe(ctx, c, domain.OnClose)
 		}
 		ret = append(ret, comp...)
 	}
@@ -0,0 +1,40 @@
+package button
+
+import (
+	"context"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/registry"
+)
+
+var _ = registry.RegisterDefaultAction("button.press", func() automation.Action {
+	return &Press{}
+})
+
+// Press presses a button, e.g. button.press: restart.
+type Press struct {
+	ID cid.Ref[entity.Button] `yaml:"id"`
+}
+
+// Shorthand implements automation.Shorthand.
+func (a *Press) Shorthand() any {
+	return &a.ID
+}
+
+// ValidateWithContext implements automation.Action.
+func (a *Press) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, a, validation.Field(&a.ID))
+}
+
+// Run implements automation.Action.
+func (a *Press) Run(ctx context.Context) error {
+	_, err := bus.Call[*ButtonPress, any](ctx, bus.Get(ctx), &ButtonPress{Key: a.ID.CID().HashID()})
+	return err
+}
+
+var _ automation.Action = (*Press)(nil)
+var _ automation.Shorthand = (*Press)(nil)
@@ -2,7 +2,10 @@ package button
 
 import (
 	"context"
+	"reflect"
 
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/bus"
 	"github.com/gosthome/gosthome/core/component"
 	cv "github.com/gosthome/gosthome/core/configvalidation"
 	"github.com/gosthome/gosthome/core/entity"
@@ -13,10 +16,12 @@ type BaseButtonConfig[T any, PT interface {
 	component.Component
 	entity.Button
 }] struct {
-	component.ConfigOf[T, PT]
+	component.ConfigOf[T, PT]                                                          `yaml:"-"`
 	entity.EntityConfig                                                                `yaml:",inline"`
 	entity.DeviceClassMixinConfig[entity.ButtonDeviceClass, *entity.ButtonDeviceClass] `yaml:",inline"`
 	entity.IconMixinConfig                                                             `yaml:",inline"`
+
+	OnPress automation.Actions `yaml:"on_press"`
 }
 
 func (bsc *BaseButtonConfig[T, PT]) ValidateWithContext(ctx context.Context) error {
@@ -26,6 +31,11 @@ func (bsc *BaseButtonConfig[T, PT]) ValidateWithContext(ctx context.Context) err
 		bsc.IconMixinConfig.ValidateWithContext(ctx))
 }
 
+// DeclaredID implements cid.Declarer.
+func (bsc *BaseButtonConfig[T, PT]) DeclaredID() (*string, reflect.Type) {
+	return bsc.EntityID(), reflect.TypeFor[entity.Button]()
+}
+
 type BaseButton[T any, PT interface {
 	*T
 	component.Component
@@ -34,6 +44,8 @@ type BaseButton[T any, PT interface {
 	entity.BaseEntity
 	entity.DeviceClassMixin[entity.ButtonDeviceClass, *entity.ButtonDeviceClass]
 	entity.IconMixin
+
+	onPress automation.Actions
 }
 
 func NewBaseButton[T any, PT interface {
@@ -45,5 +57,17 @@ func NewBaseButton[T any, PT interface {
 		BaseEntity:       entity.NewBaseEntity(entity.DomainTypeButton, &cfg.EntityConfig),
 		DeviceClassMixin: entity.NewDeviceClassMixin(&cfg.DeviceClassMixinConfig),
 		IconMixin:        entity.NewIconMixin(&cfg.IconMixinConfig),
+		onPress:          cfg.OnPress,
 	}, nil
 }
+
+// SubscribeTriggers implements automation.Subscriber.
+func (t *BaseButton[T, PT]) SubscribeTriggers(ctx context.Context) []bus.EventSubsciption {
+	if len(t.onPress) == 0 {
+		return nil
+	}
+	r := automation.GetRunner(ctx)
+	return []bus.EventSubsciption{bus.Get(ctx).HandleEvents(bus.EventHandler(func(*PressEvent) {
+		r.Run(t.ID()+" on_press", t.onPress, nil)
+	}).Filter(bus.ForKeys(t.HashID())))}
+}
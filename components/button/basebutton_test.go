@@ -37,7 +37,7 @@ func (t *testButton) Press(ctx context.Context) error {
 }
 
 // Close implements component.Component.
-func (t *testButton) Close() error {
+func (t *testButton) Close(ctx context.Context) error {
 	return nil
 }
 
@@ -47,7 +47,8 @@ func (t *testButton) InitializationPriority() component.InitializationPriority {
 }
 
 // Setup implements component.Component.
-func (t *testButton) Setup() {
+func (t *testButton) Setup(ctx context.Context) error {
+	return nil
 }
 
 func newTestButton(ctx context.Context, cfg *testButtonConfig) (ret *testButton, err error) {
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/automation"
 	"github.com/gosthome/gosthome/core/bus"
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/config"
@@ -13,7 +14,7 @@ import (
 )
 
 type Config struct {
-	component.ConfigOf[entity.ButtonDomain, *entity.ButtonDomain]
+	component.ConfigOf[entity.ButtonDomain, *entity.ButtonDomain] `yaml:"-"`
 	config.PlatformConfig
 }
 
@@ -32,15 +33,20 @@ func NewConfig() *Config {
 
 // RegisterServiceCallHandlers registers service call handlers for the button domain.
 func RegisterServiceCallHandlers(ctx context.Context, domain *entity.ButtonDomain, b *bus.Bus) {
+	pressed := bus.MakeEventEmitter[PressEvent](b)
 	// Handle button.press service calls.
-	b.HandleServiceCalls(bus.ServiceHandlerWithRespose(b, func(t *ButtonPress) error {
+	sub := b.HandleServiceCalls(bus.ServiceHandlerWithRespose(b, func(t *ButtonPress) error {
 		button, ok := domain.FindByKey(t.Key)
 		if !ok {
-			slog.Error("Tried to press nonexisting button", "key", t.Key)
-			return fmt.Errorf("tried to press nonexisting button %d", t.Key)
+			return bus.ErrNotHandled
 		}
-		return button.Press(ctx)
+		if err := button.Press(ctx); err != nil {
+			return err
+		}
+		pressed.Emit(&PressEvent{Key: t.Key})
+		return nil
 	}))
+	domain.OnClose(sub.Close)
 }
 
 type ButtonPress struct {
@@ -54,6 +60,28 @@ func (b *ButtonPress) ServiceType() string {
 
 var _ bus.ServiceRequestData = (*ButtonPress)(nil)
 
+// PressEvent is emitted when a button was pressed.
+type PressEvent struct {
+	Key uint32
+}
+
+// EventType implements bus.EventData.
+func (e *PressEvent) EventType() string {
+	return "button_press"
+}
+
+// EntityKey implements bus.EntityEventData.
+func (e *PressEvent) EntityKey() uint32 {
+	return e.Key
+}
+
+// EntityDomain implements bus.EntityEventData.
+func (e *PressEvent) EntityDomain() string {
+	return COMPONENT_KEY
+}
+
+var _ bus.EntityEventData = (*PressEvent)(nil)
+
 func New(ctx context.Context, c *Config) ([]component.Component, error) {
 	node := core.GetNode(ctx)
 	if node == nil {
@@ -73,6 +101,7 @@ func New(ctx context.Context, c *Config) ([]component.Component, error) {
 		}
 		for _, c := range comp {
 			domain.Register(c.(entity.Button))
+			automation.Subscribe(ctx, c, domain.OnClose)
 		}
 		ret = append(ret, comp...)
 	}
@@ -2,7 +2,6 @@ package climate
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 
 	"github.com/gosthome/gosthome/core"
@@ -58,7 +57,7 @@ var _ bus.ServiceRequestData = (*SetState)(nil)
 
 // Config for the climate domain.
 type Config struct {
-	component.ConfigOf[entity.ClimateDomain, *entity.ClimateDomain]
+	component.ConfigOf[entity.ClimateDomain, *entity.ClimateDomain] `yaml:"-"`
 	config.PlatformConfig
 }
 
@@ -79,11 +78,10 @@ func NewConfig() *Config {
 // RegisterServiceCallHandlers registers service call handlers for the climate domain.
 func RegisterServiceCallHandlers(ctx context.Context, domain *entity.ClimateDomain, b *bus.Bus) {
 	// Handle climate.set_state service calls.
-	b.HandleServiceCalls(bus.ServiceHandlerWithRespose(b, func(t *SetState) error {
+	sub := b.HandleServiceCalls(bus.ServiceHandlerWithRespose(b, func(t *SetState) error {
 		cl, ok := domain.FindByKey(t.Key)
 		if !ok {
-			slog.Error("Tried to set state on nonexisting climate entity", "key", t.Key)
-			return fmt.Errorf("tried to set state on nonexisting climate entity %d", t.Key)
+			return bus.ErrNotHandled
 		}
 		cur := cl.State()
 
@@ -126,6 +124,7 @@ func RegisterServiceCallHandlers(ctx context.Context, domain *entity.ClimateDoma
 		}
 		return cl.SetState(ctx, cur)
 	}))
+	domain.OnClose(sub.Close)
 }
 
 // New initializes the climate domain, registers entities, and hooks up service handlers.
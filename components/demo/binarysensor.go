@@ -51,9 +51,10 @@ func (d *DemoBinarySensor) InitializationPriority() component.InitializationPrio
 }
 
 // Setup implements component.Poller.
-func (d *DemoBinarySensor) Setup() {
+func (d *DemoBinarySensor) Setup(ctx context.Context) error {
 	d.Poll()
-	d.poll.Setup()
+	d.poll.Setup(ctx)
+	return nil
 }
 
 // Poll implements component.Poller.
@@ -65,8 +66,8 @@ func (d *DemoBinarySensor) Poll() {
 }
 
 // Close implements component.Poller.
-func (d *DemoBinarySensor) Close() error {
-	return d.poll.Close()
+func (d *DemoBinarySensor) Close(ctx context.Context) error {
+	return d.poll.Close(ctx)
 }
 
 var _ (entity.BinarySensor) = (*DemoBinarySensor)(nil)
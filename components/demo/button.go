@@ -2,6 +2,7 @@ package demo
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"math/rand/v2"
 
@@ -12,6 +13,9 @@ import (
 
 type DemoButtonConfig struct {
 	button.BaseButtonConfig[DemoButton, *DemoButton] `yaml:",inline"`
+
+	// Trigger is the id of a demo event to fire instead of reseeding.
+	Trigger string `yaml:"trigger"`
 }
 
 func NewDemoButtonConfig() DemoButtonConfig {
@@ -24,10 +28,14 @@ func (t *DemoButtonConfig) ValidateWithContext(ctx context.Context) error {
 
 type DemoButton struct {
 	button.BaseButton[DemoButton, *DemoButton]
-	demo *Demo
+	demo    *Demo
+	trigger *DemoEvent
 }
 
 func (t *DemoButton) Press(ctx context.Context) error {
+	if t.trigger != nil {
+		return t.trigger.Trigger(t.trigger.EventTypes()[0])
+	}
 	slog.Info("Demo button pressed, reseeding hash")
 	r := rand.New(t.demo.r)
 	t.demo.r.Seed(r.Uint64(), r.Uint64())
@@ -35,7 +43,7 @@ func (t *DemoButton) Press(ctx context.Context) error {
 }
 
 // Close implements component.Component.
-func (t *DemoButton) Close() error {
+func (t *DemoButton) Close(ctx context.Context) error {
 	return nil
 }
 
@@ -45,13 +53,21 @@ func (t *DemoButton) InitializationPriority() component.InitializationPriority {
 }
 
 // Setup implements component.Component.
-func (t *DemoButton) Setup() {
+func (t *DemoButton) Setup(ctx context.Context) error {
+	return nil
 }
 
 func NewDemoButton(ctx context.Context, d *Demo, cfg *DemoButtonConfig) (ret *DemoButton, err error) {
 	ret = &DemoButton{
 		demo: d,
 	}
+	if cfg.Trigger != "" {
+		var ok bool
+		ret.trigger, ok = d.events[cfg.Trigger]
+		if !ok {
+			return nil, fmt.Errorf("demo button %s triggers unknown demo event %s", cfg.Name, cfg.Trigger)
+		}
+	}
 	ret.BaseButton, err = button.NewBaseButton(ctx, ret, &cfg.BaseButtonConfig)
 	if err != nil {
 		return nil, err
@@ -6,19 +6,25 @@ import (
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/gosthome/gosthome/components/binarysensor"
 	"github.com/gosthome/gosthome/components/button"
+	"github.com/gosthome/gosthome/components/event"
+	"github.com/gosthome/gosthome/components/number"
+	"github.com/gosthome/gosthome/components/switchcomp"
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/entity"
 	"github.com/gosthome/gosthome/core/util"
 )
 
 type Config struct {
-	component.ConfigOf[Demo, *Demo]
+	component.ConfigOf[Demo, *Demo] `yaml:"-"`
 
 	Seeds [2]uint64
 
 	BinarySensors []DemoBinarySensorConfig `yaml:"binary_sensors"`
 	Buttons       []DemoButtonConfig       `yaml:"buttons"`
-	Sensors       []DemoSensorConfig       `yaml:"buttons"`
+	Events        []DemoEventConfig        `yaml:"events"`
+	Sensors       []DemoSensorConfig       `yaml:"sensors"`
+	Switches      []DemoSwitchConfig       `yaml:"switches"`
+	Numbers       []DemoNumberConfig       `yaml:"numbers"`
 }
 
 func NewConfig() *Config {
@@ -45,12 +51,46 @@ func NewConfig() *Config {
 				c.DeviceClass = entity.ButtonDeviceClassRestart
 				c.Category = entity.CategoryConfig
 			}),
+			util.Modify(NewDemoButtonConfig(), func(c *DemoButtonConfig) {
+				c.Name = "Demo Ring Doorbell"
+				c.Trigger = "demo_doorbell"
+			}),
+		},
+		Events: []DemoEventConfig{
+			util.Modify(NewDemoEventConfig(), func(c *DemoEventConfig) {
+				c.Name = "Demo Doorbell"
+				c.DeviceClass = entity.EventDeviceClassDoorbell
+				c.EventTypes = []string{"ring"}
+			}),
 		},
 		Sensors: []DemoSensorConfig{
 			util.Modify(NewDemoSensorConfig(), func(c *DemoSensorConfig) {
 				c.Name = "Demo Temperature Sensor"
 				c.DeviceClass = entity.SensorDeviceClassTemperature
-				c.UnitOfMeasurement = "*C"
+				c.UnitOfMeasurement = "°C"
+			}),
+		},
+		Switches: []DemoSwitchConfig{
+			util.Modify(NewDemoSwitchConfig(), func(c *DemoSwitchConfig) {
+				c.Name = "Demo Switch 1"
+				c.RestoreMode = switchcomp.RestoreModeRestoreDefaultOff
+			}),
+			util.Modify(NewDemoSwitchConfig(), func(c *DemoSwitchConfig) {
+				c.Name = "Demo Switch 2"
+				c.RestoreMode = switchcomp.RestoreModeAlwaysOn
+				c.DeviceClass = entity.SwitchDeviceClassOutlet
+			}),
+		},
+		Numbers: []DemoNumberConfig{
+			util.Modify(NewDemoNumberConfig(), func(c *DemoNumberConfig) {
+				c.Name = "Demo Number 1"
+				c.MinValue, c.MaxValue, c.Step = 0, 100, 1
+				c.RestoreValue = true
+			}),
+			util.Modify(NewDemoNumberConfig(), func(c *DemoNumberConfig) {
+				c.Name = "Demo Number 2"
+				c.MinValue, c.MaxValue, c.Step = -50, 50, 0.1
+				c.Mode = entity.NumberModeBox
 			}),
 		},
 	}
@@ -58,7 +98,12 @@ func NewConfig() *Config {
 
 // Validate implements validation.Validatable.
 func (c *Config) ValidateWithContext(ctx context.Context) error {
-	return validation.ValidateStructWithContext(ctx, c, validation.Field(&c.BinarySensors))
+	return validation.ValidateStructWithContext(ctx, c,
+		validation.Field(&c.BinarySensors),
+		validation.Field(&c.Events),
+		validation.Field(&c.Switches),
+		validation.Field(&c.Numbers),
+	)
 }
 
 // AutoLoad implements component.AutoLoader.
@@ -66,6 +111,9 @@ func (c *Config) AutoLoad() component.Dependencies {
 	return component.Depends(
 		binarysensor.COMPONENT_KEY,
 		button.COMPONENT_KEY,
+		event.COMPONENT_KEY,
+		number.COMPONENT_KEY,
+		switchcomp.COMPONENT_KEY,
 	)
 }
 
@@ -39,7 +39,8 @@ var _ rand.Source = (*safePCG)(nil)
 
 type Demo struct {
 	cid.CID
-	r *safePCG
+	r      *safePCG
+	events map[string]*DemoEvent
 }
 
 func New(ctx context.Context, cfg *Config) ([]component.Component, error) {
@@ -48,10 +49,23 @@ func New(ctx context.Context, cfg *Config) ([]component.Component, error) {
 		panic("No node in config during binary_sensors initialization")
 	}
 	d := &Demo{
-		CID: cid.NewID("demo"),
-		r:   newSafePCG(cfg.Seeds[0], cfg.Seeds[1]),
+		CID:    cid.NewID("demo"),
+		r:      newSafePCG(cfg.Seeds[0], cfg.Seeds[1]),
+		events: map[string]*DemoEvent{},
 	}
 	ret := []component.Component{}
+	for _, ec := range cfg.Events {
+		e, err := NewDemoEvent(ctx, &ec)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, e)
+		err = node.RegisterEvent(e)
+		if err != nil {
+			return nil, err
+		}
+		d.events[e.ID()] = e
+	}
 	for _, bsc := range cfg.BinarySensors {
 		bs, err := NewDemoBinarySensor(ctx, d.r, &bsc)
 		if err != nil {
@@ -74,16 +88,38 @@ func New(ctx context.Context, cfg *Config) ([]component.Component, error) {
 			return nil, err
 		}
 	}
+	for _, sc := range cfg.Switches {
+		s, err := NewDemoSwitch(ctx, &sc)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, s)
+		err = node.RegisterSwitch(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, nc := range cfg.Numbers {
+		n, err := NewDemoNumber(ctx, &nc)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, n)
+		err = node.RegisterNumber(n)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return ret, nil
 }
 
 // Setup implements component.Component.
-func (c *Demo) Setup() {
-
+func (c *Demo) Setup(ctx context.Context) error {
+	return nil
 }
 
 // Close implements component.Component.
-func (c *Demo) Close() error {
+func (c *Demo) Close(ctx context.Context) error {
 	return nil
 }
 
@@ -0,0 +1,51 @@
+package demo
+
+import (
+	"context"
+
+	"github.com/gosthome/gosthome/components/event"
+	"github.com/gosthome/gosthome/core/component"
+)
+
+type DemoEventConfig struct {
+	event.BaseEventConfig[DemoEvent, *DemoEvent] `yaml:",inline"`
+}
+
+func NewDemoEventConfig() DemoEventConfig {
+	return DemoEventConfig{}
+}
+
+func (t *DemoEventConfig) ValidateWithContext(ctx context.Context) error {
+	return t.BaseEventConfig.ValidateWithContext(ctx)
+}
+
+type DemoEvent struct {
+	event.BaseEvent[DemoEvent, *DemoEvent]
+}
+
+// Close implements component.Component.
+func (t *DemoEvent) Close(ctx context.Context) error {
+	return nil
+}
+
+// InitializationPriority implements component.Component.
+func (t *DemoEvent) InitializationPriority() component.InitializationPriority {
+	return component.InitializationPriorityProcessor
+}
+
+// Setup implements component.Component.
+func (t *DemoEvent) Setup(ctx context.Context) error {
+	return nil
+}
+
+func NewDemoEvent(ctx context.Context, cfg *DemoEventConfig) (ret *DemoEvent, err error) {
+	ret = &DemoEvent{}
+	ret.BaseEvent, err = event.NewBaseEvent(ctx, ret, &cfg.BaseEventConfig)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+var _ component.Component = (*DemoEvent)(nil)
+var _ event.Triggerer = (*DemoEvent)(nil)
@@ -0,0 +1,59 @@
+package demo
+
+import (
+	"context"
+
+	"github.com/gosthome/gosthome/components/number"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/entity"
+)
+
+type DemoNumberConfig struct {
+	number.BaseNumberConfig[DemoNumber, *DemoNumber] `yaml:",inline"`
+}
+
+func NewDemoNumberConfig() DemoNumberConfig {
+	return DemoNumberConfig{}
+}
+
+func (t *DemoNumberConfig) ValidateWithContext(ctx context.Context) error {
+	return t.BaseNumberConfig.ValidateWithContext(ctx)
+}
+
+// DemoNumber is a number without hardware, it keeps the value it is set to.
+type DemoNumber struct {
+	number.BaseNumber[DemoNumber, *DemoNumber]
+}
+
+func NewDemoNumber(ctx context.Context, cfg *DemoNumberConfig) (ret *DemoNumber, err error) {
+	ret = &DemoNumber{}
+	ret.BaseNumber, err = number.NewBaseNumber(ctx, ret, &cfg.BaseNumberConfig)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// SetValue implements entity.Number.
+func (t *DemoNumber) SetValue(ctx context.Context, v float32) error {
+	t.PublishState(v)
+	return nil
+}
+
+// Setup implements component.Component.
+func (t *DemoNumber) Setup(ctx context.Context) error {
+	return nil
+}
+
+// Close implements component.Component.
+func (t *DemoNumber) Close(ctx context.Context) error {
+	return nil
+}
+
+// InitializationPriority implements component.Component.
+func (t *DemoNumber) InitializationPriority() component.InitializationPriority {
+	return component.InitializationPriorityProcessor
+}
+
+var _ component.Component = (*DemoNumber)(nil)
+var _ entity.Number = (*DemoNumber)(nil)
@@ -51,22 +51,20 @@ func (d *DemoSensor) InitializationPriority() component.InitializationPriority {
 }
 
 // Setup implements component.Poller.
-func (d *DemoSensor) Setup() {
+func (d *DemoSensor) Setup(ctx context.Context) error {
 	d.Poll()
-	d.poll.Setup()
+	d.poll.Setup(ctx)
+	return nil
 }
 
 // Poll implements component.Poller.
 func (d *DemoSensor) Poll() {
-	d.SetState(entity.SensorState{
-		State:        d.rand.Float32(),
-		MissingState: false,
-	})
+	d.PublishState(d.rand.Float32())
 }
 
 // Close implements component.Poller.
-func (d *DemoSensor) Close() error {
-	return d.poll.Close()
+func (d *DemoSensor) Close(ctx context.Context) error {
+	return d.poll.Close(ctx)
 }
 
 var _ (entity.Sensor) = (*DemoSensor)(nil)
@@ -0,0 +1,59 @@
+package demo
+
+import (
+	"context"
+
+	"github.com/gosthome/gosthome/components/switchcomp"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/entity"
+)
+
+type DemoSwitchConfig struct {
+	switchcomp.BaseSwitchConfig[DemoSwitch, *DemoSwitch] `yaml:",inline"`
+}
+
+func NewDemoSwitchConfig() DemoSwitchConfig {
+	return DemoSwitchConfig{}
+}
+
+func (t *DemoSwitchConfig) ValidateWithContext(ctx context.Context) error {
+	return t.BaseSwitchConfig.ValidateWithContext(ctx)
+}
+
+// DemoSwitch is a switch without hardware, it turns on and off as told.
+type DemoSwitch struct {
+	switchcomp.BaseSwitch[DemoSwitch, *DemoSwitch]
+}
+
+func NewDemoSwitch(ctx context.Context, cfg *DemoSwitchConfig) (ret *DemoSwitch, err error) {
+	ret = &DemoSwitch{}
+	ret.BaseSwitch, err = switchcomp.NewBaseSwitch(ctx, ret, &cfg.BaseSwitchConfig)
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// SetState implements entity.Switch.
+func (t *DemoSwitch) SetState(ctx context.Context, on bool) error {
+	t.PublishState(on)
+	return nil
+}
+
+// Setup implements component.Component.
+func (t *DemoSwitch) Setup(ctx context.Context) error {
+	return nil
+}
+
+// Close implements component.Component.
+func (t *DemoSwitch) Close(ctx context.Context) error {
+	return nil
+}
+
+// InitializationPriority implements component.Component.
+func (t *DemoSwitch) InitializationPriority() component.InitializationPriority {
+	return component.InitializationPriorityProcessor
+}
+
+var _ component.Component = (*DemoSwitch)(nil)
+var _ entity.Switch = (*DemoSwitch)(nil)
@@ -0,0 +1,88 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/entity"
+)
+
+var ErrUnknownEventType = errors.New("unknown event type")
+
+type BaseEventConfig[T any, PT interface {
+	*T
+	component.Component
+	entity.Event
+}] struct {
+	component.ConfigOf[T, PT]                                                        `yaml:"-"`
+	entity.EntityConfig                                                              `yaml:",inline"`
+	entity.DeviceClassMixinConfig[entity.EventDeviceClass, *entity.EventDeviceClass] `yaml:",inline"`
+	entity.IconMixinConfig                                                           `yaml:",inline"`
+
+	EventTypes []string `yaml:"event_types"`
+}
+
+func (bec *BaseEventConfig[T, PT]) ValidateWithContext(ctx context.Context) error {
+	return cv.ValidateEmbedded(
+		bec.EntityConfig.ValidateWithContext(ctx),
+		bec.DeviceClassMixinConfig.ValidateWithContext(ctx),
+		bec.IconMixinConfig.ValidateWithContext(ctx),
+		validation.ValidateStructWithContext(ctx, bec,
+			validation.Field(&bec.EventTypes, validation.Required, validation.Each(validation.Required)),
+		),
+	)
+}
+
+type BaseEvent[T any, PT interface {
+	*T
+	component.Component
+	entity.Event
+}] struct {
+	entity.BaseEntity
+	entity.DeviceClassMixin[entity.EventDeviceClass, *entity.EventDeviceClass]
+	entity.IconMixin
+
+	emitter    bus.Emitter[bus.EntityEvent, *bus.EntityEvent]
+	e          entity.Entity
+	eventTypes []string
+}
+
+func NewBaseEvent[T any, PT interface {
+	*T
+	component.Component
+	entity.Event
+}](ctx context.Context, t PT, cfg *BaseEventConfig[T, PT]) (BaseEvent[T, PT], error) {
+	return BaseEvent[T, PT]{
+		BaseEntity:       entity.NewBaseEntity(entity.DomainTypeEvent, &cfg.EntityConfig),
+		DeviceClassMixin: entity.NewDeviceClassMixin(&cfg.DeviceClassMixinConfig),
+		IconMixin:        entity.NewIconMixin(&cfg.IconMixinConfig),
+		emitter:          bus.MakeEventEmitter[bus.EntityEvent](bus.Get(ctx)),
+		e:                t,
+		eventTypes:       slices.Clone(cfg.EventTypes),
+	}, nil
+}
+
+// EventTypes implements entity.Event.
+func (b *BaseEvent[T, PT]) EventTypes() []string {
+	return b.eventTypes
+}
+
+// Trigger fires the event, eventType must be one of the configured types.
+func (b *BaseEvent[T, PT]) Trigger(eventType string) error {
+	if !slices.Contains(b.eventTypes, eventType) {
+		return fmt.Errorf("%w %q for %s", ErrUnknownEventType, eventType, b.e.ID())
+	}
+	slog.Info("Firing event", "id", b.e.ID(), "type", eventType)
+	b.emitter.Emit(&bus.EntityEvent{
+		Key:  b.e.HashID(),
+		Type: eventType,
+	})
+	return nil
+}
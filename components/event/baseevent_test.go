@@ -0,0 +1,66 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/matryer/is"
+)
+
+type testEvent struct {
+	BaseEvent[testEvent, *testEvent]
+}
+
+// Close implements component.Component.
+func (t *testEvent) Close(ctx context.Context) error {
+	return nil
+}
+
+// InitializationPriority implements component.Component.
+func (t *testEvent) InitializationPriority() component.InitializationPriority {
+	return component.InitializationPriorityProcessor
+}
+
+// Setup implements component.Component.
+func (t *testEvent) Setup(ctx context.Context) error {
+	return nil
+}
+
+var _ component.Component = (*testEvent)(nil)
+var _ Triggerer = (*testEvent)(nil)
+
+func TestBaseEventTrigger(t *testing.T) {
+	is := is.New(t)
+	b := bus.New()
+	ctx := bus.Context(context.Background(), b)
+	fired := make(chan *bus.EntityEvent, 1)
+	b.HandleEvents(bus.EventHandler(func(e *bus.EntityEvent) {
+		fired <- e
+	}))
+
+	ev := &testEvent{}
+	var err error
+	ev.BaseEvent, err = NewBaseEvent(ctx, ev, &BaseEventConfig[testEvent, *testEvent]{
+		EntityConfig: entity.EntityConfig{Name: "Front Door"},
+		EventTypes:   []string{"ring", "knock"},
+	})
+	is.NoErr(err)
+	is.Equal(ev.EventTypes(), []string{"ring", "knock"})
+
+	err = ev.Trigger("open")
+	is.True(errors.Is(err, ErrUnknownEventType))
+
+	is.NoErr(ev.Trigger("knock"))
+	select {
+	case e := <-fired:
+		is.Equal(e.Key, ev.HashID())
+		is.Equal(e.Type, "knock")
+	case <-time.After(time.Second):
+		t.Fatal("event was not emitted")
+	}
+}
@@ -0,0 +1,102 @@
+package event
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/core/entity"
+)
+
+type Config struct {
+	component.ConfigOf[entity.EventDomain, *entity.EventDomain] `yaml:"-"`
+	config.PlatformConfig
+}
+
+// ValidateWithContext implements component.Config.
+func (c *Config) ValidateWithContext(ctx context.Context) error {
+	return c.PlatformConfig.ValidateWithContext(ctx)
+}
+
+func NewConfig() *Config {
+	return &Config{
+		PlatformConfig: config.PlatformConfig{
+			DomainType: entity.DomainTypeEvent,
+		},
+	}
+}
+
+// Trigger is a service request for firing an event entity.
+type Trigger struct {
+	Key  uint32
+	Type string
+}
+
+// ServiceType implements bus.ServiceRequestData.
+func (t *Trigger) ServiceType() string {
+	return "event.trigger"
+}
+
+var _ bus.ServiceRequestData = (*Trigger)(nil)
+
+// Triggerer is implemented by event entities that can be fired.
+type Triggerer interface {
+	entity.Event
+	Trigger(eventType string) error
+}
+
+// RegisterServiceCallHandlers registers service call handlers for the event domain.
+func RegisterServiceCallHandlers(ctx context.Context, domain *entity.EventDomain, b *bus.Bus) {
+	sub := b.HandleServiceCalls(bus.ServiceHandlerWithRespose(b, func(t *Trigger) error {
+		ev, ok := domain.FindByKey(t.Key)
+		if !ok {
+			return bus.ErrNotHandled
+		}
+		tr, ok := ev.(Triggerer)
+		if !ok {
+			return fmt.Errorf("event %s can not be triggered", ev.ID())
+		}
+		return tr.Trigger(t.Type)
+	}))
+	domain.OnClose(sub.Close)
+}
+
+func New(ctx context.Context, c *Config) ([]component.Component, error) {
+	node := core.GetNode(ctx)
+	if node == nil {
+		panic("No node in context during event initialization")
+	}
+	domain := &entity.EventDomain{}
+	ret := []component.Component{domain}
+	for _, platformConfig := range c.Configs {
+		cd, ok := node.Config.Registry.GetEntityComponent(entity.DomainTypeEvent, platformConfig.Platform)
+		if !ok {
+			panic("unregistered event platform in config " + platformConfig.Platform)
+		}
+		comp, err := cd.Component(ctx, platformConfig.Config.Config)
+		if err != nil {
+			return nil, err
+		}
+		for _, cc := range comp {
+			domain.Register(cc.(entity.Event))
+		}
+		ret = append(ret, comp...)
+	}
+	err := node.CreateDomain(entity.PublicDomain(domain))
+	if err != nil {
+		return nil, err
+	}
+	b := bus.Get(ctx)
+	if b == nil {
+		panic("No bus in context during event initialization")
+	}
+
+	RegisterServiceCallHandlers(ctx, domain, b)
+
+	return ret, nil
+}
+
+var _ component.Config = (*Config)(nil)
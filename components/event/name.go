@@ -0,0 +1,7 @@
+package event
+
+import "github.com/gosthome/gosthome/core/entity"
+
+var (
+	COMPONENT_KEY = entity.DomainTypeEvent.String()
+)
@@ -8,7 +8,7 @@ import (
 )
 
 type Config struct {
-	component.ConfigOf[File, *File]
+	component.ConfigOf[File, *File] `yaml:"-"`
 }
 
 func NewConfig() *Config {
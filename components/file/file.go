@@ -2,7 +2,7 @@ package file
 
 import (
 	"context"
-	"log/slog"
+	"fmt"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/gosthome/gosthome/core/component"
@@ -21,16 +21,17 @@ func New(ctx context.Context, cfg *Config) ([]component.Component, error) {
 }
 
 // Setup implements component.Component.
-func (f *File) Setup() {
+func (f *File) Setup(ctx context.Context) error {
 	var err error
 	f.watcher, err = fsnotify.NewWatcher()
 	if err != nil {
-		slog.Error("Failed to initialize file component", "err", err)
+		return fmt.Errorf("failed to initialize file component: %w", err)
 	}
+	return nil
 }
 
 // Close implements component.Component.
-func (f *File) Close() error {
+func (f *File) Close(ctx context.Context) error {
 	if f.watcher != nil {
 		f.watcher.Close()
 	}
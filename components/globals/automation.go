@@ -0,0 +1,82 @@
+package globals
+
+import (
+	"context"
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/lambda"
+	"github.com/gosthome/gosthome/core/registry"
+)
+
+var _ = registry.RegisterDefaultAction("globals.set", func() automation.Action {
+	return &Set{}
+})
+
+// Set sets a global, e.g. globals.set: {id: count, value: id(count) + 1}.
+// The value is an expression like the initial value.
+type Set struct {
+	ID    cid.Ref[Variable]   `yaml:"id"`
+	Value *lambda.Lambda[any] `yaml:"value"`
+}
+
+// ValidateWithContext implements automation.Action.
+func (a *Set) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, a,
+		validation.Field(&a.ID),
+		validation.Field(&a.Value, validation.NotNil),
+	)
+}
+
+// Run implements automation.Action.
+func (a *Set) Run(ctx context.Context) error {
+	g, ok := find(ctx, a.ID.ID)
+	if !ok {
+		return fmt.Errorf("there is no global %s", a.ID.ID)
+	}
+	v, err := a.Value.Eval(ctx, automation.GetArgs(ctx))
+	if err != nil {
+		return err
+	}
+	return g.Set(v)
+}
+
+var _ automation.Action = (*Set)(nil)
+
+// find returns the global with the id in the node of ctx.
+func find(ctx context.Context, id string) (Variable, bool) {
+	n := core.GetNode(ctx)
+	if n == nil {
+		return nil, false
+	}
+	c, ok := n.GetComponent(func(c component.Component) bool {
+		v, ok := c.(Variable)
+		return ok && v.ID() == id
+	})
+	if !ok {
+		return nil, false
+	}
+	return c.(Variable), true
+}
+
+// object makes id(x) the value of the global x.
+func object[T bool | float64 | string](typ lambda.Type) lambda.Object {
+	return lambda.Object{Value: lambda.Field{Type: typ, Get: func(ctx context.Context, id string) (any, error) {
+		v, _ := find(ctx, id)
+		g, ok := v.(*Global[T])
+		if !ok {
+			return nil, lambda.ErrNotFound
+		}
+		return g.Value(), nil
+	}}}
+}
+
+var (
+	_ = registry.RegisterDefaultObject[Global[bool]](object[bool](lambda.Bool))
+	_ = registry.RegisterDefaultObject[Global[float64]](object[float64](lambda.Number))
+	_ = registry.RegisterDefaultObject[Global[string]](object[string](lambda.String))
+)
@@ -0,0 +1,295 @@
+// Package globals holds typed variables automations and lambdas share, like
+// ESPHome's globals:.
+package globals
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/component/cid"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/lambda"
+	"github.com/gosthome/gosthome/core/preferences"
+)
+
+// valueType is a type of the config, the C++ types of ESPHome are the types
+// of lambdas.
+type valueType struct {
+	lambda lambda.Type
+	// whole is set for the integers, their values are truncated and must be
+	// in the range of the type.
+	whole *intRange
+	// global is the type of the Global declared for the id.
+	global reflect.Type
+	zero   any
+	new    func(ctx context.Context, gc *GlobalConfig, initial any, whole *intRange) component.Component
+}
+
+// intRange is the range of an integer type. The values are kept as float64,
+// so int64_t and uint64_t only hold the integers it represents exactly, up to
+// 2^53.
+type intRange struct {
+	min, max float64
+}
+
+// check truncates f and checks that it is in the range.
+func (r *intRange) check(f float64) (float64, error) {
+	f = math.Trunc(f)
+	if f < r.min || f > r.max {
+		return 0, fmt.Errorf("%.0f is out of the range %.0f to %.0f", f, r.min, r.max)
+	}
+	return f, nil
+}
+
+// maxExact is the largest integer a float64 represents exactly.
+const maxExact = 1 << 53
+
+func intType(min, max float64) valueType {
+	return valueType{lambda: lambda.Number, whole: &intRange{min: min, max: max}, global: reflect.TypeFor[Global[float64]](), zero: 0.0, new: newGlobal[float64]}
+}
+
+var (
+	boolType   = valueType{lambda: lambda.Bool, global: reflect.TypeFor[Global[bool]](), zero: false, new: newGlobal[bool]}
+	floatType  = valueType{lambda: lambda.Number, global: reflect.TypeFor[Global[float64]](), zero: 0.0, new: newGlobal[float64]}
+	stringType = valueType{lambda: lambda.String, global: reflect.TypeFor[Global[string]](), zero: "", new: newGlobal[string]}
+
+	valueTypes = map[string]valueType{
+		"bool":        boolType,
+		"int":         intType(math.MinInt32, math.MaxInt32),
+		"int8_t":      intType(math.MinInt8, math.MaxInt8),
+		"int16_t":     intType(math.MinInt16, math.MaxInt16),
+		"int32_t":     intType(math.MinInt32, math.MaxInt32),
+		"int64_t":     intType(-maxExact, maxExact),
+		"uint8_t":     intType(0, math.MaxUint8),
+		"uint16_t":    intType(0, math.MaxUint16),
+		"uint32_t":    intType(0, math.MaxUint32),
+		"uint64_t":    intType(0, maxExact),
+		"float":       floatType,
+		"double":      floatType,
+		"std::string": stringType,
+		"string":      stringType,
+	}
+)
+
+func valueTypeNames() []string {
+	ret := make([]string, 0, len(valueTypes))
+	for k := range valueTypes {
+		ret = append(ret, k)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+type Config struct {
+	cv.MapOrValue[GlobalConfig, *GlobalConfig]
+}
+
+// ComponentType implements component.Config, the globals are Global values
+// of different types.
+func (c *Config) ComponentType() reflect.Type {
+	return reflect.TypeFor[Variable]()
+}
+
+type GlobalConfig struct {
+	ID string `yaml:"id"`
+	// Type is the C++ type of the variable, e.g. int, float, bool or
+	// std::string.
+	Type string `yaml:"type"`
+	// InitialValue is an expression for the value of the variable, it is
+	// evaluated when the config is validated and can not use id().
+	InitialValue *lambda.Lambda[any] `yaml:"initial_value"`
+	// RestoreValue keeps the value across restarts.
+	RestoreValue bool `yaml:"restore_value"`
+}
+
+// DeclaredID implements cid.Declarer, the type of the Global depends on the
+// type of the variable.
+func (c *GlobalConfig) DeclaredID() (*string, reflect.Type) {
+	return &c.ID, c.valueType().global
+}
+
+func (c *GlobalConfig) valueType() valueType {
+	vt, ok := valueTypes[c.Type]
+	if !ok {
+		return valueTypes["int"]
+	}
+	return vt
+}
+
+func (c *GlobalConfig) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, c,
+		validation.Field(&c.ID, validation.Required, cv.String(cv.Name())),
+		validation.Field(&c.Type, validation.Required, cv.String(cv.OneOf(valueTypeNames()...))),
+		validation.Field(&c.InitialValue, validation.By(func(any) error {
+			_, err := c.initialValue(ctx)
+			return err
+		})),
+	)
+}
+
+// initialValue evaluates the initial value, it is the zero value of the type
+// when there is none.
+func (c *GlobalConfig) initialValue(ctx context.Context) (any, error) {
+	vt := c.valueType()
+	if c.InitialValue == nil {
+		return vt.zero, nil
+	}
+	v, err := c.InitialValue.Eval(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return vt.convert(v)
+}
+
+// convert checks that v, a value of a lambda, is of the type.
+func (vt valueType) convert(v any) (any, error) {
+	t := typeOf(v)
+	if t != vt.lambda {
+		return nil, fmt.Errorf("the value is a %s, not a %s", t, vt.lambda)
+	}
+	if f, ok := v.(float64); ok && vt.whole != nil {
+		return vt.whole.check(f)
+	}
+	return v, nil
+}
+
+func typeOf(v any) lambda.Type {
+	switch v.(type) {
+	case bool:
+		return lambda.Bool
+	case float64:
+		return lambda.Number
+	case string:
+		return lambda.String
+	case time.Time:
+		return lambda.Time
+	}
+	return lambda.Invalid
+}
+
+// Validate implements component.Config.
+func (c *Config) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateWithContext(ctx, c.Configs, validation.Required, validation.Length(1, 0))
+}
+
+func NewConfig() *Config {
+	return &Config{
+		MapOrValue: cv.NewMapOrValue(func() *GlobalConfig {
+			return &GlobalConfig{}
+		}),
+	}
+}
+
+var _ component.Config = (*Config)(nil)
+var _ cid.Declarer = (*GlobalConfig)(nil)
+
+// Variable is a Global of any type.
+type Variable interface {
+	component.Component
+	// Set sets the value to v, a value of a lambda of the type of the
+	// variable.
+	Set(v any) error
+}
+
+// Global is a variable of the type T, the value is kept across restarts when
+// restore_value is set.
+type Global[T bool | float64 | string] struct {
+	cid.CID
+	component.WithInitializationPriorityData
+
+	whole *intRange
+	pref  preferences.Pref[T]
+	mx    sync.Mutex
+	value T
+}
+
+func newGlobal[T bool | float64 | string](ctx context.Context, gc *GlobalConfig, initial any, whole *intRange) component.Component {
+	g := &Global[T]{
+		CID:   cid.NewID(gc.ID),
+		whole: whole,
+		value: initial.(T),
+	}
+	if gc.RestoreValue {
+		g.pref = preferences.Make[T](preferences.Get(ctx), "globals/"+gc.ID)
+		saved, ok, err := g.pref.Load()
+		if err != nil {
+			slog.Warn("Failed to restore global value", "id", gc.ID, "err", err)
+		}
+		if ok {
+			g.value = saved
+		}
+	}
+	return g
+}
+
+func New(ctx context.Context, cfg *Config) ([]component.Component, error) {
+	ret := make([]component.Component, 0, len(cfg.Configs))
+	for _, gc := range cfg.Configs {
+		initial, err := gc.initialValue(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("initial value of %s: %w", gc.ID, err)
+		}
+		vt := gc.valueType()
+		ret = append(ret, vt.new(ctx, gc, initial, vt.whole))
+	}
+	return ret, nil
+}
+
+// Setup implements component.Component.
+func (g *Global[T]) Setup(ctx context.Context) error {
+	return nil
+}
+
+// Close implements component.Component.
+func (g *Global[T]) Close(ctx context.Context) error {
+	return nil
+}
+
+// Value returns the value of the variable.
+func (g *Global[T]) Value() T {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+	return g.value
+}
+
+// SetValue sets the value of the variable and remembers it for the next
+// start when restore_value is set. The values of integers are truncated, out
+// of the range of the type they are an error.
+func (g *Global[T]) SetValue(v T) error {
+	if f, ok := any(v).(float64); ok && g.whole != nil {
+		f, err := g.whole.check(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", g.ID(), err)
+		}
+		v = any(f).(T)
+	}
+	g.mx.Lock()
+	g.value = v
+	g.mx.Unlock()
+	if err := g.pref.Save(v); err != nil {
+		slog.Warn("Failed to save global value", "id", g.ID(), "err", err)
+	}
+	return nil
+}
+
+// Set implements Variable.
+func (g *Global[T]) Set(v any) error {
+	t, ok := v.(T)
+	if !ok {
+		var zero T
+		return fmt.Errorf("the value is a %s, %s is a %s", typeOf(v), g.ID(), typeOf(zero))
+	}
+	return g.SetValue(t)
+}
+
+var _ Variable = (*Global[bool])(nil)
+var _ Variable = (*Global[float64])(nil)
+var _ Variable = (*Global[string])(nil)
@@ -0,0 +1,5 @@
+package globals
+
+const (
+	COMPONENT_KEY = "globals"
+)
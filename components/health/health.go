@@ -0,0 +1,76 @@
+// Package health exposes the setup outcome of the node as a diagnostic text
+// sensor.
+package health
+
+import (
+	"context"
+
+	"github.com/gosthome/gosthome/components/textsensor"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/entity"
+)
+
+const DEFAULT_ID = "health"
+
+type Config struct {
+	textsensor.BaseTextSensorConfig[Health, *Health] `yaml:",inline"`
+}
+
+func NewConfig() *Config {
+	ret := &Config{}
+	ret.ID = DEFAULT_ID
+	ret.Name = "Node Health"
+	ret.Category = entity.CategoryDisgnostic
+	return ret
+}
+
+// AutoLoad implements component.AutoLoader.
+func (c *Config) AutoLoad() component.Dependencies {
+	return component.Depends(textsensor.COMPONENT_KEY)
+}
+
+var _ component.Config = (*Config)(nil)
+var _ component.AutoLoader = (*Config)(nil)
+
+// Health is a text sensor with the summary of the last node start.
+type Health struct {
+	textsensor.BaseTextSensor[Health, *Health]
+	component.WithInitializationPriorityLate
+
+	ctx context.Context
+	sub bus.EventSubsciption
+}
+
+func New(ctx context.Context, cfg *Config) (ret []component.Component, err error) {
+	h := &Health{ctx: ctx}
+	h.BaseTextSensor, err = textsensor.NewBaseTextSensor(ctx, h, &cfg.BaseTextSensorConfig)
+	if err != nil {
+		return nil, err
+	}
+	return []component.Component{h}, nil
+}
+
+// Setup implements component.Component.
+func (h *Health) Setup(ctx context.Context) error {
+	node := core.GetNode(h.ctx)
+	node.RegisterTextSensor(h)
+	h.sub = node.Bus.HandleEvents(bus.EventHandler(h.update))
+	return nil
+}
+
+func (h *Health) update(e *core.HealthEvent) {
+	h.SetState(entity.TextSensorState{
+		State:        e.Health.String(),
+		MissingState: false,
+	})
+}
+
+// Close implements component.Component.
+func (h *Health) Close(ctx context.Context) error {
+	h.sub.Close()
+	return nil
+}
+
+var _ component.Component = (*Health)(nil)
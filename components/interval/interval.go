@@ -0,0 +1,105 @@
+// Package interval runs actions periodically, like ESPHome's interval:.
+package interval
+
+import (
+	"context"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/component/cid"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+)
+
+type Config struct {
+	component.ConfigOf[Interval, *Interval] `yaml:"-"`
+	cv.MapOrValue[IntervalConfig, *IntervalConfig]
+}
+
+type IntervalConfig struct {
+	cid.IDConfig[Interval] `yaml:",inline"`
+	// Interval is the time between the runs, the first run is an interval
+	// after the start.
+	Interval time.Duration `yaml:"interval"`
+	// Then are the actions run every interval.
+	Then automation.Actions `yaml:"then"`
+}
+
+func (c *IntervalConfig) ValidateWithContext(ctx context.Context) error {
+	return cv.ValidateEmbedded(
+		c.IDConfig.ValidateWithContext(ctx),
+		validation.ValidateStructWithContext(ctx, c,
+			validation.Field(&c.Interval, validation.Required, validation.Min(0*time.Second).Exclusive()),
+			validation.Field(&c.Then, validation.Required),
+		),
+	)
+}
+
+// Validate implements component.Config.
+func (c *Config) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateWithContext(ctx, c.Configs, validation.Required, validation.Length(1, 0))
+}
+
+func NewConfig() *Config {
+	return &Config{
+		MapOrValue: cv.NewMapOrValue(func() *IntervalConfig {
+			return &IntervalConfig{}
+		}),
+	}
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Interval runs its actions every interval.
+type Interval struct {
+	cid.CID
+	*component.PollingComponent[Interval, *Interval]
+	component.WithInitializationPriorityProcessor
+
+	ctx     context.Context
+	actions automation.Actions
+	// runs is cancelled when the interval closes, to stop the running
+	// actions.
+	runs context.Context
+	stop context.CancelCauseFunc
+}
+
+func New(ctx context.Context, cfg *Config) ([]component.Component, error) {
+	ret := make([]component.Component, 0, len(cfg.Configs))
+	for _, ic := range cfg.Configs {
+		id := ic.ID
+		if id == "" {
+			id = cid.MakeStringID(COMPONENT_KEY)
+		}
+		i := &Interval{
+			CID:     cid.NewID(id),
+			ctx:     ctx,
+			actions: ic.Then,
+		}
+		i.runs, i.stop = context.WithCancelCause(ctx)
+		var err error
+		i.PollingComponent, err = component.NewPollingComponent(ctx, i, &component.PollingComponentConfig{
+			UpdateInterval: ic.Interval,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, i)
+	}
+	return ret, nil
+}
+
+// Poll implements component.Poller. It waits for the actions, so the runs
+// due while they still run are skipped.
+func (i *Interval) Poll() {
+	_ = automation.GetRunner(i.ctx).RunWait(i.runs, i.ID()+" interval", i.actions, nil)
+}
+
+// Close implements component.Component, it stops the running actions.
+func (i *Interval) Close(ctx context.Context) error {
+	i.stop(automation.ErrStopped)
+	return i.PollingComponent.Close(ctx)
+}
+
+var _ component.Poller = (*Interval)(nil)
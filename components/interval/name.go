@@ -0,0 +1,5 @@
+package interval
+
+const (
+	COMPONENT_KEY = "interval"
+)
@@ -0,0 +1,74 @@
+package number
+
+import (
+	"context"
+
+	"math"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/lambda"
+	"github.com/gosthome/gosthome/core/registry"
+)
+
+var _ = registry.RegisterDefaultAction("number.set", func() automation.Action {
+	return &Set{}
+})
+
+// Set sets the value of a number, e.g. number.set: {id: volume, value: 3}.
+// The value can be a lambda, e.g. value: !lambda return x * 2;.
+type Set struct {
+	ID    cid.Ref[entity.Number] `yaml:"id"`
+	Value *lambda.Value[float32] `yaml:"value"`
+}
+
+// ValidateWithContext implements automation.Action.
+func (a *Set) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, a,
+		validation.Field(&a.ID),
+		validation.Field(&a.Value, validation.NotNil),
+	)
+}
+
+// Run implements automation.Action.
+func (a *Set) Run(ctx context.Context) error {
+	v, err := a.Value.Get(ctx, automation.GetArgs(ctx))
+	if err != nil {
+		return err
+	}
+	_, err = bus.Call[*SetValue, any](ctx, bus.Get(ctx), &SetValue{Key: a.ID.CID().HashID(), State: v})
+	return err
+}
+
+var _ automation.Action = (*Set)(nil)
+
+var _ = registry.RegisterDefaultObject[entity.Number](lambda.Object{Fields: map[string]lambda.Field{
+	// state is NAN while the number has no value, like in ESPHome
+	"state": {Type: lambda.Number, Get: func(ctx context.Context, id string) (any, error) {
+		st, err := numberState(ctx, id)
+		if err != nil || st.MissingState {
+			return math.NaN(), err
+		}
+		return st.State, nil
+	}},
+	"has_state": {Type: lambda.Bool, Get: func(ctx context.Context, id string) (any, error) {
+		st, err := numberState(ctx, id)
+		return !st.MissingState, err
+	}},
+}})
+
+func numberState(ctx context.Context, id string) (entity.NumberState, error) {
+	n := core.GetNode(ctx)
+	if n == nil {
+		return entity.NumberState{MissingState: true}, lambda.ErrNotFound
+	}
+	num, ok := n.NumberByKey(cid.HashID(id))
+	if !ok {
+		return entity.NumberState{MissingState: true}, lambda.ErrNotFound
+	}
+	return num.State(), nil
+}
@@ -0,0 +1,167 @@
+package number
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/preferences"
+	"github.com/gosthome/gosthome/core/state"
+)
+
+type BaseNumberConfig[T any, PT interface {
+	*T
+	component.Component
+	entity.Number
+}] struct {
+	component.ConfigOf[T, PT]                                                          `yaml:"-"`
+	entity.EntityConfig                                                                `yaml:",inline"`
+	entity.DeviceClassMixinConfig[entity.NumberDeviceClass, *entity.NumberDeviceClass] `yaml:",inline"`
+	entity.IconMixinConfig                                                             `yaml:",inline"`
+	entity.UnitOfMeasurementMixinConfig                                                `yaml:",inline"`
+
+	MinValue float32           `yaml:"min_value"`
+	MaxValue float32           `yaml:"max_value"`
+	Step     float32           `yaml:"step"`
+	Mode     entity.NumberMode `yaml:"mode"`
+	// RestoreValue starts the number with its last value.
+	RestoreValue bool `yaml:"restore_value"`
+	// InitialValue is used when there is no value to restore, it defaults
+	// to MinValue.
+	InitialValue *float32 `yaml:"initial_value"`
+
+	// OnValue runs on every change with the value as x, not when the number
+	// only becomes unavailable or available again.
+	OnValue automation.Trigger[float32] `yaml:"on_value"`
+}
+
+func (bnc *BaseNumberConfig[T, PT]) ValidateWithContext(ctx context.Context) error {
+	units := entity.UnitsOfMeasurementOf(bnc.DeviceClass)
+	return cv.ValidateEmbedded(
+		bnc.EntityConfig.ValidateWithContext(ctx),
+		bnc.DeviceClassMixinConfig.ValidateWithContext(ctx),
+		bnc.IconMixinConfig.ValidateWithContext(ctx),
+		bnc.UnitOfMeasurementMixinConfig.ValidateWithContext(ctx),
+		validation.ValidateStructWithContext(ctx, bnc,
+			// numbers take numeric device classes only
+			validation.Field(&bnc.DeviceClass, validation.NotIn(
+				entity.SensorDeviceClassDate,
+				entity.SensorDeviceClassEnum,
+				entity.SensorDeviceClassTimestamp,
+			).Error("numbers can not have a date, enum or timestamp device_class")),
+			validation.Field(&bnc.UnitOfMeasurement, validation.When(
+				len(units) > 0,
+				cv.String(cv.Optional(cv.OneOf(units...))),
+			)),
+			validation.Field(&bnc.MaxValue, validation.Min(bnc.MinValue)),
+			validation.Field(&bnc.Step, validation.Required, validation.Min(float32(0)).Exclusive()),
+			validation.Field(&bnc.InitialValue, validation.Min(bnc.MinValue), validation.Max(bnc.MaxValue)),
+		),
+	)
+}
+
+// DeclaredID implements cid.Declarer.
+func (bnc *BaseNumberConfig[T, PT]) DeclaredID() (*string, reflect.Type) {
+	return bnc.EntityID(), reflect.TypeFor[entity.Number]()
+}
+
+type BaseNumber[T any, PT interface {
+	*T
+	component.Component
+	entity.Number
+}] struct {
+	entity.BaseEntity
+	entity.DeviceClassMixin[entity.NumberDeviceClass, *entity.NumberDeviceClass]
+	entity.IconMixin
+	entity.UnitOfMeasurementMixin
+	state.State_[entity.NumberState]
+
+	min, max, step float32
+	mode           entity.NumberMode
+	pref           preferences.Pref[float32]
+	onValue        automation.Actions
+}
+
+// NewBaseNumber starts the number with its last value when restore_value is
+// set, with its initial_value otherwise.
+func NewBaseNumber[T any, PT interface {
+	*T
+	component.Component
+	entity.Number
+}](ctx context.Context, t PT, cfg *BaseNumberConfig[T, PT]) (ret BaseNumber[T, PT], err error) {
+	ret.BaseEntity = entity.NewBaseEntity(entity.DomainTypeNumber, &cfg.EntityConfig)
+	ret.DeviceClassMixin = entity.NewDeviceClassMixin(&cfg.DeviceClassMixinConfig)
+	ret.IconMixin = entity.NewIconMixin(&cfg.IconMixinConfig)
+	ret.UnitOfMeasurementMixin = entity.NewUnitOfMeasurementMixin(&cfg.UnitOfMeasurementMixinConfig)
+	ret.min, ret.max, ret.step = cfg.MinValue, cfg.MaxValue, cfg.Step
+	ret.mode = cfg.Mode
+	ret.onValue = cfg.OnValue.Actions
+	initial := cfg.MinValue
+	if cfg.InitialValue != nil {
+		initial = *cfg.InitialValue
+	}
+	if cfg.RestoreValue {
+		ret.pref = preferences.Make[float32](preferences.Get(ctx), "number/"+ret.ID())
+		saved, ok, err := ret.pref.Load()
+		if err != nil {
+			slog.Warn("Failed to restore number value", "id", ret.ID(), "err", err)
+		}
+		if ok && saved >= ret.min && saved <= ret.max {
+			initial = saved
+		}
+	}
+	ret.State_, err = state.NewState(ctx, t, entity.NumberState{
+		State:        initial,
+		MissingState: false,
+	}, state.FollowAvailability(&ret.BaseEntity))
+	return
+}
+
+// NumberMode implements entity.Number.
+func (t *BaseNumber[T, PT]) NumberMode() entity.NumberMode {
+	return t.mode
+}
+
+// MinValue implements entity.Number.
+func (t *BaseNumber[T, PT]) MinValue() float32 {
+	return t.min
+}
+
+// MaxValue implements entity.Number.
+func (t *BaseNumber[T, PT]) MaxValue() float32 {
+	return t.max
+}
+
+// Step implements entity.Number.
+func (t *BaseNumber[T, PT]) Step() float32 {
+	return t.step
+}
+
+// PublishState sets the value of the number and remembers it for the next
+// start when restore_value is set.
+func (t *BaseNumber[T, PT]) PublishState(v float32) {
+	t.State_.SetState(entity.NumberState{State: v})
+	err := t.pref.Save(v)
+	if err != nil {
+		slog.Warn("Failed to save number value", "id", t.ID(), "err", err)
+	}
+}
+
+// SubscribeTriggers implements automation.Subscriber.
+func (t *BaseNumber[T, PT]) SubscribeTriggers(ctx context.Context) []bus.EventSubsciption {
+	if len(t.onValue) == 0 {
+		return nil
+	}
+	r := automation.GetRunner(ctx)
+	return []bus.EventSubsciption{automation.OnState(ctx, t, func(old, nv entity.NumberState) {
+		if !nv.MissingState {
+			r.Run(t.ID()+" on_value", t.onValue, automation.Args{"x": nv.State})
+		}
+	})}
+}
@@ -2,10 +2,10 @@ package number
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 
 	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/automation"
 	"github.com/gosthome/gosthome/core/bus"
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/config"
@@ -28,7 +28,7 @@ var _ bus.ServiceRequestData = (*SetValue)(nil)
 
 // Config holds the number domain configuration.
 type Config struct {
-	component.ConfigOf[entity.NumberDomain, *entity.NumberDomain]
+	component.ConfigOf[entity.NumberDomain, *entity.NumberDomain] `yaml:"-"`
 	config.PlatformConfig
 }
 
@@ -49,14 +49,14 @@ func NewConfig() *Config {
 // RegisterServiceCallHandlers registers service call handlers for the number domain.
 func RegisterServiceCallHandlers(ctx context.Context, domain *entity.NumberDomain, b *bus.Bus) {
 	// Handle number.set service calls.
-	b.HandleServiceCalls(bus.ServiceHandlerWithRespose(b, func(t *SetValue) error {
+	sub := b.HandleServiceCalls(bus.ServiceHandlerWithRespose(b, func(t *SetValue) error {
 		num, ok := domain.FindByKey(t.Key)
 		if !ok {
-			slog.Error("Tried to set value on nonexisting number", "key", t.Key)
-			return fmt.Errorf("tried to set value on nonexisting number %d", t.Key)
+			return bus.ErrNotHandled
 		}
 		return num.SetValue(ctx, t.State)
 	}))
+	domain.OnClose(sub.Close)
 }
 
 // New initializes the number domain, registers number entities and sets up service handlers.
@@ -79,6 +79,7 @@ func New(ctx context.Context, c *Config) ([]component.Component, error) {
 		}
 		for _, cc := range comp {
 			domain.Register(cc.(entity.Number))
+			automation.Subscribe(ctx, cc, domain.OnClose)
 		}
 		ret = append(ret, comp...)
 	}
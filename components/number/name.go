@@ -0,0 +1,7 @@
+package number
+
+import "github.com/gosthome/gosthome/core/entity"
+
+var (
+	COMPONENT_KEY = entity.DomainTypeNumber.String()
+)
@@ -83,8 +83,9 @@ type CPU struct {
 	cid.CID
 	*component.PollingComponent[CPU, *CPU]
 	component.WithInitializationPriorityProcessor
-	ctx context.Context
-	cfg *CPUConfig
+	ctx        context.Context
+	cfg        *CPUConfig
+	registered registered
 
 	times    map[string]map[string]*Sensor
 	percents map[string]*Sensor
@@ -104,22 +105,18 @@ func NewCPU(ctx context.Context, cfg *CPUConfig) (ret *CPU, err error) {
 }
 
 // Setup implements component.Component.
-func (cpu *CPU) Setup() {
+func (cpu *CPU) Setup(ctx context.Context) error {
 	cpu.times = make(map[string]map[string]*Sensor)
 	cpu.percents = make(map[string]*Sensor)
 	node := core.GetNode(cpu.ctx)
 	cpus, err := pstilCPU.CountsWithContext(cpu.ctx, cpu.cfg.Count.IncludeLogical)
 	if err != nil {
-		slog.Error("failed to initialize", "err", err)
-		return
+		return fmt.Errorf("failed to count cpus: %w", err)
 	}
 	counts := &Sensor{}
 	counts.BaseSensor, err = sensor.NewBaseSensor(cpu.ctx, counts, &cpu.cfg.Count.BaseSensorConfig)
-	counts.SetState(entity.SensorState{
-		State:        float32(cpus),
-		MissingState: false,
-	})
-	node.RegisterSensor(counts)
+	counts.PublishState(float32(cpus))
+	cpu.registered.add(counts, node.RegisterSensor(counts))
 
 	if cpu.cfg.Info.Enabled {
 		cpu.setupInfo(node)
@@ -142,7 +139,8 @@ func (cpu *CPU) Setup() {
 			cpu.getPercent(node, true)
 		}
 	}
-	cpu.PollingComponent.Setup()
+	cpu.PollingComponent.Setup(ctx)
+	return nil
 }
 
 func (cpu *CPU) setupInfo(node *core.Node) {
@@ -171,7 +169,7 @@ func (cpu *CPU) setupInfo(node *core.Node) {
 				State:        val,
 				MissingState: false,
 			})
-			node.RegisterTextSensor(ns)
+			cpu.registered.add(ns, node.RegisterTextSensor(ns))
 		}
 		for id, val := range map[string]float32{
 			"stepping":   float32(cpuInfo.Stepping),
@@ -184,11 +182,8 @@ func (cpu *CPU) setupInfo(node *core.Node) {
 			})
 			ns := &Sensor{}
 			ns.BaseSensor, err = sensor.NewBaseSensor(cpu.ctx, ns, &cfg.BaseSensorConfig)
-			ns.SetState(entity.SensorState{
-				State:        float32(val),
-				MissingState: false,
-			})
-			node.RegisterSensor(ns)
+			ns.PublishState(float32(val))
+			cpu.registered.add(ns, node.RegisterSensor(ns))
 		}
 	}
 }
@@ -224,13 +219,10 @@ func (cpu *CPU) getTimes(node *core.Node, perCPU bool) {
 				})
 				ns = &Sensor{}
 				ns.BaseSensor, err = sensor.NewBaseSensor(cpu.ctx, ns, &cfg.BaseSensorConfig)
-				node.RegisterSensor(ns)
+				cpu.registered.add(ns, node.RegisterSensor(ns))
 				sns[id] = ns
 			}
-			ns.SetState(entity.SensorState{
-				State:        float32(val),
-				MissingState: false,
-			})
+			ns.PublishState(float32(val))
 		}
 		cpu.times[ctimes.CPU] = sns
 	}
@@ -249,18 +241,17 @@ func (cpu *CPU) getPercent(node *core.Node, perCPU bool) {
 		}
 		cfg := util.Modify(SensorConfig{}, func(c *SensorConfig) {
 			c.Name = fmt.Sprintf("Load %s", id)
+			c.UnitOfMeasurement = "%"
+			c.StateClass = entity.SensorStateClassMeasurement
 		})
 		ns, ok := cpu.percents[id]
 		if !ok {
 			ns = &Sensor{}
 			ns.BaseSensor, err = sensor.NewBaseSensor(cpu.ctx, ns, &cfg.BaseSensorConfig)
-			node.RegisterSensor(ns)
+			cpu.registered.add(ns, node.RegisterSensor(ns))
 			cpu.percents[id] = ns
 		}
-		ns.SetState(entity.SensorState{
-			State:        float32(percent),
-			MissingState: false,
-		})
+		ns.PublishState(float32(percent))
 	}
 }
 
@@ -286,6 +277,8 @@ func (cpu *CPU) Poll() {
 }
 
 // Close implements component.Component.
-func (cpu *CPU) Close() error {
-	return cpu.PollingComponent.Close()
+func (cpu *CPU) Close(ctx context.Context) error {
+	err := cpu.PollingComponent.Close(ctx)
+	cpu.registered.unregister(core.GetNode(cpu.ctx))
+	return err
 }
@@ -73,8 +73,9 @@ type Host struct {
 	cid.CID
 	*component.PollingComponent[Host, *Host]
 	component.WithInitializationPriorityProcessor
-	ctx context.Context
-	cfg *HostConfig
+	ctx        context.Context
+	cfg        *HostConfig
+	registered registered
 
 	sensors     map[string]*Sensor
 	textSensors map[string]*TextSensor
@@ -108,9 +109,10 @@ func timestamp(ts uint64) func() (string, uint64, bool) {
 }
 
 // Setup implements component.Component.
-func (host *Host) Setup() {
+func (host *Host) Setup(ctx context.Context) error {
 	host.Poll()
-	host.PollingComponent.Setup()
+	host.PollingComponent.Setup(ctx)
+	return nil
 }
 
 func (host *Host) getInfo(node *core.Node) {
@@ -155,7 +157,7 @@ func (host *Host) getInfo(node *core.Node) {
 					slog.Error("failed to create text sensor", "name", hs.name, "err", err)
 					continue
 				}
-				node.RegisterTextSensor(ns)
+				host.registered.add(ns, node.RegisterTextSensor(ns))
 				host.textSensors[hs.name] = ns
 			}
 			ns.SetState(entity.TextSensorState{
@@ -174,13 +176,10 @@ func (host *Host) getInfo(node *core.Node) {
 					slog.Error("failed to create text sensor", "name", hs.name, "err", err)
 					continue
 				}
-				node.RegisterSensor(ns)
+				host.registered.add(ns, node.RegisterSensor(ns))
 				host.sensors[hs.name] = ns
 			}
-			ns.SetState(entity.SensorState{
-				State:        float32(ival),
-				MissingState: false,
-			})
+			ns.PublishState(float32(ival))
 		}
 	}
 }
@@ -191,6 +190,8 @@ func (host *Host) Poll() {
 }
 
 // Close implements component.Component.
-func (host *Host) Close() error {
-	return host.PollingComponent.Close()
+func (host *Host) Close(ctx context.Context) error {
+	err := host.PollingComponent.Close(ctx)
+	host.registered.unregister(core.GetNode(host.ctx))
+	return err
 }
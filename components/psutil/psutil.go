@@ -3,17 +3,24 @@ package psutil
 import (
 	"context"
 	"errors"
+	"log/slog"
+	"slices"
+	"sync"
 
 	"github.com/gosthome/gosthome/components/binarysensor"
+	"github.com/gosthome/gosthome/components/sensor"
+	"github.com/gosthome/gosthome/components/textsensor"
+	"github.com/gosthome/gosthome/core"
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/entity"
 )
 
 type Config struct {
-	component.ConfigOf[PSUtil, *PSUtil]
-	CPU     CPUConfig     `yaml:"cpu"`
-	Host    HostConfig    `yaml:"host"`
-	Sensors SensorsConfig `yaml:"sensors"`
+	component.ConfigOf[PSUtil, *PSUtil] `yaml:"-"`
+	CPU                                 CPUConfig     `yaml:"cpu"`
+	Host                                HostConfig    `yaml:"host"`
+	Sensors                             SensorsConfig `yaml:"sensors"`
 }
 
 func NewConfig() *Config {
@@ -34,6 +41,8 @@ func (c *Config) ValidateWithContext(ctx context.Context) error {
 func (c *Config) AutoLoad() component.Dependencies {
 	return component.Depends(
 		binarysensor.COMPONENT_KEY,
+		sensor.COMPONENT_KEY,
+		textsensor.COMPONENT_KEY,
 	)
 }
 
@@ -70,19 +79,54 @@ func New(ctx context.Context, cfg *Config) (cmp []component.Component, err error
 }
 
 // Setup implements component.Component.
-func (ps *PSUtil) Setup() {
-	ps.cpu.Setup()
-	ps.host.Setup()
-	ps.sensors.Setup()
+func (ps *PSUtil) Setup(ctx context.Context) error {
+	return errors.Join(
+		ps.cpu.Setup(ctx),
+		ps.host.Setup(ctx),
+		ps.sensors.Setup(ctx),
+	)
 }
 
 // Close implements component.Component.
-func (ps *PSUtil) Close() error {
+func (ps *PSUtil) Close(ctx context.Context) error {
 	return errors.Join(
-		ps.cpu.Close(),
-		ps.host.Close(),
-		ps.sensors.Close(),
+		ps.cpu.Close(ctx),
+		ps.host.Close(ctx),
+		ps.sensors.Close(ctx),
 	)
 }
 
 var _ component.Component = (*PSUtil)(nil)
+
+// registered remembers the entities a psutil component registered, so they
+// can be unregistered when they vanish or the component is closed.
+type registered struct {
+	mx   sync.Mutex
+	ents []entity.Entity
+}
+
+func (r *registered) add(ent entity.Entity, err error) {
+	if err != nil {
+		slog.Error("failed to register entity", "id", ent.ID(), "err", err)
+		return
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.ents = append(r.ents, ent)
+}
+
+func (r *registered) remove(node *core.Node, ent entity.Entity) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.ents = slices.DeleteFunc(r.ents, func(e entity.Entity) bool { return e == ent })
+	node.Unregister(ent)
+}
+
+func (r *registered) unregister(node *core.Node) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	for _, ent := range r.ents {
+		node.Unregister(ent)
+	}
+	r.ents = nil
+}
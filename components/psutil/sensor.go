@@ -1,6 +1,7 @@
 package psutil
 
 import (
+	"context"
 	"github.com/gosthome/gosthome/components/sensor"
 	"github.com/gosthome/gosthome/core/component"
 )
@@ -15,10 +16,10 @@ type Sensor struct {
 }
 
 // Setup implements component.Component.
-func (cpu *Sensor) Setup() {
-
+func (cpu *Sensor) Setup(ctx context.Context) error {
+	return nil
 }
 
-func (cpu *Sensor) Close() error {
+func (cpu *Sensor) Close(ctx context.Context) error {
 	return nil
 }
@@ -48,9 +48,10 @@ type Sensors struct {
 	*component.PollingComponent[Sensors, *Sensors]
 	component.WithInitializationPriorityProcessor
 
-	ctx     context.Context
-	cfg     *SensorsConfig
-	sensors map[string]*Sensor
+	ctx        context.Context
+	cfg        *SensorsConfig
+	registered registered
+	sensors    map[string]*Sensor
 }
 
 func NewSensors(ctx context.Context, cfg *SensorsConfig) (ret *Sensors, err error) {
@@ -68,9 +69,10 @@ func NewSensors(ctx context.Context, cfg *SensorsConfig) (ret *Sensors, err erro
 }
 
 // Setup implements component.Component.
-func (host *Sensors) Setup() {
+func (host *Sensors) Setup(ctx context.Context) error {
 	host.Poll()
-	host.PollingComponent.Setup()
+	host.PollingComponent.Setup(ctx)
+	return nil
 }
 
 func (host *Sensors) getInfo(node *core.Node) {
@@ -85,14 +87,19 @@ func (host *Sensors) getInfo(node *core.Node) {
 		slog.Error("SensorsTemperatures", "err", err)
 		return
 	}
+	seen := map[string]struct{}{}
 	for _, info := range infos {
 		for _, hs := range []hostSensor{
 			{name: "tsensor_" + info.SensorKey + "_temperature", val: info.Temperature},
 			{name: "tsensor_" + info.SensorKey + "_high", val: info.High},
 			{name: "tsensor_" + info.SensorKey + "_critical", val: info.Critical},
 		} {
+			seen[hs.name] = struct{}{}
 			cfg := util.Modify(SensorConfig{}, func(c *SensorConfig) {
 				c.Name = hs.name
+				c.DeviceClass = entity.SensorDeviceClassTemperature
+				c.UnitOfMeasurement = "°C"
+				c.StateClass = entity.SensorStateClassMeasurement
 			})
 			ns, ok := host.sensors[hs.name]
 			if !ok {
@@ -102,13 +109,17 @@ func (host *Sensors) getInfo(node *core.Node) {
 					slog.Error("failed to create text sensor", "name", hs.name, "err", err)
 					continue
 				}
-				node.RegisterSensor(ns)
+				host.registered.add(ns, node.RegisterSensor(ns))
 				host.sensors[hs.name] = ns
 			}
-			ns.SetState(entity.SensorState{
-				State:        float32(hs.val),
-				MissingState: false,
-			})
+			ns.PublishState(float32(hs.val))
+		}
+	}
+	// unplugged hardware sensors are gone from the node too
+	for name, ns := range host.sensors {
+		if _, ok := seen[name]; !ok {
+			host.registered.remove(node, ns)
+			delete(host.sensors, name)
 		}
 	}
 }
@@ -119,6 +130,8 @@ func (host *Sensors) Poll() {
 }
 
 // Close implements component.Component.
-func (host *Sensors) Close() error {
-	return host.PollingComponent.Close()
+func (host *Sensors) Close(ctx context.Context) error {
+	err := host.PollingComponent.Close(ctx)
+	host.registered.unregister(core.GetNode(host.ctx))
+	return err
 }
@@ -1,6 +1,7 @@
 package psutil
 
 import (
+	"context"
 	"github.com/gosthome/gosthome/components/textsensor"
 	"github.com/gosthome/gosthome/core/component"
 )
@@ -15,9 +16,10 @@ type TextSensor struct {
 }
 
 // Setup implements component.Component.
-func (cpu *TextSensor) Setup() {
+func (cpu *TextSensor) Setup(ctx context.Context) error {
+	return nil
 }
 
-func (cpu *TextSensor) Close() error {
+func (cpu *TextSensor) Close(ctx context.Context) error {
 	return nil
 }
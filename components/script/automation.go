@@ -0,0 +1,113 @@
+package script
+
+import (
+	"context"
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/lambda"
+	"github.com/gosthome/gosthome/core/registry"
+)
+
+var (
+	_ = registry.RegisterDefaultAction("script.execute", func() automation.Action {
+		return &Action{run: func(s *Script, ctx context.Context) error {
+			s.Execute()
+			return nil
+		}}
+	})
+	_ = registry.RegisterDefaultAction("script.stop", func() automation.Action {
+		return &Action{run: func(s *Script, ctx context.Context) error {
+			s.Stop()
+			return nil
+		}}
+	})
+	_ = registry.RegisterDefaultAction("script.wait", func() automation.Action {
+		return &Action{run: (*Script).Wait}
+	})
+	_ = registry.RegisterDefaultCondition("script.is_running", func() automation.Condition {
+		return &IsRunning{}
+	})
+)
+
+// find returns the script with the id in the node of ctx.
+func find(ctx context.Context, id string) (*Script, bool) {
+	n := core.GetNode(ctx)
+	if n == nil {
+		return nil, false
+	}
+	c, ok := n.GetComponent(func(c component.Component) bool {
+		s, ok := c.(*Script)
+		return ok && s.ID() == id
+	})
+	if !ok {
+		return nil, false
+	}
+	return c.(*Script), true
+}
+
+// Action executes, stops or waits for a script, e.g.
+// script.execute: blink.
+type Action struct {
+	ID  cid.Ref[Script] `yaml:"id"`
+	run func(s *Script, ctx context.Context) error
+}
+
+// Shorthand implements automation.Shorthand.
+func (a *Action) Shorthand() any {
+	return &a.ID
+}
+
+// ValidateWithContext implements automation.Action.
+func (a *Action) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, a, validation.Field(&a.ID))
+}
+
+// Run implements automation.Action.
+func (a *Action) Run(ctx context.Context) error {
+	s, ok := find(ctx, a.ID.ID)
+	if !ok {
+		return fmt.Errorf("there is no script %s", a.ID.ID)
+	}
+	return a.run(s, ctx)
+}
+
+// IsRunning is true while the script runs, e.g. script.is_running: blink.
+type IsRunning struct {
+	ID cid.Ref[Script] `yaml:"id"`
+}
+
+// Shorthand implements automation.Shorthand.
+func (c *IsRunning) Shorthand() any {
+	return &c.ID
+}
+
+// ValidateWithContext implements automation.Condition.
+func (c *IsRunning) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, c, validation.Field(&c.ID))
+}
+
+// Check implements automation.Condition.
+func (c *IsRunning) Check(ctx context.Context) (bool, error) {
+	s, ok := find(ctx, c.ID.ID)
+	return ok && s.IsRunning(), nil
+}
+
+var _ automation.Action = (*Action)(nil)
+var _ automation.Shorthand = (*Action)(nil)
+var _ automation.Condition = (*IsRunning)(nil)
+var _ automation.Shorthand = (*IsRunning)(nil)
+
+var _ = registry.RegisterDefaultObject[Script](lambda.Object{Fields: map[string]lambda.Field{
+	"is_running": {Type: lambda.Bool, Get: func(ctx context.Context, id string) (any, error) {
+		s, ok := find(ctx, id)
+		if !ok {
+			return false, lambda.ErrNotFound
+		}
+		return s.IsRunning(), nil
+	}},
+}})
@@ -0,0 +1,5 @@
+package script
+
+const (
+	COMPONENT_KEY = "script"
+)
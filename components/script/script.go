@@ -0,0 +1,227 @@
+// Package script holds named lists of actions other automations run with
+// script.execute, like ESPHome's script:.
+package script
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/component/cid"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+)
+
+//go:generate go-enum --marshal --nocase --names
+
+// ENUM(
+// single,// a run while the script runs is skipped
+// restart,// a run stops the running script and starts it again
+// queued,// a run waits for the runs before it
+// parallel,// runs run at the same time
+// )
+type mode int
+
+// EnumValues implements cv.Enum.
+func (x *mode) EnumValues() []string {
+	return modeNames()
+}
+
+var _ cv.Enum = (*mode)(nil)
+
+type Config struct {
+	component.ConfigOf[Script, *Script] `yaml:"-"`
+	cv.MapOrValue[ScriptConfig, *ScriptConfig]
+}
+
+type ScriptConfig struct {
+	cid.IDConfig[Script] `yaml:",inline"`
+	// Mode tells what a run does while the script runs.
+	Mode mode `yaml:"mode"`
+	// MaxRuns limits the runs of queued and parallel scripts, the queued
+	// ones count, 0 is no limit.
+	MaxRuns int `yaml:"max_runs"`
+	// Then are the actions of the script.
+	Then automation.Actions `yaml:"then"`
+}
+
+func (c *ScriptConfig) ValidateWithContext(ctx context.Context) error {
+	return cv.ValidateEmbedded(
+		c.IDConfig.ValidateWithContext(ctx),
+		validation.ValidateStructWithContext(ctx, c,
+			validation.Field(&c.ID, validation.Required),
+			validation.Field(&c.MaxRuns, validation.Min(0), validation.When(
+				c.Mode != ModeQueued && c.Mode != ModeParallel,
+				validation.In(0).Error("max_runs is only for the queued and parallel modes"),
+			)),
+			validation.Field(&c.Then, validation.Required),
+		),
+	)
+}
+
+// Validate implements component.Config.
+func (c *Config) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateWithContext(ctx, c.Configs, validation.Required, validation.Length(1, 0))
+}
+
+func NewConfig() *Config {
+	return &Config{
+		MapOrValue: cv.NewMapOrValue(func() *ScriptConfig {
+			return &ScriptConfig{Mode: ModeSingle}
+		}),
+	}
+}
+
+var _ component.Config = (*Config)(nil)
+
+// ErrStopped is the cause of the cancellation of the runs of a stopped
+// script.
+var ErrStopped = fmt.Errorf("script %w", automation.ErrStopped)
+
+// Script runs its actions in the background as its mode allows.
+type Script struct {
+	cid.CID
+	component.WithInitializationPriorityProcessor
+
+	ctx     context.Context
+	mode    mode
+	maxRuns int
+	actions automation.Actions
+
+	mx     sync.Mutex
+	runs   map[*run]struct{}
+	queued int
+	// idle is closed when the last run ends, it is nil while the script
+	// does not run.
+	idle chan struct{}
+}
+
+type run struct {
+	cancel context.CancelCauseFunc
+}
+
+func New(ctx context.Context, cfg *Config) ([]component.Component, error) {
+	ret := make([]component.Component, 0, len(cfg.Configs))
+	for _, sc := range cfg.Configs {
+		ret = append(ret, &Script{
+			CID:     cid.NewID(sc.ID),
+			ctx:     ctx,
+			mode:    sc.Mode,
+			maxRuns: sc.MaxRuns,
+			actions: sc.Then,
+			runs:    map[*run]struct{}{},
+		})
+	}
+	return ret, nil
+}
+
+// Setup implements component.Component.
+func (s *Script) Setup(ctx context.Context) error {
+	return nil
+}
+
+// Close implements component.Component, it stops the runs and waits for
+// them.
+func (s *Script) Close(ctx context.Context) error {
+	s.Stop()
+	return s.Wait(ctx)
+}
+
+// Execute starts a run of the script as its mode allows, it does not wait
+// for the run.
+func (s *Script) Execute() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	running := len(s.runs)
+	switch s.mode {
+	case ModeSingle:
+		if running > 0 {
+			slog.Warn("Script is already running, skipping the run", "id", s.ID())
+			return
+		}
+	case ModeRestart:
+		s.stopLocked()
+	case ModeQueued:
+		if s.maxRuns > 0 && running+s.queued >= s.maxRuns {
+			slog.Warn("Script has max_runs runs queued, skipping the run", "id", s.ID())
+			return
+		}
+		if running > 0 {
+			s.queued++
+			return
+		}
+	case ModeParallel:
+		if s.maxRuns > 0 && running >= s.maxRuns {
+			slog.Warn("Script has max_runs runs, skipping the run", "id", s.ID())
+			return
+		}
+	}
+	s.startLocked()
+}
+
+func (s *Script) startLocked() {
+	if s.idle == nil {
+		s.idle = make(chan struct{})
+	}
+	ctx, cancel := context.WithCancelCause(s.ctx)
+	r := &run{cancel: cancel}
+	s.runs[r] = struct{}{}
+	go func() {
+		_ = automation.GetRunner(s.ctx).RunWait(ctx, "script "+s.ID(), s.actions, nil)
+		cancel(nil)
+		s.mx.Lock()
+		defer s.mx.Unlock()
+		delete(s.runs, r)
+		if s.queued > 0 {
+			s.queued--
+			s.startLocked()
+			return
+		}
+		if len(s.runs) == 0 {
+			close(s.idle)
+			s.idle = nil
+		}
+	}()
+}
+
+// Stop stops the runs of the script and drops the queued ones.
+func (s *Script) Stop() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.stopLocked()
+}
+
+func (s *Script) stopLocked() {
+	s.queued = 0
+	for r := range s.runs {
+		r.cancel(ErrStopped)
+	}
+}
+
+// IsRunning tells whether the script runs.
+func (s *Script) IsRunning() bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return len(s.runs) > 0
+}
+
+// Wait waits until the script does not run, at most until ctx is done.
+func (s *Script) Wait(ctx context.Context) error {
+	s.mx.Lock()
+	idle := s.idle
+	s.mx.Unlock()
+	if idle == nil {
+		return nil
+	}
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+var _ component.Component = (*Script)(nil)
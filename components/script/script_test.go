@@ -0,0 +1,139 @@
+package script
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/matryer/is"
+)
+
+// block is an action that runs until it is released or stopped.
+type block struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *block) ValidateWithContext(ctx context.Context) error { return nil }
+
+func (b *block) Run(ctx context.Context) error {
+	b.started <- struct{}{}
+	select {
+	case <-b.release:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+func newTestScript(t *testing.T, m mode, maxRuns int) (*Script, *block) {
+	r := automation.NewRunner(context.Background())
+	t.Cleanup(func() { _ = r.Stop(context.Background()) })
+	b := &block{started: make(chan struct{}, 10), release: make(chan struct{})}
+	return &Script{
+		CID:     cid.NewID("test"),
+		ctx:     automation.Context(context.Background(), r),
+		mode:    m,
+		maxRuns: maxRuns,
+		actions: automation.Actions{{Name: "block", Value: b}},
+		runs:    map[*run]struct{}{},
+	}, b
+}
+
+// started counts the runs started within a short while.
+func started(b *block) int {
+	n := 0
+	for {
+		select {
+		case <-b.started:
+			n++
+		case <-time.After(50 * time.Millisecond):
+			return n
+		}
+	}
+}
+
+func wait(t *testing.T, s *Script) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSingle(t *testing.T) {
+	is := is.New(t)
+	s, b := newTestScript(t, ModeSingle, 0)
+	s.Execute()
+	s.Execute()
+	is.Equal(started(b), 1) // the second run is skipped
+	is.True(s.IsRunning())
+	b.release <- struct{}{}
+	wait(t, s)
+	is.True(!s.IsRunning())
+}
+
+func TestRestart(t *testing.T) {
+	is := is.New(t)
+	s, b := newTestScript(t, ModeRestart, 0)
+	s.Execute()
+	is.Equal(started(b), 1)
+	s.Execute()
+	is.Equal(started(b), 1) // the first run is stopped, a new one starts
+	b.release <- struct{}{}
+	wait(t, s)
+	is.True(!s.IsRunning())
+}
+
+func TestQueued(t *testing.T) {
+	is := is.New(t)
+	s, b := newTestScript(t, ModeQueued, 2)
+	s.Execute()
+	s.Execute()
+	s.Execute()
+	is.Equal(started(b), 1) // one runs, one waits, max_runs skips the third
+	b.release <- struct{}{}
+	is.Equal(started(b), 1)
+	b.release <- struct{}{}
+	wait(t, s)
+	is.Equal(started(b), 0)
+}
+
+func TestParallel(t *testing.T) {
+	is := is.New(t)
+	s, b := newTestScript(t, ModeParallel, 2)
+	s.Execute()
+	s.Execute()
+	s.Execute()
+	is.Equal(started(b), 2) // max_runs skips the third
+	b.release <- struct{}{}
+	b.release <- struct{}{}
+	wait(t, s)
+}
+
+func TestStop(t *testing.T) {
+	is := is.New(t)
+	s, b := newTestScript(t, ModeQueued, 0)
+	s.Execute()
+	s.Execute()
+	is.Equal(started(b), 1)
+	s.Stop()
+	wait(t, s)
+	is.Equal(started(b), 0) // the queued run is dropped
+	is.True(!s.IsRunning())
+}
+
+func TestClose(t *testing.T) {
+	is := is.New(t)
+	s, b := newTestScript(t, ModeParallel, 0)
+	s.Execute()
+	s.Execute()
+	is.Equal(started(b), 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	is.NoErr(s.Close(ctx))
+	is.True(!s.IsRunning()) // the runs returned
+}
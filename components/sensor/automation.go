@@ -0,0 +1,83 @@
+package sensor
+
+import (
+	"context"
+	"math"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/lambda"
+	"github.com/gosthome/gosthome/core/registry"
+)
+
+var _ = registry.RegisterDefaultCondition("sensor.in_range", func() automation.Condition {
+	return &InRange{}
+})
+
+// InRange is true when the reading of the sensor is above and below the
+// given values, either can be left out. It is false while the sensor has
+// no reading.
+type InRange struct {
+	ID    cid.Ref[entity.Sensor] `yaml:"id"`
+	Above *float32               `yaml:"above"`
+	Below *float32               `yaml:"below"`
+}
+
+// ValidateWithContext implements automation.Condition.
+func (c *InRange) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, c,
+		validation.Field(&c.ID),
+		validation.Field(&c.Above, validation.When(c.Below == nil, validation.Required.Error("above or below is required"))),
+	)
+}
+
+// Check implements automation.Condition.
+func (c *InRange) Check(ctx context.Context) (bool, error) {
+	s, ok := core.GetNode(ctx).SensorByKey(c.ID.CID().HashID())
+	if !ok {
+		return false, nil
+	}
+	st := s.State()
+	if st.MissingState {
+		return false, nil
+	}
+	if c.Above != nil && st.State <= *c.Above {
+		return false, nil
+	}
+	if c.Below != nil && st.State >= *c.Below {
+		return false, nil
+	}
+	return true, nil
+}
+
+var _ automation.Condition = (*InRange)(nil)
+
+var _ = registry.RegisterDefaultObject[entity.Sensor](lambda.Object{Fields: map[string]lambda.Field{
+	// state is NAN while the sensor has no reading, like in ESPHome
+	"state": {Type: lambda.Number, Get: func(ctx context.Context, id string) (any, error) {
+		st, err := sensorState(ctx, id)
+		if err != nil || st.MissingState {
+			return math.NaN(), err
+		}
+		return st.State, nil
+	}},
+	"has_state": {Type: lambda.Bool, Get: func(ctx context.Context, id string) (any, error) {
+		st, err := sensorState(ctx, id)
+		return !st.MissingState, err
+	}},
+}})
+
+func sensorState(ctx context.Context, id string) (entity.SensorState, error) {
+	n := core.GetNode(ctx)
+	if n == nil {
+		return entity.SensorState{MissingState: true}, lambda.ErrNotFound
+	}
+	s, ok := n.SensorByKey(cid.HashID(id))
+	if !ok {
+		return entity.SensorState{MissingState: true}, lambda.ErrNotFound
+	}
+	return s.State(), nil
+}
@@ -2,132 +2,20 @@ package sensor
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/bus"
 	"github.com/gosthome/gosthome/core/component"
 	cv "github.com/gosthome/gosthome/core/configvalidation"
 	"github.com/gosthome/gosthome/core/entity"
 	"github.com/gosthome/gosthome/core/state"
 )
 
-func unitsOfMeasurementOf(dc entity.SensorDeviceClass) []string {
-	switch dc {
-	case entity.SensorDeviceClassApparentPower:
-		return []string{"VA"}
-	case entity.SensorDeviceClassAqi:
-		return []string{}
-	case entity.SensorDeviceClassArea:
-		return []string{"m²", "cm²", "km²", "mm²", "in²", "ft²", "yd²", "mi²", "ac", "ha"}
-	case entity.SensorDeviceClassAtmosphericPressure:
-		return []string{"cbar", "bar", "hPa", "mmHG", "inHg", "kPa", "mbar", "Pa", "psi"}
-	case entity.SensorDeviceClassBattery:
-		return []string{"%"}
-	case entity.SensorDeviceClassBloodGlucoseConcentration:
-		return []string{"mg/dL", "mmol/L"}
-	case entity.SensorDeviceClassCo2:
-		return []string{"ppm"}
-	case entity.SensorDeviceClassCo:
-		return []string{"ppm"}
-	case entity.SensorDeviceClassConductivity:
-		return []string{"S/cm", "mS/cm", "µS/cm"}
-	case entity.SensorDeviceClassCurrent:
-		return []string{"A", "mA"}
-	case entity.SensorDeviceClassDataRate:
-		return []string{"bit/s", "kbit/s", "Mbit/s", "Gbit/s", "B/s", "kB/s", "MB/s", "GB/s", "KiB/s", "MiB/s", "GiB/s"}
-	case entity.SensorDeviceClassDataSize:
-		return []string{"bit", "kbit", "Mbit", "Gbit", "B", "kB", "MB", "GB", "TB", "PB", "EB", "ZB", "YB", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB", "ZiB", "YiB"}
-	case entity.SensorDeviceClassDate:
-		return []string{}
-	case entity.SensorDeviceClassDistance:
-		return []string{"km", "m", "cm", "mm", "mi", "nmi", "yd", "in"}
-	case entity.SensorDeviceClassDuration:
-		return []string{"d", "h", "min", "s", "ms"}
-	case entity.SensorDeviceClassEnergy:
-		return []string{"J", "kJ", "MJ", "GJ", "mWh", "Wh", "kWh", "MWh", "GWh", "TWh", "cal", "kcal", "Mcal", "Gcal"}
-	case entity.SensorDeviceClassEnergyDistance:
-		return []string{"kWh/100km", "mi/kWh", "km/kWh"}
-	case entity.SensorDeviceClassEnergyStorage:
-		return []string{"J", "kJ", "MJ", "GJ", "mWh", "Wh", "kWh", "MWh", "GWh", "TWh", "cal", "kcal", "Mcal", "Gcal"}
-	case entity.SensorDeviceClassEnum:
-		return []string{}
-	case entity.SensorDeviceClassFrequency:
-		return []string{"Hz", "kHz", "MHz", "GHz"}
-	case entity.SensorDeviceClassGas:
-		return []string{"m³", "ft³", "CCF"}
-	case entity.SensorDeviceClassHumidity:
-		return []string{"%"}
-	case entity.SensorDeviceClassIlluminance:
-		return []string{"lx"}
-	case entity.SensorDeviceClassIrradiance:
-		return []string{"W/m²", "BTU/(h⋅ft²)"}
-	case entity.SensorDeviceClassMoisture:
-		return []string{"%"}
-	case entity.SensorDeviceClassMonetary:
-		return []string{"ISO 4217"}
-	case entity.SensorDeviceClassNitrogenDioxide:
-		return []string{"µg/m³"}
-	case entity.SensorDeviceClassNitrogenMonoxide:
-		return []string{"µg/m³"}
-	case entity.SensorDeviceClassNitrousOxide:
-		return []string{"µg/m³"}
-	case entity.SensorDeviceClassOzone:
-		return []string{"µg/m³"}
-	case entity.SensorDeviceClassPh:
-		return []string{"None"}
-	case entity.SensorDeviceClassPm1:
-		return []string{"µg/m³"}
-	case entity.SensorDeviceClassPm25:
-		return []string{"µg/m³"}
-	case entity.SensorDeviceClassPm10:
-		return []string{"µg/m³"}
-	case entity.SensorDeviceClassPower:
-		return []string{"mW", "W", "kW", "MW", "GW", "TW"}
-	case entity.SensorDeviceClassPowerFactor:
-		return []string{"%", "None"}
-	case entity.SensorDeviceClassPrecipitation:
-		return []string{"cm", "in", "mm"}
-	case entity.SensorDeviceClassPrecipitationIntensity:
-		return []string{"in/d", "in/h", "mm/d", "mm/h"}
-	case entity.SensorDeviceClassPressure:
-		return []string{"cbar", "bar", "hPa", "mmHg", "inHg", "kPa", "mbar", "Pa", "psi"}
-	case entity.SensorDeviceClassReactivePower:
-		return []string{"var"}
-	case entity.SensorDeviceClassSignalStrength:
-		return []string{"dB", "dBm"}
-	case entity.SensorDeviceClassSoundPressure:
-		return []string{"dB", "dBA"}
-	case entity.SensorDeviceClassSpeed:
-		return []string{"ft/s", "in/d", "in/h", "in/s", "km/h", "kn", "m/s", "mph", "mm/d", "mm/s"}
-	case entity.SensorDeviceClassSulphurDioxide:
-		return []string{"µg/m³"}
-	case entity.SensorDeviceClassTemperature:
-		return []string{"°C", "degC", "C", "°F", "degF", "F", "K"}
-	case entity.SensorDeviceClassTimestamp:
-		return []string{}
-	case entity.SensorDeviceClassVolatileOrganicCompounds:
-		return []string{"µg/m³"}
-	case entity.SensorDeviceClassVolatileOrganicCompoundsParts:
-		return []string{"ppm", "ppb"}
-	case entity.SensorDeviceClassVoltage:
-		return []string{"V", "mV", "µV", "kV", "MV"}
-	case entity.SensorDeviceClassVolume:
-		return []string{"L", "mL", "gal", "fl. oz.", "m³", "ft³", "CCF"}
-	case entity.SensorDeviceClassVolumeFlowRate:
-		return []string{"m³/h", "ft³/min", "L/min", "gal/min", "mL/s"}
-	case entity.SensorDeviceClassVolumeStorage:
-		return []string{"L", "mL", "gal", "fl. oz.", "m³", "ft³", "CCF"}
-	case entity.SensorDeviceClassWater:
-		return []string{"L", "gal", "m³", "ft³", "CCF"}
-	case entity.SensorDeviceClassWeight:
-		return []string{"kg", "g", "mg", "µg", "oz", "lb", "st"}
-	case entity.SensorDeviceClassWindDirection:
-		return []string{"°", "deg"}
-	case entity.SensorDeviceClassWindSpeed:
-		return []string{"ft/s", "km/h", "kn", "m/s", "mph"}
-	default:
-		return []string{}
-	}
-}
-
 type DeviceClassMixinConfig = entity.DeviceClassMixinConfig[entity.SensorDeviceClass, *entity.SensorDeviceClass]
 
 type BaseSensorConfig[T any, PT interface {
@@ -135,22 +23,63 @@ type BaseSensorConfig[T any, PT interface {
 	component.Component
 	entity.Sensor
 }] struct {
-	component.ConfigOf[T, PT]
+	component.ConfigOf[T, PT]           `yaml:"-"`
 	entity.EntityConfig                 `yaml:",inline"`
 	DeviceClassMixinConfig              `yaml:",inline"`
 	entity.IconMixinConfig              `yaml:",inline"`
 	entity.UnitOfMeasurementMixinConfig `yaml:",inline"`
+	// ForceUpdate publishes every reading, even when it repeats the last one.
+	ForceUpdate bool `yaml:"force_update"`
+	// AccuracyDecimals is the number of decimals clients show.
+	AccuracyDecimals int32 `yaml:"accuracy_decimals"`
+	// StateClass lets Home Assistant keep long-term statistics of the sensor.
+	StateClass entity.SensorStateClass `yaml:"state_class"`
+	// LastResetType tells when a total sensor restarts counting.
+	LastResetType entity.SensorLastResetType `yaml:"last_reset_type"`
+	// Filters change the readings before they are published.
+	Filters Filters `yaml:"filters"`
+
+	// OnValue runs on every reading with the value as x, not when the sensor
+	// only becomes unavailable or available again.
+	OnValue automation.Trigger[float32] `yaml:"on_value"`
 }
 
 func (bsc *BaseSensorConfig[T, PT]) ValidateWithContext(ctx context.Context) error {
+	units := entity.UnitsOfMeasurementOf(bsc.DeviceClass)
+	stateClasses := entity.StateClassesOf(bsc.DeviceClass)
 	return cv.ValidateEmbedded(
 		bsc.EntityConfig.ValidateWithContext(ctx),
 		bsc.DeviceClassMixinConfig.ValidateWithContext(ctx),
 		bsc.IconMixinConfig.ValidateWithContext(ctx),
 		bsc.UnitOfMeasurementMixinConfig.ValidateWithContext(ctx),
+		validation.ValidateStructWithContext(ctx, bsc,
+			validation.Field(&bsc.UnitOfMeasurement, validation.When(
+				len(units) > 0,
+				cv.String(cv.Optional(cv.OneOf(units...))),
+			)),
+			validation.Field(&bsc.StateClass, validation.When(
+				stateClasses != nil && bsc.StateClass != entity.SensorStateClassNone,
+				validation.By(func(interface{}) error {
+					if slices.Contains(stateClasses, bsc.StateClass) {
+						return nil
+					}
+					return validation.NewError("sensor_invalid_state_class", fmt.Sprintf(
+						"%s sensors should have state_class one of %v", bsc.DeviceClass, stateClasses))
+				}),
+			)),
+			validation.Field(&bsc.LastResetType, validation.When(
+				bsc.StateClass != entity.SensorStateClassTotal,
+				validation.In(entity.SensorLastResetTypeNone).Error("last_reset_type is only for state_class total"),
+			)),
+		),
 	)
 }
 
+// DeclaredID implements cid.Declarer.
+func (bsc *BaseSensorConfig[T, PT]) DeclaredID() (*string, reflect.Type) {
+	return bsc.EntityID(), reflect.TypeFor[entity.Sensor]()
+}
+
 type BaseSensor[T any, PT interface {
 	*T
 	component.Component
@@ -166,6 +95,10 @@ type BaseSensor[T any, PT interface {
 	lastResetType     entity.SensorLastResetType
 	stateClass        entity.SensorStateClass
 	unitOfMeasurement string
+	onValue           automation.Actions
+	filters           Filters
+	// ctx is the one the lambdas of the filters run with.
+	ctx context.Context
 }
 
 func NewBaseSensor[T any, PT interface {
@@ -176,10 +109,18 @@ func NewBaseSensor[T any, PT interface {
 	ret.BaseEntity = entity.NewBaseEntity(entity.DomainTypeSensor, &cfg.EntityConfig)
 	ret.DeviceClassMixin = entity.NewDeviceClassMixin(&cfg.DeviceClassMixinConfig)
 	ret.IconMixin = entity.NewIconMixin(&cfg.IconMixinConfig)
+	ret.forceUpdate = cfg.ForceUpdate
+	ret.unitOfMeasurement = cfg.UnitOfMeasurement
+	ret.accuracy = cfg.AccuracyDecimals
+	ret.stateClass = cfg.StateClass
+	ret.lastResetType = cfg.LastResetType
+	ret.onValue = cfg.OnValue.Actions
+	ret.filters = cfg.Filters
+	ret.ctx = ctx
 	ret.State_, err = state.NewState(ctx, t, entity.SensorState{
 		State:        0,
 		MissingState: true,
-	})
+	}, state.WithForceUpdate(cfg.ForceUpdate), state.FollowAvailability(&ret.BaseEntity))
 	if err != nil {
 		return
 	}
@@ -210,3 +151,32 @@ func (t *BaseSensor[T, PT]) StateClass() entity.SensorStateClass {
 func (t *BaseSensor[T, PT]) UnitOfMeasurement() string {
 	return t.unitOfMeasurement
 }
+
+// PublishState sets the reading of the sensor after its filters. Readings of
+// total_increasing sensors are never negative, Home Assistant takes a
+// decrease for a new meter cycle, so negative ones are dropped.
+func (t *BaseSensor[T, PT]) PublishState(v float32) {
+	v, err := t.filters.apply(t.ctx, v)
+	if err != nil {
+		slog.Warn("Dropping a reading the filters failed on", "id", t.ID(), "err", err)
+		return
+	}
+	if t.stateClass == entity.SensorStateClassTotalIncreasing && v < 0 {
+		slog.Warn("Dropping negative reading of a total_increasing sensor", "id", t.ID(), "value", v)
+		return
+	}
+	t.State_.SetState(entity.SensorState{State: v})
+}
+
+// SubscribeTriggers implements automation.Subscriber.
+func (t *BaseSensor[T, PT]) SubscribeTriggers(ctx context.Context) []bus.EventSubsciption {
+	if len(t.onValue) == 0 {
+		return nil
+	}
+	r := automation.GetRunner(ctx)
+	return []bus.EventSubsciption{automation.OnState(ctx, t, func(old, nv entity.SensorState) {
+		if !nv.MissingState {
+			r.Run(t.ID()+" on_value", t.onValue, automation.Args{"x": nv.State})
+		}
+	})}
+}
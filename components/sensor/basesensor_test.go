@@ -5,10 +5,12 @@ import (
 	"testing"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core/bus"
 	"github.com/gosthome/gosthome/core/component"
 	cv "github.com/gosthome/gosthome/core/configvalidation"
 	"github.com/gosthome/gosthome/core/entity"
 	"github.com/gosthome/gosthome/core/registry"
+	"github.com/matryer/is"
 )
 
 type testSensorConfig struct {
@@ -31,7 +33,7 @@ type testSensor struct {
 }
 
 // Close implements component.Component.
-func (t *testSensor) Close() error {
+func (t *testSensor) Close(ctx context.Context) error {
 	return nil
 }
 
@@ -41,7 +43,8 @@ func (t *testSensor) InitializationPriority() component.InitializationPriority {
 }
 
 // Setup implements component.Component.
-func (t *testSensor) Setup() {
+func (t *testSensor) Setup(ctx context.Context) error {
+	return nil
 }
 
 func newTestSensor(ctx context.Context, cfg *testSensorConfig) (ret *testSensor, err error) {
@@ -79,3 +82,77 @@ func TestBaseSensorComponent(t *testing.T) {
 	reg := registry.NewRegistry()
 	reg.RegisterEntityComponent(entity.DomainTypeSensor, "test", &testSensorDeclaration{})
 }
+
+func TestStateClass(t *testing.T) {
+	for _, tc := range []struct {
+		dc        entity.SensorDeviceClass
+		sc        entity.SensorStateClass
+		lastReset entity.SensorLastResetType
+		valid     bool
+	}{
+		{dc: entity.SensorDeviceClassTemperature, sc: entity.SensorStateClassMeasurement, valid: true},
+		{dc: entity.SensorDeviceClassTemperature, sc: entity.SensorStateClassTotalIncreasing, valid: false},
+		{dc: entity.SensorDeviceClassEnergy, sc: entity.SensorStateClassTotalIncreasing, valid: true},
+		{dc: entity.SensorDeviceClassEnergy, sc: entity.SensorStateClassMeasurement, valid: false},
+		{dc: entity.SensorDeviceClassTimestamp, sc: entity.SensorStateClassMeasurement, valid: false},
+		{dc: entity.SensorDeviceClass(""), sc: entity.SensorStateClassTotal, lastReset: entity.SensorLastResetTypeAuto, valid: true},
+		{dc: entity.SensorDeviceClass(""), sc: entity.SensorStateClassTotalIncreasing, lastReset: entity.SensorLastResetTypeAuto, valid: false},
+	} {
+		t.Run(string(tc.dc)+" "+tc.sc.String(), func(t *testing.T) {
+			is := is.New(t)
+			cfg := &BaseSensorConfig[testSensor, *testSensor]{}
+			cfg.Name = "test"
+			cfg.DeviceClass = tc.dc
+			cfg.StateClass = tc.sc
+			cfg.LastResetType = tc.lastReset
+			err := cfg.ValidateWithContext(context.Background())
+			is.Equal(err == nil, tc.valid)
+		})
+	}
+}
+
+func TestSensorMetadata(t *testing.T) {
+	is := is.New(t)
+	ctx := bus.Context(context.Background(), bus.New())
+	cfg := &testSensorConfig{}
+	cfg.Name = "energy"
+	cfg.DeviceClass = entity.SensorDeviceClassEnergy
+	cfg.UnitOfMeasurement = "kWh"
+	cfg.AccuracyDecimals = 3
+	cfg.StateClass = entity.SensorStateClassTotalIncreasing
+	s, err := newTestSensor(ctx, cfg)
+	is.NoErr(err)
+	is.Equal(s.UnitOfMeasurement(), "kWh")
+	is.Equal(s.AccuracyDecimals(), int32(3))
+	is.Equal(s.StateClass(), entity.SensorStateClassTotalIncreasing)
+
+	s.PublishState(12.5)
+	is.Equal(s.State(), entity.SensorState{State: 12.5})
+	// total_increasing readings are never negative
+	s.PublishState(-1)
+	is.Equal(s.State(), entity.SensorState{State: 12.5})
+}
+
+func TestUnitOfMeasurement(t *testing.T) {
+	for _, tc := range []struct {
+		dc    entity.SensorDeviceClass
+		unit  string
+		valid bool
+	}{
+		{dc: entity.SensorDeviceClassTemperature, unit: "°C", valid: true},
+		{dc: entity.SensorDeviceClassTemperature, unit: "", valid: true},
+		{dc: entity.SensorDeviceClassTemperature, unit: "*C", valid: false},
+		{dc: entity.SensorDeviceClassMonetary, unit: "EUR", valid: true},
+		{dc: entity.SensorDeviceClass(""), unit: "anything", valid: true},
+	} {
+		t.Run(string(tc.dc)+" "+tc.unit, func(t *testing.T) {
+			is := is.New(t)
+			cfg := &BaseSensorConfig[testSensor, *testSensor]{}
+			cfg.Name = "test"
+			cfg.DeviceClass = tc.dc
+			cfg.UnitOfMeasurement = tc.unit
+			err := cfg.ValidateWithContext(context.Background())
+			is.Equal(err == nil, tc.valid)
+		})
+	}
+}
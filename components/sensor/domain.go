@@ -5,13 +5,14 @@ import (
 	"log/slog"
 
 	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/automation"
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/config"
 	"github.com/gosthome/gosthome/core/entity"
 )
 
 type Config struct {
-	component.ConfigOf[entity.SensorDomain, *entity.SensorDomain]
+	component.ConfigOf[entity.SensorDomain, *entity.SensorDomain] `yaml:"-"`
 	config.PlatformConfig
 }
 
@@ -46,6 +47,7 @@ func New(ctx context.Context, c *Config) ([]component.Component, error) {
 		}
 		for _, c := range comp {
 			domain.Register(c.(entity.Sensor))
+			automation.Subscribe(ctx, c, domain.OnClose)
 		}
 		ret = append(ret, comp...)
 	}
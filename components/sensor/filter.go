@@ -0,0 +1,69 @@
+package sensor
+
+import (
+	"context"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/lambda"
+)
+
+// Filter changes the readings of a sensor before they are published, it is
+// one of multiply:, offset: or lambda:, e.g. lambda: return x * 1.8 + 32;.
+type Filter struct {
+	Multiply *float32 `yaml:"multiply"`
+	Offset   *float32 `yaml:"offset"`
+	// Lambda gets the reading as x.
+	Lambda *lambda.Lambda[float32] `yaml:"lambda"`
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (f *Filter) ValidateWithContext(ctx context.Context) error {
+	set := 0
+	for _, ok := range []bool{f.Multiply != nil, f.Offset != nil, f.Lambda != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return validation.NewError("sensor_filter", "a filter is one of multiply, offset or lambda")
+	}
+	return nil
+}
+
+// apply returns the filtered reading v.
+func (f *Filter) apply(ctx context.Context, v float32) (float32, error) {
+	switch {
+	case f.Multiply != nil:
+		return v * *f.Multiply, nil
+	case f.Offset != nil:
+		return v + *f.Offset, nil
+	}
+	return f.Lambda.Eval(ctx, map[string]any{"x": v})
+}
+
+// Filters are applied in order.
+type Filters []Filter
+
+// UnmarshalYAML implements yaml.NodeUnmarshalerContext, the lambdas of the
+// filters have x.
+func (fs *Filters) UnmarshalYAML(ctx context.Context, node ast.Node) error {
+	dec := ctx.Value(cv.ConfigYAMLDecoderKey{}).(*yaml.Decoder)
+	return dec.DecodeFromNodeContext(lambda.WithVars(ctx, lambda.Vars{"x": lambda.Number}), node, (*[]Filter)(fs))
+}
+
+// apply returns the filtered reading v.
+func (fs Filters) apply(ctx context.Context, v float32) (float32, error) {
+	var err error
+	for i := range fs {
+		if v, err = fs[i].apply(ctx, v); err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
+
+var _ cv.Validatable = (*Filter)(nil)
+var _ yaml.NodeUnmarshalerContext = (*Filters)(nil)
@@ -0,0 +1,105 @@
+package switchcomp
+
+import (
+	"context"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/lambda"
+	"github.com/gosthome/gosthome/core/registry"
+)
+
+var (
+	_ = registry.RegisterDefaultAction("switch.turn_on", func() automation.Action {
+		return &Action{set: func(bool) bool { return true }}
+	})
+	_ = registry.RegisterDefaultAction("switch.turn_off", func() automation.Action {
+		return &Action{set: func(bool) bool { return false }}
+	})
+	_ = registry.RegisterDefaultAction("switch.toggle", func() automation.Action {
+		return &Action{set: func(on bool) bool { return !on }}
+	})
+	_ = registry.RegisterDefaultCondition("switch.is_on", func() automation.Condition {
+		return &Condition{on: true}
+	})
+	_ = registry.RegisterDefaultCondition("switch.is_off", func() automation.Condition {
+		return &Condition{on: false}
+	})
+)
+
+// Action turns a switch on or off, e.g. switch.turn_on: relay.
+type Action struct {
+	ID cid.Ref[entity.Switch] `yaml:"id"`
+	// set returns the state to set given the current one.
+	set func(on bool) bool
+}
+
+// Shorthand implements automation.Shorthand.
+func (a *Action) Shorthand() any {
+	return &a.ID
+}
+
+// ValidateWithContext implements automation.Action.
+func (a *Action) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, a, validation.Field(&a.ID))
+}
+
+// Run implements automation.Action.
+func (a *Action) Run(ctx context.Context) error {
+	key := a.ID.CID().HashID()
+	on := false
+	if sw, ok := core.GetNode(ctx).SwitchByKey(key); ok {
+		on = sw.State().State
+	}
+	_, err := bus.Call[*SetState, any](ctx, bus.Get(ctx), &SetState{Key: key, State: a.set(on)})
+	return err
+}
+
+// Condition is true when the switch is on, or off, e.g.
+// switch.is_on: relay.
+type Condition struct {
+	ID cid.Ref[entity.Switch] `yaml:"id"`
+	on bool
+}
+
+// Shorthand implements automation.Shorthand.
+func (c *Condition) Shorthand() any {
+	return &c.ID
+}
+
+// ValidateWithContext implements automation.Condition.
+func (c *Condition) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, c, validation.Field(&c.ID))
+}
+
+// Check implements automation.Condition.
+func (c *Condition) Check(ctx context.Context) (bool, error) {
+	sw, ok := core.GetNode(ctx).SwitchByKey(c.ID.CID().HashID())
+	if !ok {
+		return false, nil
+	}
+	return sw.State().State == c.on, nil
+}
+
+var _ automation.Action = (*Action)(nil)
+var _ automation.Shorthand = (*Action)(nil)
+var _ automation.Condition = (*Condition)(nil)
+var _ automation.Shorthand = (*Condition)(nil)
+
+var _ = registry.RegisterDefaultObject[entity.Switch](lambda.Object{Fields: map[string]lambda.Field{
+	"state": {Type: lambda.Bool, Get: func(ctx context.Context, id string) (any, error) {
+		n := core.GetNode(ctx)
+		if n == nil {
+			return false, lambda.ErrNotFound
+		}
+		sw, ok := n.SwitchByKey(cid.HashID(id))
+		if !ok {
+			return false, lambda.ErrNotFound
+		}
+		return sw.State().State, nil
+	}},
+}})
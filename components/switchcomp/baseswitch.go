@@ -0,0 +1,162 @@
+package switchcomp
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/preferences"
+	"github.com/gosthome/gosthome/core/state"
+)
+
+//go:generate go-enum --marshal --nocase --names
+
+// RestoreMode selects the state a switch starts with, like ESPHome's
+// restore_mode.
+// ENUM(
+// always_off, // start off (default)
+// always_on, // start on
+// restore_default_off, // restore the last state, off if there is none
+// restore_default_on, // restore the last state, on if there is none
+// restore_inverted_default_off, // restore the inverted last state, off if there is none
+// restore_inverted_default_on, // restore the inverted last state, on if there is none
+// disabled, // start off and do not remember the state
+// )
+type RestoreMode int
+
+// EnumValues implements cv.Enum.
+func (x *RestoreMode) EnumValues() []string {
+	return RestoreModeNames()
+}
+
+var _ cv.Enum = (*RestoreMode)(nil)
+
+// initial returns the state to start with given the saved one.
+func (m RestoreMode) initial(saved, ok bool) bool {
+	switch m {
+	case RestoreModeAlwaysOn:
+		return true
+	case RestoreModeRestoreDefaultOff:
+		return ok && saved
+	case RestoreModeRestoreDefaultOn:
+		return !ok || saved
+	case RestoreModeRestoreInvertedDefaultOff:
+		return ok && !saved
+	case RestoreModeRestoreInvertedDefaultOn:
+		return !ok || !saved
+	}
+	return false
+}
+
+type BaseSwitchConfig[T any, PT interface {
+	*T
+	component.Component
+	entity.Switch
+}] struct {
+	component.ConfigOf[T, PT]                                                          `yaml:"-"`
+	entity.EntityConfig                                                                `yaml:",inline"`
+	entity.DeviceClassMixinConfig[entity.SwitchDeviceClass, *entity.SwitchDeviceClass] `yaml:",inline"`
+	entity.IconMixinConfig                                                             `yaml:",inline"`
+	RestoreMode                                                                        RestoreMode `yaml:"restore_mode"`
+
+	OnTurnOn  automation.Trigger[bool] `yaml:"on_turn_on"`
+	OnTurnOff automation.Trigger[bool] `yaml:"on_turn_off"`
+	// OnState runs on every change with the state as x, not when the switch
+	// only becomes unavailable or available again.
+	OnState automation.Trigger[bool] `yaml:"on_state"`
+}
+
+func (bsc *BaseSwitchConfig[T, PT]) ValidateWithContext(ctx context.Context) error {
+	return cv.ValidateEmbedded(
+		bsc.EntityConfig.ValidateWithContext(ctx),
+		bsc.DeviceClassMixinConfig.ValidateWithContext(ctx),
+		bsc.IconMixinConfig.ValidateWithContext(ctx),
+	)
+}
+
+// DeclaredID implements cid.Declarer.
+func (bsc *BaseSwitchConfig[T, PT]) DeclaredID() (*string, reflect.Type) {
+	return bsc.EntityID(), reflect.TypeFor[entity.Switch]()
+}
+
+type BaseSwitch[T any, PT interface {
+	*T
+	component.Component
+	entity.Switch
+}] struct {
+	entity.BaseEntity
+	entity.DeviceClassMixin[entity.SwitchDeviceClass, *entity.SwitchDeviceClass]
+	entity.IconMixin
+	state.State_[entity.SwitchState]
+
+	restoreMode RestoreMode
+	pref        preferences.Pref[bool]
+
+	onTurnOn  automation.Actions
+	onTurnOff automation.Actions
+	onState   automation.Actions
+}
+
+// NewBaseSwitch restores the state of the switch according to its
+// restore_mode, the platform applies it in Setup.
+func NewBaseSwitch[T any, PT interface {
+	*T
+	component.Component
+	entity.Switch
+}](ctx context.Context, t PT, cfg *BaseSwitchConfig[T, PT]) (ret BaseSwitch[T, PT], err error) {
+	ret.BaseEntity = entity.NewBaseEntity(entity.DomainTypeSwitch, &cfg.EntityConfig)
+	ret.DeviceClassMixin = entity.NewDeviceClassMixin(&cfg.DeviceClassMixinConfig)
+	ret.IconMixin = entity.NewIconMixin(&cfg.IconMixinConfig)
+	ret.restoreMode = cfg.RestoreMode
+	ret.onTurnOn = cfg.OnTurnOn.Actions
+	ret.onTurnOff = cfg.OnTurnOff.Actions
+	ret.onState = cfg.OnState.Actions
+	if ret.restoreMode != RestoreModeDisabled {
+		ret.pref = preferences.Make[bool](preferences.Get(ctx), "switch/"+ret.ID())
+	}
+	saved, ok, err := ret.pref.Load()
+	if err != nil {
+		slog.Warn("Failed to restore switch state", "id", ret.ID(), "err", err)
+	}
+	ret.State_, err = state.NewState(ctx, t, entity.SwitchState{
+		State: ret.restoreMode.initial(saved, ok),
+	}, state.FollowAvailability(&ret.BaseEntity))
+	return
+}
+
+// RestoreMode returns the restore_mode of the switch.
+func (t *BaseSwitch[T, PT]) RestoreMode() RestoreMode {
+	return t.restoreMode
+}
+
+// PublishState sets the state of the switch and remembers it for the next
+// start.
+func (t *BaseSwitch[T, PT]) PublishState(on bool) {
+	t.State_.SetState(entity.SwitchState{State: on})
+	err := t.pref.Save(on)
+	if err != nil {
+		slog.Warn("Failed to save switch state", "id", t.ID(), "err", err)
+	}
+}
+
+// SubscribeTriggers implements automation.Subscriber.
+func (t *BaseSwitch[T, PT]) SubscribeTriggers(ctx context.Context) []bus.EventSubsciption {
+	if len(t.onTurnOn)+len(t.onTurnOff)+len(t.onState) == 0 {
+		return nil
+	}
+	r := automation.GetRunner(ctx)
+	return []bus.EventSubsciption{automation.OnState(ctx, t, func(old, nv entity.SwitchState) {
+		args := automation.Args{"x": nv.State}
+		r.Run(t.ID()+" on_state", t.onState, args)
+		if nv.State {
+			r.Run(t.ID()+" on_turn_on", t.onTurnOn, args)
+		} else {
+			r.Run(t.ID()+" on_turn_off", t.onTurnOff, args)
+		}
+	})}
+}
@@ -2,10 +2,10 @@ package switchcomp
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 
 	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/automation"
 	"github.com/gosthome/gosthome/core/bus"
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/config"
@@ -28,7 +28,7 @@ var _ bus.ServiceRequestData = (*SetState)(nil)
 
 // Config holds the switch domain configuration.
 type Config struct {
-	component.ConfigOf[entity.SwitchDomain, *entity.SwitchDomain]
+	component.ConfigOf[entity.SwitchDomain, *entity.SwitchDomain] `yaml:"-"`
 	config.PlatformConfig
 }
 
@@ -48,14 +48,14 @@ func NewConfig() *Config {
 
 // RegisterServiceCallHandlers registers service call handlers for the switch domain.
 func RegisterServiceCallHandlers(ctx context.Context, domain *entity.SwitchDomain, b *bus.Bus) {
-	b.HandleServiceCalls(bus.ServiceHandlerWithRespose(b, func(t *SetState) error {
+	sub := b.HandleServiceCalls(bus.ServiceHandlerWithRespose(b, func(t *SetState) error {
 		sw, ok := domain.FindByKey(t.Key)
 		if !ok {
-			slog.Error("Tried to set state on nonexisting switch", "key", t.Key)
-			return fmt.Errorf("tried to set state on nonexisting switch %d", t.Key)
+			return bus.ErrNotHandled
 		}
 		return sw.SetState(ctx, t.State)
 	}))
+	domain.OnClose(sub.Close)
 }
 
 // New initializes the switch domain, registers switch entities and sets up service handlers.
@@ -78,6 +78,7 @@ func New(ctx context.Context, c *Config) ([]component.Component, error) {
 		}
 		for _, cc := range comp {
 			domain.Register(cc.(entity.Switch))
+			automation.Subscribe(ctx, cc, domain.OnClose)
 		}
 		ret = append(ret, comp...)
 	}
@@ -0,0 +1,7 @@
+package switchcomp
+
+import "github.com/gosthome/gosthome/core/entity"
+
+var (
+	COMPONENT_KEY = entity.DomainTypeSwitch.String()
+)
@@ -0,0 +1,5 @@
+package template
+
+const (
+	COMPONENT_KEY = "template"
+)
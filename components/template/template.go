@@ -0,0 +1,63 @@
+// Package template has the entities of ESPHome's template platform, they
+// have no hardware and keep the state they are set to.
+package template
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+)
+
+// Config is empty, the template entities are platforms of their domains,
+// e.g. switch: [{platform: template}].
+type Config struct{}
+
+func NewConfig() *Config {
+	return &Config{}
+}
+
+// ComponentType implements component.Config.
+func (*Config) ComponentType() reflect.Type {
+	return nil
+}
+
+// Validate implements validation.Validatable.
+func (c *Config) ValidateWithContext(ctx context.Context) error {
+	return nil
+}
+
+var _ component.Config = (*Config)(nil)
+
+func New(ctx context.Context, cfg *Config) ([]component.Component, error) {
+	return nil, nil
+}
+
+// noHardware is the part of component.Component of the template entities.
+type noHardware struct{}
+
+// Setup implements component.Component.
+func (noHardware) Setup(ctx context.Context) error {
+	return nil
+}
+
+// Close implements component.Component.
+func (noHardware) Close(ctx context.Context) error {
+	return nil
+}
+
+// InitializationPriority implements component.Component.
+func (noHardware) InitializationPriority() component.InitializationPriority {
+	return component.InitializationPriorityProcessor
+}
+
+// onStateChanges calls update on the state changes of the entities of the
+// node but self, the lambdas of the template entities read them.
+func onStateChanges(ctx context.Context, self uint32, update func()) bus.EventSubsciption {
+	return bus.Get(ctx).HandleEvents(bus.EventHandler(func(ev *bus.StateChangeEvent) {
+		if ev.Key != self {
+			update()
+		}
+	}))
+}
@@ -0,0 +1,75 @@
+package template
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/gosthome/gosthome/components/binarysensor"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/lambda"
+)
+
+type BinarySensorConfig struct {
+	binarysensor.BaseBinarySensorConfig[BinarySensor, *BinarySensor] `yaml:",inline"`
+
+	// Lambda returns the state of the binary sensor, it is evaluated on
+	// setup and on the state changes of the other entities.
+	Lambda *lambda.Lambda[bool] `yaml:"lambda"`
+}
+
+func NewBinarySensorConfig() *BinarySensorConfig {
+	return &BinarySensorConfig{}
+}
+
+func (c *BinarySensorConfig) ValidateWithContext(ctx context.Context) error {
+	return c.BaseBinarySensorConfig.ValidateWithContext(ctx)
+}
+
+var _ component.Config = (*BinarySensorConfig)(nil)
+
+type BinarySensor struct {
+	noHardware
+	binarysensor.BaseBinarySensor[BinarySensor, *BinarySensor]
+
+	ctx    context.Context
+	lambda *lambda.Lambda[bool]
+	sub    bus.EventSubsciption
+}
+
+func NewBinarySensor(ctx context.Context, cfg *BinarySensorConfig) (ret []component.Component, err error) {
+	s := &BinarySensor{ctx: ctx, lambda: cfg.Lambda}
+	s.BaseBinarySensor, err = binarysensor.NewBaseBinarySensor(ctx, s, &cfg.BaseBinarySensorConfig)
+	if err != nil {
+		return nil, err
+	}
+	return []component.Component{s}, nil
+}
+
+// Setup implements component.Component.
+func (s *BinarySensor) Setup(ctx context.Context) error {
+	if s.lambda == nil {
+		return nil
+	}
+	s.update()
+	s.sub = onStateChanges(s.ctx, s.HashID(), s.update)
+	return nil
+}
+
+// Close implements component.Component.
+func (s *BinarySensor) Close(ctx context.Context) error {
+	s.sub.Close()
+	return nil
+}
+
+func (s *BinarySensor) update() {
+	v, err := s.lambda.Eval(s.ctx, nil)
+	if err != nil {
+		slog.Warn("Template binary sensor lambda failed", "id", s.ID(), "err", err)
+		return
+	}
+	s.State_.SetState(entity.BinarySensorState{State: v})
+}
+
+var _ entity.BinarySensor = (*BinarySensor)(nil)
@@ -0,0 +1,46 @@
+package template
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/gosthome/gosthome/components/button"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/entity"
+)
+
+type ButtonConfig struct {
+	button.BaseButtonConfig[Button, *Button] `yaml:",inline"`
+}
+
+func NewButtonConfig() *ButtonConfig {
+	return &ButtonConfig{}
+}
+
+func (c *ButtonConfig) ValidateWithContext(ctx context.Context) error {
+	return c.BaseButtonConfig.ValidateWithContext(ctx)
+}
+
+var _ component.Config = (*ButtonConfig)(nil)
+
+type Button struct {
+	noHardware
+	button.BaseButton[Button, *Button]
+}
+
+func NewButton(ctx context.Context, cfg *ButtonConfig) (ret []component.Component, err error) {
+	b := &Button{}
+	b.BaseButton, err = button.NewBaseButton(ctx, b, &cfg.BaseButtonConfig)
+	if err != nil {
+		return nil, err
+	}
+	return []component.Component{b}, nil
+}
+
+// Press implements entity.Button.
+func (b *Button) Press(ctx context.Context) error {
+	slog.Debug("Template button pressed", "id", b.ID())
+	return nil
+}
+
+var _ entity.Button = (*Button)(nil)
@@ -0,0 +1,51 @@
+package template
+
+import (
+	"context"
+
+	"github.com/gosthome/gosthome/components/number"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/entity"
+)
+
+type NumberConfig struct {
+	number.BaseNumberConfig[Number, *Number] `yaml:",inline"`
+
+	// Optimistic publishes the value the number is set to.
+	Optimistic bool `yaml:"optimistic"`
+}
+
+func NewNumberConfig() *NumberConfig {
+	return &NumberConfig{}
+}
+
+func (c *NumberConfig) ValidateWithContext(ctx context.Context) error {
+	return c.BaseNumberConfig.ValidateWithContext(ctx)
+}
+
+var _ component.Config = (*NumberConfig)(nil)
+
+type Number struct {
+	noHardware
+	number.BaseNumber[Number, *Number]
+	optimistic bool
+}
+
+func NewNumber(ctx context.Context, cfg *NumberConfig) (ret []component.Component, err error) {
+	n := &Number{optimistic: cfg.Optimistic}
+	n.BaseNumber, err = number.NewBaseNumber(ctx, n, &cfg.BaseNumberConfig)
+	if err != nil {
+		return nil, err
+	}
+	return []component.Component{n}, nil
+}
+
+// SetValue implements entity.Number.
+func (n *Number) SetValue(ctx context.Context, v float32) error {
+	if n.optimistic {
+		n.PublishState(v)
+	}
+	return nil
+}
+
+var _ entity.Number = (*Number)(nil)
@@ -0,0 +1,93 @@
+package template
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gosthome/gosthome/components/sensor"
+	"github.com/gosthome/gosthome/core/component"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/lambda"
+)
+
+type SensorConfig struct {
+	sensor.BaseSensorConfig[Sensor, *Sensor] `yaml:",inline"`
+	component.PollingComponentConfig         `yaml:",inline"`
+
+	// Lambda returns the reading of the sensor every update_interval.
+	Lambda *lambda.Lambda[float32] `yaml:"lambda"`
+}
+
+func NewSensorConfig() *SensorConfig {
+	return &SensorConfig{
+		PollingComponentConfig: component.PollingComponentConfig{
+			UpdateInterval: 60 * time.Second,
+		},
+	}
+}
+
+func (c *SensorConfig) ValidateWithContext(ctx context.Context) error {
+	return cv.ValidateEmbedded(
+		c.BaseSensorConfig.ValidateWithContext(ctx),
+		c.PollingComponentConfig.Validate(),
+	)
+}
+
+var _ component.Config = (*SensorConfig)(nil)
+
+type Sensor struct {
+	noHardware
+	sensor.BaseSensor[Sensor, *Sensor]
+
+	ctx    context.Context
+	lambda *lambda.Lambda[float32]
+	poller *component.PollingComponent[Sensor, *Sensor]
+}
+
+func NewSensor(ctx context.Context, cfg *SensorConfig) (ret []component.Component, err error) {
+	s := &Sensor{ctx: ctx, lambda: cfg.Lambda}
+	s.BaseSensor, err = sensor.NewBaseSensor(ctx, s, &cfg.BaseSensorConfig)
+	if err != nil {
+		return nil, err
+	}
+	if s.lambda != nil {
+		s.poller, err = component.NewPollingComponent(ctx, s, &cfg.PollingComponentConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []component.Component{s}, nil
+}
+
+// Setup implements component.Component, the sensor with a lambda publishes
+// its first reading.
+func (s *Sensor) Setup(ctx context.Context) error {
+	if s.poller == nil {
+		return nil
+	}
+	s.Poll()
+	return s.poller.Setup(ctx)
+}
+
+// Close implements component.Component.
+func (s *Sensor) Close(ctx context.Context) error {
+	if s.poller == nil {
+		return nil
+	}
+	return s.poller.Close(ctx)
+}
+
+// Poll implements component.Poller.
+func (s *Sensor) Poll() {
+	v, err := s.lambda.Eval(s.ctx, nil)
+	if err != nil {
+		slog.Warn("Template sensor lambda failed", "id", s.ID(), "err", err)
+		return
+	}
+	s.PublishState(v)
+}
+
+var _ entity.Sensor = (*Sensor)(nil)
+var _ component.Poller = (*Sensor)(nil)
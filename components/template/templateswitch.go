@@ -0,0 +1,86 @@
+package template
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/gosthome/gosthome/components/switchcomp"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/lambda"
+)
+
+type SwitchConfig struct {
+	switchcomp.BaseSwitchConfig[Switch, *Switch] `yaml:",inline"`
+
+	// Optimistic publishes the state the switch is set to.
+	Optimistic bool `yaml:"optimistic"`
+	// Lambda returns the state of the switch, it is evaluated on setup and
+	// on the state changes of the other entities.
+	Lambda *lambda.Lambda[bool] `yaml:"lambda"`
+}
+
+func NewSwitchConfig() *SwitchConfig {
+	return &SwitchConfig{}
+}
+
+func (c *SwitchConfig) ValidateWithContext(ctx context.Context) error {
+	return c.BaseSwitchConfig.ValidateWithContext(ctx)
+}
+
+var _ component.Config = (*SwitchConfig)(nil)
+
+type Switch struct {
+	noHardware
+	switchcomp.BaseSwitch[Switch, *Switch]
+	optimistic bool
+
+	ctx    context.Context
+	lambda *lambda.Lambda[bool]
+	sub    bus.EventSubsciption
+}
+
+func NewSwitch(ctx context.Context, cfg *SwitchConfig) (ret []component.Component, err error) {
+	s := &Switch{optimistic: cfg.Optimistic, ctx: ctx, lambda: cfg.Lambda}
+	s.BaseSwitch, err = switchcomp.NewBaseSwitch(ctx, s, &cfg.BaseSwitchConfig)
+	if err != nil {
+		return nil, err
+	}
+	return []component.Component{s}, nil
+}
+
+// Setup implements component.Component.
+func (s *Switch) Setup(ctx context.Context) error {
+	if s.lambda == nil {
+		return nil
+	}
+	s.update()
+	s.sub = onStateChanges(s.ctx, s.HashID(), s.update)
+	return nil
+}
+
+// Close implements component.Component.
+func (s *Switch) Close(ctx context.Context) error {
+	s.sub.Close()
+	return nil
+}
+
+func (s *Switch) update() {
+	v, err := s.lambda.Eval(s.ctx, nil)
+	if err != nil {
+		slog.Warn("Template switch lambda failed", "id", s.ID(), "err", err)
+		return
+	}
+	s.PublishState(v)
+}
+
+// SetState implements entity.Switch.
+func (s *Switch) SetState(ctx context.Context, on bool) error {
+	if s.optimistic {
+		s.PublishState(on)
+	}
+	return nil
+}
+
+var _ entity.Switch = (*Switch)(nil)
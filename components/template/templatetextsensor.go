@@ -0,0 +1,93 @@
+package template
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gosthome/gosthome/components/textsensor"
+	"github.com/gosthome/gosthome/core/component"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/lambda"
+)
+
+type TextSensorConfig struct {
+	textsensor.BaseTextSensorConfig[TextSensor, *TextSensor] `yaml:",inline"`
+	component.PollingComponentConfig                         `yaml:",inline"`
+
+	// Lambda returns the text of the sensor every update_interval.
+	Lambda *lambda.Lambda[string] `yaml:"lambda"`
+}
+
+func NewTextSensorConfig() *TextSensorConfig {
+	return &TextSensorConfig{
+		PollingComponentConfig: component.PollingComponentConfig{
+			UpdateInterval: 60 * time.Second,
+		},
+	}
+}
+
+func (c *TextSensorConfig) ValidateWithContext(ctx context.Context) error {
+	return cv.ValidateEmbedded(
+		c.BaseTextSensorConfig.ValidateWithContext(ctx),
+		c.PollingComponentConfig.Validate(),
+	)
+}
+
+var _ component.Config = (*TextSensorConfig)(nil)
+
+type TextSensor struct {
+	noHardware
+	textsensor.BaseTextSensor[TextSensor, *TextSensor]
+
+	ctx    context.Context
+	lambda *lambda.Lambda[string]
+	poller *component.PollingComponent[TextSensor, *TextSensor]
+}
+
+func NewTextSensor(ctx context.Context, cfg *TextSensorConfig) (ret []component.Component, err error) {
+	s := &TextSensor{ctx: ctx, lambda: cfg.Lambda}
+	s.BaseTextSensor, err = textsensor.NewBaseTextSensor(ctx, s, &cfg.BaseTextSensorConfig)
+	if err != nil {
+		return nil, err
+	}
+	if s.lambda != nil {
+		s.poller, err = component.NewPollingComponent(ctx, s, &cfg.PollingComponentConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []component.Component{s}, nil
+}
+
+// Setup implements component.Component, the text sensor with a lambda
+// publishes its first text.
+func (s *TextSensor) Setup(ctx context.Context) error {
+	if s.poller == nil {
+		return nil
+	}
+	s.Poll()
+	return s.poller.Setup(ctx)
+}
+
+// Close implements component.Component.
+func (s *TextSensor) Close(ctx context.Context) error {
+	if s.poller == nil {
+		return nil
+	}
+	return s.poller.Close(ctx)
+}
+
+// Poll implements component.Poller.
+func (s *TextSensor) Poll() {
+	v, err := s.lambda.Eval(s.ctx, nil)
+	if err != nil {
+		slog.Warn("Template text sensor lambda failed", "id", s.ID(), "err", err)
+		return
+	}
+	s.State_.SetState(entity.TextSensorState{State: v})
+}
+
+var _ entity.TextSensor = (*TextSensor)(nil)
+var _ component.Poller = (*TextSensor)(nil)
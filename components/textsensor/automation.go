@@ -0,0 +1,34 @@
+package textsensor
+
+import (
+	"context"
+
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/lambda"
+	"github.com/gosthome/gosthome/core/registry"
+)
+
+var _ = registry.RegisterDefaultObject[entity.TextSensor](lambda.Object{Fields: map[string]lambda.Field{
+	"state": {Type: lambda.String, Get: func(ctx context.Context, id string) (any, error) {
+		st, err := textSensorState(ctx, id)
+		return st.State, err
+	}},
+	"has_state": {Type: lambda.Bool, Get: func(ctx context.Context, id string) (any, error) {
+		st, err := textSensorState(ctx, id)
+		return !st.MissingState, err
+	}},
+}})
+
+func textSensorState(ctx context.Context, id string) (entity.TextSensorState, error) {
+	n := core.GetNode(ctx)
+	if n == nil {
+		return entity.TextSensorState{MissingState: true}, lambda.ErrNotFound
+	}
+	ts, ok := n.TextSensorByKey(cid.HashID(id))
+	if !ok {
+		return entity.TextSensorState{MissingState: true}, lambda.ErrNotFound
+	}
+	return ts.State(), nil
+}
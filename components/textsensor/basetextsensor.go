@@ -2,7 +2,11 @@ package textsensor
 
 import (
 	"context"
+	"reflect"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/bus"
 	"github.com/gosthome/gosthome/core/component"
 	cv "github.com/gosthome/gosthome/core/configvalidation"
 	"github.com/gosthome/gosthome/core/entity"
@@ -14,10 +18,14 @@ type BaseTextSensorConfig[T any, PT interface {
 	component.Component
 	entity.TextSensor
 }] struct {
-	component.ConfigOf[T, PT]
+	component.ConfigOf[T, PT]                                                          `yaml:"-"`
 	entity.EntityConfig                                                                `yaml:",inline"`
 	entity.DeviceClassMixinConfig[entity.SensorDeviceClass, *entity.SensorDeviceClass] `yaml:",inline"`
 	entity.IconMixinConfig                                                             `yaml:",inline"`
+
+	// OnValue runs on every change with the text as x, not when the sensor
+	// only becomes unavailable or available again.
+	OnValue automation.Trigger[string] `yaml:"on_value"`
 }
 
 func (bsc *BaseTextSensorConfig[T, PT]) ValidateWithContext(ctx context.Context) error {
@@ -25,9 +33,21 @@ func (bsc *BaseTextSensorConfig[T, PT]) ValidateWithContext(ctx context.Context)
 		bsc.EntityConfig.ValidateWithContext(ctx),
 		bsc.DeviceClassMixinConfig.ValidateWithContext(ctx),
 		bsc.IconMixinConfig.ValidateWithContext(ctx),
+		validation.ValidateStructWithContext(ctx, bsc,
+			// the other device classes are for numeric sensors
+			validation.Field(&bsc.DeviceClass, validation.In(
+				entity.SensorDeviceClassDate,
+				entity.SensorDeviceClassTimestamp,
+			).Error("text sensors can only have a date or timestamp device_class")),
+		),
 	)
 }
 
+// DeclaredID implements cid.Declarer.
+func (bsc *BaseTextSensorConfig[T, PT]) DeclaredID() (*string, reflect.Type) {
+	return bsc.EntityID(), reflect.TypeFor[entity.TextSensor]()
+}
+
 type BaseTextSensor[T any, PT interface {
 	*T
 	component.Component
@@ -37,6 +57,8 @@ type BaseTextSensor[T any, PT interface {
 	entity.DeviceClassMixin[entity.SensorDeviceClass, *entity.SensorDeviceClass]
 	entity.IconMixin
 	state.State_[entity.TextSensorState]
+
+	onValue automation.Actions
 }
 
 func NewBaseTextSensor[T any, PT interface {
@@ -47,9 +69,23 @@ func NewBaseTextSensor[T any, PT interface {
 	ret.BaseEntity = entity.NewBaseEntity(entity.DomainTypeTextSensor, &cfg.EntityConfig)
 	ret.DeviceClassMixin = entity.NewDeviceClassMixin(&cfg.DeviceClassMixinConfig)
 	ret.IconMixin = entity.NewIconMixin(&cfg.IconMixinConfig)
+	ret.onValue = cfg.OnValue.Actions
 	ret.State_, err = state.NewState(ctx, t, entity.TextSensorState{
 		State:        "",
 		MissingState: true,
-	})
+	}, state.FollowAvailability(&ret.BaseEntity))
 	return
 }
+
+// SubscribeTriggers implements automation.Subscriber.
+func (t *BaseTextSensor[T, PT]) SubscribeTriggers(ctx context.Context) []bus.EventSubsciption {
+	if len(t.onValue) == 0 {
+		return nil
+	}
+	r := automation.GetRunner(ctx)
+	return []bus.EventSubsciption{automation.OnState(ctx, t, func(old, nv entity.TextSensorState) {
+		if !nv.MissingState {
+			r.Run(t.ID()+" on_value", t.onValue, automation.Args{"x": nv.State})
+		}
+	})}
+}
@@ -9,6 +9,7 @@ import (
 	cv "github.com/gosthome/gosthome/core/configvalidation"
 	"github.com/gosthome/gosthome/core/entity"
 	"github.com/gosthome/gosthome/core/registry"
+	"github.com/matryer/is"
 )
 
 type testTextSensorConfig struct {
@@ -31,7 +32,7 @@ type testTextSensor struct {
 }
 
 // Close implements component.Component.
-func (t *testTextSensor) Close() error {
+func (t *testTextSensor) Close(ctx context.Context) error {
 	return nil
 }
 
@@ -41,7 +42,8 @@ func (t *testTextSensor) InitializationPriority() component.InitializationPriori
 }
 
 // Setup implements component.Component.
-func (t *testTextSensor) Setup() {
+func (t *testTextSensor) Setup(ctx context.Context) error {
+	return nil
 }
 
 func newTestTextSensor(ctx context.Context, cfg *testTextSensorConfig) (ret *testTextSensor, err error) {
@@ -79,3 +81,24 @@ func TestBaseTextSensorComponent(t *testing.T) {
 	reg := registry.NewRegistry()
 	reg.RegisterEntityComponent(entity.DomainTypeTextSensor, "test", &testTextSensorDeclaration{})
 }
+
+func TestTextSensorDeviceClass(t *testing.T) {
+	for _, tc := range []struct {
+		dc    entity.SensorDeviceClass
+		valid bool
+	}{
+		{dc: "", valid: true},
+		{dc: entity.SensorDeviceClassTimestamp, valid: true},
+		{dc: entity.SensorDeviceClassDate, valid: true},
+		{dc: entity.SensorDeviceClassTemperature, valid: false},
+	} {
+		t.Run(string(tc.dc), func(t *testing.T) {
+			is := is.New(t)
+			cfg := &BaseTextSensorConfig[testTextSensor, *testTextSensor]{}
+			cfg.Name = "test"
+			cfg.DeviceClass = tc.dc
+			err := cfg.ValidateWithContext(context.Background())
+			is.Equal(err == nil, tc.valid)
+		})
+	}
+}
@@ -5,13 +5,14 @@ import (
 	"log/slog"
 
 	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/automation"
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/config"
 	"github.com/gosthome/gosthome/core/entity"
 )
 
 type Config struct {
-	component.ConfigOf[entity.SensorDomain, *entity.SensorDomain]
+	component.ConfigOf[entity.TextSensorDomain, *entity.TextSensorDomain] `yaml:"-"`
 	config.PlatformConfig
 }
 
@@ -23,7 +24,7 @@ func (c *Config) Validate() error {
 func NewConfig() *Config {
 	return &Config{
 		PlatformConfig: config.PlatformConfig{
-			DomainType: entity.DomainTypeSensor,
+			DomainType: entity.DomainTypeTextSensor,
 		},
 	}
 }
@@ -31,25 +32,26 @@ func NewConfig() *Config {
 func New(ctx context.Context, c *Config) ([]component.Component, error) {
 	node := core.GetNode(ctx)
 	if node == nil {
-		panic("No node in config during sensor initialization")
+		panic("No node in config during text sensor initialization")
 	}
-	domain := &entity.SensorDomain{}
+	domain := &entity.TextSensorDomain{}
 	ret := []component.Component{domain}
 	for _, platformConfig := range c.Configs {
-		cd, ok := node.Config.Registry.GetEntityComponent(entity.DomainTypeSensor, platformConfig.Platform)
+		cd, ok := node.Config.Registry.GetEntityComponent(entity.DomainTypeTextSensor, platformConfig.Platform)
 		if !ok {
-			panic("unregistered sensor platform in config " + platformConfig.Platform)
+			panic("unregistered text sensor platform in config " + platformConfig.Platform)
 		}
 		comp, err := cd.Component(ctx, platformConfig.Config.Config)
 		if err != nil {
 			return nil, err
 		}
 		for _, c := range comp {
-			domain.Register(c.(entity.Sensor))
+			domain.Register(c.(entity.TextSensor))
+			automation.Subscribe(ctx, c, domain.OnClose)
 		}
 		ret = append(ret, comp...)
 	}
-	slog.Info("Initialized sensor domain")
+	slog.Info("Initialized text sensor domain")
 	err := node.CreateDomain(entity.PublicDomain(domain))
 	if err != nil {
 		return nil, err
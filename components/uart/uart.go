@@ -2,6 +2,7 @@ package uart
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
@@ -12,7 +13,7 @@ import (
 	"go.bug.st/serial"
 )
 
-//go:generate go-enum
+//go:generate go-enum --marshal --nocase --names
 
 // ENUM(
 // no,//disable parity control (default)
@@ -23,6 +24,11 @@ import (
 // )
 type parity int
 
+// EnumValues implements cv.Enum.
+func (x *parity) EnumValues() []string {
+	return parityNames()
+}
+
 // ENUM(
 // one, // sets 1 stop bit (default)
 // one_point_five, // sets 1.5 stop bits
@@ -30,18 +36,26 @@ type parity int
 // )
 type stopbits int
 
+// EnumValues implements cv.Enum.
+func (x *stopbits) EnumValues() []string {
+	return stopbitsNames()
+}
+
+var _ cv.Enum = (*parity)(nil)
+var _ cv.Enum = (*stopbits)(nil)
+
 type Config struct {
-	component.ConfigOf[UART, *UART]
+	component.ConfigOf[UART, *UART] `yaml:"-"`
 	cv.MapOrValue[UARTConfig, *UARTConfig]
 }
 
 type UARTConfig struct {
-	cid.IDConfig
-	Port     string   `yaml:"port"`
-	BaudRate int      `yaml:"baud_rate"`
-	DataBits int      `yaml:"data_bits"`
-	Parity   parity   `yaml:"parity"`
-	StopBits stopbits `yaml:"stop_bits"`
+	cid.IDConfig[UART] `yaml:",inline"`
+	Port               string   `yaml:"port"`
+	BaudRate           int      `yaml:"baud_rate"`
+	DataBits           int      `yaml:"data_bits"`
+	Parity             parity   `yaml:"parity"`
+	StopBits           stopbits `yaml:"stop_bits"`
 }
 
 func (c *UARTConfig) ValidateWithContext(ctx context.Context) error {
@@ -83,6 +97,7 @@ type UART struct {
 	port serial.Port
 	cfg  *UARTConfig
 	ctx  context.Context
+	sub  bus.ServiceSubscription
 }
 
 func New(ctx context.Context, cfg *Config) ([]component.Component, error) {
@@ -103,13 +118,13 @@ func New(ctx context.Context, cfg *Config) ([]component.Component, error) {
 			cfg: uartCfg,
 		}
 		ret = append(ret, u)
-		b.HandleServiceCalls(bus.ServiceHandler[UARTWrite](u.write))
+		u.sub = b.HandleServiceCalls(bus.ServiceHandlerWithRespose(b, u.write))
 	}
 	return ret, nil
 }
 
 // Setup implements component.Component.
-func (u *UART) Setup() {
+func (u *UART) Setup(ctx context.Context) error {
 	var err error
 	u.port, err = serial.Open(u.cfg.Port, &serial.Mode{
 		BaudRate: u.cfg.BaudRate,
@@ -118,10 +133,10 @@ func (u *UART) Setup() {
 		StopBits: serial.StopBits(int(u.cfg.StopBits)),
 	})
 	if err != nil {
-		slog.Error("Failed to initialize uart", "err", err)
 		u.port = nil
-		return
+		return fmt.Errorf("failed to open uart %s: %w", u.cfg.Port, err)
 	}
+	return nil
 }
 
 type UARTRead struct {
@@ -142,22 +157,27 @@ func (*UARTWrite) ServiceType() string {
 	return "uart_write"
 }
 
-func (u *UART) write(w *UARTWrite) {
+func (u *UART) write(w *UARTWrite) error {
 	if u.HashID() != w.Key {
-		return
+		return bus.ErrNotHandled
 	}
 	if u.port == nil {
-		slog.Error("trying to write to a closed uart port")
+		return fmt.Errorf("uart %s is not open", u.ID())
 	}
 	n, err := u.port.Write(w.Data)
 	if err != nil {
-		slog.Error("failed to write to uart", "err", err)
+		return fmt.Errorf("failed to write to uart %s: %w", u.ID(), err)
 	}
 	slog.Debug("uart wrote n bytes", "n", n)
+	return nil
 }
 
 // Close implements component.Component.
-func (u *UART) Close() error {
+func (u *UART) Close(ctx context.Context) error {
+	u.sub.Close()
+	if u.port == nil {
+		return nil
+	}
 	return u.port.Close()
 }
 
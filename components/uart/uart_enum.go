@@ -7,8 +7,8 @@
 package uart
 
 import (
-	"errors"
 	"fmt"
+	"strings"
 )
 
 const (
@@ -29,10 +29,25 @@ const (
 	ParitySpace
 )
 
-var ErrInvalidparity = errors.New("not a valid parity")
+var ErrInvalidparity = fmt.Errorf("not a valid parity, try [%s]", strings.Join(_parityNames, ", "))
 
 const _parityName = "nooddevenmarkspace"
 
+var _parityNames = []string{
+	_parityName[0:2],
+	_parityName[2:5],
+	_parityName[5:9],
+	_parityName[9:13],
+	_parityName[13:18],
+}
+
+// parityNames returns a list of possible string values of parity.
+func parityNames() []string {
+	tmp := make([]string, len(_parityNames))
+	copy(tmp, _parityNames)
+	return tmp
+}
+
 var _parityMap = map[parity]string{
 	ParityNo:    _parityName[0:2],
 	ParityOdd:   _parityName[2:5],
@@ -57,11 +72,16 @@ func (x parity) IsValid() bool {
 }
 
 var _parityValue = map[string]parity{
-	_parityName[0:2]:   ParityNo,
-	_parityName[2:5]:   ParityOdd,
-	_parityName[5:9]:   ParityEven,
-	_parityName[9:13]:  ParityMark,
-	_parityName[13:18]: ParitySpace,
+	_parityName[0:2]:                    ParityNo,
+	strings.ToLower(_parityName[0:2]):   ParityNo,
+	_parityName[2:5]:                    ParityOdd,
+	strings.ToLower(_parityName[2:5]):   ParityOdd,
+	_parityName[5:9]:                    ParityEven,
+	strings.ToLower(_parityName[5:9]):   ParityEven,
+	_parityName[9:13]:                   ParityMark,
+	strings.ToLower(_parityName[9:13]):  ParityMark,
+	_parityName[13:18]:                  ParitySpace,
+	strings.ToLower(_parityName[13:18]): ParitySpace,
 }
 
 // Parseparity attempts to convert a string to a parity.
@@ -69,9 +89,29 @@ func Parseparity(name string) (parity, error) {
 	if x, ok := _parityValue[name]; ok {
 		return x, nil
 	}
+	// Case insensitive parse, do a separate lookup to prevent unnecessary cost of lowercasing a string if we don't need to.
+	if x, ok := _parityValue[strings.ToLower(name)]; ok {
+		return x, nil
+	}
 	return parity(0), fmt.Errorf("%s is %w", name, ErrInvalidparity)
 }
 
+// MarshalText implements the text marshaller method.
+func (x parity) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *parity) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := Parseparity(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
+
 const (
 	// StopbitsOne is a stopbits of type One.
 	// sets 1 stop bit (default)
@@ -84,10 +124,23 @@ const (
 	StopbitsTwo
 )
 
-var ErrInvalidstopbits = errors.New("not a valid stopbits")
+var ErrInvalidstopbits = fmt.Errorf("not a valid stopbits, try [%s]", strings.Join(_stopbitsNames, ", "))
 
 const _stopbitsName = "oneone_point_fivetwo"
 
+var _stopbitsNames = []string{
+	_stopbitsName[0:3],
+	_stopbitsName[3:17],
+	_stopbitsName[17:20],
+}
+
+// stopbitsNames returns a list of possible string values of stopbits.
+func stopbitsNames() []string {
+	tmp := make([]string, len(_stopbitsNames))
+	copy(tmp, _stopbitsNames)
+	return tmp
+}
+
 var _stopbitsMap = map[stopbits]string{
 	StopbitsOne:          _stopbitsName[0:3],
 	StopbitsOnePointFive: _stopbitsName[3:17],
@@ -110,9 +163,12 @@ func (x stopbits) IsValid() bool {
 }
 
 var _stopbitsValue = map[string]stopbits{
-	_stopbitsName[0:3]:   StopbitsOne,
-	_stopbitsName[3:17]:  StopbitsOnePointFive,
-	_stopbitsName[17:20]: StopbitsTwo,
+	_stopbitsName[0:3]:                    StopbitsOne,
+	strings.ToLower(_stopbitsName[0:3]):   StopbitsOne,
+	_stopbitsName[3:17]:                   StopbitsOnePointFive,
+	strings.ToLower(_stopbitsName[3:17]):  StopbitsOnePointFive,
+	_stopbitsName[17:20]:                  StopbitsTwo,
+	strings.ToLower(_stopbitsName[17:20]): StopbitsTwo,
 }
 
 // Parsestopbits attempts to convert a string to a stopbits.
@@ -120,5 +176,25 @@ func Parsestopbits(name string) (stopbits, error) {
 	if x, ok := _stopbitsValue[name]; ok {
 		return x, nil
 	}
+	// Case insensitive parse, do a separate lookup to prevent unnecessary cost of lowercasing a string if we don't need to.
+	if x, ok := _stopbitsValue[strings.ToLower(name)]; ok {
+		return x, nil
+	}
 	return stopbits(0), fmt.Errorf("%s is %w", name, ErrInvalidstopbits)
 }
+
+// MarshalText implements the text marshaller method.
+func (x stopbits) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *stopbits) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := Parsestopbits(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
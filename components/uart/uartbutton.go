@@ -14,8 +14,8 @@ import (
 type ButtonConfig struct {
 	button.BaseButtonConfig[Button, *Button] `yaml:",inline"`
 
-	UARTID string    `yaml:"uart_id"`
-	Data   *cv.Bytes `yaml:"data"`
+	UARTID cid.Ref[UART] `yaml:"uart_id"`
+	Data   *cv.Bytes     `yaml:"data"`
 }
 
 func NewButtonConfig() *ButtonConfig {
@@ -27,7 +27,7 @@ func (c *ButtonConfig) ValidateWithContext(ctx context.Context) error {
 	return cv.ValidateEmbedded(
 		c.BaseButtonConfig.ValidateWithContext(ctx),
 		validation.ValidateStructWithContext(
-			ctx, c, validation.Field(&c.UARTID, cv.String(cv.Optional(cv.Name()))),
+			ctx, c, validation.Field(&c.UARTID),
 		),
 	)
 }
@@ -42,8 +42,10 @@ type Button struct {
 }
 
 func NewButton(ctx context.Context, cfg *ButtonConfig) (retc []component.Component, err error) {
-	uid := cfg.UARTID
-	if uid != "" {
+	// the config resolved uart_id, it is empty when the button is made
+	// without it
+	uid := cfg.UARTID.ID
+	if uid == "" {
 		uid = COMPONENT_KEY
 	}
 	ret := &Button{
@@ -59,20 +61,25 @@ func NewButton(ctx context.Context, cfg *ButtonConfig) (retc []component.Compone
 }
 
 // Setup implements component.Component.
-func (b *Button) Setup() {
+func (b *Button) Setup(ctx context.Context) error {
+	return nil
+}
 
+// DependsOn implements component.Dependent.
+func (b *Button) DependsOn() []string {
+	return []string{b.uartID.ID()}
 }
 
 func (b *Button) Press(ctx context.Context) error {
-	b.b.CallService(&UARTWrite{
+	_, err := bus.Call[*UARTWrite, any](ctx, b.b, &UARTWrite{
 		Key:  b.uartID.HashID(),
 		Data: b.data,
 	})
-	return nil
+	return err
 }
 
 // Close implements component.Component.
-func (b *Button) Close() error {
+func (b *Button) Close(ctx context.Context) error {
 
 	return nil
 }
@@ -83,3 +90,4 @@ func (c *Button) InitializationPriority() component.InitializationPriority {
 }
 
 var _ component.Component = (*Button)(nil)
+var _ component.Dependent = (*Button)(nil)
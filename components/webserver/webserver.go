@@ -15,10 +15,10 @@ import (
 )
 
 type Config struct {
-	cid.IDConfig
-	component.ConfigOf[WebServer, *WebServer]
-	Address string `yaml:"address"`
-	Port    uint16 `yaml:"port"`
+	cid.IDConfig[WebServer]                   `yaml:",inline"`
+	component.ConfigOf[WebServer, *WebServer] `yaml:"-"`
+	Address                                   string `yaml:"address"`
+	Port                                      uint16 `yaml:"port"`
 }
 
 func NewConfig() *Config {
@@ -58,7 +58,7 @@ func New(ctx context.Context, cfg *Config) ([]component.Component, error) {
 }
 
 // Setup implements component.Component.
-func (ws *WebServer) Setup() {
+func (ws *WebServer) Setup(ctx context.Context) error {
 	mux := http.NewServeMux()
 	mux.Handle("GET /", http.HandlerFunc(ws.home))
 	ws.server = &http.Server{
@@ -68,15 +68,20 @@ func (ws *WebServer) Setup() {
 			return ws.ctx
 		},
 	}
+	l, err := net.Listen("tcp", ws.server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to start webserver: %w", err)
+	}
 
 	go func() {
-		err := ws.server.ListenAndServe()
+		err := ws.server.Serve(l)
 		slog.Error("webserver closed", "err", err)
 	}()
 
 	node := core.GetNode(ws.ctx)
 	node.Bus.HandleEvents(bus.EventHandler[bus.StateChangeEvent](func(e *bus.StateChangeEvent) {
 	}))
+	return nil
 }
 
 func (ws *WebServer) home(w http.ResponseWriter, r *http.Request) {
@@ -90,7 +95,7 @@ func (ws *WebServer) home(w http.ResponseWriter, r *http.Request) {
 }
 
 // Close implements component.Component.
-func (ws *WebServer) Close() error {
+func (ws *WebServer) Close(ctx context.Context) error {
 	if ws.server != nil {
 		ws.server.Close()
 	}
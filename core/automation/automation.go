@@ -0,0 +1,303 @@
+package automation
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/lambda"
+)
+
+// Action is a step of an automation, e.g. delay: or switch.turn_on:.
+type Action interface {
+	cv.Validatable
+	Run(ctx context.Context) error
+}
+
+// Condition is checked by the actions like if: and wait_until:, e.g.
+// binary_sensor.is_on:.
+type Condition interface {
+	cv.Validatable
+	Check(ctx context.Context) (bool, error)
+}
+
+// Shorthand is implemented by the actions and conditions written with a
+// single value, e.g. delay: 1s. The value is decoded into the field
+// Shorthand returns.
+type Shorthand interface {
+	Shorthand() any
+}
+
+// Scope is implemented by the actions passing args to their actions, e.g.
+// iteration of repeat:. The lambdas of the action have the vars.
+type Scope interface {
+	Vars() lambda.Vars
+}
+
+// Registry looks the actions and conditions of a config up by name, it is
+// the cv.ComponentRegistryKey value of the context of the config.
+type Registry interface {
+	Action(name string) (func() Action, bool)
+	Condition(name string) (func() Condition, bool)
+}
+
+// Item is an action or condition of the config with the name it has there.
+type Item[T cv.Validatable] struct {
+	Name  string
+	Value T
+}
+
+// Actions are run one after the other, the first failing one stops them.
+// In the config they are a list of actions, a single action or then: with
+// the list.
+type Actions []Item[Action]
+
+// Run runs the actions until one fails or ctx is done.
+func (a Actions) Run(ctx context.Context) error {
+	for _, it := range a {
+		if err := context.Cause(ctx); err != nil {
+			return err
+		}
+		if err := it.Value.Run(ctx); err != nil {
+			return fmt.Errorf("%s: %w", it.Name, err)
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML implements yaml.NodeUnmarshalerContext.
+func (a *Actions) UnmarshalYAML(ctx context.Context, node ast.Node) (err error) {
+	reg, err := registry(ctx, node)
+	if err != nil {
+		return err
+	}
+	*a, err = decodeItems(ctx, node, "action", reg.Action)
+	return err
+}
+
+// MarshalYAML implements yaml.InterfaceMarshalerContext.
+func (a Actions) MarshalYAML(ctx context.Context) (interface{}, error) {
+	return marshalItems(a), nil
+}
+
+// JSONSchema implements cv.JSONSchemer, the actions are in the definitions
+// of the schema of the config as they nest.
+func (a *Actions) JSONSchema(ctx context.Context, schema func(v any) map[string]any) map[string]any {
+	return map[string]any{"$ref": "#/definitions/actions"}
+}
+
+// Trigger are the actions of a trigger passing its value as the arg x of
+// type T, e.g. on_value: of a sensor. Their lambdas have x.
+type Trigger[T any] struct {
+	Actions
+}
+
+// UnmarshalYAML implements yaml.NodeUnmarshalerContext.
+func (t *Trigger[T]) UnmarshalYAML(ctx context.Context, node ast.Node) error {
+	return t.Actions.UnmarshalYAML(lambda.WithVars(ctx, lambda.Vars{"x": lambda.TypeFor[T]()}), node)
+}
+
+var _ yaml.NodeUnmarshalerContext = (*Trigger[bool])(nil)
+var _ cv.JSONSchemer = (*Trigger[bool])(nil)
+
+// Conditions are true when all of them are. In the config they are a list
+// of conditions or a single one.
+type Conditions []Item[Condition]
+
+// Check checks the conditions until one is false or fails.
+func (c Conditions) Check(ctx context.Context) (bool, error) {
+	for _, it := range c {
+		ok, err := it.Value.Check(ctx)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", it.Name, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// UnmarshalYAML implements yaml.NodeUnmarshalerContext.
+func (c *Conditions) UnmarshalYAML(ctx context.Context, node ast.Node) (err error) {
+	reg, err := registry(ctx, node)
+	if err != nil {
+		return err
+	}
+	*c, err = decodeItems(ctx, node, "condition", reg.Condition)
+	return err
+}
+
+// MarshalYAML implements yaml.InterfaceMarshalerContext.
+func (c Conditions) MarshalYAML(ctx context.Context) (interface{}, error) {
+	return marshalItems(c), nil
+}
+
+// JSONSchema implements cv.JSONSchemer.
+func (c *Conditions) JSONSchema(ctx context.Context, schema func(v any) map[string]any) map[string]any {
+	return map[string]any{"$ref": "#/definitions/conditions"}
+}
+
+var _ yaml.NodeUnmarshalerContext = (*Actions)(nil)
+var _ yaml.InterfaceMarshalerContext = (Actions)(nil)
+var _ cv.JSONSchemer = (*Actions)(nil)
+var _ yaml.NodeUnmarshalerContext = (*Conditions)(nil)
+var _ yaml.InterfaceMarshalerContext = (Conditions)(nil)
+var _ cv.JSONSchemer = (*Conditions)(nil)
+
+func registry(ctx context.Context, node ast.Node) (Registry, error) {
+	reg, ok := ctx.Value(cv.ComponentRegistryKey{}).(Registry)
+	if !ok {
+		return nil, &yaml.SyntaxError{Token: node.GetToken(), Message: "automations need the registry of the config"}
+	}
+	return reg, nil
+}
+
+// decodeItems decodes the list of single key mappings in node, a single
+// mapping or then: with the list.
+func decodeItems[T cv.Validatable](ctx context.Context, node ast.Node, kind string, lookup func(string) (func() T, bool)) ([]Item[T], error) {
+	var values []*ast.MappingValueNode
+	switch n := node.(type) {
+	case *ast.NullNode:
+		return nil, nil
+	case *ast.SequenceNode:
+		for _, v := range n.Values {
+			mv, err := singleKey(v, kind)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, mv)
+		}
+	case *ast.MappingValueNode:
+		values = append(values, n)
+	case *ast.MappingNode:
+		if len(n.Values) != 1 {
+			return nil, &yaml.SyntaxError{Token: n.GetToken(), Message: fmt.Sprintf("an %s is a single key mapping, a list of them is needed for more", kind)}
+		}
+		values = append(values, n.Values[0])
+	case *ast.AnchorNode:
+		return decodeItems(ctx, n.Value, kind, lookup)
+	case *ast.TagNode:
+		return decodeItems(ctx, n.Value, kind, lookup)
+	default:
+		return nil, &yaml.SyntaxError{Token: node.GetToken(), Message: fmt.Sprintf("expected a list of %ss", kind)}
+	}
+	if len(values) == 1 && values[0].Key.GetToken().Value == "then" {
+		return decodeItems(ctx, values[0].Value, kind, lookup)
+	}
+	dec := ctx.Value(cv.ConfigYAMLDecoderKey{}).(*yaml.Decoder)
+	ret := make([]Item[T], 0, len(values))
+	for _, mv := range values {
+		name := mv.Key.GetToken().Value
+		factory, ok := lookup(name)
+		if !ok {
+			return nil, &yaml.SyntaxError{Token: mv.Key.GetToken(), Message: fmt.Sprintf("unknown %s %s", kind, name)}
+		}
+		v := factory()
+		ictx := ctx
+		if s, ok := any(v).(Scope); ok {
+			ictx = lambda.WithVars(ctx, s.Vars())
+		}
+		if err := decodeItem(ictx, dec, mv.Value, v); err != nil {
+			return nil, err
+		}
+		ret = append(ret, Item[T]{Name: name, Value: v})
+	}
+	return ret, nil
+}
+
+// decodeItem decodes node into v, into the shorthand of v when node is not
+// a mapping of its fields.
+func decodeItem(ctx context.Context, dec *yaml.Decoder, node ast.Node, v cv.Validatable) error {
+	s, ok := v.(Shorthand)
+	if !ok || hasField(v, node) {
+		return dec.DecodeFromNodeContext(ctx, node, v)
+	}
+	if _, null := node.(*ast.NullNode); null {
+		return dec.DecodeFromNodeContext(ctx, node, v)
+	}
+	if err := dec.DecodeFromNodeContext(ctx, node, s.Shorthand()); err != nil {
+		return err
+	}
+	if err := v.ValidateWithContext(ctx); err != nil {
+		return &yaml.SyntaxError{Token: node.GetToken(), Message: err.Error()}
+	}
+	return nil
+}
+
+// hasField tells whether node is a mapping with a key that is a field of the
+// struct v points to.
+func hasField(v any, node ast.Node) bool {
+	var keys []*ast.MappingValueNode
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		keys = n.Values
+	case *ast.MappingValueNode:
+		keys = []*ast.MappingValueNode{n}
+	default:
+		return false
+	}
+	t := reflect.TypeOf(v).Elem()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		for _, k := range keys {
+			if name != "" && k.Key.GetToken().Value == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func singleKey(node ast.Node, kind string) (*ast.MappingValueNode, error) {
+	switch n := node.(type) {
+	case *ast.MappingValueNode:
+		return n, nil
+	case *ast.MappingNode:
+		if len(n.Values) == 1 {
+			return n.Values[0], nil
+		}
+	case *ast.AnchorNode:
+		return singleKey(n.Value, kind)
+	}
+	return nil, &yaml.SyntaxError{Token: node.GetToken(), Message: fmt.Sprintf("an %s is a single key mapping, e.g. delay: 1s", kind)}
+}
+
+func marshalItems[T cv.Validatable](items []Item[T]) []yaml.MapSlice {
+	ret := make([]yaml.MapSlice, 0, len(items))
+	for _, it := range items {
+		ret = append(ret, yaml.MapSlice{{Key: it.Name, Value: it.Value}})
+	}
+	return ret
+}
+
+type argsCtxKey struct{}
+
+// Args are the values of the trigger of a running automation, e.g. x of
+// on_value:.
+type Args map[string]any
+
+// WithArgs adds args to the args of the automation running with ctx.
+func WithArgs(ctx context.Context, args Args) context.Context {
+	if len(args) == 0 {
+		return ctx
+	}
+	merged := Args{}
+	for k, v := range GetArgs(ctx) {
+		merged[k] = v
+	}
+	for k, v := range args {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, argsCtxKey{}, merged)
+}
+
+// GetArgs returns the args of the automation running with ctx.
+func GetArgs(ctx context.Context) Args {
+	args, _ := ctx.Value(argsCtxKey{}).(Args)
+	return args
+}
@@ -0,0 +1,246 @@
+package automation_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/bus"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/registry"
+	"github.com/matryer/is"
+)
+
+// record appends the args it runs with to its log.
+type record struct {
+	Name string `yaml:"name"`
+	log  *[]string
+	mx   *sync.Mutex
+}
+
+func (r *record) Shorthand() any { return &r.Name }
+
+func (r *record) ValidateWithContext(ctx context.Context) error { return nil }
+
+func (r *record) Run(ctx context.Context) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	entry := r.Name
+	if i, ok := automation.GetArgs(ctx)["iteration"]; ok {
+		entry += string(rune('0' + i.(int)))
+	}
+	*r.log = append(*r.log, entry)
+	return nil
+}
+
+// flag is true while its value is.
+type flag struct {
+	Value bool `yaml:"value"`
+	set   *atomic.Bool
+}
+
+func (f *flag) Shorthand() any { return &f.Value }
+
+func (f *flag) ValidateWithContext(ctx context.Context) error { return nil }
+
+func (f *flag) Check(ctx context.Context) (bool, error) {
+	if f.set != nil {
+		return f.set.Load(), nil
+	}
+	return f.Value, nil
+}
+
+type testConfig struct {
+	Actions automation.Actions `yaml:"actions"`
+}
+
+func (c *testConfig) ValidateWithContext(ctx context.Context) error { return nil }
+
+type recorder struct {
+	mx  sync.Mutex
+	log []string
+	set atomic.Bool
+	reg *registry.Registry
+}
+
+func newRecorder(t *testing.T) *recorder {
+	r := &recorder{reg: registry.NewRegistry()}
+	is := is.New(t)
+	is.NoErr(r.reg.RegisterAction("test.record", func() automation.Action {
+		return &record{log: &r.log, mx: &r.mx}
+	}))
+	is.NoErr(r.reg.RegisterCondition("test.flag", func() automation.Condition { return &flag{} }))
+	is.NoErr(r.reg.RegisterCondition("test.set", func() automation.Condition { return &flag{set: &r.set} }))
+	return r
+}
+
+func (r *recorder) decode(src string) (automation.Actions, error) {
+	valid := &cv.Validator{}
+	dec := yaml.NewDecoder(strings.NewReader(src), yaml.Validator(valid))
+	ctx := context.WithValue(context.Background(), cv.ConfigYAMLDecoderKey{}, dec)
+	ctx = context.WithValue(ctx, cv.ComponentRegistryKey{}, r.reg)
+	valid.Context = ctx
+	c := &testConfig{}
+	err := dec.DecodeContext(ctx, c)
+	return c.Actions, err
+}
+
+func (r *recorder) entries() []string {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return append([]string{}, r.log...)
+}
+
+func TestActions(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"list", "actions:\n  - test.record: a\n  - test.record: {name: b}\n", []string{"a", "b"}},
+		{"single", "actions:\n  test.record: a\n", []string{"a"}},
+		{"then", "actions:\n  then:\n    - test.record: a\n", []string{"a"}},
+		{"delay", "actions:\n  - delay: 1ms\n  - delay: {duration: 1ms}\n  - test.record: a\n", []string{"a"}},
+		{"if", `
+actions:
+  - if:
+      condition:
+        - test.flag: true
+        - not:
+            test.flag: false
+      then:
+        - test.record: then
+      else:
+        - test.record: else
+  - if:
+      condition:
+        or: [test.flag: false, {and: [test.flag: false]}]
+      then: [test.record: then]
+      else: [test.record: else]
+`, []string{"then", "else"}},
+		{"repeat", "actions:\n  - repeat:\n      count: 3\n      then: [test.record: i]\n", []string{"i0", "i1", "i2"}},
+		{"while", "actions:\n  - while:\n      condition: {test.flag: false}\n      then: [test.record: never]\n  - test.record: done\n", []string{"done"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			r := newRecorder(t)
+			a, err := r.decode(tc.src)
+			is.NoErr(err)
+			is.NoErr(a.Run(context.Background()))
+			is.Equal(r.entries(), tc.want)
+		})
+	}
+}
+
+func TestActionErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		msg  string
+	}{
+		{"unknown", "actions:\n  - test.nope: a\n", "unknown action test.nope"},
+		{"unknown condition", "actions:\n  - wait_until: {test.nope: a}\n", "unknown condition test.nope"},
+		{"two keys", "actions:\n  - test.record: a\n    delay: 1s\n", "single key mapping"},
+		{"invalid", "actions:\n  - repeat: {count: 0, then: [test.record: a]}\n", "count"},
+		{"invalid shorthand", "actions:\n  - delay: -1s\n", "no less than 0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			_, err := newRecorder(t).decode(tc.src)
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tc.msg)) // the error tells what is wrong
+		})
+	}
+}
+
+func TestRunnerStop(t *testing.T) {
+	is := is.New(t)
+	r := newRecorder(t)
+	a, err := r.decode("actions:\n  - delay: 1h\n  - test.record: late\n")
+	is.NoErr(err)
+	runner := automation.NewRunner(context.Background())
+	runner.Run("test", a, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	is.NoErr(runner.Stop(ctx)) // the delay is cancelled
+	runner.Run("test", a, nil)
+	is.NoErr(runner.RunWait(ctx, "test", a, nil)) // nothing runs once stopped
+	is.Equal(r.entries(), []string{})
+}
+
+func TestRunWait(t *testing.T) {
+	is := is.New(t)
+	r := newRecorder(t)
+	a, err := r.decode("actions:\n  - test.record: a\n  - delay: 1h\n")
+	is.NoErr(err)
+	runner := automation.NewRunner(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = runner.RunWait(ctx, "test", a, nil)
+	is.True(errors.Is(err, context.DeadlineExceeded)) // it runs until ctx is done
+	is.Equal(r.entries(), []string{"a"})
+}
+
+func TestWaitUntil(t *testing.T) {
+	is := is.New(t)
+	r := newRecorder(t)
+	a, err := r.decode("actions:\n  - wait_until: {test.set: true}\n  - test.record: a\n")
+	is.NoErr(err)
+	b := bus.New()
+	done := make(chan error)
+	go func() { done <- a.Run(bus.Context(context.Background(), b)) }()
+
+	r.set.Store(true)
+	// the condition is checked again on state changes
+	bus.MakeEventEmitter[bus.StateChangeEvent](b).Emit(&bus.StateChangeEvent{})
+	select {
+	case err := <-done:
+		is.NoErr(err)
+	case <-time.After(time.Second):
+		t.Fatal("wait_until did not notice the change")
+	}
+	is.Equal(r.entries(), []string{"a"})
+
+	a, err = r.decode("actions:\n  - wait_until: {condition: {test.flag: false}, timeout: 1ms}\n")
+	is.NoErr(err)
+	is.True(errors.Is(a.Run(bus.Context(context.Background(), b)), automation.ErrTimeout))
+}
+
+type testEntity struct{}
+
+func (testEntity) HashID() uint32 { return 42 }
+
+func (testEntity) Domain() entity.DomainType { return entity.DomainTypeSwitch }
+
+func TestOnState(t *testing.T) {
+	is := is.New(t)
+	b := bus.New()
+	fired := make(chan [2]bool, 10)
+	sub := automation.OnState(bus.Context(context.Background(), b), testEntity{}, func(old, nv bool) {
+		fired <- [2]bool{old, nv}
+	})
+	defer sub.Close()
+
+	em := bus.MakeEventEmitter[bus.StateChangeEvent](b)
+	emit := func(old, nv, changed, available bool) {
+		em.Emit(&bus.StateChangeEvent{Key: 42, Domain: "switch", OldState: &old, NewState: &nv, Changed: changed, Available: available})
+	}
+	emit(false, false, true, false) // became unavailable
+	emit(false, false, true, true)  // available again
+	emit(false, true, true, true)
+	emit(true, true, false, true) // force_update
+	is.Equal(<-fired, [2]bool{false, true})
+	is.Equal(<-fired, [2]bool{true, true})
+	select {
+	case f := <-fired:
+		t.Fatalf("unexpected trigger %v", f)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
@@ -0,0 +1,378 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core/bus"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/lambda"
+)
+
+// BuiltinActions returns the actions every config has, by name.
+func BuiltinActions() map[string]func() Action {
+	return map[string]func() Action{
+		"delay":      func() Action { return &Delay{} },
+		"if":         func() Action { return &If{} },
+		"while":      func() Action { return &While{} },
+		"repeat":     func() Action { return &Repeat{} },
+		"wait_until": func() Action { return &WaitUntil{} },
+		"logger.log": func() Action { return &Log{Level: "DEBUG"} },
+	}
+}
+
+// BuiltinConditions returns the conditions every config has, by name.
+func BuiltinConditions() map[string]func() Condition {
+	return map[string]func() Condition{
+		"and":    func() Condition { return &And{} },
+		"or":     func() Condition { return &Or{} },
+		"not":    func() Condition { return &Not{} },
+		"lambda": func() Condition { return &Lambda{} },
+	}
+}
+
+// Delay waits for its duration, e.g. delay: 1s.
+type Delay struct {
+	Duration time.Duration `yaml:"duration"`
+}
+
+// Shorthand implements Shorthand.
+func (d *Delay) Shorthand() any {
+	return &d.Duration
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (d *Delay) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, d,
+		validation.Field(&d.Duration, validation.Min(time.Duration(0))),
+	)
+}
+
+// Run implements Action.
+func (d *Delay) Run(ctx context.Context) error {
+	t := time.NewTimer(d.Duration)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// If runs then when its condition is true, else otherwise.
+type If struct {
+	Condition Conditions `yaml:"condition"`
+	Then      Actions    `yaml:"then"`
+	Else      Actions    `yaml:"else"`
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (i *If) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, i,
+		validation.Field(&i.Condition, validation.Required),
+	)
+}
+
+// Run implements Action.
+func (i *If) Run(ctx context.Context) error {
+	ok, err := i.Condition.Check(ctx)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return i.Then.Run(ctx)
+	}
+	return i.Else.Run(ctx)
+}
+
+// While runs then as long as its condition is true.
+type While struct {
+	Condition Conditions `yaml:"condition"`
+	Then      Actions    `yaml:"then"`
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (w *While) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, w,
+		validation.Field(&w.Condition, validation.Required),
+	)
+}
+
+// Run implements Action.
+func (w *While) Run(ctx context.Context) error {
+	for {
+		if err := context.Cause(ctx); err != nil {
+			return err
+		}
+		ok, err := w.Condition.Check(ctx)
+		if err != nil || !ok {
+			return err
+		}
+		if err := w.Then.Run(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// Repeat runs then count times, the iteration arg counts from 0.
+type Repeat struct {
+	Count int     `yaml:"count"`
+	Then  Actions `yaml:"then"`
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (r *Repeat) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, r,
+		validation.Field(&r.Count, validation.Required, validation.Min(1)),
+	)
+}
+
+// Vars implements Scope.
+func (r *Repeat) Vars() lambda.Vars {
+	return lambda.Vars{"iteration": lambda.Number}
+}
+
+// Run implements Action.
+func (r *Repeat) Run(ctx context.Context) error {
+	for i := range r.Count {
+		if err := r.Then.Run(WithArgs(ctx, Args{"iteration": i})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrTimeout is returned by wait_until when its timeout passes first.
+var ErrTimeout = errors.New("timed out")
+
+// WaitUntil waits until its condition is true, it is checked again on every
+// state change. The automation stops with ErrTimeout when the timeout
+// passes first.
+type WaitUntil struct {
+	Condition Conditions    `yaml:"condition"`
+	Timeout   time.Duration `yaml:"timeout"`
+}
+
+// Shorthand implements Shorthand.
+func (w *WaitUntil) Shorthand() any {
+	return &w.Condition
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (w *WaitUntil) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, w,
+		validation.Field(&w.Condition, validation.Required),
+		validation.Field(&w.Timeout, validation.Min(time.Duration(0))),
+	)
+}
+
+// Run implements Action.
+func (w *WaitUntil) Run(ctx context.Context) error {
+	changed := make(chan struct{}, 1)
+	if b := bus.Get(ctx); b != nil {
+		sub := b.HandleEvents(bus.EventHandler(func(*bus.StateChangeEvent) {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		}))
+		defer sub.Close()
+	}
+	var timeout <-chan time.Time
+	if w.Timeout > 0 {
+		t := time.NewTimer(w.Timeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	for {
+		ok, err := w.Condition.Check(ctx)
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			return ErrTimeout
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+}
+
+// Log logs its message, e.g. logger.log: "The button was pressed". With
+// args the message is formatted like printf, e.g. format: "%.1f °C" with
+// args: [id(temperature).state].
+type Log struct {
+	Format string                `yaml:"format"`
+	Args   []*lambda.Lambda[any] `yaml:"args"`
+	Level  string                `yaml:"level"`
+	Tag    string                `yaml:"tag"`
+}
+
+// logLevels are the ESPHome log levels as slog levels.
+var logLevels = map[string]slog.Level{
+	"ERROR":        slog.LevelError,
+	"WARN":         slog.LevelWarn,
+	"INFO":         slog.LevelInfo,
+	"DEBUG":        slog.LevelDebug,
+	"VERBOSE":      slog.LevelDebug - 4,
+	"VERY_VERBOSE": slog.LevelDebug - 8,
+}
+
+// Shorthand implements Shorthand.
+func (l *Log) Shorthand() any {
+	return &l.Format
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (l *Log) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, l,
+		validation.Field(&l.Format, validation.Required),
+		validation.Field(&l.Level, cv.String(cv.OneOf("ERROR", "WARN", "INFO", "DEBUG", "VERBOSE", "VERY_VERBOSE"))),
+	)
+}
+
+// Run implements Action.
+func (l *Log) Run(ctx context.Context) error {
+	attrs := []any{}
+	if l.Tag != "" {
+		attrs = append(attrs, "tag", l.Tag)
+	}
+	args := GetArgs(ctx)
+	for k, v := range args {
+		attrs = append(attrs, k, v)
+	}
+	msg := l.Format
+	if len(l.Args) > 0 {
+		values := make([]any, len(l.Args))
+		for i, a := range l.Args {
+			v, err := a.Eval(ctx, args)
+			if err != nil {
+				return fmt.Errorf("arg %d: %w", i+1, err)
+			}
+			values[i] = v
+		}
+		msg = lambda.Sprintf(l.Format, values...)
+	}
+	slog.Log(ctx, logLevels[l.Level], msg, attrs...)
+	return nil
+}
+
+// And is true when all its conditions are.
+type And struct {
+	Conditions Conditions `yaml:"conditions"`
+}
+
+// Shorthand implements Shorthand.
+func (a *And) Shorthand() any {
+	return &a.Conditions
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (a *And) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, a,
+		validation.Field(&a.Conditions, validation.Required),
+	)
+}
+
+// Check implements Condition.
+func (a *And) Check(ctx context.Context) (bool, error) {
+	return a.Conditions.Check(ctx)
+}
+
+// Or is true when any of its conditions is.
+type Or struct {
+	Conditions Conditions `yaml:"conditions"`
+}
+
+// Shorthand implements Shorthand.
+func (o *Or) Shorthand() any {
+	return &o.Conditions
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (o *Or) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, o,
+		validation.Field(&o.Conditions, validation.Required),
+	)
+}
+
+// Check implements Condition.
+func (o *Or) Check(ctx context.Context) (bool, error) {
+	for _, it := range o.Conditions {
+		ok, err := it.Value.Check(ctx)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", it.Name, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Not is true when its conditions are not all true.
+type Not struct {
+	Conditions Conditions `yaml:"conditions"`
+}
+
+// Shorthand implements Shorthand.
+func (n *Not) Shorthand() any {
+	return &n.Conditions
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (n *Not) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, n,
+		validation.Field(&n.Conditions, validation.Required),
+	)
+}
+
+// Check implements Condition.
+func (n *Not) Check(ctx context.Context) (bool, error) {
+	ok, err := n.Conditions.Check(ctx)
+	return !ok, err
+}
+
+// Lambda is true when its lambda is, e.g.
+// lambda: return id(temperature).state > 20;.
+type Lambda struct {
+	Lambda *lambda.Lambda[bool] `yaml:"lambda"`
+}
+
+// Shorthand implements Shorthand.
+func (l *Lambda) Shorthand() any {
+	if l.Lambda == nil {
+		l.Lambda = &lambda.Lambda[bool]{}
+	}
+	return l.Lambda
+}
+
+// ValidateWithContext implements cv.Validatable.
+func (l *Lambda) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, l,
+		validation.Field(&l.Lambda, validation.NotNil),
+	)
+}
+
+// Check implements Condition.
+func (l *Lambda) Check(ctx context.Context) (bool, error) {
+	return l.Lambda.Eval(ctx, GetArgs(ctx))
+}
+
+var _ Action = (*Delay)(nil)
+var _ Action = (*If)(nil)
+var _ Action = (*While)(nil)
+var _ Action = (*Repeat)(nil)
+var _ Action = (*WaitUntil)(nil)
+var _ Action = (*Log)(nil)
+var _ Condition = (*And)(nil)
+var _ Condition = (*Or)(nil)
+var _ Condition = (*Not)(nil)
+var _ Condition = (*Lambda)(nil)
+var _ Scope = (*Repeat)(nil)
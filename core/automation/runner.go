@@ -0,0 +1,118 @@
+package automation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+// ErrStopped is the cause of the cancellation of the automations still
+// running when the node shuts down.
+var ErrStopped = errors.New("automations stopped")
+
+// Runner runs the automations of a node, each in its own goroutine, until
+// it is stopped.
+type Runner struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	mx      sync.Mutex
+	wg      sync.WaitGroup
+	stopped bool
+}
+
+type runnerCtxKey struct{}
+
+// NewRunner returns a runner running the automations with ctx, the context
+// of the node.
+func NewRunner(ctx context.Context) *Runner {
+	r := &Runner{}
+	r.ctx, r.cancel = context.WithCancelCause(ctx)
+	r.ctx = Context(r.ctx, r)
+	return r
+}
+
+// Context adds r to ctx.
+func Context(ctx context.Context, r *Runner) context.Context {
+	return context.WithValue(ctx, runnerCtxKey{}, r)
+}
+
+// GetRunner returns the runner of the node of ctx, nil without one.
+func GetRunner(ctx context.Context) *Runner {
+	r, _ := ctx.Value(runnerCtxKey{}).(*Runner)
+	return r
+}
+
+// start registers a run, it is false once r is stopped.
+func (r *Runner) start() bool {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if r.stopped {
+		return false
+	}
+	r.wg.Add(1)
+	return true
+}
+
+// Run runs the actions of the trigger with args in the background, the
+// failures are logged.
+func (r *Runner) Run(trigger string, a Actions, args Args) {
+	if r == nil || len(a) == 0 || !r.start() {
+		return
+	}
+	go func() {
+		defer r.wg.Done()
+		r.log(trigger, a.Run(WithArgs(r.ctx, args)))
+	}()
+}
+
+// RunWait runs the actions of the trigger with args and waits for them, at
+// most until ctx is done.
+func (r *Runner) RunWait(ctx context.Context, trigger string, a Actions, args Args) error {
+	if r == nil || len(a) == 0 || !r.start() {
+		return nil
+	}
+	defer r.wg.Done()
+	rctx, cancel := context.WithCancelCause(r.ctx)
+	defer cancel(nil)
+	stop := context.AfterFunc(ctx, func() { cancel(context.Cause(ctx)) })
+	defer stop()
+	err := a.Run(WithArgs(rctx, args))
+	r.log(trigger, err)
+	return err
+}
+
+func (r *Runner) log(trigger string, err error) {
+	if err == nil {
+		return
+	}
+	if errors.Is(err, ErrStopped) {
+		slog.Debug("Automation stopped", "trigger", trigger)
+		return
+	}
+	slog.Error("Automation failed", "trigger", trigger, "err", err)
+}
+
+// Stop cancels the running automations and waits for them to return, at
+// most until ctx is done. Nothing runs after Stop.
+func (r *Runner) Stop(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	r.mx.Lock()
+	r.stopped = true
+	r.mx.Unlock()
+	r.cancel(ErrStopped)
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
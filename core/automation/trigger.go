@@ -0,0 +1,52 @@
+package automation
+
+import (
+	"context"
+
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/entity"
+)
+
+// Subscriber is a component with triggers in the config, e.g. the on_press:
+// of a binary sensor.
+type Subscriber interface {
+	// SubscribeTriggers subscribes the triggers to the events firing them,
+	// the subscriptions are closed with the component.
+	SubscribeTriggers(ctx context.Context) []bus.EventSubsciption
+}
+
+// Subscribe subscribes the triggers of c when it is a Subscriber, onClose
+// gets the functions closing the subscriptions, e.g. the OnClose of the
+// domain of c.
+func Subscribe(ctx context.Context, c any, onClose func(func())) {
+	s, ok := c.(Subscriber)
+	if !ok {
+		return
+	}
+	for _, sub := range s.SubscribeTriggers(ctx) {
+		onClose(sub.Close)
+	}
+}
+
+// OnState calls f with the old and new state of the entity e when its value
+// changes or is updated with force_update. The changes of availability or
+// attributes alone keep the value and are not passed on, so the triggers
+// built on it do not fire when an entity becomes unavailable or available
+// again.
+func OnState[S comparable](ctx context.Context, e interface {
+	HashID() uint32
+	Domain() entity.DomainType
+}, f func(old, new S)) bus.EventSubsciption {
+	b := bus.Get(ctx)
+	return b.HandleEvents(bus.EventHandler(func(ev *bus.StateChangeEvent) {
+		old, ok := ev.OldState.(*S)
+		if !ok {
+			return
+		}
+		nv, ok := ev.NewState.(*S)
+		if !ok || (ev.Changed && *old == *nv) {
+			return
+		}
+		f(*old, *nv)
+	}).Filter(bus.ForKeys(e.HashID()), bus.ForDomain(e.Domain().String())))
+}
@@ -2,8 +2,13 @@ package bus
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"weak"
 
 	"github.com/majfault/signal"
@@ -11,13 +16,23 @@ import (
 	"github.com/oklog/ulid/v2"
 )
 
-type eventSignal = signal.Signal1[*Event]
+// eventSignal is the signal of an event type with the number of emitted
+// events.
+type eventSignal struct {
+	signal.Signal1[*Event]
+	emitted atomic.Uint64
+}
+
 type EventSubsciption struct {
 	sig  weak.Pointer[eventSignal]
 	slot weak.Pointer[signal.Slot1[*Event]]
+	q    *queue
 }
 
 func (b *EventSubsciption) Close() {
+	if b.q != nil {
+		b.q.close()
+	}
 	sig := b.sig.Value()
 	if sig == nil {
 		return
@@ -31,19 +46,84 @@ func (b *EventSubsciption) Close() {
 
 type serviceCallSignal = signal.Signal1[*serviceRequest]
 
+// serviceCalls is the signal of a service with the number of its handlers
+// and calls.
+type serviceCalls struct {
+	serviceCallSignal
+	handlers atomic.Int32
+	emitted  atomic.Uint64
+}
+
+type ServiceSubscription struct {
+	sig  weak.Pointer[serviceCalls]
+	slot weak.Pointer[signal.Slot1[*serviceRequest]]
+	q    *queue
+}
+
+func (b *ServiceSubscription) Close() {
+	if b.q != nil {
+		b.q.close()
+	}
+	sig := b.sig.Value()
+	if sig == nil {
+		return
+	}
+	slot := b.slot.Value()
+	if slot == nil {
+		return
+	}
+	b.slot = weak.Pointer[signal.Slot1[*serviceRequest]]{}
+	sig.Disconnect(slot)
+	sig.handlers.Add(-1)
+}
+
 type Bus struct {
 	mux      sync.RWMutex
 	events   map[string]*eventSignal
-	services map[string]*serviceCallSignal
+	services map[string]*serviceCalls
+	queue    QueueConfig
+	queues   []*queue
+	journal  *journal
 }
 
-func New() *Bus {
-	return &Bus{
-		events:   make(map[string]*eventSignal),
-		services: make(map[string]*serviceCallSignal),
+// Option configures a bus.
+type Option func(*Bus)
+
+// WithDefaultQueue configures the queues of the subscribers that do not
+// configure theirs. Service handlers always default to block since a
+// dropped call fails its caller.
+func WithDefaultQueue(opts ...QueueOption) Option {
+	return func(b *Bus) {
+		for _, opt := range opts {
+			opt(&b.queue)
+		}
 	}
 }
 
+// WithJournal keeps the last size events emitted on the bus, subscribers
+// can replay them with ReplayFrom.
+func WithJournal(size int) Option {
+	return func(b *Bus) {
+		b.journal = newJournal(size)
+	}
+}
+
+func New(opts ...Option) *Bus {
+	b := &Bus{
+		events: map[string]*eventSignal{
+			allEvents: {},
+		},
+		services: make(map[string]*serviceCalls),
+		queue: QueueConfig{
+			Size: DefaultQueueSize,
+		},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
 type busCtxKey struct{}
 
 func Context(ctx context.Context, b *Bus) context.Context {
@@ -74,12 +154,34 @@ func locked[R any](b *Bus, f func() R) R {
 	return f()
 }
 
+// allEvents is the key of the subscribers to every event.
+const allEvents = "event/*"
+
 func eventKey(e EventData) string {
 	return "event/" + e.EventType()
 }
 
 func serviceKey(e ServiceRequestData) string {
-	return "event/" + e.ServiceType()
+	return "service/" + e.ServiceType()
+}
+
+// newQueue starts the queue of a subscriber to key.
+func (b *Bus) newQueue(key string, name string, opts []QueueOption) *queue {
+	cfg := b.queue
+	cfg.Name = name
+	if strings.HasPrefix(key, "service/") {
+		cfg.Overflow = OverflowPolicyBlock
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	q := newQueue(key, cfg)
+	locked(b, func() any {
+		b.queues = slices.DeleteFunc(b.queues, (*queue).isClosed)
+		b.queues = append(b.queues, q)
+		return nil
+	})
+	return q
 }
 
 type Emitter[T any, PT interface {
@@ -93,7 +195,9 @@ type emitter[T any, PT interface {
 	*T
 	EventData
 }] struct {
-	sig weak.Pointer[eventSignal]
+	sig     weak.Pointer[eventSignal]
+	all     weak.Pointer[eventSignal]
+	journal *journal
 }
 
 func (e *emitter[T, PT]) Emit(data PT) {
@@ -103,10 +207,15 @@ func (e *emitter[T, PT]) Emit(data PT) {
 	}
 	event := &Event{
 		EventData: data,
-		ID:        ulid.Make(),
 	}
+	e.journal.add(event)
 	slog.Debug("Bus emitting", "event", event)
+	es.emitted.Add(1)
 	es.Emit(event)
+	if all := e.all.Value(); all != nil {
+		all.emitted.Add(1)
+		all.Emit(event)
+	}
 	slog.Debug("Bus emitted", "event", event)
 }
 
@@ -115,17 +224,23 @@ func MakeEventEmitter[T any, PT interface {
 	EventData
 }](b *Bus) Emitter[T, PT] {
 	ek := eventKey(PT(nil))
-	es := locked(b, func() *eventSignal {
-		es, ok := b.events[ek]
+	es := b.eventSignal(ek)
+	return &emitter[T, PT]{
+		sig:     weak.Make(es),
+		all:     weak.Make(b.eventSignal(allEvents)),
+		journal: b.journal,
+	}
+}
+
+func (b *Bus) eventSignal(key string) *eventSignal {
+	return locked(b, func() *eventSignal {
+		es, ok := b.events[key]
 		if !ok {
 			es = &eventSignal{}
-			b.events[ek] = es
+			b.events[key] = es
 		}
 		return es
 	})
-	return &emitter[T, PT]{
-		sig: weak.Make(es),
-	}
 }
 
 func (b *Bus) emitEvent(data EventData, source string) {
@@ -133,8 +248,47 @@ func (b *Bus) emitEvent(data EventData, source string) {
 }
 
 type eventHandler struct {
-	etype  string
-	handle func(*Event)
+	etype   string
+	name    string
+	handle  func(*Event)
+	filters []EventFilter
+	replay  *ulid.ULID
+}
+
+// Filter only passes the events every filter accepts to the handler. The
+// filters run in the emitting goroutine and must be fast.
+func (h eventHandler) Filter(filters ...EventFilter) eventHandler {
+	h.filters = append(slices.Clip(h.filters), filters...)
+	return h
+}
+
+// ReplayFrom first passes the journaled events emitted after the one with
+// the id since to the handler, the zero id replays the whole journal.
+func (h eventHandler) ReplayFrom(since ulid.ULID) eventHandler {
+	h.replay = &since
+	return h
+}
+
+func (h *eventHandler) accepts(e *Event) bool {
+	if h.etype != allEvents && eventKey(e.EventData) != h.etype {
+		return false
+	}
+	for _, f := range h.filters {
+		if !f(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// AnyEventHandler handles the events of every type, usually narrowed down
+// with Filter.
+func AnyEventHandler(f func(e *Event)) eventHandler {
+	return eventHandler{
+		etype:  allEvents,
+		name:   funcName(f),
+		handle: f,
+	}
 }
 
 func EventHandler[T any, PT interface {
@@ -143,6 +297,7 @@ func EventHandler[T any, PT interface {
 }](f func(t PT)) eventHandler {
 	return eventHandler{
 		etype: eventKey(PT(nil)),
+		name:  funcName(f),
 		handle: func(e *Event) {
 			slog.Debug("Bus handling", "event", e)
 			f(e.EventData.(PT))
@@ -150,45 +305,131 @@ func EventHandler[T any, PT interface {
 	}
 }
 
-func (b *Bus) HandleEvents(h eventHandler) EventSubsciption {
-	es := locked(b, func() *eventSignal {
-		es, ok := b.events[h.etype]
-		if !ok {
-			es = &eventSignal{}
-			b.events[h.etype] = es
+// HandleEvents calls h with the events of its type in the order they were
+// emitted. The events are queued for h as configured by opts.
+func (b *Bus) HandleEvents(h eventHandler, opts ...QueueOption) EventSubsciption {
+	es := b.eventSignal(h.etype)
+	q := b.newQueue(h.etype, h.name, opts)
+	// the live events the replay already passed are skipped
+	var last ulid.ULID
+	if h.replay != nil {
+		if b.journal == nil {
+			slog.Warn("Replaying events without a bus journal", "subscriber", q.cfg.Name)
+		} else {
+			// nothing is journaled until the handler is connected
+			b.journal.mx.Lock()
+			defer b.journal.mx.Unlock()
+			var events []*Event
+			var complete bool
+			events, last, complete = b.journal.since(*h.replay)
+			if !complete {
+				slog.Warn("Bus journal does not reach back to the replayed event", "subscriber", q.cfg.Name, "since", *h.replay)
+			}
+			calls := []queuedCall{}
+			for _, e := range events {
+				if h.accepts(e) {
+					calls = append(calls, queuedCall{run: func() { h.handle(e) }})
+				}
+			}
+			q.preload(calls)
 		}
-		return es
+	}
+	slot := es.Connect(dispatcher.Direct(), func(e *Event) {
+		if e.ID.Compare(last) <= 0 || !h.accepts(e) {
+			return
+		}
+		q.Dispatch(func() { h.handle(e) })
 	})
-	slot := es.Connect(&dispatcher.Queued{
-		BlockOnFull: true,
-	}, h.handle)
 	return EventSubsciption{
 		sig:  weak.Make(es),
 		slot: weak.Make(slot),
+		q:    q,
 	}
 }
 
-func (b *Bus) CallService(data ServiceRequestData) *ulid.ULID {
+func (b *Bus) service(data ServiceRequestData) *serviceCalls {
 	sk := serviceKey(data)
-	scID := ulid.Make()
+	return rlocked(b, func() *serviceCalls {
+		return b.services[sk]
+	})
+}
+
+// CallService calls the service data without waiting for its handlers, it
+// returns nil if the service has no handler.
+func (b *Bus) CallService(data ServiceRequestData) *ulid.ULID {
 	service := &serviceRequest{
-		ID:   scID,
+		ID:   ulid.Make(),
 		Data: data,
 	}
-	var es *serviceCallSignal
-	if !locked(b, func() (ok bool) {
-		es, ok = b.services[sk]
-		return
-	}) {
-		slog.Error("calling unknown service", "key", sk, "event", service)
+	sc := b.service(data)
+	if sc == nil || sc.handlers.Load() == 0 {
+		slog.Error("calling unknown service", "key", serviceKey(data), "event", service)
 		return nil
 	}
-	es.Emit(service)
-	return &scID
+	sc.emitted.Add(1)
+	sc.Emit(service)
+	return &service.ID
+}
+
+// Call calls the service req and waits for the response of its handler or
+// for ctx to be done. A service may have several handlers, e.g. one per
+// uart, those the call is not meant for return ErrNotHandled. An error
+// returned by the handler fails the call.
+func Call[Req ServiceRequestData, Resp any](ctx context.Context, b *Bus, req Req) (Resp, error) {
+	resp, _, err := CallWithID[Req, Resp](ctx, b, req)
+	return resp, err
+}
+
+// CallWithID is Call returning the id of the request too, it is the
+// RequestID of the ServiceResponseEvent of the call. The id is nil if the
+// service has no handler.
+func CallWithID[Req ServiceRequestData, Resp any](ctx context.Context, b *Bus, req Req) (ret Resp, id *ulid.ULID, err error) {
+	sc := b.service(req)
+	n := 0
+	if sc != nil {
+		n = int(sc.handlers.Load())
+	}
+	if n == 0 {
+		return ret, nil, fmt.Errorf("%w %s", ErrNoHandler, req.ServiceType())
+	}
+	replies := make(chan serviceReply, n)
+	service := &serviceRequest{
+		ID:    ulid.Make(),
+		Data:  req,
+		reply: replies,
+	}
+	sc.emitted.Add(1)
+	sc.Emit(service)
+	// handlers may have unsubscribed since they were counted, only the
+	// queues the call was delivered to reply
+	n = int(service.delivered.Load())
+	if n == 0 {
+		return ret, nil, fmt.Errorf("%w %s", ErrNoHandler, req.ServiceType())
+	}
+	for range n {
+		select {
+		case <-ctx.Done():
+			return ret, &service.ID, fmt.Errorf("no response to %s: %w", req.ServiceType(), context.Cause(ctx))
+		case r := <-replies:
+			if errors.Is(r.err, ErrNotHandled) {
+				continue
+			}
+			if r.err != nil || r.response == nil {
+				return ret, &service.ID, r.err
+			}
+			resp, ok := r.response.(Resp)
+			if !ok {
+				return ret, &service.ID, fmt.Errorf("unexpected response %T to %s", r.response, req.ServiceType())
+			}
+			return resp, &service.ID, nil
+		}
+	}
+	return ret, &service.ID, fmt.Errorf("%w: %s", ErrNotHandled, req.ServiceType())
 }
 
 type serviceHandler struct {
 	stype  string
+	name   string
 	handle func(*serviceRequest)
 }
 
@@ -198,8 +439,10 @@ func ServiceHandler[T any, PT interface {
 }](f func(t PT)) serviceHandler {
 	return serviceHandler{
 		stype: serviceKey(PT(nil)),
+		name:  funcName(f),
 		handle: func(e *serviceRequest) {
 			f(e.Data.(PT))
+			e.respond(nil, nil)
 		},
 	}
 }
@@ -211,26 +454,56 @@ func ServiceHandlerWithRespose[R any, T any, PT interface {
 	em := MakeEventEmitter[ServiceResponseEvent](b)
 	return serviceHandler{
 		stype: serviceKey(PT(nil)),
+		name:  funcName(f),
 		handle: func(e *serviceRequest) {
 			r := f(e.Data.(PT))
+			err, _ := any(r).(error)
+			if errors.Is(err, ErrNotHandled) {
+				e.respond(nil, err)
+				return
+			}
 			em.Emit(&ServiceResponseEvent{
 				RequestID: e.ID,
 				Response:  r,
 			})
+			if err != nil {
+				e.respond(nil, err)
+				return
+			}
+			e.respond(r, nil)
 		},
 	}
 }
 
-func (b *Bus) HandleServiceCalls(h serviceHandler) {
-	es := locked(b, func() *serviceCallSignal {
+// HandleServiceCalls calls h with the calls of its service. The calls are
+// queued for h as configured by opts.
+func (b *Bus) HandleServiceCalls(h serviceHandler, opts ...QueueOption) ServiceSubscription {
+	es := locked(b, func() *serviceCalls {
 		es, ok := b.services[h.stype]
 		if !ok {
-			es = &serviceCallSignal{}
+			es = &serviceCalls{}
 			b.services[h.stype] = es
 		}
 		return es
 	})
-	es.Connect(&dispatcher.Queued{
-		BlockOnFull: true,
-	}, h.handle)
+	q := b.newQueue(h.stype, h.name, opts)
+	slot := es.Connect(dispatcher.Direct(), func(r *serviceRequest) {
+		if !r.deliver() {
+			return
+		}
+		if !q.dispatch(queuedCall{
+			run:  func() { h.handle(r) },
+			fail: func(err error) { r.respond(nil, err) },
+		}) {
+			// the queue is closed or dropped the call, it is answered
+			// right away to not be waited for
+			r.respond(nil, ErrDropped)
+		}
+	})
+	es.handlers.Add(1)
+	return ServiceSubscription{
+		sig:  weak.Make(es),
+		slot: weak.Make(slot),
+		q:    q,
+	}
 }
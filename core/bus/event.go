@@ -1,6 +1,10 @@
 package bus
 
-import "github.com/oklog/ulid/v2"
+import (
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
 
 type EventData interface {
 	EventType() string
@@ -8,12 +12,25 @@ type EventData interface {
 
 type Event struct {
 	EventData
-	ID ulid.ULID
+	// ID orders the events, the journal hands them out by it.
+	ID   ulid.ULID
+	Time time.Time
 }
 
+// StateChangeEvent is emitted when the state of an entity is updated.
 type StateChangeEvent struct {
 	Key      uint32
+	Domain   string
+	OldState any
 	NewState any
+	// Changed is false for the updates published only because the entity
+	// forces updates.
+	Changed     bool
+	LastChanged time.Time
+	LastUpdated time.Time
+	// Available is false while the source of the state is unreachable.
+	Available  bool
+	Attributes map[string]any
 }
 
 // EventType implements EventData.
@@ -21,4 +38,114 @@ func (s *StateChangeEvent) EventType() string {
 	return "state_change"
 }
 
-var _ EventData = (*StateChangeEvent)(nil)
+// EntityKey implements EntityEventData.
+func (s *StateChangeEvent) EntityKey() uint32 {
+	return s.Key
+}
+
+// EntityDomain implements EntityEventData.
+func (s *StateChangeEvent) EntityDomain() string {
+	return s.Domain
+}
+
+var _ EntityEventData = (*StateChangeEvent)(nil)
+
+// CommandResult is the outcome of a command sent to an entity.
+type CommandResult string
+
+const (
+	CommandResultOK            CommandResult = "ok"
+	CommandResultRateLimited   CommandResult = "rate_limited"
+	CommandResultUnknownEntity CommandResult = "unknown_entity"
+	CommandResultFailed        CommandResult = "failed"
+	// CommandResultUnsupported is for commands no component handles, e.g.
+	// for a domain gosthome has no components of.
+	CommandResultUnsupported CommandResult = "unsupported"
+)
+
+// CommandEvent is emitted for every command a client sends to an entity,
+// whether it was executed or not.
+type CommandEvent struct {
+	Time time.Time
+	// Client is the client info the connection introduced itself with.
+	Client string
+	// Remote is the address the connection came from.
+	Remote  string
+	Key     uint32
+	Domain  string
+	Entity  string
+	Command string
+	Args    ServiceRequestData
+	Result  CommandResult
+	// Err is why the command failed.
+	Err error
+	// RequestID is the id of the service call, empty if it was not called.
+	RequestID *ulid.ULID
+}
+
+// EventType implements EventData.
+func (s *CommandEvent) EventType() string {
+	return "command"
+}
+
+// EntityKey implements EntityEventData.
+func (s *CommandEvent) EntityKey() uint32 {
+	return s.Key
+}
+
+// EntityDomain implements EntityEventData.
+func (s *CommandEvent) EntityDomain() string {
+	return s.Domain
+}
+
+var _ EntityEventData = (*CommandEvent)(nil)
+
+// EntityEvent is emitted when an event entity fires one of its event types.
+type EntityEvent struct {
+	Key  uint32
+	Type string
+}
+
+// EventType implements EventData.
+func (s *EntityEvent) EventType() string {
+	return "entity_event"
+}
+
+// EntityKey implements EntityEventData.
+func (s *EntityEvent) EntityKey() uint32 {
+	return s.Key
+}
+
+// EntityDomain implements EntityEventData.
+func (s *EntityEvent) EntityDomain() string {
+	return "event"
+}
+
+var _ EntityEventData = (*EntityEvent)(nil)
+
+// RegistryChangedEvent is emitted when an entity is registered or
+// unregistered, e.g. when a device is plugged in or a component reloaded.
+type RegistryChangedEvent struct {
+	Domain string
+	Key    uint32
+	ID     string
+	// Removed is set when the entity was unregistered.
+	Removed bool
+}
+
+// EventType implements EventData.
+func (s *RegistryChangedEvent) EventType() string {
+	return "registry_changed"
+}
+
+// EntityKey implements EntityEventData.
+func (s *RegistryChangedEvent) EntityKey() uint32 {
+	return s.Key
+}
+
+// EntityDomain implements EntityEventData.
+func (s *RegistryChangedEvent) EntityDomain() string {
+	return s.Domain
+}
+
+var _ EntityEventData = (*RegistryChangedEvent)(nil)
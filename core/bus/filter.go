@@ -0,0 +1,39 @@
+package bus
+
+import "slices"
+
+// EventFilter tells whether a subscriber gets an event.
+type EventFilter func(e *Event) bool
+
+// EntityEventData is implemented by the events about an entity.
+type EntityEventData interface {
+	EventData
+	// EntityKey is the hashed id of the entity.
+	EntityKey() uint32
+	// EntityDomain is the domain of the entity, e.g. "switch". It is empty
+	// when the entity does not tell its domain.
+	EntityDomain() string
+}
+
+// OfType passes the events of the given types.
+func OfType(types ...string) EventFilter {
+	return func(e *Event) bool {
+		return slices.Contains(types, e.EventType())
+	}
+}
+
+// ForDomain passes the events about the entities of the given domains.
+func ForDomain(domains ...string) EventFilter {
+	return func(e *Event) bool {
+		ed, ok := e.EventData.(EntityEventData)
+		return ok && slices.Contains(domains, ed.EntityDomain())
+	}
+}
+
+// ForKeys passes the events about the entities with the given keys.
+func ForKeys(keys ...uint32) EventFilter {
+	return func(e *Event) bool {
+		ed, ok := e.EventData.(EntityEventData)
+		return ok && slices.Contains(keys, ed.EntityKey())
+	}
+}
@@ -0,0 +1,85 @@
+package bus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// journal keeps the last events emitted on a bus. It stamps the events in
+// the order they are added, so a later event always has a greater ID.
+type journal struct {
+	mx     sync.Mutex
+	events []*Event
+	next   int
+	full   bool
+}
+
+func newJournal(size int) *journal {
+	if size <= 0 {
+		return nil
+	}
+	return &journal{
+		events: make([]*Event, size),
+	}
+}
+
+func stamp(e *Event) {
+	e.ID = ulid.Make()
+	e.Time = time.Now()
+}
+
+// add stamps e and keeps it, it only stamps e without a journal.
+func (j *journal) add(e *Event) {
+	if j == nil {
+		stamp(e)
+		return
+	}
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	stamp(e)
+	j.events[j.next] = e
+	j.next = (j.next + 1) % len(j.events)
+	if j.next == 0 {
+		j.full = true
+	}
+}
+
+// since returns the events after the one with the id since, complete is
+// false if the journal does not reach back to it. The zero id returns the
+// whole journal. last is the id of the latest event, every event emitted
+// later has a greater id.
+func (j *journal) since(since ulid.ULID) (ret []*Event, last ulid.ULID, complete bool) {
+	if j == nil {
+		return nil, last, since == (ulid.ULID{})
+	}
+	ordered := j.events[:j.next]
+	if j.full {
+		ordered = append(j.events[j.next:len(j.events):len(j.events)], ordered...)
+	}
+	if len(ordered) == 0 {
+		return nil, last, true
+	}
+	last = ordered[len(ordered)-1].ID
+	complete = since == (ulid.ULID{}) || !j.full || since.Compare(ordered[0].ID) >= 0
+	for _, e := range ordered {
+		if e.ID.Compare(since) > 0 {
+			ret = append(ret, e)
+		}
+	}
+	return ret, last, complete
+}
+
+// Journal returns the journaled events emitted after the one with the id
+// since, complete is false if older events were already dropped from the
+// journal. The zero id returns the whole journal.
+func (b *Bus) Journal(since ulid.ULID) (events []*Event, complete bool) {
+	if b.journal == nil {
+		return nil, since == (ulid.ULID{})
+	}
+	b.journal.mx.Lock()
+	defer b.journal.mx.Unlock()
+	events, _, complete = b.journal.since(since)
+	return events, complete
+}
@@ -0,0 +1,94 @@
+package bus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"github.com/oklog/ulid/v2"
+)
+
+func collect(t *testing.T, events <-chan *Event, n int) []*Event {
+	t.Helper()
+	ret := []*Event{}
+	for range n {
+		select {
+		case e := <-events:
+			ret = append(ret, e)
+		case <-time.After(time.Second):
+			t.Fatalf("got %d events, want %d", len(ret), n)
+		}
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event %#v", e.EventData)
+	case <-time.After(20 * time.Millisecond):
+	}
+	return ret
+}
+
+func TestFilteredEvents(t *testing.T) {
+	is := is.New(t)
+	b := New()
+	all := make(chan *Event, 10)
+	switches := make(chan *Event, 10)
+	keys := make(chan *Event, 10)
+	subs := []EventSubsciption{
+		b.HandleEvents(AnyEventHandler(func(e *Event) { all <- e })),
+		b.HandleEvents(AnyEventHandler(func(e *Event) { switches <- e }).Filter(OfType("state_change"), ForDomain("switch"))),
+		b.HandleEvents(EventHandler(func(e *RegistryChangedEvent) {
+			keys <- &Event{EventData: e}
+		}).Filter(ForKeys(1, 3))),
+	}
+	for _, sub := range subs {
+		defer sub.Close()
+	}
+	states := MakeEventEmitter[StateChangeEvent](b)
+	changes := MakeEventEmitter[RegistryChangedEvent](b)
+	states.Emit(&StateChangeEvent{Key: 1, Domain: "switch"})
+	states.Emit(&StateChangeEvent{Key: 2, Domain: "sensor"})
+	changes.Emit(&RegistryChangedEvent{Key: 1, Domain: "switch"})
+	changes.Emit(&RegistryChangedEvent{Key: 2, Domain: "switch"})
+	MakeEventEmitter[testEvent](b).Emit(&testEvent{})
+
+	got := collect(t, all, 5)
+	for i := 1; i < len(got); i++ {
+		is.True(got[i-1].ID.Compare(got[i].ID) < 0)
+		is.True(!got[i].Time.IsZero())
+	}
+	got = collect(t, switches, 1)
+	is.Equal(got[0].EventData, &StateChangeEvent{Key: 1, Domain: "switch"})
+	got = collect(t, keys, 1)
+	is.Equal(got[0].EventData, &RegistryChangedEvent{Key: 1, Domain: "switch"})
+}
+
+func TestJournalReplay(t *testing.T) {
+	is := is.New(t)
+	b := New(WithJournal(3))
+	em := MakeEventEmitter[testEvent](b)
+	for n := range 4 {
+		em.Emit(&testEvent{N: n})
+	}
+	journal, complete := b.Journal(ulid.ULID{})
+	is.True(complete)
+	is.Equal(len(journal), 3) // the first event was dropped
+	is.Equal(journal[0].EventData, &testEvent{N: 1})
+	_, complete = b.Journal(journal[0].ID)
+	is.True(complete)
+
+	events := make(chan *Event, 10)
+	sub := b.HandleEvents(EventHandler(func(e *testEvent) {
+		events <- &Event{EventData: e}
+	}).ReplayFrom(journal[0].ID))
+	defer sub.Close()
+	em.Emit(&testEvent{N: 4})
+	got := collect(t, events, 3)
+	is.Equal(got[0].EventData, &testEvent{N: 2})
+	is.Equal(got[1].EventData, &testEvent{N: 3})
+	is.Equal(got[2].EventData, &testEvent{N: 4})
+
+	journal, complete = b.Journal(journal[0].ID)
+	is.True(!complete)
+	is.Equal(len(journal), 3)
+	is.Equal(journal[2].EventData, &testEvent{N: 4})
+}
@@ -0,0 +1,310 @@
+package bus
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/majfault/signal/dispatcher"
+)
+
+//go:generate go-enum --marshal --nocase
+
+// OverflowPolicy is what a subscriber queue does with an event when it is
+// full.
+// ENUM(
+// block, // wait for the subscriber, the emitter is blocked meanwhile (default)
+// drop_oldest, // drop the oldest queued event to make room
+// drop_newest, // drop the event being emitted
+// )
+type OverflowPolicy int
+
+// DefaultQueueSize is the size of a subscriber queue without WithQueueSize.
+const DefaultQueueSize = 64
+
+// QueueConfig configures the queue of a subscriber.
+type QueueConfig struct {
+	// Name identifies the subscriber in the stats, it defaults to the name
+	// of the handler function.
+	Name     string
+	Size     int
+	Overflow OverflowPolicy
+}
+
+// QueueOption changes the queue of a subscriber, or the default queue of
+// the subscribers with WithDefaultQueue.
+type QueueOption func(*QueueConfig)
+
+// WithName names the subscriber in the stats.
+func WithName(name string) QueueOption {
+	return func(qc *QueueConfig) {
+		qc.Name = name
+	}
+}
+
+// WithQueueSize bounds the queue of the subscriber to size events.
+func WithQueueSize(size int) QueueOption {
+	return func(qc *QueueConfig) {
+		qc.Size = size
+	}
+}
+
+// WithOverflow sets what happens to events emitted to a full queue.
+func WithOverflow(p OverflowPolicy) QueueOption {
+	return func(qc *QueueConfig) {
+		qc.Overflow = p
+	}
+}
+
+func funcName(f any) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return fmt.Sprintf("%T", f)
+	}
+	return fn.Name()
+}
+
+// latencyBounds are the upper bounds of the buckets of a LatencyHistogram,
+// the last bucket has no bound.
+var latencyBounds = []time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+type latency struct {
+	buckets [7]atomic.Uint64
+	sum     atomic.Int64
+	max     atomic.Int64
+}
+
+func (l *latency) observe(d time.Duration) {
+	i := 0
+	for i < len(latencyBounds) && d > latencyBounds[i] {
+		i++
+	}
+	l.buckets[i].Add(1)
+	l.sum.Add(int64(d))
+	for {
+		m := l.max.Load()
+		if int64(d) <= m || l.max.CompareAndSwap(m, int64(d)) {
+			return
+		}
+	}
+}
+
+// LatencyHistogram counts how long handlers took.
+type LatencyHistogram struct {
+	// Bounds are the upper bounds of Counts, the last count is for the
+	// handlers slower than every bound.
+	Bounds []time.Duration
+	Counts []uint64
+	Count  uint64
+	Sum    time.Duration
+	Max    time.Duration
+}
+
+func (l *latency) snapshot() (ret LatencyHistogram) {
+	ret.Bounds = latencyBounds
+	ret.Counts = make([]uint64, len(l.buckets))
+	for i := range l.buckets {
+		ret.Counts[i] = l.buckets[i].Load()
+		ret.Count += ret.Counts[i]
+	}
+	ret.Sum = time.Duration(l.sum.Load())
+	ret.Max = time.Duration(l.max.Load())
+	return ret
+}
+
+func (h *LatencyHistogram) merge(o LatencyHistogram) {
+	if h.Counts == nil {
+		h.Bounds = o.Bounds
+		h.Counts = make([]uint64, len(o.Counts))
+	}
+	for i := range o.Counts {
+		h.Counts[i] += o.Counts[i]
+	}
+	h.Count += o.Count
+	h.Sum += o.Sum
+	h.Max = max(h.Max, o.Max)
+}
+
+// Mean returns the average latency.
+func (h *LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// queuedCall is a handler call waiting in a queue, fail is told when the
+// call is dropped or the handler panics, it may be nil.
+type queuedCall struct {
+	run  func()
+	fail func(error)
+}
+
+func (c queuedCall) failed(err error) {
+	if c.fail != nil {
+		c.fail(err)
+	}
+}
+
+// queue is the dispatcher of a subscriber, it calls the handler of the
+// subscriber in its own goroutine.
+type queue struct {
+	key string
+	cfg QueueConfig
+
+	mx       sync.Mutex
+	notEmpty sync.Cond
+	notFull  sync.Cond
+	calls    []queuedCall
+	maxDepth int
+	closed   bool
+
+	handled atomic.Uint64
+	dropped atomic.Uint64
+	latency latency
+}
+
+func newQueue(key string, cfg QueueConfig) *queue {
+	if cfg.Size <= 0 {
+		cfg.Size = DefaultQueueSize
+	}
+	q := &queue{
+		key: key,
+		cfg: cfg,
+	}
+	q.notEmpty.L = &q.mx
+	q.notFull.L = &q.mx
+	go q.run()
+	return q
+}
+
+// Dispatch implements dispatcher.Interface.
+func (q *queue) Dispatch(call func()) {
+	q.dispatch(queuedCall{run: call})
+}
+
+// dispatch queues call and reports whether it was queued. The calls
+// dropped to make room are failed with ErrDropped.
+func (q *queue) dispatch(call queuedCall) bool {
+	var dropped []queuedCall
+	defer func() {
+		for _, c := range dropped {
+			c.failed(ErrDropped)
+		}
+	}()
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	for !q.closed && len(q.calls) >= q.cfg.Size {
+		switch q.cfg.Overflow {
+		case OverflowPolicyDropOldest:
+			dropped = append(dropped, q.calls[0])
+			q.calls[0] = queuedCall{}
+			q.calls = q.calls[1:]
+		case OverflowPolicyDropNewest:
+			q.dropped.Add(1)
+			return false
+		default:
+			q.notFull.Wait()
+			continue
+		}
+		q.dropped.Add(1)
+	}
+	if q.closed {
+		return false
+	}
+	q.calls = append(q.calls, call)
+	q.maxDepth = max(q.maxDepth, len(q.calls))
+	q.notEmpty.Signal()
+	return true
+}
+
+// preload queues calls ahead of the dispatched ones, even beyond the size
+// of the queue.
+func (q *queue) preload(calls []queuedCall) {
+	if len(calls) == 0 {
+		return
+	}
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	q.calls = append(calls, q.calls...)
+	q.maxDepth = max(q.maxDepth, len(q.calls))
+	q.notEmpty.Signal()
+}
+
+// Priority implements dispatcher.Interface.
+func (q *queue) Priority() int {
+	return dispatcher.QueuedPriority
+}
+
+func (q *queue) next() (queuedCall, bool) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	for !q.closed && len(q.calls) == 0 {
+		q.notEmpty.Wait()
+	}
+	if q.closed {
+		return queuedCall{}, false
+	}
+	call := q.calls[0]
+	q.calls[0] = queuedCall{}
+	q.calls = q.calls[1:]
+	q.notFull.Signal()
+	return call, true
+}
+
+func (q *queue) run() {
+	for {
+		call, ok := q.next()
+		if !ok {
+			return
+		}
+		start := time.Now()
+		q.call(call)
+		q.latency.observe(time.Since(start))
+		q.handled.Add(1)
+	}
+}
+
+func (q *queue) call(call queuedCall) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Bus handler panicked", "key", q.key, "subscriber", q.cfg.Name, "panic", r, "stack", string(debug.Stack()))
+			call.failed(fmt.Errorf("%w: %v", ErrHandlerPanicked, r))
+		}
+	}()
+	call.run()
+}
+
+// close stops the queue, the queued events are dropped.
+func (q *queue) close() {
+	q.mx.Lock()
+	calls := q.calls
+	q.closed = true
+	q.calls = nil
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	q.mx.Unlock()
+	for _, c := range calls {
+		c.failed(ErrDropped)
+	}
+}
+
+func (q *queue) isClosed() bool {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+	return q.closed
+}
+
+var _ dispatcher.Interface = (*queue)(nil)
@@ -0,0 +1,87 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version:
+// Revision:
+// Build Date:
+// Built By:
+
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// OverflowPolicyBlock is a OverflowPolicy of type Block.
+	// wait for the subscriber, the emitter is blocked meanwhile (default)
+	OverflowPolicyBlock OverflowPolicy = iota
+	// OverflowPolicyDropOldest is a OverflowPolicy of type Drop_oldest.
+	// drop the oldest queued event to make room
+	OverflowPolicyDropOldest
+	// OverflowPolicyDropNewest is a OverflowPolicy of type Drop_newest.
+	// drop the event being emitted
+	OverflowPolicyDropNewest
+)
+
+var ErrInvalidOverflowPolicy = errors.New("not a valid OverflowPolicy")
+
+const _OverflowPolicyName = "blockdrop_oldestdrop_newest"
+
+var _OverflowPolicyMap = map[OverflowPolicy]string{
+	OverflowPolicyBlock:      _OverflowPolicyName[0:5],
+	OverflowPolicyDropOldest: _OverflowPolicyName[5:16],
+	OverflowPolicyDropNewest: _OverflowPolicyName[16:27],
+}
+
+// String implements the Stringer interface.
+func (x OverflowPolicy) String() string {
+	if str, ok := _OverflowPolicyMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("OverflowPolicy(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x OverflowPolicy) IsValid() bool {
+	_, ok := _OverflowPolicyMap[x]
+	return ok
+}
+
+var _OverflowPolicyValue = map[string]OverflowPolicy{
+	_OverflowPolicyName[0:5]:                    OverflowPolicyBlock,
+	strings.ToLower(_OverflowPolicyName[0:5]):   OverflowPolicyBlock,
+	_OverflowPolicyName[5:16]:                   OverflowPolicyDropOldest,
+	strings.ToLower(_OverflowPolicyName[5:16]):  OverflowPolicyDropOldest,
+	_OverflowPolicyName[16:27]:                  OverflowPolicyDropNewest,
+	strings.ToLower(_OverflowPolicyName[16:27]): OverflowPolicyDropNewest,
+}
+
+// ParseOverflowPolicy attempts to convert a string to a OverflowPolicy.
+func ParseOverflowPolicy(name string) (OverflowPolicy, error) {
+	if x, ok := _OverflowPolicyValue[name]; ok {
+		return x, nil
+	}
+	// Case insensitive parse, do a separate lookup to prevent unnecessary cost of lowercasing a string if we don't need to.
+	if x, ok := _OverflowPolicyValue[strings.ToLower(name)]; ok {
+		return x, nil
+	}
+	return OverflowPolicy(0), fmt.Errorf("%s is %w", name, ErrInvalidOverflowPolicy)
+}
+
+// MarshalText implements the text marshaller method.
+func (x OverflowPolicy) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method.
+func (x *OverflowPolicy) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseOverflowPolicy(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
@@ -0,0 +1,118 @@
+package bus
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type testEvent struct {
+	N int
+}
+
+func (*testEvent) EventType() string {
+	return "test"
+}
+
+// overflow emits 5 events to a subscriber with a queue of 2 that is stuck
+// handling the first one and returns the handled events.
+func overflow(t *testing.T, p OverflowPolicy) (*Bus, []int) {
+	is := is.New(t)
+	b := New()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handled := make(chan int, 5)
+	sub := b.HandleEvents(EventHandler(func(e *testEvent) {
+		if e.N == 0 {
+			close(started)
+			<-release
+		}
+		handled <- e.N
+	}), WithName("stuck"), WithQueueSize(2), WithOverflow(p))
+	defer sub.Close()
+
+	em := MakeEventEmitter[testEvent](b)
+	emitted := make(chan struct{})
+	go func() {
+		defer close(emitted)
+		for n := range 5 {
+			em.Emit(&testEvent{N: n})
+			if n == 0 {
+				<-started
+			}
+		}
+	}()
+	select {
+	case <-emitted:
+		is.True(p != OverflowPolicyBlock) // blocked emitters do not return
+	case <-time.After(50 * time.Millisecond):
+		is.Equal(p, OverflowPolicyBlock)
+	}
+	close(release)
+	<-emitted
+	ret := []int{}
+	for len(ret) < 5 {
+		select {
+		case n := <-handled:
+			ret = append(ret, n)
+		case <-time.After(50 * time.Millisecond):
+			return b, ret
+		}
+	}
+	return b, ret
+}
+
+func TestQueueOverflow(t *testing.T) {
+	is := is.New(t)
+	_, handled := overflow(t, OverflowPolicyBlock)
+	is.Equal(handled, []int{0, 1, 2, 3, 4})
+	_, handled = overflow(t, OverflowPolicyDropNewest)
+	is.Equal(handled, []int{0, 1, 2})
+	b, handled := overflow(t, OverflowPolicyDropOldest)
+	is.Equal(handled, []int{0, 3, 4})
+
+	s := b.Stats()
+	is.Equal(len(s.Topics), 2)
+	is.Equal(s.Topics[0].Topic, "event/*")
+	is.Equal(s.Topics[1].Topic, "event/test")
+	is.Equal(s.Topics[1].Emitted, uint64(5))
+	is.Equal(len(s.Subscribers), 0) // closed
+}
+
+func TestStats(t *testing.T) {
+	is := is.New(t)
+	b := New(WithDefaultQueue(WithQueueSize(8)))
+	done := make(chan struct{})
+	sub := b.HandleEvents(EventHandler(func(e *testEvent) {
+		time.Sleep(2 * time.Millisecond)
+		if e.N == 2 {
+			close(done)
+		}
+	}))
+	defer sub.Close()
+	em := MakeEventEmitter[testEvent](b)
+	for n := range 3 {
+		em.Emit(&testEvent{N: n})
+	}
+	<-done
+	time.Sleep(10 * time.Millisecond)
+
+	s := b.Stats()
+	is.Equal(len(s.Subscribers), 1)
+	q := s.Subscribers[0]
+	is.Equal(q.Topic, "event/test")
+	is.True(strings.HasPrefix(q.Name, "github.com/gosthome/gosthome/core/bus.TestStats"))
+	is.Equal(q.Size, 8)
+	is.Equal(q.Handled, uint64(3))
+	is.Equal(q.Latency.Count, uint64(3))
+	is.Equal(q.Latency.Counts[0]+q.Latency.Counts[1], uint64(0)) // slower than 1ms
+	is.True(q.Latency.Mean() >= 2*time.Millisecond)
+	is.Equal(s.Topics[1].Handled, uint64(3))
+
+	w := &strings.Builder{}
+	is.NoErr(b.Dump(w))
+	lines := strings.Split(w.String(), "\n")
+	is.Equal(strings.Fields(lines[2])[:5], []string{"event/test", "3", "1", "3", "0"})
+}
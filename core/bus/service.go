@@ -1,6 +1,27 @@
 package bus
 
-import "github.com/oklog/ulid/v2"
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// ErrNoHandler is returned by Call for a service without handlers.
+var ErrNoHandler = errors.New("no handler for service")
+
+// ErrNotHandled is returned by a service handler for a call meant for
+// another handler of the same service, e.g. a write to another uart. Call
+// fails with it when no handler took the call.
+var ErrNotHandled = errors.New("service call not handled")
+
+// ErrDropped is returned by Call when the queue of a handler dropped the
+// call before it was handled.
+var ErrDropped = errors.New("service call dropped")
+
+// ErrHandlerPanicked is returned by Call when a handler panicked on the
+// call.
+var ErrHandlerPanicked = errors.New("service handler panicked")
 
 type ServiceRequestData interface {
 	ServiceType() string
@@ -9,6 +30,45 @@ type ServiceRequestData interface {
 type serviceRequest struct {
 	Data ServiceRequestData
 	ID   ulid.ULID
+	// reply receives the outcome of every handler of a Call, it is nil for
+	// CallService.
+	reply chan<- serviceReply
+	// delivered counts the handler queues the call was queued to, at most
+	// cap(reply) so that every reply fits.
+	delivered atomic.Int32
+}
+
+type serviceReply struct {
+	response any
+	err      error
+}
+
+// deliver reserves a reply for a handler before the call is queued to it,
+// it returns false if the call has no room left for another reply.
+func (sr *serviceRequest) deliver() bool {
+	if sr.reply == nil {
+		return true
+	}
+	for {
+		n := sr.delivered.Load()
+		if int(n) >= cap(sr.reply) {
+			// a handler registered after the call was sent
+			return false
+		}
+		if sr.delivered.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+func (sr *serviceRequest) respond(response any, err error) {
+	if sr.reply == nil {
+		return
+	}
+	select {
+	case sr.reply <- serviceReply{response: response, err: err}:
+	default:
+	}
 }
 
 type ServiceResponseEvent struct {
@@ -0,0 +1,149 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type testWrite struct {
+	Key uint32
+}
+
+func (*testWrite) ServiceType() string {
+	return "test.write"
+}
+
+type testEcho struct {
+	Text string
+}
+
+func (*testEcho) ServiceType() string {
+	return "test.echo"
+}
+
+func TestCall(t *testing.T) {
+	is := is.New(t)
+	b := New()
+	ctx := context.Background()
+
+	_, err := Call[*testEcho, string](ctx, b, &testEcho{})
+	is.True(errors.Is(err, ErrNoHandler))
+
+	sub := b.HandleServiceCalls(ServiceHandlerWithRespose(b, func(e *testEcho) string {
+		return e.Text
+	}))
+	resp, err := Call[*testEcho, string](ctx, b, &testEcho{Text: "hello"})
+	is.NoErr(err)
+	is.Equal(resp, "hello")
+	_, err = Call[*testEcho, int](ctx, b, &testEcho{})
+	is.True(err != nil) // wrong response type
+
+	sub.Close()
+	sub.Close()
+	_, err = Call[*testEcho, string](ctx, b, &testEcho{})
+	is.True(errors.Is(err, ErrNoHandler))
+}
+
+func TestCallFanOut(t *testing.T) {
+	is := is.New(t)
+	b := New()
+	ctx := context.Background()
+	written := make(chan uint32, 3)
+	for key := range uint32(3) {
+		sub := b.HandleServiceCalls(ServiceHandlerWithRespose(b, func(w *testWrite) error {
+			if w.Key != key {
+				return ErrNotHandled
+			}
+			if key == 2 {
+				return fmt.Errorf("write %d failed", key)
+			}
+			written <- key
+			return nil
+		}))
+		defer sub.Close()
+	}
+
+	_, err := Call[*testWrite, any](ctx, b, &testWrite{Key: 1})
+	is.NoErr(err)
+	is.Equal(<-written, uint32(1))
+	_, err = Call[*testWrite, any](ctx, b, &testWrite{Key: 2})
+	is.Equal(err.Error(), "write 2 failed")
+	_, err = Call[*testWrite, any](ctx, b, &testWrite{Key: 3})
+	is.True(errors.Is(err, ErrNotHandled))
+	is.Equal(len(written), 0)
+}
+
+func TestCallTimeout(t *testing.T) {
+	is := is.New(t)
+	b := New()
+	release := make(chan struct{})
+	defer close(release)
+	sub := b.HandleServiceCalls(ServiceHandler(func(w *testWrite) {
+		<-release
+	}))
+	defer sub.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, id, err := CallWithID[*testWrite, any](ctx, b, &testWrite{})
+	is.True(errors.Is(err, context.DeadlineExceeded))
+	is.True(id != nil)
+}
+
+func TestCallDropped(t *testing.T) {
+	is := is.New(t)
+	b := New()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	sub := b.HandleServiceCalls(ServiceHandler(func(w *testWrite) {
+		if w.Key == 0 {
+			close(started)
+			<-release
+		}
+	}), WithQueueSize(1), WithOverflow(OverflowPolicyDropNewest))
+	defer sub.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go Call[*testWrite, any](ctx, b, &testWrite{Key: 0})
+	<-started
+	queued := make(chan error)
+	go func() {
+		_, err := Call[*testWrite, any](ctx, b, &testWrite{Key: 1})
+		queued <- err
+	}()
+	// wait for the second call to fill the queue
+	for {
+		sub.q.mx.Lock()
+		n := len(sub.q.calls)
+		sub.q.mx.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	_, err := Call[*testWrite, any](ctx, b, &testWrite{Key: 2})
+	is.True(errors.Is(err, ErrDropped))
+	sub.Close()
+	is.True(errors.Is(<-queued, ErrDropped)) // dropped when the queue closed
+}
+
+func TestCallPanic(t *testing.T) {
+	is := is.New(t)
+	b := New()
+	sub := b.HandleServiceCalls(ServiceHandler(func(w *testWrite) {
+		panic("boom")
+	}))
+	defer sub.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := Call[*testWrite, any](ctx, b, &testWrite{})
+	is.True(errors.Is(err, ErrHandlerPanicked))
+}
@@ -0,0 +1,119 @@
+package bus
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+)
+
+// Stats is a snapshot of the traffic and queues of a bus.
+type Stats struct {
+	Topics      []TopicStats
+	Subscribers []SubscriberStats
+}
+
+// TopicStats sums up an event type or a service, e.g. "event/state_change"
+// or "service/button.press".
+type TopicStats struct {
+	Topic       string
+	Emitted     uint64
+	Subscribers int
+	Handled     uint64
+	Dropped     uint64
+	Latency     LatencyHistogram
+}
+
+// SubscriberStats describes the queue of a subscriber.
+type SubscriberStats struct {
+	Topic    string
+	Name     string
+	Overflow OverflowPolicy
+	Size     int
+	Depth    int
+	MaxDepth int
+	Handled  uint64
+	Dropped  uint64
+	Latency  LatencyHistogram
+}
+
+func (q *queue) stats() SubscriberStats {
+	q.mx.Lock()
+	depth, maxDepth := len(q.calls), q.maxDepth
+	q.mx.Unlock()
+	return SubscriberStats{
+		Topic:    q.key,
+		Name:     q.cfg.Name,
+		Overflow: q.cfg.Overflow,
+		Size:     q.cfg.Size,
+		Depth:    depth,
+		MaxDepth: maxDepth,
+		Handled:  q.handled.Load(),
+		Dropped:  q.dropped.Load(),
+		Latency:  q.latency.snapshot(),
+	}
+}
+
+// Stats returns the counters of every topic and open subscriber queue.
+func (b *Bus) Stats() (ret Stats) {
+	topics := map[string]*TopicStats{}
+	topic := func(key string) *TopicStats {
+		t, ok := topics[key]
+		if !ok {
+			t = &TopicStats{Topic: key}
+			topics[key] = t
+		}
+		return t
+	}
+	queues := rlocked(b, func() []*queue {
+		for k, es := range b.events {
+			topic(k).Emitted = es.emitted.Load()
+		}
+		for k, sc := range b.services {
+			topic(k).Emitted = sc.emitted.Load()
+		}
+		return slices.Clone(b.queues)
+	})
+	for _, q := range queues {
+		if q.isClosed() {
+			continue
+		}
+		s := q.stats()
+		t := topic(s.Topic)
+		t.Subscribers++
+		t.Handled += s.Handled
+		t.Dropped += s.Dropped
+		t.Latency.merge(s.Latency)
+		ret.Subscribers = append(ret.Subscribers, s)
+	}
+	for _, t := range topics {
+		ret.Topics = append(ret.Topics, *t)
+	}
+	slices.SortFunc(ret.Topics, func(a, b TopicStats) int {
+		return cmp.Compare(a.Topic, b.Topic)
+	})
+	slices.SortStableFunc(ret.Subscribers, func(a, b SubscriberStats) int {
+		return cmp.Compare(a.Topic, b.Topic)
+	})
+	return ret
+}
+
+// Dump writes the stats of the bus as tables, the slow handlers are the ones
+// with deep queues and high latencies.
+func (b *Bus) Dump(w io.Writer) error {
+	s := b.Stats()
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOPIC\tEMITTED\tSUBSCRIBERS\tHANDLED\tDROPPED\tMEAN\tMAX")
+	for _, t := range s.Topics {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\t%s\n",
+			t.Topic, t.Emitted, t.Subscribers, t.Handled, t.Dropped, t.Latency.Mean(), t.Latency.Max)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "TOPIC\tSUBSCRIBER\tDEPTH\tMAX DEPTH\tSIZE\tOVERFLOW\tHANDLED\tDROPPED\tMEAN\tMAX")
+	for _, q := range s.Subscribers {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\t%d\t%d\t%s\t%s\n",
+			q.Topic, q.Name, q.Depth, q.MaxDepth, q.Size, q.Overflow, q.Handled, q.Dropped, q.Latency.Mean(), q.Latency.Max)
+	}
+	return tw.Flush()
+}
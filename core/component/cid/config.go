@@ -2,18 +2,85 @@ package cid
 
 import (
 	"context"
+	"reflect"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
 	cv "github.com/gosthome/gosthome/core/configvalidation"
 )
 
-type IDConfig struct {
+// IDConfig declares the id of a T in the config, Ref[T] refers to it.
+type IDConfig[T any] struct {
 	ID string `yaml:"id"`
 }
 
 // ValidateWithContext implements validation.ValidatableWithContext.
-func (i *IDConfig) ValidateWithContext(ctx context.Context) error {
+func (i *IDConfig[T]) ValidateWithContext(ctx context.Context) error {
 	return validation.ValidateStructWithContext(ctx, i, validation.Field(&i.ID, cv.String(cv.Optional(cv.Name()))))
 }
 
-var _ cv.Validatable = (*IDConfig)(nil)
+// DeclaredID implements Declarer.
+func (i *IDConfig[T]) DeclaredID() (*string, reflect.Type) {
+	return &i.ID, reflect.TypeFor[T]()
+}
+
+func (i *IDConfig[T]) nameable() {}
+
+var _ cv.Validatable = (*IDConfig[struct{}])(nil)
+var _ Declarer = (*IDConfig[struct{}])(nil)
+
+// Ref refers to the id of a T in the config, T may be an interface the
+// referred type implements. Resolve checks it after the config is loaded
+// and, when it is empty, sets it to the only T there is.
+type Ref[T any] struct {
+	ID string
+	tk *token.Token
+}
+
+// UnmarshalYAML implements yaml.NodeUnmarshalerContext.
+func (r *Ref[T]) UnmarshalYAML(ctx context.Context, node ast.Node) error {
+	r.tk = node.GetToken()
+	s, ok := node.(*ast.StringNode)
+	if !ok {
+		return &yaml.UnexpectedNodeTypeError{Actual: node.Type(), Expected: ast.StringType, Token: r.tk}
+	}
+	r.ID = s.Value
+	return nil
+}
+
+// MarshalYAML implements yaml.InterfaceMarshaler.
+func (r Ref[T]) MarshalYAML() (interface{}, error) {
+	return r.ID, nil
+}
+
+// ValidateWithContext implements validation.ValidatableWithContext.
+func (r Ref[T]) ValidateWithContext(ctx context.Context) error {
+	return validation.ValidateStructWithContext(ctx, &r, validation.Field(&r.ID, cv.String(cv.Optional(cv.Name()))))
+}
+
+// JSONSchema implements cv.JSONSchemer.
+func (r *Ref[T]) JSONSchema(ctx context.Context, schema func(v any) map[string]any) map[string]any {
+	return map[string]any{"type": "string", "description": "id of a " + typeName(reflect.TypeFor[T]())}
+}
+
+// Equal compares the ids of the references, not where they are in the
+// config.
+func (r Ref[T]) Equal(other Ref[T]) bool {
+	return r.ID == other.ID
+}
+
+// CID is the id the reference is resolved to.
+func (r *Ref[T]) CID() CID {
+	return NewID(r.ID)
+}
+
+func (r *Ref[T]) reference() (*string, *token.Token, reflect.Type) {
+	return &r.ID, r.tk, reflect.TypeFor[T]()
+}
+
+var _ cv.Validatable = (*Ref[struct{}])(nil)
+var _ cv.JSONSchemer = (*Ref[struct{}])(nil)
+var _ yaml.NodeUnmarshalerContext = (*Ref[struct{}])(nil)
+var _ reference = (*Ref[struct{}])(nil)
@@ -0,0 +1,315 @@
+package cid
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/token"
+)
+
+// Declarer declares the id of a T in the config, e.g. IDConfig or the
+// config of an entity. Ref[T] refers to it.
+type Declarer interface {
+	DeclaredID() (*string, reflect.Type)
+}
+
+// nameable is a declaration that gets the name of its type as id when a
+// Ref refers to it without one.
+type nameable interface {
+	nameable()
+}
+
+// Linker refers to ids in a way Resolve can not see, e.g. the id() of a
+// lambda. Resolve links it with the types declared for the ids once the
+// references are checked, the declarations and Ref values in it are checked
+// like the others.
+type Linker interface {
+	LinkIDs(types func(id string) []reflect.Type) error
+}
+
+type reference interface {
+	reference() (*string, *token.Token, reflect.Type)
+}
+
+// PathError is an error at the YAML path of a config value that has no
+// token, e.g. a Ref left out of the config.
+type PathError struct {
+	Path    string
+	Message string
+}
+
+func (e *PathError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+type declared struct {
+	id   *string
+	typ  reflect.Type
+	path string
+	// named ones get the name of their type as id, the others are
+	// entities: their ids are unique in their domain only.
+	named bool
+}
+
+// conflicts tells whether d and other cannot have the same id.
+func (d declared) conflicts(other declared) bool {
+	return d.named || other.named || d.typ == other.typ
+}
+
+type referred struct {
+	id   *string
+	tk   *token.Token
+	typ  reflect.Type
+	path string
+}
+
+// Resolve checks the Ref values in the config v against its Declarer
+// values: the id of a Ref has to be declared for its type. An empty Ref gets
+// the id of the only declaration of its type, an IDConfig gets the name of
+// its type as id when it has none. The Linker values are linked last.
+func Resolve(v any) error {
+	r := &resolver{seen: map[uintptr]bool{}, declSeen: map[*string]bool{}}
+	r.walk(reflect.ValueOf(v), "$")
+	defer func() {
+		for _, store := range r.stores {
+			store()
+		}
+	}()
+	ids := map[string][]declared{}
+	for _, d := range r.decls {
+		if *d.id == "" {
+			continue
+		}
+		for _, other := range ids[*d.id] {
+			if d.conflicts(other) {
+				return &PathError{Path: d.path, Message: fmt.Sprintf("id %q is already the id of the %s at %s", *d.id, typeName(other.typ), other.path)}
+			}
+		}
+		ids[*d.id] = append(ids[*d.id], d)
+	}
+	for _, ref := range r.refs {
+		if *ref.id == "" {
+			if err := r.implicit(ref, ids); err != nil {
+				return err
+			}
+			continue
+		}
+		ds, ok := ids[*ref.id]
+		if !ok {
+			var d declared
+			d, ok = r.undeclared(ref, ids)
+			ds = []declared{d}
+		}
+		if !ok {
+			return ref.error(fmt.Sprintf("there is no %s with id %q", typeName(ref.typ), *ref.id))
+		}
+		if !slices.ContainsFunc(ds, func(d declared) bool { return ref.matches(d.typ) }) {
+			return ref.error(fmt.Sprintf("%q is the id of the %s, not of a %s", *ref.id, typeName(ds[0].typ), typeName(ref.typ)))
+		}
+	}
+	types := func(id string) (ret []reflect.Type) {
+		for _, d := range ids[id] {
+			ret = append(ret, d.typ)
+		}
+		return ret
+	}
+	for _, l := range r.linkers {
+		if err := l.LinkIDs(types); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// implicit sets the empty ref to the only declaration of its type.
+func (r *resolver) implicit(ref referred, ids map[string][]declared) error {
+	candidates := r.candidates(ref)
+	switch len(candidates) {
+	case 0:
+		return ref.error(fmt.Sprintf("there is no %s to refer to", typeName(ref.typ)))
+	case 1:
+	default:
+		return ref.error(fmt.Sprintf("the config has %d of type %s, the id of one is needed", len(candidates), typeName(ref.typ)))
+	}
+	d := candidates[0]
+	if *d.id == "" {
+		id := defaultID(d.typ)
+		if others, ok := ids[id]; ok {
+			return &PathError{Path: d.path, Message: fmt.Sprintf("the %s needs an id, %q is the id of the %s at %s", typeName(d.typ), id, typeName(others[0].typ), others[0].path)}
+		}
+		*d.id = id
+		ids[id] = []declared{d}
+	}
+	*ref.id = *d.id
+	return nil
+}
+
+// undeclared gives the only declaration of the type of ref the id it gets
+// without one when ref refers to it by that id.
+func (r *resolver) undeclared(ref referred, ids map[string][]declared) (declared, bool) {
+	candidates := r.candidates(ref)
+	if len(candidates) != 1 || *candidates[0].id != "" || defaultID(candidates[0].typ) != *ref.id {
+		return declared{}, false
+	}
+	d := candidates[0]
+	*d.id = *ref.id
+	ids[*d.id] = []declared{d}
+	return d, true
+}
+
+// candidates are the declarations of the type of ref.
+func (r *resolver) candidates(ref referred) (ret []declared) {
+	for _, d := range r.decls {
+		if ref.matches(d.typ) {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+func (ref referred) matches(t reflect.Type) bool {
+	if t == ref.typ {
+		return true
+	}
+	return ref.typ.Kind() == reflect.Interface && (t.Implements(ref.typ) || reflect.PointerTo(t).Implements(ref.typ))
+}
+
+func (ref referred) error(msg string) error {
+	if ref.tk != nil {
+		return &yaml.SyntaxError{Token: ref.tk, Message: msg}
+	}
+	return &PathError{Path: ref.path, Message: msg}
+}
+
+// defaultID is the id of the only T of a config when it has none.
+func defaultID(t reflect.Type) string {
+	name, _, _ := strings.Cut(t.Name(), "[")
+	return strings.ToLower(name)
+}
+
+// typeName names t in errors, with its package when it is not named after
+// it, e.g. uart or api server. Interfaces are named like the domains of
+// entities, e.g. binary_sensor.
+func typeName(t reflect.Type) string {
+	if t.Kind() == reflect.Interface {
+		var b strings.Builder
+		for i, c := range t.Name() {
+			if unicode.IsUpper(c) && i > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(c))
+		}
+		return b.String()
+	}
+	name := defaultID(t)
+	pkg := path.Base(t.PkgPath())
+	if pkg == name || pkg == "." {
+		return name
+	}
+	return pkg + " " + name
+}
+
+// resolver collects the declarations and references of a config.
+type resolver struct {
+	decls   []declared
+	refs    []referred
+	linkers []Linker
+	// stores put the walked values of maps back
+	stores []func()
+	seen   map[uintptr]bool
+	// declSeen holds the declared ids, the method of an embedded
+	// declaration declares the same id for the structs embedding it.
+	declSeen map[*string]bool
+}
+
+func (r *resolver) walk(v reflect.Value, path string) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Pointer {
+			if r.seen[v.Pointer()] {
+				return
+			}
+			r.seen[v.Pointer()] = true
+		}
+		r.walk(v.Elem(), path)
+		return
+	case reflect.Struct:
+		if !v.CanAddr() {
+			p := reflect.New(v.Type())
+			p.Elem().Set(v)
+			v = p.Elem()
+		}
+		// a struct may be a declaration and a Linker at once, e.g. a config
+		// with an id and a lambda, a Ref has nothing else to walk
+		p := v.Addr().Interface()
+		if d, ok := p.(Declarer); ok {
+			id, typ := d.DeclaredID()
+			if !r.declSeen[id] {
+				r.declSeen[id] = true
+				_, named := p.(nameable)
+				r.decls = append(r.decls, declared{id: id, typ: typ, path: path, named: named})
+			}
+		}
+		if ref, ok := p.(reference); ok {
+			id, tk, typ := ref.reference()
+			r.refs = append(r.refs, referred{id: id, tk: tk, typ: typ, path: path})
+			return
+		}
+		if l, ok := p.(Linker); ok {
+			r.linkers = append(r.linkers, l)
+		}
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			r.walk(v.Field(i), fieldPath(path, f))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			r.walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Map:
+		byName := map[string]reflect.Value{}
+		for _, k := range v.MapKeys() {
+			byName[keyPath(path, k)] = k
+		}
+		for _, name := range slices.Sorted(maps.Keys(byName)) {
+			// the values of a map can not be set in place, the resolved
+			// ids are stored back once they are resolved
+			k := byName[name]
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(v.MapIndex(k))
+			r.walk(e, name)
+			r.stores = append(r.stores, func() { v.SetMapIndex(k, e) })
+		}
+	}
+}
+
+// keyPath is the YAML path of the value of the map key k in path.
+func keyPath(path string, k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return path + "." + k.String()
+	}
+	return fmt.Sprintf("%s[%v]", path, k.Interface())
+}
+
+// fieldPath is the YAML path of f in path, inline fields and the ones the
+// YAML does not have are where their struct is.
+func fieldPath(path string, f reflect.StructField) string {
+	name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if f.Anonymous || name == "" || name == "-" || strings.Contains(opts, "inline") {
+		return path
+	}
+	return path + "." + name
+}
@@ -11,9 +11,11 @@ import (
 
 type Component interface {
 	cid.Identifier
-	Setup()
+	Setup(ctx context.Context) error
 	InitializationPriority() InitializationPriority
-	Close() error
+	// Close is also called on the components whose Setup failed or was
+	// skipped, after the components that were set up.
+	Close(ctx context.Context) error
 }
 
 type Config interface {
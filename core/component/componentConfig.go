@@ -21,8 +21,11 @@ func (c *ConfigDecoder) ValidateWithContext(ctx context.Context) error {
 }
 
 // MarshalYAML implements yaml.InterfaceMarshalerContext.
-func (c *ConfigDecoder) MarshalYAML(context.Context) (interface{}, error) {
-	panic("unimplemented")
+func (c *ConfigDecoder) MarshalYAML(ctx context.Context) (interface{}, error) {
+	if c.Marshal == nil {
+		return c.Config, nil
+	}
+	return c.Marshal(ctx, c.Config)
 }
 
 // UnmarshalYAML implements yaml.InterfaceUnmarshalerContext.
@@ -43,8 +46,8 @@ var _ cv.Validatable = (*ConfigDecoder)(nil)
 func Marshal[T any, PT interface {
 	*T
 	Config
-}](context.Context, Config) (interface{}, error) {
-	panic("unimplemented")
+}](ctx context.Context, cfg Config) (interface{}, error) {
+	return cfg.(PT), nil
 }
 
 func Unmarshal[T any, PT interface {
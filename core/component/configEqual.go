@@ -0,0 +1,103 @@
+package component
+
+import (
+	"reflect"
+)
+
+// Equal reports whether both decoders hold the same configuration. Unlike
+// reflect.DeepEqual it ignores functions, so nested decoders compare by
+// their configs only, and uses the Equal method of the values having one,
+// e.g. the references that keep their position in the config.
+func (c *ConfigDecoder) Equal(other *ConfigDecoder) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	return configEqual(reflect.ValueOf(c.Config), reflect.ValueOf(other.Config))
+}
+
+// ConfigEqual reports whether the config values l and r are the same, like
+// ConfigDecoder.Equal does for configs of components.
+func ConfigEqual(l, r any) bool {
+	return configEqual(reflect.ValueOf(l), reflect.ValueOf(r))
+}
+
+func configEqual(l, r reflect.Value) bool {
+	if l.IsValid() != r.IsValid() {
+		return false
+	}
+	if !l.IsValid() {
+		return true
+	}
+	if l.Type() != r.Type() {
+		return false
+	}
+	if eq, ok := equalMethod(l); ok {
+		return eq.Call([]reflect.Value{r})[0].Bool()
+	}
+	switch l.Kind() {
+	case reflect.Func:
+		return true
+	case reflect.Pointer, reflect.Interface:
+		if l.IsNil() || r.IsNil() {
+			return l.IsNil() == r.IsNil()
+		}
+		return configEqual(l.Elem(), r.Elem())
+	case reflect.Struct:
+		for i := range l.NumField() {
+			if !configEqual(l.Field(i), r.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice, reflect.Array:
+		if l.Len() != r.Len() {
+			return false
+		}
+		for i := range l.Len() {
+			if !configEqual(l.Index(i), r.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if l.Len() != r.Len() {
+			return false
+		}
+		for _, k := range l.MapKeys() {
+			if !configEqual(l.MapIndex(k), r.MapIndex(k)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Bool:
+		return l.Bool() == r.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return l.Int() == r.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return l.Uint() == r.Uint()
+	case reflect.Float32, reflect.Float64:
+		return l.Float() == r.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return l.Complex() == r.Complex()
+	case reflect.String:
+		return l.String() == r.String()
+	}
+	// channels and unsafe pointers do not appear in configs
+	return l.Pointer() == r.Pointer()
+}
+
+// equalMethod returns the Equal(T) bool method of v of type T.
+func equalMethod(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() == reflect.Interface || !v.CanInterface() {
+		return reflect.Value{}, false
+	}
+	m, ok := v.Type().MethodByName("Equal")
+	if !ok {
+		return reflect.Value{}, false
+	}
+	t := m.Type
+	if t.NumIn() != 2 || t.In(1) != v.Type() || t.NumOut() != 1 || t.Out(0).Kind() != reflect.Bool {
+		return reflect.Value{}, false
+	}
+	return v.Method(m.Index), true
+}
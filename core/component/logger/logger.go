@@ -102,6 +102,11 @@ func ParseLevelFromInt[T ~int | ~int32 | ~int64](l T) Level {
 	return _levelFromInt[min(max(int(l), _levelIntFirst), _levelIntLast)]
 }
 
+// Int returns the position of the level in the ESPHome log level order.
+func (x Level) Int() int {
+	return _levelToInt[x]
+}
+
 func (x Level) String() string {
 	if str, ok := _levelMap[x]; ok {
 		return str
@@ -26,6 +26,12 @@ func (p *PollingComponentConfig) Validate() error {
 	)
 }
 
+// ValidateWithContext implements cv.Validatable, for the configs that
+// inline it.
+func (p *PollingComponentConfig) ValidateWithContext(ctx context.Context) error {
+	return p.Validate()
+}
+
 type Poller interface {
 	Component
 	Poll()
@@ -107,8 +113,9 @@ func NewPollingComponent[T any, PT interface {
 }
 
 // Setup implements Component.
-func (p *PollingComponent[T, PT]) Setup() {
+func (p *PollingComponent[T, PT]) Setup(ctx context.Context) error {
 	p.Start()
+	return nil
 }
 
 func (p *PollingComponent[T, PT]) Start() {
@@ -122,7 +129,7 @@ func (p *PollingComponent[T, PT]) Stop() {
 }
 
 // Close implements Component.
-func (p *PollingComponent[T, PT]) Close() error {
+func (p *PollingComponent[T, PT]) Close(ctx context.Context) error {
 	p.Stop()
 	return nil
 }
@@ -25,8 +25,8 @@ func newTestComponent(ctx context.Context, cfg *PollingComponentConfig) (ret *te
 }
 
 // Setup implements Poller.
-func (t *testPoll) Setup() {
-	t.PollingComponent.Setup()
+func (t *testPoll) Setup(ctx context.Context) error {
+	return t.PollingComponent.Setup(ctx)
 }
 
 // Poll implements Poller.
@@ -35,8 +35,8 @@ func (t *testPoll) Poll() {
 }
 
 // Close implements Poller.
-func (t *testPoll) Close() error {
-	return errors.Join(t.PollingComponent.Close())
+func (t *testPoll) Close(ctx context.Context) error {
+	return errors.Join(t.PollingComponent.Close(ctx))
 }
 
 // InitializationPriority implements Poller.
@@ -0,0 +1,55 @@
+package component
+
+import (
+	"errors"
+)
+
+//go:generate go-enum
+
+// ENUM(ok,warning,failed)
+type Status uint8
+
+// Dependent is implemented by components that have to be set up after the
+// components with the given ids.
+type Dependent interface {
+	DependsOn() []string
+}
+
+var (
+	ErrDependencyFailed  = errors.New("dependency failed")
+	ErrMissingDependency = errors.New("missing dependency")
+	ErrDependencyCycle   = errors.New("dependency cycle")
+)
+
+type warning struct {
+	err error
+}
+
+func (w warning) Error() string {
+	return w.err.Error()
+}
+
+func (w warning) Unwrap() error {
+	return w.err
+}
+
+// Warning marks a setup error the component can keep running with.
+// Components returning it are set up with a warning status and their
+// dependents are set up as usual.
+func Warning(err error) error {
+	if err == nil {
+		return nil
+	}
+	return warning{err: err}
+}
+
+// StatusOf returns the status of a component which setup returned err.
+func StatusOf(err error) Status {
+	if err == nil {
+		return StatusOk
+	}
+	if errors.As(err, new(warning)) {
+		return StatusWarning
+	}
+	return StatusFailed
+}
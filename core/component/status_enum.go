@@ -0,0 +1,60 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version:
+// Revision:
+// Build Date:
+// Built By:
+
+package component
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// StatusOk is a Status of type Ok.
+	StatusOk Status = iota
+	// StatusWarning is a Status of type Warning.
+	StatusWarning
+	// StatusFailed is a Status of type Failed.
+	StatusFailed
+)
+
+var ErrInvalidStatus = errors.New("not a valid Status")
+
+const _StatusName = "okwarningfailed"
+
+var _StatusMap = map[Status]string{
+	StatusOk:      _StatusName[0:2],
+	StatusWarning: _StatusName[2:9],
+	StatusFailed:  _StatusName[9:15],
+}
+
+// String implements the Stringer interface.
+func (x Status) String() string {
+	if str, ok := _StatusMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("Status(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x Status) IsValid() bool {
+	_, ok := _StatusMap[x]
+	return ok
+}
+
+var _StatusValue = map[string]Status{
+	_StatusName[0:2]:  StatusOk,
+	_StatusName[2:9]:  StatusWarning,
+	_StatusName[9:15]: StatusFailed,
+}
+
+// ParseStatus attempts to convert a string to a Status.
+func ParseStatus(name string) (Status, error) {
+	if x, ok := _StatusValue[name]; ok {
+		return x, nil
+	}
+	return Status(0), fmt.Errorf("%s is %w", name, ErrInvalidStatus)
+}
@@ -0,0 +1,329 @@
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/registry"
+)
+
+// IgnoredKey is a key of an ESPHome config the ESPHome compatibility mode
+// left out.
+type IgnoredKey struct {
+	// File is empty for the config read from a reader.
+	File string
+	Line int
+	// Path is the YAML path of the key, e.g. $.wifi.
+	Path   string
+	Reason string
+}
+
+func (k IgnoredKey) String() string {
+	return fmt.Sprintf("%s: %s", k.Path, k.Reason)
+}
+
+// esphomeBlocks are the ESPHome blocks of the hardware and the firmware,
+// gosthome runs on a host that has them set up.
+var esphomeBlocks = map[string]string{
+	"esp32":               "hardware platform of ESPHome",
+	"esp8266":             "hardware platform of ESPHome",
+	"rp2040":              "hardware platform of ESPHome",
+	"bk72xx":              "hardware platform of ESPHome",
+	"rtl87xx":             "hardware platform of ESPHome",
+	"ln882x":              "hardware platform of ESPHome",
+	"libretiny":           "hardware platform of ESPHome",
+	"host":                "hardware platform of ESPHome",
+	"psram":               "hardware of ESPHome",
+	"status_led":          "hardware of ESPHome",
+	"deep_sleep":          "hardware of ESPHome",
+	"wifi":                "the network of the host is used",
+	"ethernet":            "the network of the host is used",
+	"network":             "the network of the host is used",
+	"openthread":          "the network of the host is used",
+	"captive_portal":      "the network of the host is used",
+	"improv_serial":       "the network of the host is used",
+	"esp32_improv":        "the network of the host is used",
+	"mdns":                "the network of the host is used",
+	"ota":                 "gosthome is updated like any other program",
+	"safe_mode":           "gosthome is updated like any other program",
+	"external_components": "ESPHome components do not run in gosthome",
+	"logger":              "gosthome logs to stderr, --verbose shows debug logs",
+	"debug":               "gosthome logs to stderr, --verbose shows debug logs",
+	"preferences":         "gosthome keeps preferences in gosthome: data_dir:",
+	"time":                "the clock of the host is used",
+}
+
+// esphomeRenames are the ESPHome blocks gosthome has under another name.
+var esphomeRenames = map[string]string{
+	"esphome":    "gosthome",
+	"web_server": "webserver",
+}
+
+// esphomeCompat converts ESPHome configs to gosthome ones.
+type esphomeCompat struct {
+	cr      *registry.Registry
+	g       *schemaGenerator
+	ignored []IgnoredKey
+}
+
+func newESPHomeCompat(cr *registry.Registry) *esphomeCompat {
+	return &esphomeCompat{
+		cr: cr,
+		g:  newSchemaGenerator(cr, false),
+	}
+}
+
+func (c *esphomeCompat) ignore(l *loader, path string, mv *ast.MappingValueNode, reason string) {
+	tk := mv.Key.GetToken()
+	c.ignored = append(c.ignored, IgnoredKey{
+		File:   l.fileOf(tk),
+		Line:   tk.Position.Line,
+		Path:   path,
+		Reason: reason,
+	})
+}
+
+// convert renames the ESPHome blocks of root, leaves out the ones of the
+// hardware and the keys gosthome has no use for.
+func (c *esphomeCompat) convert(l *loader, root *ast.MappingNode) error {
+	values := root.Values[:0]
+	for _, mv := range root.Values {
+		key, _ := scalarString(mv.Key)
+		// the paths in the report are the ones of the ESPHome config
+		path := "$." + key
+		if reason, ok := esphomeBlocks[key]; ok {
+			c.ignore(l, path, mv, reason)
+			continue
+		}
+		if to, ok := esphomeRenames[key]; ok {
+			if _, registered := c.cr.Get(key); !registered {
+				if _, taken := findKey(root, to); taken {
+					return l.wrap(&yaml.SyntaxError{
+						Token:   mv.Key.GetToken(),
+						Message: fmt.Sprintf("%s is %s in gosthome, the config has both", key, to),
+					})
+				}
+				if k, ok := mv.Key.(*ast.StringNode); ok {
+					replaceScalar(k, to)
+				}
+				key = to
+			}
+		}
+		values = append(values, mv)
+		if key == "gosthome" {
+			c.gosthome(l, path, mv)
+			continue
+		}
+		c.component(l, path, key, mv)
+	}
+	root.Values = values
+	return nil
+}
+
+// gosthome keeps the keys of the esphome block gosthome has and, as ESPHome
+// nodes have no mac, derives one from the name.
+func (c *esphomeCompat) gosthome(l *loader, path string, mv *ast.MappingValueNode) {
+	mn, ok := asMapping(mv.Value)
+	if !ok {
+		return
+	}
+	c.keep(l, path, mn, c.g.schema(&GosthomeConfig{}))
+	mv.Value = mn
+	if _, ok := findKey(mn, "mac"); ok {
+		return
+	}
+	name, _ := findKey(mn, "name")
+	s, _ := scalarString(name)
+	if s == "" {
+		return
+	}
+	mac := derivedMAC(s)
+	f, err := parser.ParseBytes([]byte(fmt.Sprintf("mac: %q\n", mac)), 0)
+	if err != nil {
+		return
+	}
+	if m, ok := asMapping(f.Docs[0].Body); ok {
+		merge(mn, m)
+		slog.Info("Derived the mac of the ESPHome node from its name", "name", s, "mac", mac)
+	}
+}
+
+// component leaves out the keys the config of a component or its platforms
+// do not have, e.g. the lambdas and actions of ESPHome.
+func (c *esphomeCompat) component(l *loader, path, key string, mv *ast.MappingValueNode) {
+	cd, ok := c.cr.Get(key)
+	if !ok {
+		return
+	}
+	if domain, err := entity.ParseDomainType(key); err == nil {
+		list, ok := mv.Value.(*ast.SequenceNode)
+		if !ok {
+			return
+		}
+		for i, item := range list.Values {
+			mn, ok := asMapping(item)
+			if !ok {
+				continue
+			}
+			pn, _ := findKey(mn, "platform")
+			platform, _ := scalarString(pn)
+			pcd, ok := c.cr.GetEntityComponent(domain, platform)
+			if !ok {
+				continue
+			}
+			s := c.g.schema(pcd.Config().Config)
+			if props, ok := s["properties"].(map[string]any); ok {
+				props["platform"] = true
+			}
+			c.keep(l, fmt.Sprintf("%s[%d]", path, i), mn, s)
+			list.Values[i] = mn
+		}
+		return
+	}
+	mn, ok := asMapping(mv.Value)
+	if !ok {
+		return
+	}
+	c.keep(l, path, mn, c.g.schema(cd.Config().Config))
+	mv.Value = mn
+}
+
+// keep leaves out the keys of mn that are not properties of schema.
+func (c *esphomeCompat) keep(l *loader, path string, mn *ast.MappingNode, schema map[string]any) {
+	props, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return
+	}
+	values := mn.Values[:0]
+	for _, mv := range mn.Values {
+		key, _ := scalarString(mv.Key)
+		prop, ok := props[key]
+		if !ok {
+			c.ignore(l, path+"."+key, mv, "gosthome has no "+key)
+			continue
+		}
+		if def, ok := automationDef(prop); ok && !c.automation(l, path+"."+key, mv.Value, def) {
+			continue
+		}
+		values = append(values, mv)
+	}
+	mn.Values = values
+}
+
+// automationDef tells whether schema is the one of automation.Actions or
+// Conditions and which.
+func automationDef(schema any) (string, bool) {
+	s, ok := schema.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	switch s["$ref"] {
+	case "#/definitions/actions":
+		return "actions", true
+	case "#/definitions/conditions":
+		return "conditions", true
+	}
+	return "", false
+}
+
+// automation leaves out the actions or conditions of node gosthome does not
+// have, an action goes with the conditions of it that are left out. It is
+// false when nothing is left of node.
+func (c *esphomeCompat) automation(l *loader, path string, node ast.Node, def string) bool {
+	if list, ok := node.(*ast.SequenceNode); ok {
+		values := list.Values[:0]
+		for i, item := range list.Values {
+			mn, ok := asMapping(item)
+			if ok && len(mn.Values) == 1 && !c.item(l, fmt.Sprintf("%s[%d]", path, i), mn.Values[0], def) {
+				continue
+			}
+			values = append(values, item)
+		}
+		kept := len(values) == len(list.Values) || def == "actions" && len(values) > 0
+		list.Values = values
+		return kept
+	}
+	mn, ok := asMapping(node)
+	if !ok || len(mn.Values) != 1 {
+		// the decoder reports what is not an automation
+		return true
+	}
+	if key, _ := scalarString(mn.Values[0].Key); key == "then" && def == "actions" {
+		return c.automation(l, path+".then", mn.Values[0].Value, def)
+	}
+	return c.item(l, path, mn.Values[0], def)
+}
+
+// item tells whether gosthome has the action or condition of mv and the
+// ones nested in it.
+func (c *esphomeCompat) item(l *loader, path string, mv *ast.MappingValueNode, def string) bool {
+	name, _ := scalarString(mv.Key)
+	var v any
+	if def == "actions" {
+		if f, ok := c.cr.Action(name); ok {
+			v = f()
+		}
+	} else if f, ok := c.cr.Condition(name); ok {
+		v = f()
+	}
+	if v == nil {
+		c.ignore(l, path+"."+name, mv, "gosthome has no "+strings.TrimSuffix(def, "s")+" "+name)
+		return false
+	}
+	path += "." + name
+	props, _ := c.g.schema(v)["properties"].(map[string]any)
+	if mn, ok := asMapping(mv.Value); ok && slices.ContainsFunc(mn.Values, func(f *ast.MappingValueNode) bool {
+		key, _ := scalarString(f.Key)
+		_, ok := props[key]
+		return ok
+	}) {
+		for _, f := range mn.Values {
+			key, _ := scalarString(f.Key)
+			if nested, ok := automationDef(props[key]); ok && !c.automation(l, path+"."+key, f.Value, nested) {
+				c.ignore(l, path, mv, "its "+key+" is left out")
+				return false
+			}
+		}
+		return true
+	}
+	// the nested conditions of the shorthand of e.g. not:
+	if sh, ok := v.(automation.Shorthand); ok {
+		if nested, ok := automationDef(c.g.schema(sh.Shorthand())); ok && !c.automation(l, path, mv.Value, nested) {
+			c.ignore(l, path, mv, "its "+nested+" are left out")
+			return false
+		}
+	}
+	return true
+}
+
+// findKey returns the value of key in mn.
+func findKey(mn *ast.MappingNode, key string) (ast.Node, bool) {
+	for _, mv := range mn.Values {
+		if k, _ := scalarString(mv.Key); k == key {
+			return mv.Value, true
+		}
+	}
+	return nil, false
+}
+
+// derivedMAC makes a locally administered mac out of the name of a node, the
+// same name always gets the same mac.
+func derivedMAC(name string) string {
+	h := sha256.Sum256([]byte(name))
+	mac := net.HardwareAddr(h[:6])
+	const (
+		local     = 0b10
+		multicast = 0b1
+	)
+	mac[0] = mac[0]&^multicast | local
+	return mac.String()
+}
@@ -0,0 +1,801 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+	"github.com/gosthome/gosthome/core/component/cid"
+)
+
+// SecretsFile is the file next to the config !secret looks values up in.
+const SecretsFile = "secrets.yaml"
+
+// FileError is an error at a position in a config file.
+type FileError struct {
+	// File is empty for the config read from a reader.
+	File   string
+	Line   int
+	Column int
+	// Path is the YAML path of the value with the error, e.g. $.api.port.
+	Path string
+	Err  error
+}
+
+// newFileError locates err in file.
+func newFileError(file string, err error) *FileError {
+	fe := &FileError{File: file, Err: err}
+	if tk := errorToken(err); tk != nil && tk.Position != nil {
+		fe.Line = tk.Position.Line
+		fe.Column = tk.Position.Column
+	}
+	return fe
+}
+
+// Error implements error.
+func (e *FileError) Error() string {
+	if e.File == "" {
+		return e.Err.Error()
+	}
+	return e.File + ": " + e.Err.Error()
+}
+
+// Message is the error without the position and the source snippet.
+func (e *FileError) Message() string {
+	msg := yaml.FormatError(e.Err, false, false)
+	if e.Line == 0 {
+		return strings.TrimSpace(msg)
+	}
+	_, msg, _ = strings.Cut(msg, "] ")
+	return strings.TrimSpace(msg)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// substitutionRe matches ${name} and $name like ESPHome does.
+var substitutionRe = regexp.MustCompile(`\$\{(\w+)\}|\$(\w+)`)
+
+// loader expands substitutions, !secret, !include and packages before the
+// config is decoded.
+type loader struct {
+	// dir is where the main config and its secrets are.
+	dir     string
+	secrets map[string]string
+	// used maps the tokens of the values read with !secret to the names of
+	// their secrets.
+	used map[*token.Token]string
+	// files maps the first token of every included file to its path, the
+	// errors in the included files are reported with it.
+	files map[*token.Token]string
+	// file is the main config, empty when it is read from a reader.
+	file string
+	// read are the files read so far, the main config first.
+	read []string
+	// including are the files being included, to find include cycles.
+	including []string
+	// substituted maps the substitutions to the tokens of their first
+	// declaration, the cycles between them are reported there.
+	substituted map[string]*token.Token
+	// esphome turns on the ESPHome compatibility mode, what it leaves out is
+	// in ignored.
+	esphome *esphomeCompat
+}
+
+func newLoader(dir, file string) *loader {
+	l := &loader{
+		dir:         dir,
+		file:        file,
+		files:       map[*token.Token]string{},
+		substituted: map[string]*token.Token{},
+	}
+	if file != "" {
+		l.including = append(l.including, filepath.Clean(file))
+		l.read = append(l.read, filepath.Clean(file))
+	}
+	return l
+}
+
+func firstToken(tk *token.Token) *token.Token {
+	for tk.Prev != nil {
+		tk = tk.Prev
+	}
+	return tk
+}
+
+func errorToken(err error) *token.Token {
+	var syntax *yaml.SyntaxError
+	var typ *yaml.TypeError
+	var overflow *yaml.OverflowError
+	var duplicate *yaml.DuplicateKeyError
+	var unknown *yaml.UnknownFieldError
+	var unexpected *yaml.UnexpectedNodeTypeError
+	switch {
+	case errors.As(err, &syntax):
+		return syntax.Token
+	case errors.As(err, &typ):
+		return typ.Token
+	case errors.As(err, &overflow):
+		return overflow.Token
+	case errors.As(err, &duplicate):
+		return duplicate.Token
+	case errors.As(err, &unknown):
+		return unknown.Token
+	case errors.As(err, &unexpected):
+		return unexpected.Token
+	}
+	return nil
+}
+
+// wrap tells in which included file err happened.
+func (l *loader) wrap(err error) error {
+	var fe *FileError
+	if err == nil || errors.As(err, &fe) {
+		return err
+	}
+	tk := errorToken(err)
+	if tk == nil {
+		return err
+	}
+	return newFileError(l.fileOf(tk), err)
+}
+
+// fileOf finds the file tk is in.
+func (l *loader) fileOf(tk *token.Token) string {
+	f, ok := l.files[firstToken(tk)]
+	if !ok {
+		f = l.file
+	}
+	return f
+}
+
+// locate adds the YAML path in root to the position of err.
+func locate(err error, root ast.Node) error {
+	var fe *FileError
+	if !errors.As(err, &fe) || fe.Path != "" {
+		return err
+	}
+	if tk := errorToken(fe.Err); tk != nil {
+		fe.Path, _ = nodePath(root, tk)
+	}
+	return err
+}
+
+// pathToken turns a cid.PathError into an error at the token of the
+// closest node of its path in root, the value at the path may be left out.
+func pathToken(err error, root ast.Node) error {
+	var pe *cid.PathError
+	if !errors.As(err, &pe) {
+		return err
+	}
+	for p := pe.Path; p != "$"; {
+		if yp, perr := yaml.PathString(p); perr == nil {
+			if n, ferr := yp.FilterNode(root); ferr == nil && n != nil {
+				return &yaml.SyntaxError{Token: n.GetToken(), Message: pe.Path + ": " + pe.Message}
+			}
+		}
+		i := strings.LastIndexAny(p, ".[")
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+	return err
+}
+
+// nodePath finds the YAML path of the node of tk in n, e.g. $.button[0].name.
+func nodePath(n ast.Node, tk *token.Token) (string, bool) {
+	var walk func(n ast.Node, path string) (string, bool)
+	walk = func(n ast.Node, path string) (string, bool) {
+		switch n := n.(type) {
+		case *ast.MappingNode:
+			for _, v := range n.Values {
+				if p, ok := walk(v, path); ok {
+					return p, true
+				}
+			}
+		case *ast.MappingValueNode:
+			path += "." + n.Key.GetToken().Value
+			if n.GetToken() == tk || n.Key.GetToken() == tk {
+				return path, true
+			}
+			return walk(n.Value, path)
+		case *ast.SequenceNode:
+			for i, v := range n.Values {
+				if p, ok := walk(v, fmt.Sprintf("%s[%d]", path, i)); ok {
+					return p, true
+				}
+			}
+		case *ast.TagNode:
+			return walk(n.Value, path)
+		case *ast.AnchorNode:
+			return walk(n.Value, path)
+		case nil:
+			return "", false
+		}
+		if n.GetToken() == tk {
+			return path, true
+		}
+		return "", false
+	}
+	return walk(n, "$")
+}
+
+// readFile reads path and records it in the files read.
+func (l *loader) readFile(path string) ([]byte, error) {
+	path = filepath.Clean(path)
+	if !slices.Contains(l.read, path) {
+		l.read = append(l.read, path)
+	}
+	return os.ReadFile(path)
+}
+
+func (l *loader) parseFile(path string) (ast.Node, error) {
+	data, err := l.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parser.ParseBytes(data, 0)
+	if err != nil {
+		return nil, newFileError(path, err)
+	}
+	if len(f.Docs) == 0 || f.Docs[0].Body == nil {
+		return nil, &FileError{File: path, Err: errors.New("file is empty")}
+	}
+	body := f.Docs[0].Body
+	l.files[firstToken(body.GetToken())] = path
+	return body, nil
+}
+
+// include parses path and resolves its tags, the paths in it are relative
+// to its directory.
+func (l *loader) include(path string, tk *token.Token) (ast.Node, error) {
+	path = filepath.Clean(path)
+	if slices.Contains(l.including, path) {
+		return nil, l.wrap(&yaml.SyntaxError{
+			Token:   tk,
+			Message: fmt.Sprintf("include cycle: %s", strings.Join(append(l.including, path), " -> ")),
+		})
+	}
+	body, err := l.parseFile(path)
+	if err != nil {
+		return nil, l.wrap(&yaml.SyntaxError{Token: tk, Message: err.Error()})
+	}
+	l.including = append(l.including, path)
+	defer func() { l.including = l.including[:len(l.including)-1] }()
+	return l.resolveTags(body, filepath.Dir(path))
+}
+
+func (l *loader) secret(name string, tk *token.Token) (string, error) {
+	if l.secrets == nil {
+		path := filepath.Join(l.dir, SecretsFile)
+		data, err := l.readFile(path)
+		if err != nil {
+			return "", l.wrap(&yaml.SyntaxError{Token: tk, Message: fmt.Sprintf("cannot read secrets: %s", err)})
+		}
+		l.secrets = map[string]string{}
+		err = yaml.Unmarshal(data, &l.secrets)
+		if err != nil {
+			return "", newFileError(path, err)
+		}
+	}
+	v, ok := l.secrets[name]
+	if !ok {
+		return "", l.wrap(&yaml.SyntaxError{Token: tk, Message: fmt.Sprintf("secret %s is not in %s", name, SecretsFile)})
+	}
+	return v, nil
+}
+
+// secretPaths maps the YAML paths in root of the values read with !secret
+// to the names of their secrets.
+func (l *loader) secretPaths(root ast.Node) map[string]string {
+	if len(l.used) == 0 {
+		return nil
+	}
+	ret := map[string]string{}
+	for tk, name := range l.used {
+		if p, ok := nodePath(root, tk); ok {
+			ret[p] = name
+		}
+	}
+	return ret
+}
+
+func scalarString(n ast.Node) (string, bool) {
+	s, ok := n.(ast.ScalarNode)
+	if !ok {
+		return "", false
+	}
+	switch v := s.GetValue().(type) {
+	case string:
+		return v, true
+	case nil:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// replaceScalar sets the value of n to value, keeping the position of its
+// token for the errors. A plain scalar is typed again, e.g. "${port}"
+// becomes an integer.
+func replaceScalar(n *ast.StringNode, value string) ast.Node {
+	tk := n.Token
+	// the decoder formats the nodes back to text for TextUnmarshalers
+	trimmed := strings.TrimSpace(tk.Origin)
+	start := strings.Index(tk.Origin, trimmed)
+	prefix, suffix := tk.Origin[:start], tk.Origin[start+len(trimmed):]
+	origin := func(s string) {
+		tk.Origin = prefix + s + suffix
+	}
+	tk.Value = value
+	n.Value = value
+	if tk.Type != token.StringType {
+		origin(strconv.Quote(value))
+		return n
+	}
+	typed := token.New(value, value, tk.Position)
+	tk.Type = typed.Type
+	tk.CharacterType = typed.CharacterType
+	tk.Indicator = typed.Indicator
+	origin(value)
+	switch tk.Type {
+	case token.NullType:
+		return ast.Null(tk)
+	case token.BoolType:
+		return ast.Bool(tk)
+	case token.IntegerType, token.BinaryIntegerType, token.OctetIntegerType, token.HexIntegerType:
+		return ast.Integer(tk)
+	case token.FloatType:
+		return ast.Float(tk)
+	case token.InfinityType:
+		return ast.Infinity(tk)
+	case token.NanType:
+		return ast.Nan(tk)
+	default:
+		tk.Type = token.StringType
+		origin(strconv.Quote(value))
+		return n
+	}
+}
+
+// resolveTags replaces the !secret and !include nodes under n.
+func (l *loader) resolveTags(n ast.Node, dir string) (ast.Node, error) {
+	var err error
+	switch n := n.(type) {
+	case *ast.TagNode:
+		switch n.Start.Value {
+		case "!secret":
+			s, ok := n.Value.(*ast.StringNode)
+			if !ok {
+				return nil, l.wrap(&yaml.SyntaxError{Token: n.Start, Message: "!secret takes the name of a secret"})
+			}
+			v, err := l.secret(s.Value, n.Start)
+			if err != nil {
+				return nil, err
+			}
+			if l.used == nil {
+				l.used = map[*token.Token]string{}
+			}
+			l.used[s.Token] = s.Value
+			return replaceScalar(s, v), nil
+		case "!include":
+			return l.resolveInclude(n, dir)
+		}
+		n.Value, err = l.resolveTags(n.Value, dir)
+		return n, err
+	case *ast.MappingNode:
+		for _, mv := range n.Values {
+			mv.Value, err = l.resolveTags(mv.Value, dir)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case *ast.MappingValueNode:
+		n.Value, err = l.resolveTags(n.Value, dir)
+		return n, err
+	case *ast.SequenceNode:
+		for i, v := range n.Values {
+			n.Values[i], err = l.resolveTags(v, dir)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case *ast.AnchorNode:
+		n.Value, err = l.resolveTags(n.Value, dir)
+		return n, err
+	}
+	return n, nil
+}
+
+// resolveInclude handles `!include file.yaml` and
+// `!include {file: file.yaml, vars: {name: value}}`.
+func (l *loader) resolveInclude(n *ast.TagNode, dir string) (ast.Node, error) {
+	var file string
+	var vars map[string]string
+	switch v := n.Value.(type) {
+	case *ast.StringNode:
+		file = v.Value
+	case *ast.MappingNode:
+		for _, mv := range v.Values {
+			key, _ := scalarString(mv.Key)
+			switch key {
+			case "file":
+				file, _ = scalarString(mv.Value)
+			case "vars":
+				var err error
+				vars, err = l.substitutions(mv.Value)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				return nil, l.wrap(&yaml.UnknownFieldError{Token: mv.Key.GetToken(), Message: fmt.Sprintf("unknown !include field %s", key)})
+			}
+		}
+	}
+	if file == "" {
+		return nil, l.wrap(&yaml.SyntaxError{Token: n.Start, Message: "!include takes a file name or file and vars"})
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	body, err := l.include(file, n.Start)
+	if err != nil {
+		return nil, err
+	}
+	if len(vars) > 0 {
+		body = substitute(body, vars, false)
+	}
+	return body, nil
+}
+
+// substitutions reads a mapping of substitutions, the values can refer to
+// each other.
+func (l *loader) substitutions(n ast.Node) (map[string]string, error) {
+	if n == nil {
+		return map[string]string{}, nil
+	}
+	if _, ok := n.(*ast.NullNode); ok {
+		return map[string]string{}, nil
+	}
+	mn, ok := n.(*ast.MappingNode)
+	if !ok {
+		return nil, l.wrap(&yaml.UnexpectedNodeTypeError{Actual: n.Type(), Expected: ast.MappingType, Token: n.GetToken()})
+	}
+	vars := map[string]string{}
+	tokens := map[string]*token.Token{}
+	for _, mv := range mn.Values {
+		k, _ := scalarString(mv.Key)
+		v, ok := scalarString(mv.Value)
+		if !ok {
+			return nil, l.wrap(&yaml.SyntaxError{Token: mv.Value.GetToken(), Message: fmt.Sprintf("substitution %s should be a scalar", k)})
+		}
+		vars[k] = v
+		tokens[k] = mv.Key.GetToken()
+		if _, ok := l.substituted[k]; !ok {
+			l.substituted[k] = tokens[k]
+		}
+	}
+	if err := l.resolveVars(vars, tokens); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// cycleError is a substitution that refers to itself, directly or through
+// other substitutions.
+type cycleError struct {
+	cycle []string
+}
+
+func (e *cycleError) Error() string {
+	if len(e.cycle) == 2 {
+		return fmt.Sprintf("substitution %s refers to itself", e.cycle[0])
+	}
+	return fmt.Sprintf("substitutions refer to each other: %s", strings.Join(e.cycle, " -> "))
+}
+
+// references returns the names of the substitutions s refers to.
+func references(s string) []string {
+	ret := []string{}
+	for _, sm := range substitutionRe.FindAllStringSubmatch(s, -1) {
+		ret = append(ret, sm[1]+sm[2])
+	}
+	return ret
+}
+
+// resolveVars expands the substitutions that refer to other ones, each one
+// once after the ones it refers to. A cycle is reported at the token of its
+// first substitution in tokens.
+func (l *loader) resolveVars(vars map[string]string, tokens map[string]*token.Token) error {
+	err := resolveVars(vars)
+	var ce *cycleError
+	if errors.As(err, &ce) {
+		return l.wrap(&yaml.SyntaxError{Token: tokens[ce.cycle[0]], Message: ce.Error()})
+	}
+	return err
+}
+
+// resolveVars expands vars in topological order, it fails on the first
+// substitution that refers to itself.
+func resolveVars(vars map[string]string) error {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	path := []string{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			i := slices.Index(path, name)
+			return &cycleError{cycle: append(slices.Clone(path[i:]), name)}
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, ref := range references(vars[name]) {
+			if _, ok := vars[ref]; !ok {
+				continue
+			}
+			if err := visit(ref); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		vars[name], _ = expand(vars[name], vars)
+		return nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expand replaces the substitutions in s, unknown are the ones not in vars.
+func expand(s string, vars map[string]string) (ret string, unknown []string) {
+	ret = substitutionRe.ReplaceAllStringFunc(s, func(m string) string {
+		sm := substitutionRe.FindStringSubmatch(m)
+		name := sm[1] + sm[2]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		unknown = append(unknown, name)
+		return m
+	})
+	return ret, unknown
+}
+
+// substitute expands vars in the values under n, warn logs the
+// substitutions that are not in vars.
+func substitute(n ast.Node, vars map[string]string, warn bool) ast.Node {
+	switch n := n.(type) {
+	case *ast.StringNode:
+		v, unknown := expand(n.Value, vars)
+		if warn {
+			for _, name := range unknown {
+				slog.Warn("Found a substitution that was not declared", "name", name, "line", n.Token.Position.Line)
+			}
+		}
+		if v != n.Value {
+			return replaceScalar(n, v)
+		}
+	case *ast.MappingNode:
+		for _, mv := range n.Values {
+			mv.Value = substitute(mv.Value, vars, warn)
+		}
+	case *ast.MappingValueNode:
+		n.Value = substitute(n.Value, vars, warn)
+	case *ast.SequenceNode:
+		for i, v := range n.Values {
+			n.Values[i] = substitute(v, vars, warn)
+		}
+	case *ast.TagNode:
+		n.Value = substitute(n.Value, vars, warn)
+	case *ast.AnchorNode:
+		n.Value = substitute(n.Value, vars, warn)
+	}
+	return n
+}
+
+// takeKey removes key from mn and returns its value.
+func takeKey(mn *ast.MappingNode, key string) (ast.Node, bool) {
+	for i, mv := range mn.Values {
+		if k, _ := scalarString(mv.Key); k == key {
+			mn.Values = slices.Delete(mn.Values, i, i+1)
+			return mv.Value, true
+		}
+	}
+	return nil, false
+}
+
+func asMapping(n ast.Node) (*ast.MappingNode, bool) {
+	switch n := n.(type) {
+	case *ast.MappingNode:
+		return n, true
+	case *ast.MappingValueNode:
+		return ast.Mapping(n.GetToken(), false, n), true
+	}
+	return nil, false
+}
+
+// merge adds the values of src dst does not have. The mappings in both
+// are merged and the lists are joined, src first, like ESPHome packages.
+func merge(dst, src *ast.MappingNode) {
+	for _, smv := range src.Values {
+		key, _ := scalarString(smv.Key)
+		i := slices.IndexFunc(dst.Values, func(mv *ast.MappingValueNode) bool {
+			k, _ := scalarString(mv.Key)
+			return k == key
+		})
+		if i < 0 {
+			dst.Values = append(dst.Values, smv)
+			continue
+		}
+		dmv := dst.Values[i]
+		if dmv.Value == nil || dmv.Value.Type() == ast.NullType {
+			dmv.Value = smv.Value
+			continue
+		}
+		dm, dok := asMapping(dmv.Value)
+		sm, sok := asMapping(smv.Value)
+		if dok && sok {
+			merge(dm, sm)
+			dmv.Value = dm
+			continue
+		}
+		ds, dok := dmv.Value.(*ast.SequenceNode)
+		ss, sok := smv.Value.(*ast.SequenceNode)
+		if dok && sok {
+			ds.Values = append(slices.Clone(ss.Values), ds.Values...)
+		}
+	}
+}
+
+// packages returns the packages of n, a mapping of names to mappings, to
+// files or to directories of files.
+func (l *loader) packages(n ast.Node, dir string) ([]*ast.MappingNode, []string, error) {
+	if n == nil || n.Type() == ast.NullType {
+		return nil, nil, nil
+	}
+	mn, ok := asMapping(n)
+	if !ok {
+		return nil, nil, l.wrap(&yaml.UnexpectedNodeTypeError{Actual: n.Type(), Expected: ast.MappingType, Token: n.GetToken()})
+	}
+	ret := []*ast.MappingNode{}
+	dirs := []string{}
+	add := func(n ast.Node, pdir string) error {
+		pm, ok := asMapping(n)
+		if !ok {
+			return l.wrap(&yaml.UnexpectedNodeTypeError{Actual: n.Type(), Expected: ast.MappingType, Token: n.GetToken()})
+		}
+		ret = append(ret, pm)
+		dirs = append(dirs, pdir)
+		return nil
+	}
+	for _, mv := range mn.Values {
+		s, ok := mv.Value.(*ast.StringNode)
+		if !ok {
+			err := add(mv.Value, dir)
+			if err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		path := s.Value
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		files := []string{path}
+		if st, err := os.Stat(path); err == nil && st.IsDir() {
+			files, err = packageDir(path)
+			if err != nil {
+				return nil, nil, l.wrap(&yaml.SyntaxError{Token: s.Token, Message: err.Error()})
+			}
+		}
+		for _, f := range files {
+			body, err := l.include(f, s.Token)
+			if err != nil {
+				return nil, nil, err
+			}
+			err = add(body, filepath.Dir(f))
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return ret, dirs, nil
+}
+
+// packageDir lists the yaml files of a package directory in name order.
+func packageDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	ret := []string{}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") || e.Name() == SecretsFile {
+			continue
+		}
+		ret = append(ret, filepath.Join(dir, e.Name()))
+	}
+	return ret, nil
+}
+
+// expandPackages merges the packages of mn into it and returns the
+// substitutions of mn and its packages, mn wins over its packages.
+func (l *loader) expandPackages(mn *ast.MappingNode, dir string) (map[string]string, error) {
+	subs, _ := takeKey(mn, "substitutions")
+	vars, err := l.substitutions(subs)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, _ := takeKey(mn, "packages")
+	packages, dirs, err := l.packages(pkgs, dir)
+	if err != nil {
+		return nil, err
+	}
+	// merged last first, so the earlier packages come first in the lists
+	// and the later ones win
+	for i, p := range slices.Backward(packages) {
+		pvars, err := l.expandPackages(p, dirs[i])
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range pvars {
+			if _, ok := vars[k]; !ok {
+				vars[k] = v
+			}
+		}
+		merge(mn, p)
+	}
+	if err := l.resolveVars(vars, l.substituted); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// load resolves the tags, merges the packages and expands the
+// substitutions of the config root.
+func (l *loader) load(root ast.Node) (ast.Node, error) {
+	root, err := l.resolveTags(root, l.dir)
+	if err != nil {
+		return nil, err
+	}
+	mn, ok := asMapping(root)
+	if !ok {
+		return root, nil
+	}
+	vars, err := l.expandPackages(mn, l.dir)
+	if err != nil {
+		return nil, err
+	}
+	substitute(mn, vars, true)
+	if l.esphome != nil {
+		err = l.esphome.convert(l, mn)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return mn, nil
+}
@@ -0,0 +1,323 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gosthome/gosthome/components/api"
+	"github.com/gosthome/gosthome/components/button"
+	"github.com/gosthome/gosthome/components/uart"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/matryer/is"
+)
+
+// writeFiles writes files into a temporary directory and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSubstitutions(t *testing.T) {
+	is := is.New(t)
+	dir := writeFiles(t, map[string]string{
+		"secrets.yaml": `
+api_key: 9kD0vcdCbh9UQWaSCUJXsX3Rt0PWj5BHWoqMTI2TTkM=
+`,
+		"node.yaml": `
+substitutions:
+  name: kitchen
+  friendly: ${name} node
+  port: "6969"
+
+gosthome:
+  name: $name
+  friendly_name: "${friendly}"
+  mac: 00:aa:bb:cc:dd:ee
+
+api: !include
+  file: common/api.yaml
+  vars:
+    address: 127.0.0.1
+`,
+		"common/api.yaml": `
+address: ${address}
+port: ${port}
+encryption:
+  key: !secret api_key
+`,
+	})
+	cfg, err := config.LoadConfigFile(filepath.Join(dir, "node.yaml"))
+	is.NoErr(err)
+	is.Equal(cfg.Gosthome.Name, "kitchen")
+	is.Equal(cfg.Gosthome.FriendlyName, "kitchen node")
+	ac := cfg.Components["api"].Config.(*api.Config)
+	is.Equal(ac.Address, "127.0.0.1")
+	is.Equal(ac.Port, uint16(6969))
+	is.Equal(ac.Encryption.Key.String(), "9kD0vcdCbh9UQWaSCUJXsX3Rt0PWj5BHWoqMTI2TTkM=")
+	is.Equal(cfg.Files, []string{
+		filepath.Join(dir, "node.yaml"),
+		filepath.Join(dir, "common", "api.yaml"),
+		filepath.Join(dir, "secrets.yaml"),
+	})
+
+	shown, err := cfg.MarshalRedacted(context.Background())
+	is.NoErr(err)
+	is.True(!strings.Contains(string(shown), "9kD0vcdCbh9UQWaSCUJXsX3Rt0PWj5BHWoqMTI2TTkM="))
+	is.True(strings.Contains(string(shown), `key: !secret "api_key"`))
+	is.NoErr(os.WriteFile(filepath.Join(dir, "shown.yaml"), shown, 0o600))
+	again, err := config.LoadConfigFile(filepath.Join(dir, "shown.yaml"))
+	is.NoErr(err) // the shown config loads with the same secrets
+	is.Equal(again.Components["api"].Config.(*api.Config).Encryption.Key.String(), ac.Encryption.Key.String())
+}
+
+func TestMarshalRedactedPassword(t *testing.T) {
+	is := is.New(t)
+	cfg, err := config.LoadConfig(strings.NewReader(`
+gosthome:
+  name: kitchen
+  mac: 00:aa:bb:cc:dd:ee
+api:
+  password: hunter2
+`))
+	is.NoErr(err)
+	shown, err := cfg.MarshalRedacted(context.Background())
+	is.NoErr(err)
+	is.True(!strings.Contains(string(shown), "hunter2"))
+	is.True(strings.Contains(string(shown), `password: "<redacted>"`))
+}
+
+func TestMarshalRedactedSecrets(t *testing.T) {
+	is := is.New(t)
+	dir := writeFiles(t, map[string]string{
+		"secrets.yaml": `
+api_password: hunter2
+api_port: "6053"
+`,
+		"node.yaml": `
+gosthome:
+  name: "6053"
+  mac: 00:aa:bb:cc:dd:ee
+api:
+  port: !secret api_port
+  password: !secret api_password
+`,
+	})
+	cfg, err := config.LoadConfigFile(filepath.Join(dir, "node.yaml"))
+	is.NoErr(err)
+	shown, err := cfg.MarshalRedacted(context.Background())
+	is.NoErr(err)
+	is.True(strings.Contains(string(shown), `port: !secret "api_port"`))
+	is.True(strings.Contains(string(shown), `password: !secret "api_password"`))
+	// only the values read with !secret are references
+	is.True(strings.Contains(string(shown), `name: "6053"`))
+	is.True(!strings.Contains(string(shown), "hunter2"))
+	// the defaults are filled in
+	is.True(strings.Contains(string(shown), "shutdown_timeout: 10s"))
+}
+
+func TestPackages(t *testing.T) {
+	is := is.New(t)
+	dir := writeFiles(t, map[string]string{
+		"node.yaml": `
+substitutions:
+  name: hall
+
+packages:
+  base: base.yaml
+  buttons: buttons/
+  inline:
+    button:
+      - platform: uart
+        name: inline
+        data: "i"
+
+gosthome:
+  name: ${name}
+
+button:
+  - platform: uart
+    name: main
+    data: "m"
+`,
+		"base.yaml": `
+substitutions:
+  name: overridden
+  mac: 00:aa:bb:cc:dd:ee
+
+gosthome:
+  name: base
+  mac: ${mac}
+
+uart:
+  port: /dev/ttyS0
+  baud_rate: 9600
+`,
+		"buttons/a.yaml": `
+button:
+  - platform: uart
+    name: ${name}_a
+    data: "a"
+`,
+		"buttons/b.yml": `
+button:
+  - platform: uart
+    name: b
+    data: "b"
+`,
+		"buttons/readme.txt": `not a package`,
+	})
+	cfg, err := config.LoadConfigFile(filepath.Join(dir, "node.yaml"))
+	is.NoErr(err)
+	is.Equal(cfg.Gosthome.Name, "hall")
+	is.Equal(cfg.Gosthome.MAC.String(), "00:aa:bb:cc:dd:ee")
+	_, ok := cfg.Components["uart"]
+	is.True(ok)
+	buttons := cfg.Components["button"].Config.(*button.Config)
+	names := []string{}
+	for _, b := range buttons.Configs {
+		names = append(names, b.Config.Config.(*uart.ButtonConfig).Name)
+	}
+	is.Equal(names, []string{"hall_a", "b", "inline", "main"})
+}
+
+func TestIncludeErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"unknown.yaml": `
+gosthome:
+  name: a
+  mac: 00:aa:bb:cc:dd:ee
+packages:
+  bad: bad.yaml
+`,
+		"bad.yaml": `
+nosuchcomponent:
+  a: b
+`,
+		"cycle.yaml": `
+gosthome: !include cycle.yaml
+`,
+		"secret.yaml": `
+gosthome:
+  name: !secret missing
+  mac: 00:aa:bb:cc:dd:ee
+`,
+		"secrets.yaml": `
+other: x
+`,
+		"doubling.yaml": `
+substitutions:
+  a: "${a}${a}y"
+gosthome:
+  name: $a
+  mac: 00:aa:bb:cc:dd:ee
+`,
+		"growing.yaml": `
+substitutions:
+  a: "${a}-k"
+  b: $a
+gosthome:
+  name: $b
+  mac: 00:aa:bb:cc:dd:ee
+`,
+		"substitution_cycle.yaml": `
+substitutions:
+  a: ${b}
+  b: ${a}
+gosthome:
+  name: $a
+  mac: 00:aa:bb:cc:dd:ee
+`,
+	})
+	t.Run("unknown_component_in_package", func(t *testing.T) {
+		is := is.New(t)
+		_, err := config.LoadConfigFile(filepath.Join(dir, "unknown.yaml"))
+		var fe *config.FileError
+		is.True(errors.As(err, &fe))
+		is.Equal(fe.File, filepath.Join(dir, "bad.yaml"))
+		is.True(strings.Contains(err.Error(), "[3:4] cannot parse unknown component: nosuchcomponent"))
+	})
+	t.Run("cycle", func(t *testing.T) {
+		is := is.New(t)
+		_, err := config.LoadConfigFile(filepath.Join(dir, "cycle.yaml"))
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "include cycle"))
+	})
+	t.Run("missing_secret", func(t *testing.T) {
+		is := is.New(t)
+		_, err := config.LoadConfigFile(filepath.Join(dir, "secret.yaml"))
+		is.True(err != nil)
+		is.True(strings.Contains(err.Error(), "[3:9] secret missing is not in secrets.yaml"))
+	})
+	for _, tc := range []struct {
+		file string
+		msg  string
+	}{
+		{"doubling.yaml", "[3:3] substitution a refers to itself"},
+		{"growing.yaml", "[3:3] substitution a refers to itself"},
+		{"substitution_cycle.yaml", "[3:3] substitutions refer to each other: a -> b -> a"},
+	} {
+		t.Run(strings.TrimSuffix(tc.file, ".yaml"), func(t *testing.T) {
+			is := is.New(t)
+			_, err := config.LoadConfigFile(filepath.Join(dir, tc.file))
+			var fe *config.FileError
+			is.True(errors.As(err, &fe))
+			is.Equal(fe.File, filepath.Join(dir, tc.file))
+			is.True(strings.Contains(err.Error(), tc.msg))
+		})
+	}
+}
+
+func TestErrorLocation(t *testing.T) {
+	is := is.New(t)
+	dir := writeFiles(t, map[string]string{
+		"node.yaml": `
+gosthome:
+  name: a
+  mac: 00:aa:bb:cc:dd:ee
+packages:
+  api: api.yaml
+`,
+		"api.yaml": `
+api:
+  port: abc
+`,
+	})
+	_, err := config.LoadConfigFile(filepath.Join(dir, "node.yaml"))
+	var fe *config.FileError
+	is.True(errors.As(err, &fe))
+	is.Equal(fe.File, filepath.Join(dir, "api.yaml"))
+	is.Equal(fe.Line, 3)
+	is.Equal(fe.Column, 9)
+	is.Equal(fe.Path, "$.api.port")
+	is.True(strings.HasPrefix(fe.Message(), "cannot unmarshal string"))
+
+	_, err = config.LoadConfig(strings.NewReader(`
+gosthome:
+  name: a
+  mac: 00:aa:bb:cc:dd:ee
+button:
+  - platform: uart
+    name: x
+    icon: bad
+`))
+	is.True(errors.As(err, &fe))
+	is.Equal(fe.File, "")
+	is.Equal(fe.Path, "$.button[0].icon")
+	is.Equal(fe.Line, 8)
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"context"
+	"maps"
+	"slices"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/goccy/go-yaml"
@@ -30,7 +32,12 @@ func (c *Configs) ValidateWithContext(ctx context.Context) error {
 
 // MarshalYAML implements yaml.InterfaceMarshalerContext.
 func (c *Configs) MarshalYAML(context.Context) (interface{}, error) {
-	panic("unimplemented")
+	keys := slices.Sorted(maps.Keys(*c))
+	ret := make(yaml.MapSlice, 0, len(keys))
+	for _, k := range keys {
+		ret = append(ret, yaml.MapItem{Key: k, Value: (*c)[k]})
+	}
+	return ret, nil
 }
 
 // UnmarshalYAML implements yaml.InterfaceUnmarshaler.
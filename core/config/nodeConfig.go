@@ -8,11 +8,19 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/gosthome/gosthome/core/automation"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component/cid"
 	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/preferences"
 	"github.com/gosthome/gosthome/core/registry"
 )
 
@@ -21,10 +29,23 @@ type Config struct {
 
 	Gosthome   GosthomeConfig `yaml:"gosthome"`
 	Components Configs        `yaml:",inline"`
+
+	// Ignored lists what the ESPHome compatibility mode left out of the
+	// config.
+	Ignored []IgnoredKey `yaml:"-"`
+	// Secrets maps the YAML paths of the values read with !secret, e.g.
+	// $.api.encryption.key, to the names of their secrets.
+	Secrets map[string]string `yaml:"-"`
+	// Files are the files the config was read from: the main config, the
+	// included files, the packages and the secrets.
+	Files []string `yaml:"-"`
 }
 
 type lcOpt struct {
-	cr *registry.Registry
+	cr      *registry.Registry
+	dir     string
+	file    string
+	esphome bool
 }
 
 type loadConfigOption func(*lcOpt)
@@ -35,29 +56,99 @@ func WithRegistry(reg *registry.Registry) loadConfigOption {
 	}
 }
 
+// WithDir sets the directory !include, packages and !secret are relative
+// to, it defaults to the working directory.
+func WithDir(dir string) loadConfigOption {
+	return func(lo *lcOpt) {
+		lo.dir = dir
+	}
+}
+
+// WithESPHomeCompat lets the config be an ESPHome one: esphome: is
+// gosthome:, the blocks of the hardware and the keys gosthome has no use for
+// are left out and listed in Config.Ignored.
+func WithESPHomeCompat(on bool) loadConfigOption {
+	return func(lo *lcOpt) {
+		lo.esphome = on
+	}
+}
+
+// LoadConfigFile loads the config in path, the files it includes are
+// relative to it.
+func LoadConfigFile(path string, opts ...loadConfigOption) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	opts = append([]loadConfigOption{WithDir(filepath.Dir(path))}, opts...)
+	opts = append(opts, func(lo *lcOpt) {
+		lo.file = path
+	})
+	return LoadConfig(f, opts...)
+}
+
 func LoadConfig(r io.Reader, opts ...loadConfigOption) (*Config, error) {
 	o := lcOpt{
-		cr: registry.DefaultRegistry(),
+		cr:  registry.DefaultRegistry(),
+		dir: ".",
 	}
 	for _, opt := range opts {
 		opt(&o)
 	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	l := newLoader(o.dir, o.file)
+	if o.esphome {
+		l.esphome = newESPHomeCompat(o.cr)
+	}
+	f, err := parser.ParseBytes(data, 0)
+	if err != nil {
+		return nil, l.wrap(err)
+	}
+	if len(f.Docs) == 0 || f.Docs[0].Body == nil {
+		return nil, io.EOF
+	}
+	root, err := l.load(f.Docs[0].Body)
+	if err != nil {
+		return nil, locate(err, f.Docs[0].Body)
+	}
 	ctx := context.Background()
 	valid := &cv.Validator{}
-	dec := yaml.NewDecoder(r, yaml.Validator(valid))
+	dec := yaml.NewDecoder(bytes.NewReader(data), yaml.Validator(valid))
 	ctx = context.WithValue(ctx, cv.ConfigYAMLDecoderKey{}, dec)
 	ctx = context.WithValue(ctx, cv.ComponentRegistryKey{}, o.cr)
 	valid.Context = ctx
 	ret := &Config{
 		Registry: o.cr,
 	}
-	err := dec.DecodeContext(ctx, ret)
+	err = dec.DecodeFromNodeContext(ctx, root, ret)
 	if err != nil {
-		return nil, err
+		return nil, locate(l.wrap(err), root)
+	}
+	// the ids components refer to are known once all of them are decoded
+	if err := cid.Resolve(ret); err != nil {
+		return nil, locate(l.wrap(pathToken(err, root)), root)
 	}
+	if l.esphome != nil {
+		ret.Ignored = l.esphome.ignored
+	}
+	ret.Secrets = l.secretPaths(root)
+	ret.Files = l.read
 	return ret, nil
 }
 
+// MarshalYAML implements yaml.InterfaceMarshalerContext.
+func (c *Config) MarshalYAML(ctx context.Context) (interface{}, error) {
+	components, err := c.Components.MarshalYAML(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(yaml.MapSlice{{Key: "gosthome", Value: &c.Gosthome}}, components.(yaml.MapSlice)...), nil
+}
+
 // Validate implements validation.Validatable.
 func (c *Config) ValidateWithContext(ctx context.Context) error {
 	return cv.ValidateEmbedded(
@@ -68,6 +159,9 @@ func (c *Config) ValidateWithContext(ctx context.Context) error {
 	)
 }
 
+// DefaultShutdownTimeout is used when the config has no shutdown_timeout.
+const DefaultShutdownTimeout = 10 * time.Second
+
 type GosthomeConfig struct {
 	Name         string `yaml:"name"`
 	FriendlyName string `yaml:"friendly_name"`
@@ -79,9 +173,30 @@ type GosthomeConfig struct {
 
 	Project GosthomeProject `yaml:"project"`
 
-	// OnBoot string
-	// OnShutdown string
-	// OnLoop string
+	// ShutdownTimeout bounds the time components get to close.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	// DataDir holds the preferences of the node, they are kept in memory
+	// only without it.
+	DataDir string `yaml:"data_dir"`
+	// FlushInterval is how often changed preferences are written to DataDir.
+	FlushInterval time.Duration `yaml:"flush_interval"`
+
+	// JournalSize is the number of bus events kept for replay, 0 keeps none.
+	JournalSize int `yaml:"journal_size"`
+	// QueueSize bounds the queues of the bus subscribers, it defaults to
+	// bus.DefaultQueueSize.
+	QueueSize int `yaml:"queue_size"`
+	// QueueOverflow is what a full event subscriber queue does with new
+	// events, block by default: the emitter waits and no event is lost.
+	// Service handlers always block.
+	QueueOverflow bus.OverflowPolicy `yaml:"queue_overflow"`
+
+	// OnBoot runs once the components are set up.
+	OnBoot automation.Actions `yaml:"on_boot"`
+	// OnShutdown runs before the components are closed, within the
+	// shutdown_timeout.
+	OnShutdown automation.Actions `yaml:"on_shutdown"`
 }
 
 // Validate implements validation.Validatable.
@@ -91,9 +206,28 @@ func (g GosthomeConfig) ValidateWithContext(ctx context.Context) error {
 		validation.Field(&g.FriendlyName),
 		validation.Field(&g.MAC, validation.Required),
 		validation.Field(&g.Project),
+		validation.Field(&g.ShutdownTimeout, validation.Min(time.Duration(0))),
+		validation.Field(&g.FlushInterval, validation.Min(time.Duration(0))),
+		validation.Field(&g.JournalSize, validation.Min(0)),
+		validation.Field(&g.QueueSize, validation.Min(0)),
 	)
 }
 
+// WithDefaults returns g with the defaults the node uses for the settings
+// left unset.
+func (g GosthomeConfig) WithDefaults() GosthomeConfig {
+	if g.ShutdownTimeout == 0 {
+		g.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if g.FlushInterval <= 0 {
+		g.FlushInterval = preferences.DefaultFlushInterval
+	}
+	if g.QueueSize <= 0 {
+		g.QueueSize = bus.DefaultQueueSize
+	}
+	return g
+}
+
 type GosthomeProject struct {
 	Name    string
 	Version string
@@ -3,8 +3,10 @@ package config_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"os"
+	"reflect"
 	"testing"
 	"unsafe"
 
@@ -15,6 +17,8 @@ import (
 	_ "github.com/gosthome/gosthome/components"
 	"github.com/gosthome/gosthome/components/api"
 	"github.com/gosthome/gosthome/components/api/frameshakers"
+	"github.com/gosthome/gosthome/components/button"
+	"github.com/gosthome/gosthome/components/uart"
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/component/cid"
 	"github.com/gosthome/gosthome/core/config"
@@ -38,7 +42,8 @@ type testComponent struct {
 }
 
 // Setup implements component.Component.
-func (t *testComponent) Setup() {
+func (t *testComponent) Setup(ctx context.Context) error {
+	return nil
 }
 
 // InitializationPriority implements component.Component.
@@ -46,15 +51,15 @@ func (t *testComponent) InitializationPriority() component.InitializationPriorit
 	return component.InitializationPriorityBus
 }
 
-func (t *testComponent) Close() error {
+func (t *testComponent) Close(ctx context.Context) error {
 	return nil
 }
 
 var _ component.Component = (*testComponent)(nil)
 
 type testComponentConfig struct {
-	component.ConfigOf[testComponent, *testComponent]
-	A string `yaml:"a"`
+	component.ConfigOf[testComponent, *testComponent] `yaml:"-"`
+	A                                                 string `yaml:"a"`
 }
 
 // Validate implements component.ComponentConfig.
@@ -298,3 +303,217 @@ api:
 		})
 	}
 }
+
+func TestMarshalConfig(t *testing.T) {
+	is := is.New(t)
+	cfg, err := config.LoadConfig(bytes.NewBufferString(`
+gosthome:
+  name: exampl
+  mac: 00:aa:bb:cc:dd:ee
+
+uart:
+  port: /dev/ttyS0
+  baud_rate: 9600
+
+button:
+  - platform: uart
+    name: a
+    data: "a"
+  - platform: uart
+    name: b
+    data: "b"
+`))
+	is.NoErr(err)
+	data, err := yaml.MarshalContext(context.Background(), cfg)
+	is.NoErr(err)
+	is.True(bytes.HasPrefix(data, []byte("gosthome:\n  name: exampl\n")))
+	is.True(bytes.Contains(data, []byte("button:\n- platform: uart\n")))
+	// the marshalled config loads back into the same config
+	again, err := config.LoadConfig(bytes.NewReader(data))
+	is.NoErr(err)
+	againData, err := yaml.MarshalContext(context.Background(), again)
+	is.NoErr(err)
+	is.Equal(string(againData), string(data))
+}
+
+func TestIDReferences(t *testing.T) {
+	const head = `
+gosthome:
+  name: refs
+  mac: 00:aa:bb:cc:dd:ee
+`
+	for _, tc := range []struct {
+		name   string
+		config string
+		uartID string
+		line   int
+		msg    string
+	}{
+		{
+			name: "implicit",
+			config: `
+uart:
+  port: /dev/ttyS0
+button:
+  - platform: uart
+    name: a
+    data: "a"
+`,
+			uartID: "uart",
+		},
+		{
+			name: "explicit",
+			config: `
+uart:
+  - id: first
+    port: /dev/ttyS0
+  - id: second
+    port: /dev/ttyS0
+button:
+  - platform: uart
+    name: a
+    uart_id: second
+    data: "a"
+`,
+			uartID: "second",
+		},
+		{
+			name: "missing",
+			config: `
+uart:
+  id: serial
+  port: /dev/ttyS0
+button:
+  - platform: uart
+    name: a
+    uart_id: seria
+    data: "a"
+`,
+			line: 12,
+			msg:  `there is no uart with id "seria"`,
+		},
+		{
+			name: "wrong_type",
+			config: `
+api:
+  id: serial
+uart:
+  port: /dev/ttyS0
+button:
+  - platform: uart
+    name: a
+    uart_id: serial
+    data: "a"
+`,
+			line: 13,
+			msg:  `"serial" is the id of the api server, not of a uart`,
+		},
+		{
+			name: "ambiguous",
+			config: `
+uart:
+  - id: first
+    port: /dev/ttyS0
+  - id: second
+    port: /dev/ttyS0
+button:
+  - platform: uart
+    name: a
+    data: "a"
+`,
+			line: 12,
+			msg:  "$.button[0].uart_id: the config has 2 of type uart, the id of one is needed",
+		},
+		{
+			name: "duplicate",
+			config: `
+api:
+  id: dup
+webserver:
+  id: dup
+`,
+			line: 9,
+			msg:  `$.webserver: id "dup" is already the id of the api server at $.api`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			cfg, err := config.LoadConfig(bytes.NewBufferString(head + tc.config))
+			if tc.msg != "" {
+				var fe *config.FileError
+				is.True(errors.As(err, &fe))
+				is.Equal(fe.Line, tc.line)
+				is.Equal(fe.Message(), tc.msg)
+				return
+			}
+			is.NoErr(err)
+			buttons := cfg.Components["button"].Config.(*button.Config)
+			is.Equal(buttons.Configs[0].Config.Config.(*uart.ButtonConfig).UARTID.ID, tc.uartID)
+		})
+	}
+}
+
+// linked is a declaration and a Linker with a reference in it.
+type linked struct {
+	cid.IDConfig[testComponent] `yaml:",inline"`
+	Target                      cid.Ref[uart.UART] `yaml:"target"`
+	types                       []reflect.Type
+}
+
+func (l *linked) LinkIDs(types func(id string) []reflect.Type) error {
+	l.types = types(l.ID)
+	return nil
+}
+
+func TestResolveWalk(t *testing.T) {
+	is := is.New(t)
+	type config struct {
+		UART   cid.IDConfig[uart.UART]        `yaml:"uart"`
+		Linked linked                         `yaml:"linked"`
+		ByPin  map[int]cid.Ref[uart.UART]     `yaml:"by_pin"`
+		ByName map[string]cid.Ref[uart.UART]  `yaml:"by_name"`
+		Others map[int]cid.IDConfig[struct{}] `yaml:"others"`
+	}
+	cfg := &config{
+		UART:   cid.IDConfig[uart.UART]{ID: "serial"},
+		Linked: linked{IDConfig: cid.IDConfig[testComponent]{ID: "l"}},
+		ByPin:  map[int]cid.Ref[uart.UART]{3: {}},
+		ByName: map[string]cid.Ref[uart.UART]{"a": {ID: "serial"}},
+	}
+	is.NoErr(cid.Resolve(cfg))
+	is.Equal(cfg.Linked.Target.ID, "serial")                                     // the reference in the linker is resolved
+	is.Equal(cfg.Linked.types, []reflect.Type{reflect.TypeFor[testComponent]()}) // and the linker is linked with its own declaration
+	is.Equal(cfg.ByPin[3].ID, "serial")                                          // the values of maps get their ids
+
+	cfg.ByPin = map[int]cid.Ref[uart.UART]{3: {ID: "seria"}}
+	var pe *cid.PathError
+	is.True(errors.As(cid.Resolve(cfg), &pe))
+	is.Equal(pe.Path, "$.by_pin[3]")
+
+	cfg.ByPin = nil
+	cfg.Others = map[int]cid.IDConfig[struct{}]{7: {ID: "l"}}
+	is.True(errors.As(cid.Resolve(cfg), &pe))
+	is.Equal(pe.Path, "$.others[7]")
+}
+
+func TestIDReferencesEqual(t *testing.T) {
+	is := is.New(t)
+	load := func(extra string) *config.Config {
+		cfg, err := config.LoadConfig(bytes.NewBufferString(extra + `
+gosthome:
+  name: refs
+  mac: 00:aa:bb:cc:dd:ee
+uart:
+  port: /dev/ttyS0
+button:
+  - platform: uart
+    name: a
+    uart_id: uart
+    data: "a"
+`))
+		is.NoErr(err)
+		return cfg
+	}
+	// the references moved in the config, their ids did not
+	is.True(load("").Components["button"].Equal(load("\n\n").Components["button"]))
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"bytes"
 	"context"
 
 	"github.com/gosthome/gosthome/core/component"
@@ -32,8 +33,58 @@ func (p *PlatformConfig) ValidateWithContext(ctx context.Context) error {
 }
 
 // MarshalYAML implements yaml.InterfaceMarshalerContext.
-func (p *PlatformConfig) MarshalYAML(context.Context) (interface{}, error) {
-	panic("unimplemented")
+func (p *PlatformConfig) MarshalYAML(ctx context.Context) (interface{}, error) {
+	ret := make([]rawYAML, 0, len(p.Configs))
+	for _, pc := range p.Configs {
+		// the platform is a key of the config of the platform
+		platform, err := yaml.Marshal(yaml.MapSlice{{Key: "platform", Value: pc.Platform}})
+		if err != nil {
+			return nil, err
+		}
+		data, err := yaml.MarshalContext(ctx, pc.Config)
+		if err != nil {
+			return nil, err
+		}
+		if string(bytes.TrimSpace(data)) == "{}" {
+			data = nil
+		}
+		ret = append(ret, append(platform, data...))
+	}
+	return ret, nil
+}
+
+// rawYAML is marshalled as is, it keeps the tags of the config, e.g.
+// !lambda.
+type rawYAML []byte
+
+// MarshalYAML implements yaml.BytesMarshaler.
+func (r rawYAML) MarshalYAML() ([]byte, error) {
+	return r, nil
+}
+
+// JSONSchema implements cv.JSONSchemer.
+func (p *PlatformConfig) JSONSchema(ctx context.Context, schema func(v any) map[string]any) map[string]any {
+	cr := ctx.Value(cv.ComponentRegistryKey{}).(*registry.Registry)
+	platforms := []any{}
+	for _, name := range cr.Platforms(p.DomainType) {
+		cd, _ := cr.GetEntityComponent(p.DomainType, name)
+		s := schema(cd.Config().Config)
+		// the platform is a key of the config of the platform
+		props, ok := s["properties"].(map[string]any)
+		if !ok {
+			props = map[string]any{}
+			s["properties"] = props
+		}
+		props["platform"] = map[string]any{"const": name}
+		required, _ := s["required"].([]string)
+		s["required"] = append([]string{"platform"}, required...)
+		platforms = append(platforms, s)
+	}
+	var items any = false
+	if len(platforms) > 0 {
+		items = map[string]any{"oneOf": platforms}
+	}
+	return map[string]any{"type": "array", "items": items}
 }
 
 // UnmarshalYAML implements yaml.NodeUnmarshaler.
@@ -73,3 +124,4 @@ func (p *PlatformConfig) UnmarshalYAML(ctx context.Context, src ast.Node) error
 var _ yaml.InterfaceMarshalerContext = (*PlatformConfig)(nil)
 var _ yaml.NodeUnmarshalerContext = (*PlatformConfig)(nil)
 var _ cv.Validatable = (*PlatformConfig)(nil)
+var _ cv.JSONSchemer = (*PlatformConfig)(nil)
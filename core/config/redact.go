@@ -0,0 +1,93 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// Redacted replaces the passwords and keys in a shown config, like in the
+// api recordings.
+const Redacted = "<redacted>"
+
+// sensitiveKeys are the keys whose values are never shown.
+var sensitiveKeys = map[string]struct{}{
+	"password": {},
+	"key":      {},
+}
+
+// MarshalRedacted marshals the config to be shown with the defaults of the
+// gosthome section filled in. The values read with !secret are shown as
+// their !secret references, the other passwords and keys as Redacted.
+func (c *Config) MarshalRedacted(ctx context.Context) ([]byte, error) {
+	shown := *c
+	shown.Gosthome = c.Gosthome.WithDefaults()
+	data, err := yaml.MarshalContext(ctx, &shown)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parser.ParseBytes(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range f.Docs {
+		doc.Body, err = c.redact(doc.Body, "$", false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []byte(f.String() + "\n"), nil
+}
+
+// scalar parses a single scalar, tagged or not.
+func scalar(src string) (ast.Node, error) {
+	f, err := parser.ParseBytes([]byte(src), 0)
+	if err != nil {
+		return nil, err
+	}
+	return f.Docs[0].Body, nil
+}
+
+func (c *Config) redact(n ast.Node, path string, sensitive bool) (ast.Node, error) {
+	if name, ok := c.Secrets[path]; ok {
+		return scalar(fmt.Sprintf("!secret %s", strconv.Quote(name)))
+	}
+	var err error
+	switch n := n.(type) {
+	case *ast.MappingNode:
+		for _, mv := range n.Values {
+			key := mv.Key.GetToken().Value
+			_, sensitive := sensitiveKeys[key]
+			mv.Value, err = c.redact(mv.Value, path+"."+key, sensitive)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case *ast.MappingValueNode:
+		key := n.Key.GetToken().Value
+		_, sensitive := sensitiveKeys[key]
+		n.Value, err = c.redact(n.Value, path+"."+key, sensitive)
+		if err != nil {
+			return nil, err
+		}
+	case *ast.SequenceNode:
+		for i, v := range n.Values {
+			n.Values[i], err = c.redact(v, fmt.Sprintf("%s[%d]", path, i), false)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case ast.ScalarNode:
+		if _, ok := n.(*ast.NullNode); ok {
+			return n, nil
+		}
+		if sensitive {
+			return scalar(strconv.Quote(Redacted))
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gosthome/gosthome/core/automation"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/registry"
+)
+
+// Schema generates the JSON Schema of the configs of the components in cr,
+// for editors to complete and check configs.
+func Schema(cr *registry.Registry) map[string]any {
+	g := newSchemaGenerator(cr, true)
+	props := map[string]any{
+		"gosthome": g.schema(&GosthomeConfig{}),
+		"substitutions": map[string]any{
+			"type":                 "object",
+			"additionalProperties": map[string]any{"type": []any{"string", "number", "boolean"}},
+		},
+		"packages": map[string]any{
+			"type":                 "object",
+			"additionalProperties": map[string]any{"type": []any{"string", "object"}},
+		},
+	}
+	for _, name := range cr.Names() {
+		cd, _ := cr.Get(name)
+		// components with the default config are written as `name:`
+		props[name] = map[string]any{
+			"anyOf": []any{map[string]any{"type": "null"}, g.schema(cd.Config().Config)},
+		}
+	}
+	return map[string]any{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"title":                "gosthome config",
+		"type":                 "object",
+		"properties":           props,
+		"required":             []string{"gosthome"},
+		"additionalProperties": false,
+		// automations nest, their schemas refer to these
+		"definitions": map[string]any{
+			"actions": g.items(cr.ActionNames(), true, func(name string) any {
+				f, _ := cr.Action(name)
+				return f()
+			}),
+			"conditions": g.items(cr.ConditionNames(), false, func(name string) any {
+				f, _ := cr.Condition(name)
+				return f()
+			}),
+		},
+	}
+}
+
+// items generates the schema of automation.Actions or Conditions: a list
+// of single key mappings or one of them, actions can be in then: too.
+func (g *schemaGenerator) items(names []string, then bool, item func(name string) any) map[string]any {
+	variants := []any{}
+	for _, name := range names {
+		v := item(name)
+		s := g.schema(v)
+		if sh, ok := v.(automation.Shorthand); ok {
+			s = map[string]any{"anyOf": []any{s, g.schema(sh.Shorthand())}}
+		}
+		variants = append(variants, map[string]any{
+			"type":                 "object",
+			"properties":           map[string]any{name: s},
+			"required":             []string{name},
+			"additionalProperties": false,
+		})
+	}
+	single := map[string]any{"oneOf": variants}
+	ret := []any{
+		map[string]any{"type": "null"},
+		single,
+		map[string]any{"type": "array", "items": single},
+	}
+	if then {
+		ret = append(ret, map[string]any{
+			"type":                 "object",
+			"properties":           map[string]any{"then": map[string]any{"$ref": "#/definitions/actions"}},
+			"required":             []string{"then"},
+			"additionalProperties": false,
+		})
+	}
+	return map[string]any{"anyOf": ret}
+}
+
+// schemaGenerator generates the JSON Schema of config values from their
+// types, yaml tags, defaults and validation.
+type schemaGenerator struct {
+	ctx context.Context
+	// required finds the required fields, it validates the configs.
+	required bool
+}
+
+func newSchemaGenerator(cr *registry.Registry, required bool) *schemaGenerator {
+	return &schemaGenerator{
+		ctx:      context.WithValue(context.Background(), cv.ComponentRegistryKey{}, cr),
+		required: required,
+	}
+}
+
+// schema generates the schema of v, a config or a pointer to it.
+func (g *schemaGenerator) schema(v any) map[string]any {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer {
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+		rv = p
+	}
+	if rv.IsNil() {
+		rv = reflect.New(rv.Type().Elem())
+	}
+	return g.value(rv.Elem())
+}
+
+// value generates the schema of an addressable value.
+func (g *schemaGenerator) value(v reflect.Value) map[string]any {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return g.value(reflect.New(v.Type().Elem()).Elem())
+		}
+		return g.value(v.Elem())
+	}
+	switch p := v.Addr().Interface().(type) {
+	case cv.JSONSchemer:
+		return p.JSONSchema(g.ctx, g.schema)
+	case cv.Enum:
+		return substitutable(enumSchema(p.EnumValues()))
+	case entity.DeviceClassValues:
+		return enumSchema(p.DeviceClassValues())
+	case *time.Duration:
+		return map[string]any{"type": "string", "description": "duration, e.g. 10s or 1m30s"}
+	case encoding.TextUnmarshaler:
+		return map[string]any{"type": "string"}
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return substitutable(map[string]any{"type": "boolean"})
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return substitutable(map[string]any{"type": "integer"})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return substitutable(map[string]any{"type": "integer", "minimum": 0})
+	case reflect.Float32, reflect.Float64:
+		return substitutable(map[string]any{"type": "number"})
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{
+			"type":  "array",
+			"items": g.value(reflect.New(v.Type().Elem()).Elem()),
+		}
+	case reflect.Map:
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": g.value(reflect.New(v.Type().Elem()).Elem()),
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return map[string]any{}
+		}
+		return g.schema(v.Elem().Interface())
+	case reflect.Struct:
+		return g.object(v)
+	}
+	return map[string]any{}
+}
+
+// object generates the schema of a struct, its fields are its properties.
+func (g *schemaGenerator) object(v reflect.Value) map[string]any {
+	props := map[string]any{}
+	// keys maps the go names of the fields to their yaml keys
+	keys := map[string]string{}
+	g.fields(v, props, keys)
+	ret := map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if !g.required {
+		return ret
+	}
+	if required := g.requiredFields(v, keys); len(required) > 0 {
+		ret["required"] = required
+	}
+	return ret
+}
+
+func (g *schemaGenerator) fields(v reflect.Value, props map[string]any, keys map[string]string) {
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		key, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if key == "-" || !f.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if slices.Contains(strings.Split(opts, ","), "inline") {
+			if fv.Kind() == reflect.Pointer {
+				fv = reflect.New(fv.Type().Elem()).Elem()
+			}
+			g.fields(fv, props, keys)
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(f.Name)
+		}
+		s := g.value(fv)
+		if d, ok := defaultValue(fv); ok {
+			s["default"] = d
+		}
+		props[key] = s
+		keys[f.Name] = key
+	}
+}
+
+// requiredFields finds the fields the validation of v requires whatever the other
+// fields are: the ones still blank when the other strings are set.
+func (g *schemaGenerator) requiredFields(v reflect.Value, keys map[string]string) []string {
+	ret := []string{}
+	for field := range g.blank(v) {
+		key, ok := keys[field]
+		if !ok {
+			continue
+		}
+		other := reflect.New(v.Type()).Elem()
+		other.Set(v)
+		for f := range keys {
+			fv := other.FieldByName(f)
+			if f != field && fv.Kind() == reflect.String && fv.String() == "" {
+				fv.SetString("x")
+			}
+		}
+		if g.blank(other)[field] {
+			ret = append(ret, key)
+		}
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// blank validates v and finds the fields that are required but blank.
+func (g *schemaGenerator) blank(v reflect.Value) (ret map[string]bool) {
+	val, ok := v.Addr().Interface().(cv.Validatable)
+	if !ok {
+		return nil
+	}
+	// some validations need a loaded config, their fields are not required
+	defer func() {
+		if recover() != nil {
+			ret = nil
+		}
+	}()
+	var errs validation.Errors
+	if !errors.As(val.ValidateWithContext(g.ctx), &errs) {
+		return nil
+	}
+	ret = map[string]bool{}
+	for field, err := range errs {
+		var e validation.Error
+		if errors.As(err, &e) && (e.Code() == validation.ErrRequired.Code() || e.Code() == validation.ErrNilOrNotEmpty.Code()) {
+			ret[field] = true
+		}
+	}
+	return ret
+}
+
+// defaultValue is the value of v in the config if it is not zero.
+func defaultValue(v reflect.Value) (any, bool) {
+	if v.Kind() == reflect.Pointer || v.IsZero() {
+		return nil, false
+	}
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String(), true
+	}
+	if m, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		return string(text), err == nil
+	}
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return v.Interface(), true
+	}
+	return nil, false
+}
+
+func enumSchema(values []string) map[string]any {
+	return map[string]any{"type": "string", "enum": values}
+}
+
+// substitutable lets a value that is not a string be a substitution.
+func substitutable(s map[string]any) map[string]any {
+	return map[string]any{
+		"anyOf": []any{s, map[string]any{"type": "string", "pattern": `\$\{?\w+`}},
+	}
+}
@@ -0,0 +1,66 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/core/registry"
+	"github.com/matryer/is"
+)
+
+// path walks a schema through the keys and the indices in it.
+func path(t *testing.T, s any, keys ...any) any {
+	t.Helper()
+	for _, k := range keys {
+		switch k := k.(type) {
+		case string:
+			m, ok := s.(map[string]any)
+			if !ok {
+				t.Fatalf("%v is not an object at %q", s, k)
+			}
+			s = m[k]
+		case int:
+			l, ok := s.([]any)
+			if !ok || len(l) <= k {
+				t.Fatalf("%v has no element %d", s, k)
+			}
+			s = l[k]
+		}
+	}
+	return s
+}
+
+func TestSchema(t *testing.T) {
+	is := is.New(t)
+	// the schema is checked as json, the way editors read it
+	data, err := json.Marshal(config.Schema(registry.DefaultRegistry()))
+	is.NoErr(err)
+	var s any
+	is.NoErr(json.Unmarshal(data, &s))
+
+	props := path(t, s, "properties")
+	is.Equal(path(t, props, "gosthome", "required"), []any{"mac"})
+	is.Equal(path(t, props, "gosthome", "properties", "shutdown_timeout", "type"), "string")
+
+	api := path(t, props, "api", "anyOf", 1, "properties")
+	is.Equal(path(t, api, "port", "default"), 6053.)
+	is.Equal(path(t, api, "port", "anyOf", 0, "type"), "integer")
+	is.Equal(path(t, api, "rate_limits", "items", "required"), []any{"entity", "interval"})
+
+	// uart takes one config or a list of them
+	uart := path(t, props, "uart", "anyOf", 1, "oneOf")
+	is.Equal(path(t, uart, 0, "properties", "parity", "anyOf", 0, "enum"), []any{"no", "odd", "even", "mark", "space"})
+	is.Equal(path(t, uart, 1, "items", "properties", "data_bits", "default"), 8.)
+
+	// every platform of a domain is one of its items
+	buttons := path(t, props, "button", "anyOf", 1, "items", "oneOf").([]any)
+	platforms := []any{}
+	for _, b := range buttons {
+		platforms = append(platforms, path(t, b, "properties", "platform", "const"))
+		is.Equal(path(t, b, "required", 0), "platform")
+	}
+	is.Equal(platforms, []any{"template", "uart"})
+	is.Equal(path(t, buttons[0], "properties", "device_class", "enum"), []any{"identify", "restart", "update"})
+	is.Equal(path(t, props, "demo", "anyOf", 1, "properties", "switches", "items", "properties", "restore_mode", "anyOf", 0, "enum", 0), "always_off")
+}
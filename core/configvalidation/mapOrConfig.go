@@ -17,7 +17,10 @@ type MapOrValue[Config any, PConfig interface {
 
 // MarshalYAML implements yaml.InterfaceMarshalerContext.
 func (m *MapOrValue[Config, PConfig]) MarshalYAML(context.Context) (interface{}, error) {
-	panic("unimplemented")
+	if len(m.Configs) == 1 {
+		return m.Configs[0], nil
+	}
+	return m.Configs, nil
 }
 
 // UnmarshalYAML implements yaml.InterfaceUnmarshalerContext.
@@ -55,8 +58,20 @@ func (m *MapOrValue[Config, PConfig]) UnmarshalYAML(ctx context.Context, node as
 	return nil
 }
 
+// JSONSchema implements JSONSchemer.
+func (m *MapOrValue[Config, PConfig]) JSONSchema(ctx context.Context, schema func(v any) map[string]any) map[string]any {
+	item := schema(PConfig(new(Config)))
+	if m.factory != nil {
+		item = schema(m.factory())
+	}
+	return map[string]any{
+		"oneOf": []any{item, map[string]any{"type": "array", "items": item}},
+	}
+}
+
 var _ yaml.InterfaceMarshalerContext = (*MapOrValue[string, *string])(nil)
 var _ yaml.NodeUnmarshalerContext = (*MapOrValue[string, *string])(nil)
+var _ JSONSchemer = (*MapOrValue[string, *string])(nil)
 
 func NewMapOrValue[Config any, PConfig interface {
 	*Config
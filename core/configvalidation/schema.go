@@ -0,0 +1,14 @@
+package cv
+
+import "context"
+
+// JSONSchemer is a config value that describes itself in the JSON Schema of
+// the config, schema gives the JSON Schema of other config values.
+type JSONSchemer interface {
+	JSONSchema(ctx context.Context, schema func(v any) map[string]any) map[string]any
+}
+
+// Enum is a config value that is one of its values.
+type Enum interface {
+	EnumValues() []string
+}
@@ -16,8 +16,10 @@ func (b *Bytes) ValidateWithContext(ctx context.Context) error {
 func (b *Bytes) Equal(other *Bytes) bool {
 	return bytes.Equal(b.Data, other.Data)
 }
-func (b *Bytes) MarshalText(text []byte) (err error) {
-	panic("unimpl")
+
+// MarshalText implements encoding.TextMarshaler.
+func (b *Bytes) MarshalText() ([]byte, error) {
+	return b.Data, nil
 }
 func (b *Bytes) UnmarshalText(text []byte) (err error) {
 	slog.Info("(b *Bytes) UnmarshalText(", "text", text)
@@ -1,28 +1,283 @@
 package cv
 
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// unitDef is a unit of a quantity, its spellings and how its values convert
+// to the base unit of the quantity.
+type unitDef struct {
+	names []string
+	// toBase is nil for the base unit.
+	toBase func(float64) float64
+}
+
 type unitOfMeasurement interface {
+	// Domain names the quantity in errors, e.g. frequency.
 	Domain() string
-	UnitNames() []string
+	// Units lists the units of the quantity, the first name of the first
+	// one is the base unit, a "" name takes values without a unit.
+	Units() []unitDef
 }
 
+// unit is a config value of a quantity, it is parsed from strings like
+// "115.2kHz" and kept in the base unit of the quantity.
 type unit[U any, P interface {
 	*U
 	unitOfMeasurement
 }] struct {
-	value float32
+	value float64
+}
+
+// metricPrefixes are the exponents of the SI prefixes allowed before any
+// unit.
+var metricPrefixes = map[string]string{
+	"p": "e-12",
+	"n": "e-9",
+	"u": "e-6",
+	"µ": "e-6",
+	"m": "e-3",
+	"c": "e-2",
+	"d": "e-1",
+	"h": "e2",
+	"k": "e3",
+	"K": "e3",
+	"M": "e6",
+	"G": "e9",
+	"T": "e12",
+}
+
+var unitRe = regexp.MustCompile(`^\s*([-+]?(?:\d+\.?\d*|\.\d+))\s*(.*?)\s*$`)
+
+// Value is the value in the base unit.
+func (u unit[U, P]) Value() float64 {
+	return u.value
+}
+
+// Unit is the base unit of the value.
+func (u unit[U, P]) Unit() string {
+	return P(nil).Units()[0].names[0]
+}
+
+func (u unit[U, P]) String() string {
+	return strconv.FormatFloat(u.value, 'g', -1, 64) + u.Unit()
+}
+
+// toBase converts number in the unit suffix, with an optional SI prefix, to
+// the base unit.
+func (u unit[U, P]) toBase(number, suffix string) (float64, bool) {
+	units := P(nil).Units()
+	// the prefix is added to the number as an exponent to keep 115.2k exact
+	convert := func(number, name string) (float64, bool) {
+		for _, ud := range units {
+			for _, n := range ud.names {
+				if n != name {
+					continue
+				}
+				value, err := strconv.ParseFloat(number, 64)
+				if err != nil {
+					return 0, false
+				}
+				if ud.toBase == nil {
+					return value, true
+				}
+				return ud.toBase(value), true
+			}
+		}
+		return 0, false
+	}
+	if v, ok := convert(number, suffix); ok {
+		return v, true
+	}
+	for prefix, exp := range metricPrefixes {
+		name, ok := strings.CutPrefix(suffix, prefix)
+		if !ok {
+			continue
+		}
+		if v, ok := convert(number+exp, name); ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *unit[U, P]) UnmarshalText(text []byte) error {
+	m := unitRe.FindStringSubmatch(string(text))
+	if m == nil {
+		return fmt.Errorf("%q is not a %s", text, P(nil).Domain())
+	}
+	value, ok := u.toBase(m[1], m[2])
+	if !ok {
+		names := []string{}
+		for _, ud := range P(nil).Units() {
+			for _, n := range ud.names {
+				if n != "" {
+					names = append(names, n)
+				}
+			}
+		}
+		return fmt.Errorf("%q is not a %s, the unit should be one of %v", text, P(nil).Domain(), names)
+	}
+	u.value = value
+	return nil
 }
 
-// frequency = float_with_unit("frequency", "(Hz|HZ|hz)?")
-// resistance = float_with_unit("resistance", "(Ω|Ω|ohm|Ohm|OHM)?")
-// current = float_with_unit("current", "(a|A|amp|Amp|amps|Amps|ampere|Ampere)?")
-// voltage = float_with_unit("voltage", "(v|V|volt|Volts)?")
-// distance = float_with_unit("distance", "(m)")
-// framerate = float_with_unit("framerate", "(FPS|fps|Fps|FpS|Hz)")
-// angle = float_with_unit("angle", "(°|deg)", optional_unit=True)
+// MarshalText implements encoding.TextMarshaler.
+func (u unit[U, P]) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// ValidateWithContext implements Validatable.
+func (u *unit[U, P]) ValidateWithContext(ctx context.Context) error {
+	return nil
+}
+
+// JSONSchema implements JSONSchemer.
+func (u *unit[U, P]) JSONSchema(ctx context.Context, schema func(v any) map[string]any) map[string]any {
+	return map[string]any{
+		"type":        []any{"string", "number"},
+		"description": fmt.Sprintf("%s, e.g. 10%s", P(nil).Domain(), u.Unit()),
+	}
+}
+
+type valueWithUnit interface {
+	Value() float64
+	Unit() string
+}
+
+// UnitRange checks that a value with a unit is between min and max, in its
+// base unit.
+func UnitRange(min, max float64) validation.Rule {
+	return validation.By(func(value interface{}) error {
+		v, ok := value.(valueWithUnit)
+		if !ok {
+			return validation.NewError("cv_not_a_unit", "this value should have a unit")
+		}
+		if v.Value() < min || v.Value() > max {
+			return validation.NewError("cv_unit_out_of_range", fmt.Sprintf(
+				"%s should be between %g%s and %g%s", v, min, v.Unit(), max, v.Unit()))
+		}
+		return nil
+	})
+}
+
+func linear(scale float64) func(float64) float64 {
+	return func(v float64) float64 { return v * scale }
+}
+
+type frequency struct{}
+
+func (*frequency) Domain() string { return "frequency" }
+func (*frequency) Units() []unitDef {
+	return []unitDef{{names: []string{"Hz", "HZ", "hz", ""}}}
+}
+
+type resistance struct{}
+
+func (*resistance) Domain() string { return "resistance" }
+func (*resistance) Units() []unitDef {
+	return []unitDef{{names: []string{"\u03a9", "\u2126", "ohm", "Ohm", "OHM", ""}}}
+}
+
+type current struct{}
+
+func (*current) Domain() string { return "current" }
+func (*current) Units() []unitDef {
+	return []unitDef{{names: []string{"A", "a", "amp", "Amp", "amps", "Amps", "ampere", "Ampere", ""}}}
+}
+
+type voltage struct{}
+
+func (*voltage) Domain() string { return "voltage" }
+func (*voltage) Units() []unitDef {
+	return []unitDef{{names: []string{"V", "v", "volt", "Volts", ""}}}
+}
+
+type distance struct{}
+
+func (*distance) Domain() string { return "distance" }
+func (*distance) Units() []unitDef {
+	return []unitDef{{names: []string{"m"}}}
+}
+
+type framerate struct{}
+
+func (*framerate) Domain() string { return "framerate" }
+func (*framerate) Units() []unitDef {
+	return []unitDef{{names: []string{"FPS", "fps", "Fps", "FpS", "Hz"}}}
+}
+
+type angle struct{}
+
+func (*angle) Domain() string { return "angle" }
+func (*angle) Units() []unitDef {
+	return []unitDef{{names: []string{"°", "deg", ""}}}
+}
+
+type decibel struct{}
+
+func (*decibel) Domain() string { return "decibel" }
+func (*decibel) Units() []unitDef {
+	return []unitDef{{names: []string{"dB", "dBm", "db", "dbm", ""}}}
+}
+
+type pressure struct{}
+
+func (*pressure) Domain() string { return "pressure" }
+func (*pressure) Units() []unitDef {
+	return []unitDef{
+		{names: []string{"bar", "Bar", ""}},
+		{names: []string{"Pa"}, toBase: linear(1e-5)},
+	}
+}
+
+type temperature struct{}
+
+func (*temperature) Domain() string { return "temperature" }
+func (*temperature) Units() []unitDef {
+	return []unitDef{
+		{names: []string{"°C", "° C", "°", "C", ""}},
+		{names: []string{"K", "°K", "° K"}, toBase: func(v float64) float64 { return v - 273.15 }},
+		{names: []string{"°F", "° F", "F"}, toBase: func(v float64) float64 { return (v - 32) * 5 / 9 }},
+	}
+}
+
+// Frequency is in Hz.
+type Frequency = unit[frequency, *frequency]
+
+// Resistance is in ohm.
+type Resistance = unit[resistance, *resistance]
+
+// Current is in A.
+type Current = unit[current, *current]
+
+// Voltage is in V.
+type Voltage = unit[voltage, *voltage]
+
+// Distance is in m.
+type Distance = unit[distance, *distance]
+
+// Framerate is in FPS.
+type Framerate = unit[framerate, *framerate]
+
+// Angle is in degrees.
+type Angle = unit[angle, *angle]
+
+// Decibel is in dB.
+type Decibel = unit[decibel, *decibel]
+
+// Pressure is in bar.
+type Pressure = unit[pressure, *pressure]
 
-// decibel = float_with_unit("decibel", "(dB|dBm|db|dbm)", optional_unit=True)
-// pressure = float_with_unit("pressure", "(bar|Bar)", optional_unit=True)
+// Temperature is in °C.
+type Temperature = unit[temperature, *temperature]
 
-// _temperature_c = float_with_unit("temperature", "(°C|° C|°|C)?")
-// _temperature_k = float_with_unit("temperature", "(° K|° K|K)?")
-// _temperature_f = float_with_unit("temperature", "(°F|° F|F)?")
+var _ Validatable = (*Frequency)(nil)
+var _ JSONSchemer = (*Frequency)(nil)
@@ -0,0 +1,78 @@
+package cv_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/goccy/go-yaml"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/matryer/is"
+)
+
+func TestUnits(t *testing.T) {
+	type config struct {
+		Frequency   cv.Frequency   `yaml:"frequency"`
+		Temperature cv.Temperature `yaml:"temperature"`
+		Distance    cv.Distance    `yaml:"distance"`
+		Pressure    cv.Pressure    `yaml:"pressure"`
+	}
+	value := func(c config) float64 {
+		return c.Frequency.Value() + c.Temperature.Value() + c.Distance.Value() + c.Pressure.Value()
+	}
+	for _, tc := range []struct {
+		yaml     string
+		expected float64
+		err      string
+	}{
+		{yaml: `frequency: 115.2kHz`, expected: 115200},
+		{yaml: `frequency: 50`, expected: 50},
+		{yaml: `frequency: "2.4 GHz"`, expected: 2.4e9},
+		{yaml: `temperature: 21.5°C`, expected: 21.5},
+		{yaml: `temperature: 70°F`, expected: 21.1111},
+		{yaml: `temperature: 0K`, expected: -273.15},
+		{yaml: `distance: 25cm`, expected: 0.25},
+		{yaml: `distance: 5mm`, expected: 0.005},
+		{yaml: `pressure: 1013hPa`, expected: 1.013},
+		{yaml: `distance: 3`, err: `"3" is not a distance, the unit should be one of [m]`},
+		{yaml: `frequency: 10V`, err: `"10V" is not a frequency`},
+		{yaml: `frequency: fast`, err: `"fast" is not a frequency`},
+	} {
+		t.Run(tc.yaml, func(t *testing.T) {
+			is := is.New(t)
+			actual := config{}
+			err := yaml.Unmarshal([]byte(tc.yaml), &actual)
+			if tc.err != "" {
+				is.True(err != nil)
+				is.True(strings.Contains(err.Error(), tc.err))
+				return
+			}
+			is.NoErr(err)
+			is.True(math.Abs(value(actual)-tc.expected) < 1e-3*math.Max(1, math.Abs(tc.expected)))
+		})
+	}
+}
+
+func TestUnitRange(t *testing.T) {
+	is := is.New(t)
+	f := cv.Frequency{}
+	is.NoErr(f.UnmarshalText([]byte("115.2kHz")))
+	is.NoErr(validation.ValidateWithContext(context.Background(), f, cv.UnitRange(1, 1e6)))
+	err := validation.ValidateWithContext(context.Background(), f, cv.UnitRange(1, 1e3))
+	is.True(err != nil)
+	is.Equal(err.Error(), "115200Hz should be between 1Hz and 1000Hz")
+}
+
+func TestUnitMarshal(t *testing.T) {
+	is := is.New(t)
+	temp := cv.Temperature{}
+	is.NoErr(temp.UnmarshalText([]byte("300K")))
+	text, err := temp.MarshalText()
+	is.NoErr(err)
+	is.Equal(string(text), temp.String())
+	back := cv.Temperature{}
+	is.NoErr(back.UnmarshalText(text))
+	is.Equal(back, temp)
+}
@@ -2,6 +2,7 @@ package cv
 
 import (
 	"fmt"
+	"reflect"
 	"slices"
 	"strings"
 
@@ -32,10 +33,12 @@ type stringRule struct {
 
 // Validate implements validation.Rule.
 func (s *stringRule) Validate(ivalue interface{}) error {
-	value, ok := ivalue.(string)
-	if !ok {
+	// named string types like device classes are strings too
+	rv := reflect.ValueOf(ivalue)
+	if rv.Kind() != reflect.String {
 		return validation.NewError("cv_not_a_string", "this value should be a string")
 	}
+	value := rv.String()
 	for _, rule := range s.rules {
 		err := rule.Validate(value)
 		if err != nil {
@@ -76,7 +79,7 @@ type stringWithChars struct {
 // Validate implements StringRule.
 func (n *stringWithChars) Validate(value string) error {
 	for _, c := range value {
-		if strings.ContainsRune(n.chars, c) {
+		if !strings.ContainsRune(n.chars, c) {
 			return validation.NewError("cv_string_has_illegal_chars", fmt.Sprintf(
 				"'%c' is an invalid character for names. Valid characters are: %s (lowercase, no spaces)",
 				c, ALLOWED_NAME_CHARS,
@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"slices"
+	"sync"
+)
+
+// WithAvailability is an entity whose source can go offline, e.g. a sensor
+// on a uart port that failed to open.
+type WithAvailability interface {
+	Available() bool
+	SetAvailable(available bool)
+}
+
+// AvailabilityWatcher notifies about the availability changes of an entity.
+type AvailabilityWatcher interface {
+	WithAvailability
+	WatchAvailability(f func(available bool))
+}
+
+// availability is shared by the copies of a BaseEntity.
+type availability struct {
+	mx        sync.Mutex
+	available bool
+	watchers  []func(bool)
+}
+
+// Available implements WithAvailability.
+func (b *BaseEntity) Available() bool {
+	if b.availability == nil {
+		return true
+	}
+	b.availability.mx.Lock()
+	defer b.availability.mx.Unlock()
+	return b.availability.available
+}
+
+// SetAvailable implements WithAvailability.
+func (b *BaseEntity) SetAvailable(available bool) {
+	if b.availability == nil {
+		return
+	}
+	b.availability.mx.Lock()
+	if b.availability.available == available {
+		b.availability.mx.Unlock()
+		return
+	}
+	b.availability.available = available
+	watchers := slices.Clone(b.availability.watchers)
+	b.availability.mx.Unlock()
+	for _, w := range watchers {
+		w(available)
+	}
+}
+
+// WatchAvailability calls f whenever the entity becomes available or
+// unavailable.
+func (b *BaseEntity) WatchAvailability(f func(available bool)) {
+	if b.availability == nil {
+		return
+	}
+	b.availability.mx.Lock()
+	defer b.availability.mx.Unlock()
+	b.availability.watchers = append(b.availability.watchers, f)
+}
+
+var _ AvailabilityWatcher = (*BaseEntity)(nil)
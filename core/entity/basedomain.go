@@ -2,6 +2,7 @@ package entity
 
 import (
 	"cmp"
+	"context"
 	"fmt"
 	"slices"
 
@@ -54,6 +55,7 @@ type BaseDomain[Domain any, EntityType EntityComponent, PD interface {
 	*Domain
 }] struct {
 	entities guarded.RWValue[[]EntityType]
+	onClose  guarded.Value[[]func()]
 }
 
 func (bd *BaseDomain[Domain, EntityType, PD]) ID() string {
@@ -63,20 +65,35 @@ func (bd *BaseDomain[Domain, EntityType, PD]) HashID() uint32 {
 	return domainHashes[PD(nil).DomainType()]
 }
 
-func (bd *BaseDomain[Domain, EntityType, PD]) Setup() {
+func (bd *BaseDomain[Domain, EntityType, PD]) Setup(ctx context.Context) error {
+	return nil
 }
 
 func (bd *BaseDomain[Domain, EntityType, PD]) InitializationPriority() component.InitializationPriority {
 	return component.InitializationPriorityBus
 }
 
-func (bd *BaseDomain[Domain, EntityType, PD]) Close() error {
+func (bd *BaseDomain[Domain, EntityType, PD]) Close(ctx context.Context) error {
+	bd.onClose.Do(func(fs *[]func()) {
+		for _, f := range *fs {
+			f()
+		}
+		*fs = nil
+	})
 	bd.entities.Write(func(entities *[]EntityType) {
 		*entities = nil
 	})
 	return nil
 }
 
+// OnClose registers f to be called when the domain is closed, e.g. to stop
+// the service call handlers of the domain.
+func (bd *BaseDomain[Domain, EntityType, PD]) OnClose(f func()) {
+	bd.onClose.Do(func(fs *[]func()) {
+		*fs = append(*fs, f)
+	})
+}
+
 func (bd *BaseDomain[Domain, EntityType, PD]) Clone() (cloned []Entity) {
 	bd.entities.Read(func(et *[]EntityType) {
 		cloned = clone(*et)
@@ -98,6 +115,22 @@ func (bd *BaseDomain[Domain, EntityType, PD]) Register(ent EntityType) (err erro
 	return err
 }
 
+// Unregister removes ent from the domain, it reports whether ent was
+// registered.
+func (bd *BaseDomain[Domain, EntityType, PD]) Unregister(ent Entity) (found bool) {
+	bd.entities.Write(func(entities *[]EntityType) {
+		var i int
+		i, found = slices.BinarySearchFunc(*entities, ent.HashID(), func(e EntityType, t uint32) int {
+			return cmp.Compare(e.HashID(), t)
+		})
+		found = found && Entity((*entities)[i]) == ent
+		if found {
+			*entities = slices.Delete(*entities, i, i+1)
+		}
+	})
+	return
+}
+
 func (bd *BaseDomain[Domain, EntityType, PD]) DomainType() DomainType {
 	return (PD)(nil).DomainType()
 }
@@ -7,6 +7,7 @@ import (
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	cv "github.com/gosthome/gosthome/core/configvalidation"
+	"github.com/gosthome/gosthome/core/util"
 )
 
 type EntityConfig struct {
@@ -25,6 +26,23 @@ func (ec *EntityConfig) ValidateWithContext(ctx context.Context) error {
 	)
 }
 
+// nameID is the id of the entity, made from its name when the config has
+// none.
+func (ec *EntityConfig) nameID() string {
+	if ec.ID == "" && ec.Name != "" {
+		return util.CleanString(util.SnakeCase(ec.Name))
+	}
+	return ec.ID
+}
+
+// EntityID returns the id of the entity for the cid.Declarer of the config
+// of its platform, it is made from the name and kept when the config has
+// none.
+func (ec *EntityConfig) EntityID() *string {
+	ec.ID = ec.nameID()
+	return &ec.ID
+}
+
 type IconMixinConfig struct {
 	Icon string `yaml:"icon"`
 }
@@ -46,7 +64,7 @@ type DeviceClassMixinConfig[Enum any, PE interface {
 	DeviceClassValues
 	*Enum
 }] struct {
-	DeviceClass Enum `yaml:"device_class"`
+	DeviceClass Enum `yaml:"device_class,omitempty"`
 }
 
 // Validate implements validation.Validatable.
@@ -57,10 +75,10 @@ func (d *DeviceClassMixinConfig[Enum, PE]) ValidateWithContext(ctx context.Conte
 }
 
 type UnitOfMeasurementMixinConfig struct {
-	UnitOfMeasurement string
+	UnitOfMeasurement string `yaml:"unit_of_measurement"`
 }
 
 // Validate implements validation.Validatable.
 func (u *UnitOfMeasurementMixinConfig) ValidateWithContext(ctx context.Context) error {
-	panic("unimplemented")
+	return nil
 }
@@ -297,6 +297,11 @@ type SensorState struct {
 // )
 type SensorStateClass int32
 
+// EnumValues implements cv.Enum.
+func (x *SensorStateClass) EnumValues() []string {
+	return SensorStateClassNames()
+}
+
 // ENUM(
 // none,
 // never,
@@ -304,6 +309,11 @@ type SensorStateClass int32
 // )
 type SensorLastResetType int32
 
+// EnumValues implements cv.Enum.
+func (x *SensorLastResetType) EnumValues() []string {
+	return SensorLastResetTypeNames()
+}
+
 // ENUM(
 // apparent_power, // Apparent power
 // aqi, // Air Quality Index
@@ -371,6 +381,161 @@ func (x *SensorDeviceClass) DeviceClassValues() []string {
 
 var _ (DeviceClassValues) = (*SensorDeviceClass)(nil)
 
+// UnitsOfMeasurementOf lists the units of sensors of a device class, any unit
+// is fine when it is empty.
+func UnitsOfMeasurementOf(dc SensorDeviceClass) []string {
+	switch dc {
+	case SensorDeviceClassApparentPower:
+		return []string{"VA"}
+	case SensorDeviceClassAqi:
+		return []string{}
+	case SensorDeviceClassArea:
+		return []string{"m²", "cm²", "km²", "mm²", "in²", "ft²", "yd²", "mi²", "ac", "ha"}
+	case SensorDeviceClassAtmosphericPressure:
+		return []string{"cbar", "bar", "hPa", "mmHG", "inHg", "kPa", "mbar", "Pa", "psi"}
+	case SensorDeviceClassBattery:
+		return []string{"%"}
+	case SensorDeviceClassBloodGlucoseConcentration:
+		return []string{"mg/dL", "mmol/L"}
+	case SensorDeviceClassCo2:
+		return []string{"ppm"}
+	case SensorDeviceClassCo:
+		return []string{"ppm"}
+	case SensorDeviceClassConductivity:
+		return []string{"S/cm", "mS/cm", "µS/cm"}
+	case SensorDeviceClassCurrent:
+		return []string{"A", "mA"}
+	case SensorDeviceClassDataRate:
+		return []string{"bit/s", "kbit/s", "Mbit/s", "Gbit/s", "B/s", "kB/s", "MB/s", "GB/s", "KiB/s", "MiB/s", "GiB/s"}
+	case SensorDeviceClassDataSize:
+		return []string{"bit", "kbit", "Mbit", "Gbit", "B", "kB", "MB", "GB", "TB", "PB", "EB", "ZB", "YB", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB", "ZiB", "YiB"}
+	case SensorDeviceClassDate:
+		return []string{}
+	case SensorDeviceClassDistance:
+		return []string{"km", "m", "cm", "mm", "mi", "nmi", "yd", "in"}
+	case SensorDeviceClassDuration:
+		return []string{"d", "h", "min", "s", "ms"}
+	case SensorDeviceClassEnergy:
+		return []string{"J", "kJ", "MJ", "GJ", "mWh", "Wh", "kWh", "MWh", "GWh", "TWh", "cal", "kcal", "Mcal", "Gcal"}
+	case SensorDeviceClassEnergyDistance:
+		return []string{"kWh/100km", "mi/kWh", "km/kWh"}
+	case SensorDeviceClassEnergyStorage:
+		return []string{"J", "kJ", "MJ", "GJ", "mWh", "Wh", "kWh", "MWh", "GWh", "TWh", "cal", "kcal", "Mcal", "Gcal"}
+	case SensorDeviceClassEnum:
+		return []string{}
+	case SensorDeviceClassFrequency:
+		return []string{"Hz", "kHz", "MHz", "GHz"}
+	case SensorDeviceClassGas:
+		return []string{"m³", "ft³", "CCF"}
+	case SensorDeviceClassHumidity:
+		return []string{"%"}
+	case SensorDeviceClassIlluminance:
+		return []string{"lx"}
+	case SensorDeviceClassIrradiance:
+		return []string{"W/m²", "BTU/(h⋅ft²)"}
+	case SensorDeviceClassMoisture:
+		return []string{"%"}
+	case SensorDeviceClassMonetary:
+		// any ISO 4217 currency code
+		return []string{}
+	case SensorDeviceClassNitrogenDioxide:
+		return []string{"µg/m³"}
+	case SensorDeviceClassNitrogenMonoxide:
+		return []string{"µg/m³"}
+	case SensorDeviceClassNitrousOxide:
+		return []string{"µg/m³"}
+	case SensorDeviceClassOzone:
+		return []string{"µg/m³"}
+	case SensorDeviceClassPh:
+		return []string{"None"}
+	case SensorDeviceClassPm1:
+		return []string{"µg/m³"}
+	case SensorDeviceClassPm25:
+		return []string{"µg/m³"}
+	case SensorDeviceClassPm10:
+		return []string{"µg/m³"}
+	case SensorDeviceClassPower:
+		return []string{"mW", "W", "kW", "MW", "GW", "TW"}
+	case SensorDeviceClassPowerFactor:
+		return []string{"%", "None"}
+	case SensorDeviceClassPrecipitation:
+		return []string{"cm", "in", "mm"}
+	case SensorDeviceClassPrecipitationIntensity:
+		return []string{"in/d", "in/h", "mm/d", "mm/h"}
+	case SensorDeviceClassPressure:
+		return []string{"cbar", "bar", "hPa", "mmHg", "inHg", "kPa", "mbar", "Pa", "psi"}
+	case SensorDeviceClassReactivePower:
+		return []string{"var"}
+	case SensorDeviceClassSignalStrength:
+		return []string{"dB", "dBm"}
+	case SensorDeviceClassSoundPressure:
+		return []string{"dB", "dBA"}
+	case SensorDeviceClassSpeed:
+		return []string{"ft/s", "in/d", "in/h", "in/s", "km/h", "kn", "m/s", "mph", "mm/d", "mm/s"}
+	case SensorDeviceClassSulphurDioxide:
+		return []string{"µg/m³"}
+	case SensorDeviceClassTemperature:
+		return []string{"°C", "degC", "C", "°F", "degF", "F", "K"}
+	case SensorDeviceClassTimestamp:
+		return []string{}
+	case SensorDeviceClassVolatileOrganicCompounds:
+		return []string{"µg/m³"}
+	case SensorDeviceClassVolatileOrganicCompoundsParts:
+		return []string{"ppm", "ppb"}
+	case SensorDeviceClassVoltage:
+		return []string{"V", "mV", "µV", "kV", "MV"}
+	case SensorDeviceClassVolume:
+		return []string{"L", "mL", "gal", "fl. oz.", "m³", "ft³", "CCF"}
+	case SensorDeviceClassVolumeFlowRate:
+		return []string{"m³/h", "ft³/min", "L/min", "gal/min", "mL/s"}
+	case SensorDeviceClassVolumeStorage:
+		return []string{"L", "mL", "gal", "fl. oz.", "m³", "ft³", "CCF"}
+	case SensorDeviceClassWater:
+		return []string{"L", "gal", "m³", "ft³", "CCF"}
+	case SensorDeviceClassWeight:
+		return []string{"kg", "g", "mg", "µg", "oz", "lb", "st"}
+	case SensorDeviceClassWindDirection:
+		return []string{"°", "deg"}
+	case SensorDeviceClassWindSpeed:
+		return []string{"ft/s", "km/h", "kn", "m/s", "mph"}
+	default:
+		return []string{}
+	}
+}
+
+// StateClassesOf lists the state classes Home Assistant allows for sensors of
+// a device class, any is fine when it is nil.
+func StateClassesOf(dc SensorDeviceClass) []SensorStateClass {
+	measurement := []SensorStateClass{SensorStateClassMeasurement}
+	totals := []SensorStateClass{SensorStateClassTotal, SensorStateClassTotalIncreasing}
+	switch dc {
+	case SensorDeviceClassArea,
+		SensorDeviceClassDataSize,
+		SensorDeviceClassDistance,
+		SensorDeviceClassDuration:
+		return nil
+	case SensorDeviceClassDate,
+		SensorDeviceClassEnum,
+		SensorDeviceClassTimestamp:
+		// these are not numbers, they have no statistics
+		return []SensorStateClass{}
+	case SensorDeviceClassEnergy,
+		SensorDeviceClassGas,
+		SensorDeviceClassWater:
+		return totals
+	case SensorDeviceClassMonetary:
+		return []SensorStateClass{SensorStateClassTotal}
+	case SensorDeviceClassPrecipitation,
+		SensorDeviceClassVolume,
+		SensorDeviceClassWeight:
+		return append(measurement, totals...)
+	case "":
+		return nil
+	default:
+		return measurement
+	}
+}
+
 type Sensor interface {
 	EntityComponent
 	WithState[SensorState]
@@ -557,6 +722,11 @@ type Climate interface {
 // ENUM(auto,box,slider)
 type NumberMode int32
 
+// EnumValues implements cv.Enum.
+func (x *NumberMode) EnumValues() []string {
+	return NumberModeNames()
+}
+
 // ==================	Number		=============================================
 
 type NumberDomain struct {
@@ -848,20 +1018,18 @@ func (*EventDomain) DomainType() DomainType {
 
 type EventDeviceClass string
 
+const (
+	EventDeviceClassButton   EventDeviceClass = "button"
+	EventDeviceClassDoorbell EventDeviceClass = "doorbell"
+	EventDeviceClassMotion   EventDeviceClass = "motion"
+)
+
 var _EventDeviceClassNamesValues = sync.OnceValue(func() map[string]EventDeviceClass {
-	a := slices.Clone(BinarySensorDeviceClassNames())
-	a = append(a, CoverDeviceClassNames()...)
-	a = append(a, SwitchDeviceClassNames()...)
-	a = append(a, ButtonDeviceClassNames()...)
-	a = append(a, SensorDeviceClassNames()...)
-	a = append(a, ValveDeviceClassNames()...)
-	return maps.Collect[string, EventDeviceClass](func(yield func(string, EventDeviceClass) bool) {
-		for _, e := range a {
-			if !yield(e, EventDeviceClass(e)) {
-				return
-			}
-		}
-	})
+	return map[string]EventDeviceClass{
+		string(EventDeviceClassButton):   EventDeviceClassButton,
+		string(EventDeviceClassDoorbell): EventDeviceClassDoorbell,
+		string(EventDeviceClassMotion):   EventDeviceClassMotion,
+	}
 })
 
 type errInvalidEventDeviceClass func() error
@@ -878,7 +1046,7 @@ var EventDeviceClassNames = sync.OnceValue(func() []string {
 	return slices.Collect(maps.Keys(_EventDeviceClassNamesValues()))
 })
 
-// ParseBinarySensorDeviceClass attempts to convert a string to a BinarySensorDeviceClass.
+// ParseEventDeviceClass attempts to convert a string to a EventDeviceClass.
 func ParseEventDeviceClass(name string) (EventDeviceClass, error) {
 	if x, ok := _EventDeviceClassNamesValues()[name]; ok {
 		return x, nil
@@ -890,7 +1058,7 @@ func (x EventDeviceClass) String() string {
 	return string(x)
 }
 func (x EventDeviceClass) IsValid() bool {
-	_, err := ParseBinarySensorDeviceClass(string(x))
+	_, err := ParseEventDeviceClass(string(x))
 	return err == nil
 }
 func (x EventDeviceClass) MarshalText() ([]byte, error) {
@@ -916,6 +1084,8 @@ type Event interface {
 	EntityComponent
 	WithIcon
 	WithDeviceClass[EventDeviceClass, *EventDeviceClass]
+	// EventTypes are the types of events the entity can fire.
+	EventTypes() []string
 }
 
 // ==================	Update		=============================================
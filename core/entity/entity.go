@@ -3,7 +3,6 @@ package entity
 import (
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/component/cid"
-	"github.com/gosthome/gosthome/core/util"
 )
 
 //go:generate go-enum
@@ -46,6 +45,7 @@ type WithState[T any] interface {
 type BaseEntity struct {
 	cid.CID
 	idhash   uint32
+	domain   DomainType
 	category Category
 	name     string
 
@@ -53,27 +53,32 @@ type BaseEntity struct {
 	idhashReady bool
 
 	disabledByDefault bool
+
+	availability *availability
 }
 
 func NewBaseEntity(t DomainType, cfg *EntityConfig) BaseEntity {
-	id := cfg.ID
+	id := cfg.nameID()
 	if id == "" {
-		if cfg.Name != "" {
-			id = util.CleanString(util.SnakeCase(cfg.Name))
-		} else {
-			id = cid.MakeStringID(t.String())
-		}
+		id = cid.MakeStringID(t.String())
 	}
 	b := BaseEntity{
 		CID:               cid.NewID(id),
+		domain:            t,
 		name:              cfg.Name,
 		internal:          cfg.Internal,
 		category:          cfg.Category,
 		disabledByDefault: cfg.DisabledByDefault,
+		availability:      &availability{available: true},
 	}
 	return b
 }
 
+// Domain returns the domain the entity was made for.
+func (b *BaseEntity) Domain() DomainType {
+	return b.domain
+}
+
 // Name implements Entity.
 func (b *BaseEntity) Name() string {
 	return b.name
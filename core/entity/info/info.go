@@ -91,15 +91,16 @@ type TextSensor struct {
 	DeviceClass       string
 }
 
-//	type Services struct {
-//		Name string
-//		Type entity.ServiceArgType
-//	}
+// ServicesArgument is an arg of a service, Type is the name of its type,
+// e.g. int or string_array.
+type ServicesArgument struct {
+	Name string
+	Type string
+}
 type Services struct {
 	Name string
 	Key  uint32
-	//Args []*entity.ServicesArgument
-	// TODO: Handle Args
+	Args []ServicesArgument
 }
 type Camera struct {
 	ObjectId          string
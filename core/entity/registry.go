@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"sync/atomic"
 
+	"github.com/gosthome/gosthome/core/bus"
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/guarded"
 )
@@ -37,6 +38,8 @@ type Registry struct {
 	updateDomain            atomic.Pointer[UpdateDomain]
 
 	external guarded.RWValue[map[string]component.Component]
+
+	changes bus.Emitter[bus.RegistryChangedEvent, *bus.RegistryChangedEvent]
 }
 
 type ErrAlreadyRegistered DomainType
@@ -127,14 +130,135 @@ func (pdd publicDomainDefinition) create(reg *Registry) error {
 	}
 }
 
+func removePD[T any, PT interface {
+	*T
+	DomainTyper
+}](ptr *atomic.Pointer[T], t *T) error {
+	if !ptr.CompareAndSwap(t, nil) {
+		return fmt.Errorf("domain is not registered: %s", PT(nil).DomainType())
+	}
+	return nil
+}
+
+func (pdd publicDomainDefinition) remove(reg *Registry) error {
+	switch d := pdd.d.(type) {
+	case *BinarySensorDomain:
+		return removePD(&reg.binarySensorDomain, d)
+	case *CoverDomain:
+		return removePD(&reg.coverDomain, d)
+	case *FanDomain:
+		return removePD(&reg.fanDomain, d)
+	case *LightDomain:
+		return removePD(&reg.lightDomain, d)
+	case *SensorDomain:
+		return removePD(&reg.sensorDomain, d)
+	case *SwitchDomain:
+		return removePD(&reg.switchDomain, d)
+	case *ButtonDomain:
+		return removePD(&reg.buttonDomain, d)
+	case *TextSensorDomain:
+		return removePD(&reg.textSensorDomain, d)
+	case *ServiceDomain:
+		return removePD(&reg.serviceDomain, d)
+	case *CameraDomain:
+		return removePD(&reg.cameraDomain, d)
+	case *ClimateDomain:
+		return removePD(&reg.climateDomain, d)
+	case *NumberDomain:
+		return removePD(&reg.numberDomain, d)
+	case *DateDomain:
+		return removePD(&reg.dateDomain, d)
+	case *TimeDomain:
+		return removePD(&reg.timeDomain, d)
+	case *DatetimeDomain:
+		return removePD(&reg.datetimeDomain, d)
+	case *TextDomain:
+		return removePD(&reg.textDomain, d)
+	case *SelectDomain:
+		return removePD(&reg.selectDomain, d)
+	case *SirenDomain:
+		return removePD(&reg.sirenDomain, d)
+	case *LockDomain:
+		return removePD(&reg.lockDomain, d)
+	case *ValveDomain:
+		return removePD(&reg.valveDomain, d)
+	case *MediaPlayerDomain:
+		return removePD(&reg.mediaPlayerDomain, d)
+	case *AlarmControlPanelDomain:
+		return removePD(&reg.alarmControlPanelDomain, d)
+	case *EventDomain:
+		return removePD(&reg.eventDomain, d)
+	case *UpdateDomain:
+		return removePD(&reg.updateDomain, d)
+	default:
+		panic("unknown domain")
+	}
+}
+
 func NewRegistry() *Registry {
 	return &Registry{}
 }
 
+// EmitChanges makes the registry emit a bus.RegistryChangedEvent on b for
+// every entity registered or unregistered from now on. It has to be called
+// before the registry is used.
+func (er *Registry) EmitChanges(b *bus.Bus) {
+	er.changes = bus.MakeEventEmitter[bus.RegistryChangedEvent](b)
+}
+
+func (er *Registry) changed(d DomainTyper, ent Entity, removed bool) {
+	if er.changes == nil {
+		return
+	}
+	er.changes.Emit(&bus.RegistryChangedEvent{
+		Domain:  d.DomainType().String(),
+		Key:     ent.HashID(),
+		ID:      ent.ID(),
+		Removed: removed,
+	})
+}
+
+func (er *Registry) registered(d DomainTyper, ent Entity, err error) error {
+	if err == nil {
+		er.changed(d, ent, false)
+	}
+	return err
+}
+
 func (er *Registry) CreateDomain(f DomainDefinition) error {
 	return f.create(er)
 }
 
+// RemoveDomain removes a domain created with CreateDomain so it can be
+// created again. The entities left in the domain are reported as removed.
+func (er *Registry) RemoveDomain(d DomainTyper) error {
+	err := publicDomainDefinition{d: d}.remove(er)
+	if err != nil {
+		return err
+	}
+	if c, ok := d.(interface{ Clone() []Entity }); ok {
+		for _, ent := range c.Clone() {
+			er.changed(d, ent, true)
+		}
+	}
+	return nil
+}
+
+// RestoreDomain adds a domain removed with RemoveDomain back along with its
+// entities.
+func (er *Registry) RestoreDomain(d DomainTyper) error {
+	err := publicDomainDefinition{d: d}.create(er)
+	if err != nil {
+		return err
+	}
+	if c, ok := d.(interface{ Clone() []Entity }); ok {
+		for _, ent := range c.Clone() {
+			er.changed(d, ent, false)
+		}
+	}
+	return nil
+}
+
 func (er *Registry) BinarySensorByKey(key uint32) (BinarySensor, bool) {
 	d := er.binarySensorDomain.Load()
 	if d == nil {
@@ -525,7 +649,7 @@ func (er *Registry) RegisterBinarySensor(ent BinarySensor) (err error) {
 	if d == nil {
 		return errors.New("BinarySensorDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterCover(ent Cover) (err error) {
@@ -533,7 +657,7 @@ func (er *Registry) RegisterCover(ent Cover) (err error) {
 	if d == nil {
 		return errors.New("CoverDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterFan(ent Fan) (err error) {
@@ -541,7 +665,7 @@ func (er *Registry) RegisterFan(ent Fan) (err error) {
 	if d == nil {
 		return errors.New("FanDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterLight(ent Light) (err error) {
@@ -549,7 +673,7 @@ func (er *Registry) RegisterLight(ent Light) (err error) {
 	if d == nil {
 		return errors.New("LightDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterSensor(ent Sensor) (err error) {
@@ -557,7 +681,7 @@ func (er *Registry) RegisterSensor(ent Sensor) (err error) {
 	if d == nil {
 		return errors.New("SensorDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterSwitch(ent Switch) (err error) {
@@ -565,7 +689,7 @@ func (er *Registry) RegisterSwitch(ent Switch) (err error) {
 	if d == nil {
 		return errors.New("SwitcheDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterButton(ent Button) (err error) {
@@ -573,7 +697,7 @@ func (er *Registry) RegisterButton(ent Button) (err error) {
 	if d == nil {
 		return errors.New("ButtonDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterTextSensor(ent TextSensor) (err error) {
@@ -581,7 +705,7 @@ func (er *Registry) RegisterTextSensor(ent TextSensor) (err error) {
 	if d == nil {
 		return errors.New("TextSensorDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterService(ent Service) (err error) {
@@ -589,7 +713,7 @@ func (er *Registry) RegisterService(ent Service) (err error) {
 	if d == nil {
 		return errors.New("CameraDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterCamera(ent Camera) (err error) {
@@ -597,7 +721,7 @@ func (er *Registry) RegisterCamera(ent Camera) (err error) {
 	if d == nil {
 		return errors.New("CameraDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterClimate(ent Climate) (err error) {
@@ -605,7 +729,7 @@ func (er *Registry) RegisterClimate(ent Climate) (err error) {
 	if d == nil {
 		return errors.New("ClimateDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterNumber(ent Number) (err error) {
@@ -613,7 +737,7 @@ func (er *Registry) RegisterNumber(ent Number) (err error) {
 	if d == nil {
 		return errors.New("NumberDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterDate(ent Date) (err error) {
@@ -621,7 +745,7 @@ func (er *Registry) RegisterDate(ent Date) (err error) {
 	if d == nil {
 		return errors.New("DateDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterTime(ent Time) (err error) {
@@ -629,7 +753,7 @@ func (er *Registry) RegisterTime(ent Time) (err error) {
 	if d == nil {
 		return errors.New("TimeDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterDatetime(ent Datetime) (err error) {
@@ -637,7 +761,7 @@ func (er *Registry) RegisterDatetime(ent Datetime) (err error) {
 	if d == nil {
 		return errors.New("DatetimeDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterText(ent Text) (err error) {
@@ -645,7 +769,7 @@ func (er *Registry) RegisterText(ent Text) (err error) {
 	if d == nil {
 		return errors.New("TextDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterSelect(ent Select) (err error) {
@@ -653,7 +777,7 @@ func (er *Registry) RegisterSelect(ent Select) (err error) {
 	if d == nil {
 		return errors.New("SelectDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterSiren(ent Siren) (err error) {
@@ -661,7 +785,7 @@ func (er *Registry) RegisterSiren(ent Siren) (err error) {
 	if d == nil {
 		return errors.New("SirenDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterLock(ent Lock) (err error) {
@@ -669,7 +793,7 @@ func (er *Registry) RegisterLock(ent Lock) (err error) {
 	if d == nil {
 		return errors.New("LockDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterValve(ent Valve) (err error) {
@@ -677,7 +801,7 @@ func (er *Registry) RegisterValve(ent Valve) (err error) {
 	if d == nil {
 		return errors.New("ValveDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterMediaPlayer(ent MediaPlayer) (err error) {
@@ -685,7 +809,7 @@ func (er *Registry) RegisterMediaPlayer(ent MediaPlayer) (err error) {
 	if d == nil {
 		return errors.New("MediaPlayerDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterAlarmControlPanel(ent AlarmControlPanel) (err error) {
@@ -693,7 +817,7 @@ func (er *Registry) RegisterAlarmControlPanel(ent AlarmControlPanel) (err error)
 	if d == nil {
 		return errors.New("AlarmControlPanelDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterEvent(ent Event) (err error) {
@@ -701,7 +825,7 @@ func (er *Registry) RegisterEvent(ent Event) (err error) {
 	if d == nil {
 		return errors.New("EventDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
 }
 
 func (er *Registry) RegisterUpdate(ent Update) (err error) {
@@ -709,5 +833,172 @@ func (er *Registry) RegisterUpdate(ent Update) (err error) {
 	if d == nil {
 		return errors.New("UpdateDomain is not registered!")
 	}
-	return d.Register(ent)
+	return er.registered(d, ent, d.Register(ent))
+}
+
+func registerAs[T Entity](dt DomainType, ent Entity, register func(T) error) error {
+	typed, ok := ent.(T)
+	if !ok {
+		return fmt.Errorf("%s is not a %s entity", ent.ID(), dt)
+	}
+	return register(typed)
+}
+
+// Register registers ent in the domain of type dt, e.g. again after
+// Unregister.
+func (er *Registry) Register(dt DomainType, ent Entity) error {
+	switch dt {
+	case DomainTypeBinarySensor:
+		return registerAs(dt, ent, er.RegisterBinarySensor)
+	case DomainTypeCover:
+		return registerAs(dt, ent, er.RegisterCover)
+	case DomainTypeFan:
+		return registerAs(dt, ent, er.RegisterFan)
+	case DomainTypeLight:
+		return registerAs(dt, ent, er.RegisterLight)
+	case DomainTypeSensor:
+		return registerAs(dt, ent, er.RegisterSensor)
+	case DomainTypeSwitch:
+		return registerAs(dt, ent, er.RegisterSwitch)
+	case DomainTypeButton:
+		return registerAs(dt, ent, er.RegisterButton)
+	case DomainTypeTextSensor:
+		return registerAs(dt, ent, er.RegisterTextSensor)
+	case DomainTypeService:
+		return registerAs(dt, ent, er.RegisterService)
+	case DomainTypeCamera:
+		return registerAs(dt, ent, er.RegisterCamera)
+	case DomainTypeClimate:
+		return registerAs(dt, ent, er.RegisterClimate)
+	case DomainTypeNumber:
+		return registerAs(dt, ent, er.RegisterNumber)
+	case DomainTypeDatetimeDate:
+		return registerAs(dt, ent, er.RegisterDate)
+	case DomainTypeDatetimeTime:
+		return registerAs(dt, ent, er.RegisterTime)
+	case DomainTypeDatetimeDatetime:
+		return registerAs(dt, ent, er.RegisterDatetime)
+	case DomainTypeText:
+		return registerAs(dt, ent, er.RegisterText)
+	case DomainTypeSelect:
+		return registerAs(dt, ent, er.RegisterSelect)
+	case DomainTypeLock:
+		return registerAs(dt, ent, er.RegisterLock)
+	case DomainTypeValve:
+		return registerAs(dt, ent, er.RegisterValve)
+	case DomainTypeMediaPlayer:
+		return registerAs(dt, ent, er.RegisterMediaPlayer)
+	case DomainTypeAlarmControlPanel:
+		return registerAs(dt, ent, er.RegisterAlarmControlPanel)
+	case DomainTypeSiren:
+		return registerAs(dt, ent, er.RegisterSiren)
+	case DomainTypeEvent:
+		return registerAs(dt, ent, er.RegisterEvent)
+	case DomainTypeUpdate:
+		return registerAs(dt, ent, er.RegisterUpdate)
+	default:
+		return fmt.Errorf("unknown domain type %d", dt)
+	}
+}
+
+// Unregister removes ent from the domain it was registered in, it reports
+// whether ent was registered.
+func (er *Registry) Unregister(ent Entity) bool {
+	if d := er.binarySensorDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.coverDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.fanDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.lightDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.sensorDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.switchDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.buttonDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.textSensorDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.serviceDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.cameraDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.climateDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.numberDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.dateDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.timeDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.datetimeDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.textDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.selectDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.sirenDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.lockDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.valveDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.mediaPlayerDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.alarmControlPanelDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.eventDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	if d := er.updateDomain.Load(); d != nil && d.Unregister(ent) {
+		er.changed(d, ent, true)
+		return true
+	}
+	return false
 }
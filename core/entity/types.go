@@ -1,6 +1,11 @@
 package entity
 
-//go:generate go-enum --marshal
+//go:generate go-enum --marshal --names
 
 // ENUM(none,config,disgnostic)
 type Category int32
+
+// EnumValues implements cv.Enum.
+func (x *Category) EnumValues() []string {
+	return CategoryNames()
+}
@@ -7,8 +7,8 @@
 package entity
 
 import (
-	"errors"
 	"fmt"
+	"strings"
 )
 
 const (
@@ -20,10 +20,23 @@ const (
 	CategoryDisgnostic
 )
 
-var ErrInvalidCategory = errors.New("not a valid Category")
+var ErrInvalidCategory = fmt.Errorf("not a valid Category, try [%s]", strings.Join(_CategoryNames, ", "))
 
 const _CategoryName = "noneconfigdisgnostic"
 
+var _CategoryNames = []string{
+	_CategoryName[0:4],
+	_CategoryName[4:10],
+	_CategoryName[10:20],
+}
+
+// CategoryNames returns a list of possible string values of Category.
+func CategoryNames() []string {
+	tmp := make([]string, len(_CategoryNames))
+	copy(tmp, _CategoryNames)
+	return tmp
+}
+
 var _CategoryMap = map[Category]string{
 	CategoryNone:       _CategoryName[0:4],
 	CategoryConfig:     _CategoryName[4:10],
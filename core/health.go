@@ -0,0 +1,126 @@
+package core
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+)
+
+// ComponentHealth is the outcome of the setup of a single component.
+type ComponentHealth struct {
+	ID     string
+	Type   string
+	Status component.Status
+	Err    error
+}
+
+// Health summarizes the setup of all components of a node.
+type Health struct {
+	Status     component.Status
+	Components []ComponentHealth
+}
+
+// String returns "ok" or the status of the node followed by the components
+// that are not ok.
+func (h Health) String() string {
+	if h.Status == component.StatusOk {
+		return h.Status.String()
+	}
+	problems := []string{}
+	for _, ch := range h.Components {
+		if ch.Status == component.StatusOk {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("%s %s: %v", ch.ID, ch.Status, ch.Err))
+	}
+	return h.Status.String() + " (" + strings.Join(problems, "; ") + ")"
+}
+
+// HealthEvent is emitted after the node finished setting up its components.
+type HealthEvent struct {
+	Health Health
+}
+
+// EventType implements bus.EventData.
+func (h *HealthEvent) EventType() string {
+	return "node_health"
+}
+
+var _ bus.EventData = (*HealthEvent)(nil)
+
+// setupOrder sorts components so that every component comes after the
+// components it depends on, otherwise by initialization priority. Dependencies
+// on the existing ids are already satisfied. The components which
+// dependencies can not be satisfied are returned with the reason in
+// unordered and are left out of ordered, their dependents are ordered as if
+// they were set up.
+func setupOrder(cmps []component.Component, existing map[string]struct{}) (ordered []int, unordered map[int]error) {
+	byID := map[string][]int{}
+	for i, c := range cmps {
+		byID[c.ID()] = append(byID[c.ID()], i)
+	}
+	unordered = map[int]error{}
+	dependents := make([][]int, len(cmps))
+	waiting := make([]int, len(cmps))
+	for i, c := range cmps {
+		d, ok := c.(component.Dependent)
+		if !ok {
+			continue
+		}
+		for _, id := range d.DependsOn() {
+			deps, ok := byID[id]
+			if _, found := existing[id]; !ok && found {
+				continue
+			}
+			if !ok {
+				unordered[i] = fmt.Errorf("%w %s", component.ErrMissingDependency, id)
+				continue
+			}
+			for _, dep := range deps {
+				dependents[dep] = append(dependents[dep], i)
+				waiting[i]++
+			}
+		}
+	}
+	before := func(l, r int) int {
+		return cmp.Or(
+			cmp.Compare(cmps[l].InitializationPriority(), cmps[r].InitializationPriority()),
+			cmp.Compare(l, r),
+		)
+	}
+	ready := []int{}
+	for i := range cmps {
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) > 0 {
+		slices.SortFunc(ready, before)
+		i := ready[0]
+		ready = ready[1:]
+		if _, ok := unordered[i]; !ok {
+			ordered = append(ordered, i)
+		}
+		for _, d := range dependents[i] {
+			waiting[d]--
+			if waiting[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+	for i := range cmps {
+		if waiting[i] > 0 {
+			unordered[i] = component.ErrDependencyCycle
+		}
+	}
+	return ordered, unordered
+}
+
+func typeName(c component.Component) string {
+	return reflect.TypeOf(c).String()
+}
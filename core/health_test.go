@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/guarded"
+	"github.com/matryer/is"
+)
+
+type testComponent struct {
+	cid.CID
+	component.WithInitializationPriorityProcessor
+	deps      []string
+	err       error
+	setup     *[]string
+	closed    *[]string
+	available map[string]bool
+	hang      chan struct{}
+}
+
+func (t *testComponent) Setup(ctx context.Context) error {
+	*t.setup = append(*t.setup, t.ID())
+	return t.err
+}
+
+func (t *testComponent) DependsOn() []string {
+	return t.deps
+}
+
+func (t *testComponent) Close(ctx context.Context) error {
+	if t.hang != nil {
+		<-t.hang
+	}
+	if t.closed != nil {
+		*t.closed = append(*t.closed, t.ID())
+	}
+	return nil
+}
+
+func (t *testComponent) Available() bool {
+	return t.available[t.ID()]
+}
+
+func (t *testComponent) SetAvailable(available bool) {
+	if t.available != nil {
+		t.available[t.ID()] = available
+	}
+}
+
+var _ component.Component = (*testComponent)(nil)
+var _ component.Dependent = (*testComponent)(nil)
+var _ entity.WithAvailability = (*testComponent)(nil)
+
+func TestNodeStart(t *testing.T) {
+	is := is.New(t)
+	setup := []string{}
+	available := map[string]bool{}
+	mk := func(id string, err error, deps ...string) component.Component {
+		return &testComponent{CID: cid.NewID(id), deps: deps, err: err, setup: &setup, available: available}
+	}
+	n := &Node{
+		Bus: bus.New(),
+		cmp: []component.Component{
+			mk("button", nil, "uart"),
+			mk("uart", nil),
+			mk("broken", errors.New("no port")),
+			mk("dependent", nil, "broken"),
+			mk("transitive", nil, "dependent"),
+			mk("missing", nil, "nowhere"),
+			mk("flaky", component.Warning(errors.New("slow"))),
+			mk("after_flaky", nil, "flaky"),
+			mk("cycle_a", nil, "cycle_b"),
+			mk("cycle_b", nil, "cycle_a"),
+		},
+		ctx:    context.Background(),
+		health: guarded.NewRW(Health{}),
+	}
+	emitted := make(chan *HealthEvent, 1)
+	sub := n.HandleEvents(bus.EventHandler(func(e *HealthEvent) { emitted <- e }))
+	defer sub.Close()
+
+	h := n.Start()
+	is.Equal(setup, []string{"uart", "button", "broken", "flaky", "after_flaky"})
+	is.Equal(h.Status, component.StatusFailed)
+	status := map[string]component.Status{}
+	for _, ch := range h.Components {
+		status[ch.ID] = ch.Status
+	}
+	is.Equal(status, map[string]component.Status{
+		"button":      component.StatusOk,
+		"uart":        component.StatusOk,
+		"broken":      component.StatusFailed,
+		"dependent":   component.StatusFailed,
+		"transitive":  component.StatusFailed,
+		"missing":     component.StatusFailed,
+		"flaky":       component.StatusWarning,
+		"after_flaky": component.StatusOk,
+		"cycle_a":     component.StatusFailed,
+		"cycle_b":     component.StatusFailed,
+	})
+	// the entities of failed components are unavailable
+	for id, st := range status {
+		is.Equal(available[id], st != component.StatusFailed)
+	}
+	is.True(errors.Is(h.Components[3].Err, component.ErrDependencyFailed))
+	is.True(errors.Is(h.Components[5].Err, component.ErrMissingDependency))
+	is.True(errors.Is(h.Components[8].Err, component.ErrDependencyCycle))
+	// only the components set up without failing count as started
+	started := []string{}
+	for _, c := range n.started {
+		started = append(started, c.ID())
+	}
+	is.Equal(started, []string{"uart", "button", "flaky", "after_flaky"})
+	ordered, unordered := setupOrder(n.cmp, nil)
+	is.True(!slices.Contains(ordered, 5)) // missing is only unordered
+	is.True(errors.Is(unordered[5], component.ErrMissingDependency))
+	is.Equal(n.Health().Status, component.StatusFailed)
+	select {
+	case e := <-emitted:
+		is.Equal(e.Health.Status, component.StatusFailed)
+	case <-time.After(time.Second):
+		t.Fatal("no health event")
+	}
+}
@@ -0,0 +1,559 @@
+package lambda
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Type is the type of a value of the language.
+type Type int
+
+const (
+	Invalid Type = iota
+	Bool
+	// Number is a float64, there are no integers.
+	Number
+	String
+	Time
+	// Any is only the result of the lambdas that take any value, e.g. the
+	// args of logger.log.
+	Any
+)
+
+func (t Type) String() string {
+	switch t {
+	case Bool:
+		return "bool"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Time:
+		return "time"
+	case Any:
+		return "any"
+	}
+	return "invalid"
+}
+
+// TypeFor is the type of the values of the Go type T, Invalid when the
+// language has none.
+func TypeFor[T any]() Type {
+	return typeOf(reflect.TypeFor[T]())
+}
+
+func typeOf(t reflect.Type) Type {
+	if t == reflect.TypeFor[time.Time]() {
+		return Time
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return Bool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return Number
+	case reflect.String:
+		return String
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return Any
+		}
+	}
+	return Invalid
+}
+
+// value makes v a value of the language: numbers are float64.
+func value(v any) any {
+	switch v := v.(type) {
+	case bool, float64, string, time.Time:
+		return v
+	case float32:
+		return float64(v)
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.String:
+		return rv.String()
+	}
+	return v
+}
+
+// env is what a running lambda reads.
+type env struct {
+	ctx  context.Context
+	args map[string]any
+}
+
+type evalFunc func(e *env) (any, error)
+
+// expr is a type checked node.
+type expr struct {
+	typ  Type
+	eval evalFunc
+	// obj is set for id(x), it is only a value when it has one.
+	obj *object
+}
+
+type object struct {
+	id string
+	Object
+}
+
+// checker type checks and compiles nodes.
+type checker struct {
+	vars Vars
+	// object returns what id() gives for id.
+	object func(pos int, id string) (Object, error)
+}
+
+func (c *checker) value(n node) (expr, error) {
+	x, err := c.check(n)
+	if err != nil || x.obj == nil {
+		return x, err
+	}
+	if x.obj.Value.Get == nil {
+		return x, errorf(n.position(), "id(%s) has no value, it has %s", x.obj.id, fieldNames(x.obj.Fields))
+	}
+	return field(x.obj.id, x.obj.Value), nil
+}
+
+func fieldNames(fields map[string]Field) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, "."+name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+func field(id string, f Field) expr {
+	return expr{typ: f.Type, eval: func(e *env) (any, error) {
+		v, err := f.Get(e.ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("id(%s): %w", id, err)
+		}
+		return value(v), nil
+	}}
+}
+
+// typed checks that n is a value of type t.
+func (c *checker) typed(n node, t Type, what string) (expr, error) {
+	x, err := c.value(n)
+	if err != nil {
+		return x, err
+	}
+	if x.typ != t {
+		return x, errorf(n.position(), "%s is a %s, not a %s", what, x.typ, t)
+	}
+	return x, nil
+}
+
+func (c *checker) check(n node) (expr, error) {
+	switch n := n.(type) {
+	case *literal:
+		v := n.value
+		return expr{typ: typeOf(reflect.TypeOf(v)), eval: func(*env) (any, error) { return v, nil }}, nil
+	case *ident:
+		return c.ident(n)
+	case *unary:
+		return c.unary(n)
+	case *binary:
+		return c.binary(n)
+	case *conditional:
+		return c.conditional(n)
+	case *call:
+		return c.call(n)
+	case *member:
+		return c.member(n)
+	}
+	return expr{}, errorf(n.position(), "unexpected %T", n)
+}
+
+// constants are the names the language has besides the vars.
+var constants = map[string]any{
+	"true":  true,
+	"false": false,
+	"NAN":   math.NaN(),
+	"PI":    math.Pi,
+}
+
+func (c *checker) ident(n *ident) (expr, error) {
+	if t, ok := c.vars[n.name]; ok {
+		name := n.name
+		return expr{typ: t, eval: func(e *env) (any, error) {
+			v, ok := e.args[name]
+			if !ok {
+				return nil, fmt.Errorf("%s is not set", name)
+			}
+			return value(v), nil
+		}}, nil
+	}
+	if v, ok := constants[n.name]; ok {
+		return expr{typ: typeOf(reflect.TypeOf(v)), eval: func(*env) (any, error) { return v, nil }}, nil
+	}
+	if len(c.vars) == 0 {
+		return expr{}, errorf(n.pos, "unknown name %s", n.name)
+	}
+	return expr{}, errorf(n.pos, "unknown name %s, the lambda has %s", n.name, strings.Join(c.vars.names(), ", "))
+}
+
+func (c *checker) unary(n *unary) (expr, error) {
+	if n.op == "!" {
+		x, err := c.typed(n.x, Bool, "the operand of !")
+		if err != nil {
+			return x, err
+		}
+		return expr{typ: Bool, eval: func(e *env) (any, error) {
+			v, err := x.eval(e)
+			if err != nil {
+				return nil, err
+			}
+			return !v.(bool), nil
+		}}, nil
+	}
+	x, err := c.typed(n.x, Number, "the operand of "+n.op)
+	if err != nil || n.op == "+" {
+		return x, err
+	}
+	return expr{typ: Number, eval: func(e *env) (any, error) {
+		v, err := x.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		return -v.(float64), nil
+	}}, nil
+}
+
+func (c *checker) binary(n *binary) (expr, error) {
+	l, err := c.value(n.l)
+	if err != nil {
+		return l, err
+	}
+	r, err := c.value(n.r)
+	if err != nil {
+		return r, err
+	}
+	mismatch := func() (expr, error) {
+		return expr{}, errorf(n.pos, "%s is not defined for %s and %s", n.op, l.typ, r.typ)
+	}
+	switch n.op {
+	case "&&", "||":
+		if l.typ != Bool || r.typ != Bool {
+			return mismatch()
+		}
+		and := n.op == "&&"
+		return expr{typ: Bool, eval: func(e *env) (any, error) {
+			lv, err := l.eval(e)
+			if err != nil {
+				return nil, err
+			}
+			if lv.(bool) != and {
+				return lv, nil
+			}
+			return r.eval(e)
+		}}, nil
+	case "==", "!=":
+		if l.typ != r.typ {
+			return mismatch()
+		}
+		eq := n.op == "=="
+		return both(l, r, Bool, func(lv, rv any) any {
+			if lt, ok := lv.(time.Time); ok {
+				return lt.Equal(rv.(time.Time)) == eq
+			}
+			return (lv == rv) == eq
+		}), nil
+	case "<", "<=", ">", ">=":
+		if l.typ != r.typ || l.typ == Bool {
+			return mismatch()
+		}
+		op := n.op
+		return both(l, r, Bool, func(lv, rv any) any {
+			return compare(lv, rv, op)
+		}), nil
+	case "+":
+		if l.typ == String && r.typ == String {
+			return both(l, r, String, func(lv, rv any) any { return lv.(string) + rv.(string) }), nil
+		}
+	}
+	if l.typ != Number || r.typ != Number {
+		return mismatch()
+	}
+	var f func(a, b float64) float64
+	switch n.op {
+	case "+":
+		f = func(a, b float64) float64 { return a + b }
+	case "-":
+		f = func(a, b float64) float64 { return a - b }
+	case "*":
+		f = func(a, b float64) float64 { return a * b }
+	case "/":
+		f = func(a, b float64) float64 { return a / b }
+	case "%":
+		f = math.Mod
+	}
+	return both(l, r, Number, func(lv, rv any) any { return f(lv.(float64), rv.(float64)) }), nil
+}
+
+// both evaluates l and r and combines them with f.
+func both(l, r expr, typ Type, f func(lv, rv any) any) expr {
+	return expr{typ: typ, eval: func(e *env) (any, error) {
+		lv, err := l.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		rv, err := r.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		return f(lv, rv), nil
+	}}
+}
+
+func compare(lv, rv any, op string) bool {
+	var c int
+	switch lv := lv.(type) {
+	case float64:
+		rv := rv.(float64)
+		// comparisons with NaN are false, like in C++
+		if math.IsNaN(lv) || math.IsNaN(rv) {
+			return false
+		}
+		c = cmpOrdered(lv, rv)
+	case string:
+		c = strings.Compare(lv, rv.(string))
+	case time.Time:
+		c = lv.Compare(rv.(time.Time))
+	}
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+func cmpOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (c *checker) conditional(n *conditional) (expr, error) {
+	cond, err := c.typed(n.cond, Bool, "the condition of ?:")
+	if err != nil {
+		return cond, err
+	}
+	a, err := c.value(n.a)
+	if err != nil {
+		return a, err
+	}
+	b, err := c.value(n.b)
+	if err != nil {
+		return b, err
+	}
+	if a.typ != b.typ {
+		return expr{}, errorf(n.pos, "the results of ?: are a %s and a %s", a.typ, b.typ)
+	}
+	return expr{typ: a.typ, eval: func(e *env) (any, error) {
+		v, err := cond.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		if v.(bool) {
+			return a.eval(e)
+		}
+		return b.eval(e)
+	}}, nil
+}
+
+func (c *checker) call(n *call) (expr, error) {
+	if n.fn == "id" {
+		return c.id(n)
+	}
+	f, ok := functions[n.fn]
+	if !ok {
+		return expr{}, errorf(n.pos, "unknown function %s", n.fn)
+	}
+	args, err := c.args(n.pos, n.fn, f.params, n.args)
+	if err != nil {
+		return expr{}, err
+	}
+	return expr{typ: f.result, eval: func(e *env) (any, error) {
+		vs, err := evalArgs(e, args)
+		if err != nil {
+			return nil, err
+		}
+		return f.call(e, vs)
+	}}, nil
+}
+
+// args checks the args of the function or method name with params, a
+// variadic function has Invalid as last param and takes more of the one
+// before it.
+func (c *checker) args(pos int, name string, params []Type, nodes []node) ([]expr, error) {
+	variadic := len(params) > 0 && params[len(params)-1] == Invalid
+	fixed := params
+	if variadic {
+		fixed = params[:len(params)-1]
+	}
+	if len(nodes) < len(fixed) || !variadic && len(nodes) > len(fixed) {
+		return nil, errorf(pos, "%s takes %s", name, describeParams(params))
+	}
+	ret := make([]expr, len(nodes))
+	for i, a := range nodes {
+		var err error
+		want := Any
+		if i < len(fixed) {
+			want = fixed[i]
+		} else if len(fixed) > 0 {
+			want = fixed[len(fixed)-1]
+		}
+		if want == Any {
+			ret[i], err = c.value(a)
+		} else {
+			ret[i], err = c.typed(a, want, fmt.Sprintf("arg %d of %s", i+1, name))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func describeParams(params []Type) string {
+	if len(params) == 0 {
+		return "no args"
+	}
+	names := make([]string, 0, len(params))
+	for _, p := range params {
+		if p == Invalid {
+			names[len(names)-1] += "..."
+			continue
+		}
+		names = append(names, p.String())
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+func evalArgs(e *env, args []expr) ([]any, error) {
+	vs := make([]any, len(args))
+	for i, a := range args {
+		v, err := a.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		vs[i] = v
+	}
+	return vs, nil
+}
+
+// id checks id(x), x is the id of an entity or other object of the config.
+func (c *checker) id(n *call) (expr, error) {
+	if len(n.args) != 1 {
+		return expr{}, errorf(n.pos, "id takes the id of an entity, e.g. id(temperature)")
+	}
+	var id string
+	switch a := n.args[0].(type) {
+	case *ident:
+		id = a.name
+	case *literal:
+		id, _ = a.value.(string)
+	}
+	if id == "" {
+		return expr{}, errorf(n.args[0].position(), "id takes the id of an entity, e.g. id(temperature)")
+	}
+	o, err := c.object(n.args[0].position(), id)
+	if err != nil {
+		return expr{}, err
+	}
+	return expr{obj: &object{id: id, Object: o}}, nil
+}
+
+func (c *checker) member(n *member) (expr, error) {
+	x, err := c.check(n.x)
+	if err != nil {
+		return x, err
+	}
+	if x.obj != nil {
+		f, ok := x.obj.Fields[n.name]
+		if !ok {
+			if len(x.obj.Fields) == 0 {
+				return expr{}, errorf(n.pos, "id(%s) has no .%s", x.obj.id, n.name)
+			}
+			return expr{}, errorf(n.pos, "id(%s) has no .%s, it has %s", x.obj.id, n.name, fieldNames(x.obj.Fields))
+		}
+		// fields are methods in C++, e.g. has_state()
+		if len(n.args) > 0 {
+			return expr{}, errorf(n.pos, ".%s takes no args", n.name)
+		}
+		return field(x.obj.id, f), nil
+	}
+	ms := methods[x.typ]
+	m, ok := ms[n.name]
+	if !ok {
+		if len(ms) == 0 {
+			return expr{}, errorf(n.pos, "a %s has no .%s", x.typ, n.name)
+		}
+		names := make([]string, 0, len(ms))
+		for name, m := range ms {
+			if m.field {
+				names = append(names, "."+name)
+			} else {
+				names = append(names, "."+name+"()")
+			}
+		}
+		slices.Sort(names)
+		return expr{}, errorf(n.pos, "a %s has no .%s, it has %s", x.typ, n.name, strings.Join(names, ", "))
+	}
+	if m.field && n.args != nil {
+		return expr{}, errorf(n.pos, ".%s is not a method", n.name)
+	}
+	if !m.field && n.args == nil {
+		return expr{}, errorf(n.pos, ".%s is a method, e.g. .%s()", n.name, n.name)
+	}
+	args, err := c.args(n.pos, "."+n.name, m.params, n.args)
+	if err != nil {
+		return expr{}, err
+	}
+	return expr{typ: m.result, eval: func(e *env) (any, error) {
+		recv, err := x.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		vs, err := evalArgs(e, args)
+		if err != nil {
+			return nil, err
+		}
+		return m.call(e, append([]any{recv}, vs...))
+	}}, nil
+}
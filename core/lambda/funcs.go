@@ -0,0 +1,308 @@
+package lambda
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// function is a function of the language, e.g. round(x).
+type function struct {
+	params []Type
+	result Type
+	call   func(e *env, args []any) (any, error)
+}
+
+// method is a field or method of the values of a type, e.g. .hour of a
+// time. The receiver is the first arg of call.
+type method struct {
+	field  bool
+	params []Type
+	result Type
+	call   func(e *env, args []any) (any, error)
+}
+
+func math1(f func(float64) float64) function {
+	return function{params: []Type{Number}, result: Number, call: func(e *env, args []any) (any, error) {
+		return f(args[0].(float64)), nil
+	}}
+}
+
+func math2(f func(a, b float64) float64) function {
+	return function{params: []Type{Number, Number}, result: Number, call: func(e *env, args []any) (any, error) {
+		return f(args[0].(float64), args[1].(float64)), nil
+	}}
+}
+
+func fold(f func(a, b float64) float64) function {
+	return function{params: []Type{Number, Number, Invalid}, result: Number, call: func(e *env, args []any) (any, error) {
+		ret := args[0].(float64)
+		for _, a := range args[1:] {
+			ret = f(ret, a.(float64))
+		}
+		return ret, nil
+	}}
+}
+
+func str1(f func(string) string) function {
+	return function{params: []Type{String}, result: String, call: func(e *env, args []any) (any, error) {
+		return f(args[0].(string)), nil
+	}}
+}
+
+func strPredicate(f func(s, x string) bool) function {
+	return function{params: []Type{String, String}, result: Bool, call: func(e *env, args []any) (any, error) {
+		return f(args[0].(string), args[1].(string)), nil
+	}}
+}
+
+// start is when millis() counts from.
+var start = time.Now()
+
+var functions = map[string]function{
+	"abs":   math1(math.Abs),
+	"floor": math1(math.Floor),
+	"ceil":  math1(math.Ceil),
+	"round": math1(math.Round),
+	"sqrt":  math1(math.Sqrt),
+	"exp":   math1(math.Exp),
+	"log":   math1(math.Log),
+	"log10": math1(math.Log10),
+	"sin":   math1(math.Sin),
+	"cos":   math1(math.Cos),
+	"tan":   math1(math.Tan),
+	"pow":   math2(math.Pow),
+	"min":   fold(math.Min),
+	"max":   fold(math.Max),
+	"clamp": {params: []Type{Number, Number, Number}, result: Number, call: func(e *env, args []any) (any, error) {
+		return math.Min(math.Max(args[0].(float64), args[1].(float64)), args[2].(float64)), nil
+	}},
+	// remap maps x from the range [a, b] to [c, d]
+	"remap": {params: []Type{Number, Number, Number, Number, Number}, result: Number, call: func(e *env, args []any) (any, error) {
+		x, a, b, c, d := args[0].(float64), args[1].(float64), args[2].(float64), args[3].(float64), args[4].(float64)
+		return c + (x-a)*(d-c)/(b-a), nil
+	}},
+	"isnan": {params: []Type{Number}, result: Bool, call: func(e *env, args []any) (any, error) {
+		return math.IsNaN(args[0].(float64)), nil
+	}},
+
+	"to_string": {params: []Type{Any}, result: String, call: func(e *env, args []any) (any, error) {
+		return toString(args[0]), nil
+	}},
+	"parse_number": {params: []Type{String}, result: Number, call: func(e *env, args []any) (any, error) {
+		v, err := strconv.ParseFloat(strings.TrimSpace(args[0].(string)), 64)
+		if err != nil {
+			return math.NaN(), nil
+		}
+		return v, nil
+	}},
+	"str_sprintf": {params: []Type{String, Any, Invalid}, result: String, call: func(e *env, args []any) (any, error) {
+		return Sprintf(args[0].(string), args[1:]...), nil
+	}},
+	"str_upper_case": str1(strings.ToUpper),
+	"str_lower_case": str1(strings.ToLower),
+	"str_startswith": strPredicate(strings.HasPrefix),
+	"str_endswith":   strPredicate(strings.HasSuffix),
+	"str_contains":   strPredicate(strings.Contains),
+
+	"now": {result: Time, call: func(e *env, args []any) (any, error) {
+		return time.Now(), nil
+	}},
+	"millis": {result: Number, call: func(e *env, args []any) (any, error) {
+		return float64(time.Since(start).Milliseconds()), nil
+	}},
+}
+
+func timeField(f func(t time.Time) int) method {
+	return method{field: true, result: Number, call: func(e *env, args []any) (any, error) {
+		return float64(f(args[0].(time.Time))), nil
+	}}
+}
+
+func strMethod(params []Type, result Type, f func(s string, args []any) any) method {
+	return method{params: params, result: result, call: func(e *env, args []any) (any, error) {
+		return f(args[0].(string), args[1:]), nil
+	}}
+}
+
+// methods are the ones of ESPHome's ESPTime and std::string.
+var methods = map[Type]map[string]method{
+	Time: {
+		"second":       timeField(time.Time.Second),
+		"minute":       timeField(time.Time.Minute),
+		"hour":         timeField(time.Time.Hour),
+		"day_of_week":  timeField(func(t time.Time) int { return int(t.Weekday()) + 1 }),
+		"day_of_month": timeField(time.Time.Day),
+		"day_of_year":  timeField(time.Time.YearDay),
+		"month":        timeField(func(t time.Time) int { return int(t.Month()) }),
+		"year":         timeField(time.Time.Year),
+		"timestamp":    timeField(func(t time.Time) int { return int(t.Unix()) }),
+		"strftime": {params: []Type{String}, result: String, call: func(e *env, args []any) (any, error) {
+			return Strftime(args[0].(time.Time), args[1].(string)), nil
+		}},
+	},
+	String: {
+		"c_str": strMethod(nil, String, func(s string, args []any) any { return s }),
+		"length": strMethod(nil, Number, func(s string, args []any) any {
+			return float64(len(s))
+		}),
+		"size": strMethod(nil, Number, func(s string, args []any) any {
+			return float64(len(s))
+		}),
+		"empty": strMethod(nil, Bool, func(s string, args []any) any { return s == "" }),
+		"substr": strMethod([]Type{Number, Number}, String, func(s string, args []any) any {
+			pos := min(max(int(args[0].(float64)), 0), len(s))
+			n := max(int(args[1].(float64)), 0)
+			return s[pos:min(pos+n, len(s))]
+		}),
+	},
+}
+
+// toString formats v like to_string() does.
+func toString(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.DateTime)
+	}
+	return fmt.Sprint(v)
+}
+
+// Sprintf formats args like C's printf, the numbers of the language are
+// whole for %d, %i, %u, %x and %o.
+func Sprintf(format string, args ...any) string {
+	var b strings.Builder
+	next := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		j := i + 1
+		for j < len(format) && strings.IndexByte("-+ #0123456789.", format[j]) >= 0 {
+			j++
+		}
+		spec := format[i+1 : j]
+		// length modifiers are the size of the C types
+		for j < len(format) && strings.IndexByte("hlLqjzt", format[j]) >= 0 {
+			j++
+		}
+		if j >= len(format) {
+			b.WriteString(format[i:])
+			break
+		}
+		verb := format[j]
+		i = j
+		if verb == '%' {
+			b.WriteByte('%')
+			continue
+		}
+		if next >= len(args) {
+			b.WriteString("%!" + string(verb) + "(MISSING)")
+			continue
+		}
+		arg := value(args[next])
+		next++
+		switch verb {
+		case 'd', 'i', 'u', 'x', 'X', 'o', 'c':
+			n := int64(0)
+			switch v := arg.(type) {
+			case float64:
+				n = int64(v)
+			case bool:
+				if v {
+					n = 1
+				}
+			}
+			if verb == 'i' || verb == 'u' {
+				verb = 'd'
+			}
+			fmt.Fprintf(&b, "%"+spec+string(verb), n)
+		case 'f', 'F', 'e', 'E', 'g', 'G':
+			f, ok := arg.(float64)
+			if !ok {
+				f = math.NaN()
+			}
+			if verb == 'F' {
+				verb = 'f'
+			}
+			fmt.Fprintf(&b, "%"+spec+string(verb), f)
+		case 's':
+			fmt.Fprintf(&b, "%"+spec+"s", toString(arg))
+		default:
+			fmt.Fprintf(&b, "%%!%c(%v)", verb, arg)
+		}
+	}
+	return b.String()
+}
+
+// Strftime formats t like C's strftime.
+func Strftime(t time.Time, format string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'Y':
+			fmt.Fprintf(&b, "%04d", t.Year())
+		case 'y':
+			fmt.Fprintf(&b, "%02d", t.Year()%100)
+		case 'm':
+			fmt.Fprintf(&b, "%02d", int(t.Month()))
+		case 'd':
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case 'e':
+			fmt.Fprintf(&b, "%2d", t.Day())
+		case 'j':
+			fmt.Fprintf(&b, "%03d", t.YearDay())
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case 'I':
+			fmt.Fprintf(&b, "%02d", (t.Hour()+11)%12+1)
+		case 'M':
+			fmt.Fprintf(&b, "%02d", t.Minute())
+		case 'S':
+			fmt.Fprintf(&b, "%02d", t.Second())
+		case 'p':
+			b.WriteString(t.Format("PM"))
+		case 'a':
+			b.WriteString(t.Format("Mon"))
+		case 'A':
+			b.WriteString(t.Format("Monday"))
+		case 'b':
+			b.WriteString(t.Format("Jan"))
+		case 'B':
+			b.WriteString(t.Format("January"))
+		case 'w':
+			fmt.Fprintf(&b, "%d", int(t.Weekday()))
+		case 'z':
+			b.WriteString(t.Format("-0700"))
+		case 'Z':
+			b.WriteString(t.Format("MST"))
+		case 's':
+			fmt.Fprintf(&b, "%d", t.Unix())
+		case 'F':
+			b.WriteString(t.Format(time.DateOnly))
+		case 'T':
+			b.WriteString(t.Format(time.TimeOnly))
+		case 'R':
+			b.WriteString(t.Format("15:04"))
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(format[i])
+		}
+	}
+	return b.String()
+}
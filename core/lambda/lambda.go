@@ -0,0 +1,331 @@
+// Package lambda is the expression language of the lambdas of the config,
+// the safe replacement of ESPHome's C++ lambdas, e.g.
+//
+//	lambda: return id(outside).state > 20 ? "warm" : "cold";
+//
+// A lambda is a single expression, the return and the semicolon of ESPHome
+// are optional. It has numbers, strings, bools and times, the operators of
+// C, the vars of its trigger like x, id() of the entities of the config and
+// the functions of funcs.go. Lambdas are parsed when the config is
+// decoded and type checked against the ids of the config once they are
+// resolved, so a config with a broken lambda does not load.
+package lambda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
+	"github.com/gosthome/gosthome/core/component/cid"
+	cv "github.com/gosthome/gosthome/core/configvalidation"
+)
+
+// Vars are the types of the values a lambda gets from its trigger, e.g. x
+// of on_value:.
+type Vars map[string]Type
+
+func (v Vars) names() []string {
+	return slices.Sorted(maps.Keys(v))
+}
+
+type varsCtxKey struct{}
+
+// WithVars adds vars to the vars of the lambdas decoded with ctx.
+func WithVars(ctx context.Context, vars Vars) context.Context {
+	merged := Vars{}
+	maps.Copy(merged, GetVars(ctx))
+	maps.Copy(merged, vars)
+	return context.WithValue(ctx, varsCtxKey{}, merged)
+}
+
+// GetVars returns the vars of the lambdas decoded with ctx.
+func GetVars(ctx context.Context) Vars {
+	v, _ := ctx.Value(varsCtxKey{}).(Vars)
+	return v
+}
+
+// ErrNotFound is returned by the Get of a Field when the object of the id is
+// not in the node, e.g. its component failed.
+var ErrNotFound = errors.New("not found")
+
+// Field is a value id() gives, e.g. the state of a sensor.
+type Field struct {
+	Type Type
+	// Get returns the value of the object with the id, a value of the Go
+	// type of Type.
+	Get func(ctx context.Context, id string) (any, error)
+}
+
+// Object is what id() gives for the ids declared with a type, e.g. the
+// state of a sensor.
+type Object struct {
+	// Value is id(x) itself, when it has a Get.
+	Value Field
+	// Fields are the ones of id(x), e.g. id(x).state.
+	Fields map[string]Field
+}
+
+// Registry looks the objects of the declared types up, it is the
+// cv.ComponentRegistryKey value of the context of the config.
+type Registry interface {
+	Object(t reflect.Type) (Object, bool)
+}
+
+// Lambda is an expression of the config with a result of type T, the Go
+// type of a Type or any.
+type Lambda[T any] struct {
+	Source string
+
+	tk   *token.Token
+	vars Vars
+	reg  Registry
+
+	mx      sync.Mutex
+	node    node
+	program evalFunc
+}
+
+// New parses src with vars, reg looks the objects of id() up. The lambda is
+// type checked by LinkIDs.
+func New[T any](src string, vars Vars, reg Registry) (*Lambda[T], error) {
+	l := &Lambda[T]{Source: src, vars: vars, reg: reg}
+	if TypeFor[T]() == Invalid {
+		return nil, fmt.Errorf("lambdas have no results of type %v", reflect.TypeFor[T]())
+	}
+	var err error
+	l.node, err = parse(src)
+	return l, err
+}
+
+// UnmarshalYAML implements yaml.NodeUnmarshalerContext.
+func (l *Lambda[T]) UnmarshalYAML(ctx context.Context, node ast.Node) error {
+	if tn, ok := node.(*ast.TagNode); ok && tn.Start.Value == "!lambda" {
+		node = tn.Value
+	}
+	if ln, ok := node.(*ast.LiteralNode); ok {
+		node = ln.Value
+	}
+	var src string
+	switch n := node.(type) {
+	case *ast.StringNode:
+		src = n.Value
+	case *ast.IntegerNode, *ast.FloatNode, *ast.BoolNode:
+		// e.g. initial_value: 0
+		src = n.GetToken().Value
+	default:
+		return &yaml.UnexpectedNodeTypeError{Actual: node.Type(), Expected: ast.StringType, Token: node.GetToken()}
+	}
+	reg, _ := ctx.Value(cv.ComponentRegistryKey{}).(Registry)
+	parsed, err := New[T](src, GetVars(ctx), reg)
+	if err != nil {
+		return &yaml.SyntaxError{Token: node.GetToken(), Message: "lambda " + err.Error()}
+	}
+	*l = Lambda[T]{Source: parsed.Source, tk: node.GetToken(), vars: parsed.vars, reg: reg, node: parsed.node}
+	return nil
+}
+
+// MarshalYAML implements yaml.InterfaceMarshaler.
+func (l *Lambda[T]) MarshalYAML() (interface{}, error) {
+	return l.Source, nil
+}
+
+// ValidateWithContext implements cv.Validatable, the lambda is checked by
+// LinkIDs.
+func (l *Lambda[T]) ValidateWithContext(ctx context.Context) error {
+	return nil
+}
+
+// JSONSchema implements cv.JSONSchemer.
+func (l *Lambda[T]) JSONSchema(ctx context.Context, schema func(v any) map[string]any) map[string]any {
+	return map[string]any{"type": "string", "description": "lambda returning a " + TypeFor[T]().String()}
+}
+
+// Equal compares the sources of the lambdas.
+func (l *Lambda[T]) Equal(other *Lambda[T]) bool {
+	if l == nil || other == nil {
+		return l == other
+	}
+	return l.Source == other.Source
+}
+
+// LinkIDs implements cid.Linker, it type checks the lambda with the types
+// declared for the ids of id().
+func (l *Lambda[T]) LinkIDs(types func(id string) []reflect.Type) error {
+	if err := l.link(types); err != nil {
+		if l.tk != nil {
+			return &yaml.SyntaxError{Token: l.tk, Message: "lambda " + err.Error()}
+		}
+		return err
+	}
+	return nil
+}
+
+func (l *Lambda[T]) link(types func(id string) []reflect.Type) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	if l.node == nil {
+		var err error
+		if l.node, err = parse(l.Source); err != nil {
+			return err
+		}
+	}
+	c := &checker{vars: l.vars, object: func(pos int, id string) (Object, error) {
+		return l.object(pos, id, types)
+	}}
+	x, err := c.value(l.node)
+	if err != nil {
+		return err
+	}
+	want := TypeFor[T]()
+	if want != Any && x.typ != want {
+		return errorf(l.node.position(), "the lambda returns a %s, not a %s", x.typ, want)
+	}
+	l.program = x.eval
+	return nil
+}
+
+// object finds the object of id among the types declared for it.
+func (l *Lambda[T]) object(pos int, id string, types func(id string) []reflect.Type) (Object, error) {
+	var declared []reflect.Type
+	if types != nil {
+		declared = types(id)
+	}
+	if len(declared) == 0 {
+		return Object{}, errorf(pos, "there is no id %q", id)
+	}
+	var found []Object
+	for _, t := range declared {
+		if l.reg == nil {
+			break
+		}
+		if o, ok := l.reg.Object(t); ok {
+			found = append(found, o)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Object{}, errorf(pos, "id(%s) has no value for lambdas", id)
+	case 1:
+		return found[0], nil
+	}
+	return Object{}, errorf(pos, "%q is the id of more than one entity, lambdas need unique ids", id)
+}
+
+// Eval evaluates the lambda with the values of its vars. A lambda is linked
+// when the config is resolved, one that is not is linked without ids.
+func (l *Lambda[T]) Eval(ctx context.Context, args map[string]any) (ret T, err error) {
+	l.mx.Lock()
+	program := l.program
+	l.mx.Unlock()
+	if program == nil {
+		if err := l.link(nil); err != nil {
+			return ret, err
+		}
+		l.mx.Lock()
+		program = l.program
+		l.mx.Unlock()
+	}
+	v, err := program(&env{ctx: ctx, args: args})
+	if err != nil {
+		return ret, err
+	}
+	return convert[T](v)
+}
+
+// convert makes v, a value of the language, a T. A nil v is the zero T if
+// T can be nil.
+func convert[T any](v any) (ret T, err error) {
+	if r, ok := v.(T); ok {
+		return r, nil
+	}
+	rv := reflect.ValueOf(&ret).Elem()
+	if v == nil {
+		switch rv.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return ret, nil
+		}
+		return ret, fmt.Errorf("lambda returned no value, want %s", rv.Type())
+	}
+	if f, ok := v.(float64); ok && rv.CanInt() {
+		rv.SetInt(int64(f))
+		return ret, nil
+	}
+	if f, ok := v.(float64); ok && rv.CanUint() {
+		rv.SetUint(uint64(max(f, 0)))
+		return ret, nil
+	}
+	vv := reflect.ValueOf(v)
+	if !vv.CanConvert(rv.Type()) {
+		return ret, fmt.Errorf("lambda returned %T, want %s", v, rv.Type())
+	}
+	rv.Set(vv.Convert(rv.Type()))
+	return ret, nil
+}
+
+var _ yaml.NodeUnmarshalerContext = (*Lambda[bool])(nil)
+var _ yaml.InterfaceMarshaler = (*Lambda[bool])(nil)
+var _ cv.JSONSchemer = (*Lambda[bool])(nil)
+var _ cv.Validatable = (*Lambda[bool])(nil)
+var _ cid.Linker = (*Lambda[bool])(nil)
+
+// Value is a value of the config that is a constant or a lambda with the
+// tag !lambda, e.g. value: !lambda return x * 2;.
+type Value[T any] struct {
+	Const  T
+	Lambda *Lambda[T]
+}
+
+// UnmarshalYAML implements yaml.NodeUnmarshalerContext.
+func (v *Value[T]) UnmarshalYAML(ctx context.Context, node ast.Node) error {
+	if tn, ok := node.(*ast.TagNode); ok && tn.Start.Value == "!lambda" {
+		v.Lambda = &Lambda[T]{}
+		return v.Lambda.UnmarshalYAML(ctx, node)
+	}
+	dec := ctx.Value(cv.ConfigYAMLDecoderKey{}).(*yaml.Decoder)
+	return dec.DecodeFromNodeContext(ctx, node, &v.Const)
+}
+
+// MarshalYAML implements yaml.BytesMarshaler, the lambda keeps its tag.
+func (v Value[T]) MarshalYAML() ([]byte, error) {
+	if v.Lambda != nil {
+		return []byte("!lambda " + strconv.Quote(v.Lambda.Source)), nil
+	}
+	return yaml.Marshal(v.Const)
+}
+
+// JSONSchema implements cv.JSONSchemer.
+func (v *Value[T]) JSONSchema(ctx context.Context, schema func(v any) map[string]any) map[string]any {
+	return map[string]any{"anyOf": []any{schema(&v.Const), (&Lambda[T]{}).JSONSchema(ctx, schema)}}
+}
+
+// ValidateWithContext implements cv.Validatable, the lambda is checked by
+// LinkIDs.
+func (v *Value[T]) ValidateWithContext(ctx context.Context) error {
+	return nil
+}
+
+// IsSet tells whether the value is in the config.
+func (v *Value[T]) IsSet() bool {
+	return v.Lambda != nil || !reflect.ValueOf(&v.Const).Elem().IsZero()
+}
+
+// Get returns the constant or evaluates the lambda with args.
+func (v *Value[T]) Get(ctx context.Context, args map[string]any) (T, error) {
+	if v.Lambda != nil {
+		return v.Lambda.Eval(ctx, args)
+	}
+	return v.Const, nil
+}
+
+var _ yaml.NodeUnmarshalerContext = (*Value[bool])(nil)
+var _ yaml.BytesMarshaler = (*Value[bool])(nil)
+var _ cv.JSONSchemer = (*Value[bool])(nil)
+var _ cv.Validatable = (*Value[bool])(nil)
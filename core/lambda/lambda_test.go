@@ -0,0 +1,197 @@
+package lambda
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type testEntity interface{ testEntity() }
+
+type brokenEntity interface{ brokenEntity() }
+
+type testRegistry map[reflect.Type]Object
+
+func (r testRegistry) Object(t reflect.Type) (Object, bool) {
+	o, ok := r[t]
+	return o, ok
+}
+
+var testStates = map[string]float64{"temp": 21.5}
+
+var testReg = testRegistry{reflect.TypeFor[testEntity](): {Fields: map[string]Field{
+	"state": {Type: Number, Get: func(ctx context.Context, id string) (any, error) {
+		v, ok := testStates[id]
+		if !ok {
+			return nil, ErrNotFound
+		}
+		return v, nil
+	}},
+	"has_state": {Type: Bool, Get: func(ctx context.Context, id string) (any, error) {
+		_, ok := testStates[id]
+		return ok, nil
+	}},
+}}, reflect.TypeFor[brokenEntity](): {Fields: map[string]Field{
+	// a lambda returning these fails instead of panicking
+	"nothing": {Type: Number, Get: func(ctx context.Context, id string) (any, error) {
+		return nil, nil
+	}},
+	"mistyped": {Type: Number, Get: func(ctx context.Context, id string) (any, error) {
+		return "21.5", nil
+	}},
+}}}
+
+func testTypes(id string) []reflect.Type {
+	switch id {
+	case "temp", "gone":
+		return []reflect.Type{reflect.TypeFor[testEntity]()}
+	case "broken":
+		return []reflect.Type{reflect.TypeFor[brokenEntity]()}
+	case "other":
+		return []reflect.Type{reflect.TypeFor[error]()}
+	}
+	return nil
+}
+
+func eval[T any](t *testing.T, src string, args map[string]any) (T, error) {
+	t.Helper()
+	vars := Vars{}
+	for k, v := range args {
+		vars[k] = typeOf(reflect.TypeOf(v))
+	}
+	l, err := New[T](src, vars, testReg)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if err := l.link(testTypes); err != nil {
+		var zero T
+		return zero, err
+	}
+	return l.Eval(context.Background(), args)
+}
+
+func TestEval(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		args map[string]any
+		want any
+	}{
+		{"return 1 + 2 * 3;", nil, 7.0},
+		{"(1 + 2) * 3", nil, 9.0},
+		{"7 % 4 - -1", nil, 4.0},
+		{"1.5f / 2", nil, 0.75},
+		{"x > 20 ? \"warm\" : \"cold\"", map[string]any{"x": float32(25)}, "warm"},
+		{"x > 20 ? \"warm\" : \"cold\"", map[string]any{"x": float32(15)}, "cold"},
+		{"!true || false && true", nil, false},
+		{"NAN == NAN", nil, false},
+		{"isnan(NAN) && !isnan(PI)", nil, true},
+		{"\"a\" + \"b\" == \"ab\"", nil, true},
+		{"\"abc\" < \"abd\"", nil, true},
+		{"id(temp).state", nil, 21.5},
+		{"id(temp).has_state() && id(temp).state > 20", nil, true},
+		{"round(2.5) + floor(-0.5) + ceil(0.2) + abs(-1)", nil, 4.0},
+		{"min(3, 1, 2) + max(3, 1, 2) + clamp(12, 0, 10)", nil, 14.0},
+		{"remap(5, 0, 10, 0, 100)", nil, 50.0},
+		{"pow(2, 10) + sqrt(16)", nil, 1028.0},
+		{"to_string(1.5) + to_string(2)", nil, "1.52"},
+		{"parse_number(\"4.25\") * 2", nil, 8.5},
+		{"str_sprintf(\"%.1f %d%% %s %05.1f\", 1.25, 42.7, \"x\", 3.14159)", nil, "1.2 42% x 003.1"},
+		{"str_upper_case(\"ab\") + str_lower_case(\"CD\")", nil, "ABcd"},
+		{"str_startswith(\"abc\", \"ab\") && str_endswith(\"abc\", \"bc\") && str_contains(\"abc\", \"b\")", nil, true},
+		{"\"hello\".substr(1, 3) + \"!\".c_str()", nil, "ell!"},
+		{"\"hello\".length() + \"\".size()", nil, 5.0},
+		{"\"\".empty()", nil, true},
+		{"now().year >= 2024 && millis() >= 0", nil, true},
+		{"iteration + 1 // comment", map[string]any{"iteration": 2}, 3.0},
+	} {
+		t.Run(tc.src, func(t *testing.T) {
+			is := is.New(t)
+			v, err := eval[any](t, tc.src, tc.args)
+			is.NoErr(err)
+			is.Equal(v, tc.want)
+		})
+	}
+}
+
+func TestResults(t *testing.T) {
+	is := is.New(t)
+	f, err := eval[float32](t, "x * 2", map[string]any{"x": float32(1.5)})
+	is.NoErr(err)
+	is.Equal(f, float32(3))
+	i, err := eval[int](t, "7 / 2", nil)
+	is.NoErr(err)
+	is.Equal(i, 3)
+	s, err := eval[string](t, "x", map[string]any{"x": "on"})
+	is.NoErr(err)
+	is.Equal(s, "on")
+}
+
+func TestErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		vars Vars
+		msg  string
+	}{
+		{"1 +", nil, "col 4: unexpected end of lambda"},
+		{"return 1 +;", nil, "col 11: unexpected end of lambda"},
+		{"\"abc", nil, "col 1: unterminated string"},
+		{"a; b", Vars{"a": Bool, "b": Bool}, "col 2: a lambda is a single expression"},
+		{"if (x) return true; return false;", Vars{"x": Bool}, "col 1: a lambda is a single expression, if is a statement"},
+		{"y > 1", nil, "col 1: unknown name y"},
+		{"x + \"a\"", Vars{"x": Number}, "col 3: + is not defined for number and string"},
+		{"x ? 1 : \"a\"", Vars{"x": Bool}, "col 3: the results of ?: are a number and a string"},
+		{"1", nil, "col 1: the lambda returns a number, not a bool"},
+		{"nope(1)", nil, "col 1: unknown function nope"},
+		{"round(1, 2)", nil, "col 1: round takes (number)"},
+		{"id(missing).state", nil, "col 4: there is no id \"missing\""},
+		{"id(other).state", nil, "col 4: id(other) has no value for lambdas"},
+		{"id(temp).value", nil, "col 10: id(temp) has no .value, it has .has_state, .state"},
+		{"id(temp) > 1", nil, "col 1: id(temp) has no value, it has .has_state, .state"},
+		{"now().hour()", nil, "col 7: .hour is not a method"},
+		{"\"a\".length", nil, "col 5: .length is a method, e.g. .length()"},
+	} {
+		t.Run(tc.src, func(t *testing.T) {
+			is := is.New(t)
+			l, err := New[bool](tc.src, tc.vars, testReg)
+			if err == nil {
+				err = l.link(testTypes)
+			}
+			is.True(err != nil)
+			is.Equal(err.Error(), tc.msg)
+		})
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	is := is.New(t)
+	_, err := eval[float64](t, "id(gone).state", nil)
+	is.True(err != nil) // the entity is not in the node
+	v, err := eval[bool](t, "id(gone).has_state()", nil)
+	is.NoErr(err)
+	is.Equal(v, false)
+	_, err = eval[float64](t, "id(broken).nothing", nil)
+	is.True(err != nil)
+	_, err = eval[float64](t, "id(broken).mistyped", nil)
+	is.True(err != nil)
+}
+
+func TestSprintf(t *testing.T) {
+	is := is.New(t)
+	is.Equal(Sprintf("%s is %.2f", "pi", math.Pi), "pi is 3.14")
+	is.Equal(Sprintf("%d %i %u %x %o %c", 42.9, -3.0, 7.0, 255.0, 8.0, 65.0), "42 -3 7 ff 10 A")
+	is.Equal(Sprintf("%5.1f|%-4d|", 2.25, 3.0), "  2.2|3   |")
+	is.Equal(Sprintf("%s", true), "true")
+	is.Equal(Sprintf("100%%"), "100%")
+}
+
+func TestStrftime(t *testing.T) {
+	is := is.New(t)
+	tm := time.Date(2024, time.March, 5, 7, 8, 9, 0, time.UTC)
+	is.Equal(Strftime(tm, "%Y-%m-%d %H:%M:%S"), "2024-03-05 07:08:09")
+	is.Equal(Strftime(tm, "%a %b %e %I%p %j %%"), "Tue Mar  5 07AM 065 %")
+}
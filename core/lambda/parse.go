@@ -0,0 +1,456 @@
+package lambda
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Error is an error at the byte offset Pos of the source of a lambda.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("col %d: %s", e.Pos+1, e.Msg)
+}
+
+func errorf(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type lexToken struct {
+	kind tokenKind
+	pos  int
+	text string
+}
+
+// operators are the operators of the language, the longer ones first.
+var operators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"+", "-", "*", "/", "%", "<", ">", "!", "(", ")", ",", ".", "?", ":",
+	// only to tell that statements are not supported
+	";", "{", "}",
+}
+
+func lex(src string) ([]lexToken, error) {
+	var ret []lexToken
+	i := 0
+	for i < len(src) {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '/' && strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			i += end
+		case r >= '0' && r <= '9' || r == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.' ||
+				(src[i] == 'e' || src[i] == 'E') ||
+				(src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E')) {
+				i++
+			}
+			text := src[start:i]
+			// C++ float literals, e.g. 1.0f
+			if i < len(src) && (src[i] == 'f' || src[i] == 'F') {
+				i++
+			}
+			ret = append(ret, lexToken{tokNumber, start, text})
+		case r == '"':
+			start := i
+			i++
+			for i < len(src) && src[i] != '"' {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(src) {
+				return nil, errorf(start, "unterminated string")
+			}
+			i++
+			ret = append(ret, lexToken{tokString, start, src[start:i]})
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(src) {
+				r, size := utf8.DecodeRuneInString(src[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			ret = append(ret, lexToken{tokIdent, start, src[start:i]})
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, errorf(i, "unexpected %q", r)
+			}
+			ret = append(ret, lexToken{tokOp, i, op})
+			i += len(op)
+		}
+	}
+	return append(ret, lexToken{tokEOF, len(src), ""}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// node is an expression of the parsed source.
+type node interface {
+	position() int
+}
+
+type (
+	literal struct {
+		pos   int
+		value any
+	}
+	ident struct {
+		pos  int
+		name string
+	}
+	unary struct {
+		pos int
+		op  string
+		x   node
+	}
+	binary struct {
+		pos  int
+		op   string
+		l, r node
+	}
+	conditional struct {
+		pos        int
+		cond, a, b node
+	}
+	call struct {
+		pos  int
+		fn   string
+		args []node
+	}
+	member struct {
+		pos  int
+		x    node
+		name string
+		// args are the args of a method call, nil for a field.
+		args []node
+	}
+)
+
+func (n *literal) position() int     { return n.pos }
+func (n *ident) position() int       { return n.pos }
+func (n *unary) position() int       { return n.pos }
+func (n *binary) position() int      { return n.pos }
+func (n *conditional) position() int { return n.pos }
+func (n *call) position() int        { return n.pos }
+func (n *member) position() int      { return n.pos }
+
+// precedence of the binary operators, higher binds tighter.
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+// statements are the C++ keywords of statements, which lambdas have no
+// use for.
+var statements = map[string]bool{
+	"if": true, "else": true, "for": true, "while": true, "do": true,
+	"switch": true, "return": true, "auto": true,
+}
+
+type parser struct {
+	toks []lexToken
+	i    int
+}
+
+// body is the expression of a lambda, ESPHome lambdas are C++ with a single
+// return statement, e.g. return id(temp).state > 20;.
+func body(src string) (string, int) {
+	trimmed := strings.TrimLeftFunc(src, unicode.IsSpace)
+	offset := len(src) - len(trimmed)
+	if rest, ok := strings.CutPrefix(trimmed, "return"); ok && (rest == "" || !isIdentRune(rest)) {
+		offset += len("return")
+		trimmed = rest
+	}
+	trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+	trimmed = strings.TrimSuffix(trimmed, ";")
+	return trimmed, offset
+}
+
+func isIdentRune(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// parse parses the source of a lambda.
+func parse(src string) (node, error) {
+	expr, offset := body(src)
+	toks, err := lex(expr)
+	if err != nil {
+		return nil, shift(err, offset)
+	}
+	for _, t := range toks {
+		if t.kind == tokIdent && statements[t.text] {
+			return nil, shift(errorf(t.pos, "a lambda is a single expression, %s is a statement", t.text), offset)
+		}
+	}
+	p := &parser{toks: toks}
+	n, err := p.expr()
+	if err == nil && p.peek().kind != tokEOF {
+		t := p.peek()
+		if t.text == ";" || t.text == "{" {
+			err = errorf(t.pos, "a lambda is a single expression")
+		} else {
+			err = errorf(t.pos, "unexpected %s", t.describe())
+		}
+	}
+	if err != nil {
+		return nil, shift(err, offset)
+	}
+	shiftNode(n, offset)
+	return n, nil
+}
+
+func shift(err error, offset int) error {
+	if e, ok := err.(*Error); ok {
+		e.Pos += offset
+	}
+	return err
+}
+
+// shiftNode makes the positions of n offsets in the whole source.
+func shiftNode(n node, offset int) {
+	switch n := n.(type) {
+	case *literal:
+		n.pos += offset
+	case *ident:
+		n.pos += offset
+	case *unary:
+		n.pos += offset
+		shiftNode(n.x, offset)
+	case *binary:
+		n.pos += offset
+		shiftNode(n.l, offset)
+		shiftNode(n.r, offset)
+	case *conditional:
+		n.pos += offset
+		shiftNode(n.cond, offset)
+		shiftNode(n.a, offset)
+		shiftNode(n.b, offset)
+	case *call:
+		n.pos += offset
+		for _, a := range n.args {
+			shiftNode(a, offset)
+		}
+	case *member:
+		n.pos += offset
+		shiftNode(n.x, offset)
+		for _, a := range n.args {
+			shiftNode(a, offset)
+		}
+	}
+}
+
+func (t lexToken) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of lambda"
+	case tokNumber:
+		return "number " + t.text
+	case tokString:
+		return "string " + t.text
+	}
+	return strconv.Quote(t.text)
+}
+
+func (p *parser) peek() lexToken {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() lexToken {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) isOp(op string) bool {
+	t := p.peek()
+	return t.kind == tokOp && t.text == op
+}
+
+func (p *parser) expect(op string) error {
+	if !p.isOp(op) {
+		t := p.peek()
+		return errorf(t.pos, "expected %q, got %s", op, t.describe())
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) expr() (node, error) {
+	cond, err := p.binary(1)
+	if err != nil || !p.isOp("?") {
+		return cond, err
+	}
+	pos := p.next().pos
+	a, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	b, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	return &conditional{pos: pos, cond: cond, a: a, b: b}, nil
+}
+
+func (p *parser) binary(min int) (node, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		prec, ok := precedence[t.text]
+		if t.kind != tokOp || !ok || prec < min {
+			return l, nil
+		}
+		p.next()
+		r, err := p.binary(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		l = &binary{pos: t.pos, op: t.text, l: l, r: r}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if p.isOp("!") || p.isOp("-") || p.isOp("+") {
+		t := p.next()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{pos: t.pos, op: t.text, x: x}, nil
+	}
+	return p.postfix()
+}
+
+func (p *parser) postfix() (node, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(".") {
+		p.next()
+		t := p.next()
+		if t.kind != tokIdent {
+			return nil, errorf(t.pos, "expected a name after \".\", got %s", t.describe())
+		}
+		m := &member{pos: t.pos, x: x, name: t.text}
+		if p.isOp("(") {
+			p.next()
+			if m.args, err = p.args(); err != nil {
+				return nil, err
+			}
+			if m.args == nil {
+				m.args = []node{}
+			}
+		}
+		x = m
+	}
+	return x, nil
+}
+
+// args parses the args of a call after its "(".
+func (p *parser) args() ([]node, error) {
+	var ret []node
+	if p.isOp(")") {
+		p.next()
+		return ret, nil
+	}
+	for {
+		a, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, a)
+		if p.isOp(")") {
+			p.next()
+			return ret, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, errorf(t.pos, "invalid number %s", t.text)
+		}
+		return &literal{pos: t.pos, value: v}, nil
+	case tokString:
+		v, err := strconv.Unquote(t.text)
+		if err != nil {
+			return nil, errorf(t.pos, "invalid string %s", t.text)
+		}
+		return &literal{pos: t.pos, value: v}, nil
+	case tokIdent:
+		if !p.isOp("(") {
+			return &ident{pos: t.pos, name: t.text}, nil
+		}
+		p.next()
+		args, err := p.args()
+		if err != nil {
+			return nil, err
+		}
+		return &call{pos: t.pos, fn: t.text, args: args}, nil
+	case tokOp:
+		if t.text == "(" {
+			x, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		}
+	}
+	return nil, errorf(t.pos, "unexpected %s", t.describe())
+}
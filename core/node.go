@@ -1,18 +1,24 @@
 package core
 
 import (
-	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"log/slog"
-	"reflect"
+	"maps"
+	"path/filepath"
 	"slices"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/gosthome/gosthome/core/automation"
 	"github.com/gosthome/gosthome/core/bus"
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/config"
 	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/guarded"
+	"github.com/gosthome/gosthome/core/preferences"
 )
 
 type Node struct {
@@ -21,9 +27,28 @@ type Node struct {
 	Config *config.Config
 
 	cmp []component.Component
-	ctx context.Context
+	// keys holds the config key each of cmp was created from.
+	keys   []string
+	ctx    context.Context
+	health *guarded.RWValue[Health]
+	prefs  *preferences.Store
+	// started holds the set up components in setup order.
+	started []component.Component
+	// lifecycle serializes Start, Reload and Shutdown.
+	lifecycle sync.Mutex
+	// automations runs the automations until the node shuts down.
+	automations *automation.Runner
 }
 
+// DefaultShutdownTimeout is used when the config has no shutdown_timeout.
+const DefaultShutdownTimeout = config.DefaultShutdownTimeout
+
+// shutdownGrace is the time components closed after the shutdown deadline
+// get to notice the expired context and return.
+const shutdownGrace = 100 * time.Millisecond
+
+var ErrShutdownTimeout = errors.New("shutdown deadline exceeded")
+
 type nodeCtxKey struct{}
 
 func GetNode(ctx context.Context) *Node {
@@ -50,7 +75,9 @@ func createFromConfig(ctx context.Context, cfg *config.Config, name string, conf
 	return cmpA, nil
 }
 
-func NewNode(ctx context.Context, cfg *config.Config) (*Node, error) {
+// componentKeys adds the default config of the auto loaded components missing
+// in cfg and returns the keys of cfg in creation order.
+func componentKeys(cfg *config.Config) ([]string, error) {
 	requiredComponents := component.Depends()
 	for _, componentConfig := range cfg.Components {
 		al, ok := componentConfig.Config.(component.AutoLoader)
@@ -58,52 +85,197 @@ func NewNode(ctx context.Context, cfg *config.Config) (*Node, error) {
 			requiredComponents.Join(al.AutoLoad())
 		}
 	}
+	keys := slices.Sorted(maps.Keys(requiredComponents))
+	for _, c := range keys {
+		if _, ok := cfg.Components[c]; ok {
+			continue
+		}
+		slog.Debug("required component is not in config, using default", "component", c)
+		cd, ok := cfg.Get(c)
+		if !ok {
+			return nil, fmt.Errorf("required component %s is not registered!", c)
+		}
+		cfg.Components[c] = cd.Config()
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Components)) {
+		if _, ok := requiredComponents[name]; !ok {
+			keys = append(keys, name)
+		}
+	}
+	return keys, nil
+}
+
+// create creates the components of the config key.
+func (n *Node) create(key string) error {
+	cmpA, err := createFromConfig(n.ctx, n.Config, key, n.Config.Components[key].Config)
+	if err != nil {
+		return err
+	}
+	for _, c := range cmpA {
+		n.cmp = append(n.cmp, c)
+		n.keys = append(n.keys, key)
+	}
+	return nil
+}
+
+// preferencesPath returns the preferences file of the node in data_dir.
+func preferencesPath(cfg *config.Config) string {
+	if cfg.Gosthome.DataDir == "" {
+		return ""
+	}
+	name := cfg.Gosthome.Name
+	if name == "" {
+		name = cfg.Gosthome.FriendlyName
+	}
+	return filepath.Join(cfg.Gosthome.DataDir, name+".preferences.json")
+}
+
+// queueOptions are the bus queue settings of the gosthome config.
+func queueOptions(cfg *config.Config) []bus.QueueOption {
+	ret := []bus.QueueOption{bus.WithOverflow(cfg.Gosthome.QueueOverflow)}
+	if cfg.Gosthome.QueueSize > 0 {
+		ret = append(ret, bus.WithQueueSize(cfg.Gosthome.QueueSize))
+	}
+	return ret
+}
+
+func NewNode(ctx context.Context, cfg *config.Config) (*Node, error) {
 	ret := &Node{
 		Config:   cfg,
-		Bus:      bus.New(),
+		Bus:      bus.New(bus.WithJournal(cfg.Gosthome.JournalSize), bus.WithDefaultQueue(queueOptions(cfg)...)),
 		Registry: &entity.Registry{},
 		cmp:      []component.Component{},
+		health:   guarded.NewRW(Health{}),
 	}
 	ctx = context.WithValue(ctx, nodeCtxKey{}, ret)
 	ctx = bus.Context(ctx, ret.Bus)
-	for c := range requiredComponents {
-		componentConfig, ok := cfg.Components[c]
-		if !ok {
-			slog.Debug("required component is not in config, using default", "component", c)
-			cd, ok := cfg.Get(c)
-			if !ok {
-				return nil, fmt.Errorf("required component %s is not registered!", c)
-			}
-			cfg.Components[c] = cd.Config()
-			componentConfig = cfg.Components[c]
-		}
-		cmpA, err := createFromConfig(ctx, cfg, c, componentConfig.Config)
+	var err error
+	ret.prefs, err = preferences.Open(preferencesPath(cfg), cfg.Gosthome.FlushInterval)
+	if err != nil {
+		return nil, err
+	}
+	ctx = preferences.Context(ctx, ret.prefs)
+	ret.automations = automation.NewRunner(ctx)
+	ctx = automation.Context(ctx, ret.automations)
+	ret.ctx = ctx
+	ret.Registry.EmitChanges(ret.Bus)
+	keys, err := componentKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		err := ret.create(key)
 		if err != nil {
 			return nil, err
 		}
-		ret.cmp = append(ret.cmp, cmpA...)
 	}
-	for name, componentConfig := range cfg.Components {
-		if _, ok := requiredComponents[name]; ok {
+	return ret, nil
+}
+
+// Start sets up the components in dependency order and runs on_boot.
+// Components which dependencies failed are not set up and are reported as
+// failed.
+func (n *Node) Start() Health {
+	n.lifecycle.Lock()
+	defer n.lifecycle.Unlock()
+	h := n.publish(n.setup(n.cmp, nil))
+	if n.Config != nil {
+		n.automations.Run("on_boot", n.Config.Gosthome.OnBoot, nil)
+	}
+	return h
+}
+
+// setup sets up cmps in dependency order, running are the already set up
+// components cmps may depend on.
+func (n *Node) setup(cmps []component.Component, running []ComponentHealth) []ComponentHealth {
+	hs := make([]ComponentHealth, len(cmps))
+	for i, c := range cmps {
+		hs[i] = ComponentHealth{ID: c.ID(), Type: typeName(c), Status: component.StatusOk}
+	}
+	failed := map[string]struct{}{}
+	existing := map[string]struct{}{}
+	for _, ch := range running {
+		existing[ch.ID] = struct{}{}
+		if ch.Status == component.StatusFailed {
+			failed[ch.ID] = struct{}{}
+		}
+	}
+	ordered, unordered := setupOrder(cmps, existing)
+	for i, err := range unordered {
+		hs[i].Status = component.StatusFailed
+		hs[i].Err = err
+		failed[cmps[i].ID()] = struct{}{}
+		setAvailable(cmps[i], false)
+	}
+	for _, i := range ordered {
+		c := cmps[i]
+		ch := &hs[i]
+		if d, ok := c.(component.Dependent); ok {
+			for _, id := range d.DependsOn() {
+				if _, ok := failed[id]; ok {
+					ch.Status = component.StatusFailed
+					ch.Err = fmt.Errorf("%w: %s", component.ErrDependencyFailed, id)
+					break
+				}
+			}
+		}
+		if ch.Status == component.StatusFailed {
+			slog.Error("Skipping component setup", "cmp", ch.Type, "id", ch.ID, "err", ch.Err)
+			failed[ch.ID] = struct{}{}
+			setAvailable(c, false)
 			continue
 		}
-		cmpA, err := createFromConfig(ctx, cfg, name, componentConfig.Config)
-		if err != nil {
-			return nil, err
+		slog.Info("Setting up component", "cmp", ch.Type)
+		err := c.Setup(n.ctx)
+		ch.Status = component.StatusOf(err)
+		ch.Err = err
+		setAvailable(c, ch.Status != component.StatusFailed)
+		if ch.Status != component.StatusFailed {
+			n.started = append(n.started, c)
+		}
+		switch ch.Status {
+		case component.StatusFailed:
+			slog.Error("Failed to set up component", "cmp", ch.Type, "id", ch.ID, "err", err)
+			failed[ch.ID] = struct{}{}
+		case component.StatusWarning:
+			slog.Warn("Component set up with warning", "cmp", ch.Type, "id", ch.ID, "err", err)
+		default:
+			slog.Info("Done setting up", "cmp", ch.Type)
 		}
-		ret.cmp = append(ret.cmp, cmpA...)
 	}
-	return ret, nil
+	return hs
+}
+
+// setAvailable marks the entity c unavailable when its setup failed.
+func setAvailable(c component.Component, available bool) {
+	if a, ok := c.(entity.WithAvailability); ok {
+		a.SetAvailable(available)
+	}
 }
 
-func (n *Node) Start() {
-	for _, cmp := range slices.SortedFunc(slices.Values(n.cmp), func(l component.Component, r component.Component) int {
-		return cmp.Compare(int(l.InitializationPriority()), int(r.InitializationPriority()))
-	}) {
-		slog.Info("Setting up component", "cmp", reflect.TypeOf(cmp).String())
-		cmp.Setup()
-		slog.Info("Done setting up", "cmp", reflect.TypeOf(cmp).String())
+// publish stores the health of the components and emits a HealthEvent.
+func (n *Node) publish(components []ComponentHealth) Health {
+	h := Health{
+		Status:     component.StatusOk,
+		Components: components,
 	}
+	for _, ch := range h.Components {
+		h.Status = max(h.Status, ch.Status)
+	}
+	n.health.Write(func(v *Health) { *v = h })
+	bus.MakeEventEmitter[HealthEvent](n.Bus).Emit(&HealthEvent{Health: h})
+	return h
+}
+
+// Health returns the outcome of the last Start or Reload.
+func (n *Node) Health() (h Health) {
+	n.health.Read(func(v *Health) { h = *v })
+	return
+}
+
+// Preferences returns the store components persist their data in.
+func (n *Node) Preferences() *preferences.Store {
+	return n.prefs
 }
 
 type ComponentPredicate func(c component.Component) bool
@@ -116,15 +288,89 @@ func (n *Node) GetComponent(predicate ComponentPredicate) (component.Component,
 	return n.cmp[i], true
 }
 
+// shutdownTimeout returns the configured shutdown_timeout.
+func (n *Node) shutdownTimeout() time.Duration {
+	if n.Config.Gosthome.ShutdownTimeout == 0 {
+		return DefaultShutdownTimeout
+	}
+	return n.Config.Gosthome.ShutdownTimeout
+}
+
+// Close shuts the node down within the configured shutdown_timeout.
 func (n *Node) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), n.shutdownTimeout())
+	defer cancel()
+	return n.Shutdown(ctx)
+}
+
+// Shutdown runs on_shutdown and stops the running automations, then closes
+// the components in reverse setup order, followed by the components that
+// were never set up, and writes the preferences. Components still closing
+// when ctx is done are logged and left behind, the rest are closed with the
+// expired context and a short grace period each.
+func (n *Node) Shutdown(ctx context.Context) error {
+	n.lifecycle.Lock()
+	defer n.lifecycle.Unlock()
+	if n.Config != nil {
+		n.automations.RunWait(ctx, "on_shutdown", n.Config.Gosthome.OnShutdown, nil)
+	}
+	if aerr := n.automations.Stop(ctx); aerr != nil {
+		slog.Error("Automations missed the shutdown deadline", "err", aerr)
+	}
+	err := closeComponents(ctx, n.closeOrder(n.cmp))
+	n.started = nil
+	if perr := n.prefs.Close(); perr != nil {
+		slog.Error("Failed to write preferences", "err", perr)
+		err = errors.Join(err, perr)
+	}
+	return err
+}
+
+// closeOrder orders cmps by reverse setup order, followed by the components
+// that were never set up.
+func (n *Node) closeOrder(cmps []component.Component) []component.Component {
+	order := []component.Component{}
+	for _, c := range slices.Backward(n.started) {
+		if slices.Contains(cmps, c) {
+			order = append(order, c)
+		}
+	}
+	for _, c := range slices.Backward(cmps) {
+		if !slices.Contains(n.started, c) {
+			order = append(order, c)
+		}
+	}
+	return order
+}
+
+func closeComponents(ctx context.Context, order []component.Component) error {
 	errs := []error{}
-	for _, c := range n.cmp {
-		err := c.Close()
+	missed := []string{}
+	for _, c := range order {
+		done := make(chan error, 1)
+		go func() {
+			done <- c.Close(ctx)
+		}()
+		var err error
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			select {
+			case err = <-done:
+			case <-time.After(shutdownGrace):
+				slog.Error("Component missed the shutdown deadline", "cmp", typeName(c), "id", c.ID())
+				missed = append(missed, c.ID())
+				continue
+			}
+		}
 		if err != nil {
 			errs = append(errs, err)
-			slog.Error("Failed to stop component", "err", err)
+			slog.Error("Failed to stop component", "cmp", typeName(c), "id", c.ID(), "err", err)
 		}
 	}
+	if len(missed) > 0 {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrShutdownTimeout, strings.Join(missed, ", ")))
+	}
 	if len(errs) > 0 {
 		return errors.Join(errs...)
 	}
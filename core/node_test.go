@@ -0,0 +1,46 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/core/guarded"
+	"github.com/matryer/is"
+)
+
+func TestNodeShutdown(t *testing.T) {
+	is := is.New(t)
+	setup, closed := []string{}, []string{}
+	hang := make(chan struct{})
+	defer close(hang)
+	mk := func(id string, hang chan struct{}, deps ...string) component.Component {
+		return &testComponent{CID: cid.NewID(id), deps: deps, setup: &setup, closed: &closed, hang: hang}
+	}
+	n := &Node{
+		Bus:    bus.New(),
+		Config: &config.Config{Gosthome: config.GosthomeConfig{ShutdownTimeout: 50 * time.Millisecond}},
+		cmp: []component.Component{
+			mk("button", nil, "uart"),
+			mk("uart", nil),
+			mk("serial", hang),
+			mk("unused", nil, "missing"),
+		},
+		ctx:    context.Background(),
+		health: guarded.NewRW(Health{}),
+	}
+	n.Start()
+	is.Equal(setup, []string{"uart", "button", "serial"})
+
+	start := time.Now()
+	err := n.Close()
+	is.True(time.Since(start) < time.Second)
+	is.True(errors.Is(err, ErrShutdownTimeout))
+	is.Equal(err.Error(), "shutdown deadline exceeded: serial")
+	is.Equal(closed, []string{"button", "uart", "unused"})
+}
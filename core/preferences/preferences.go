@@ -0,0 +1,208 @@
+// Package preferences persists small values, like the last state of an
+// entity, across restarts of the node.
+package preferences
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultFlushInterval is used when the config has no flush_interval.
+const DefaultFlushInterval = time.Minute
+
+// Store is a key-value store kept in memory and written to its file in
+// batches. The zero path keeps the values in memory only.
+type Store struct {
+	path     string
+	interval time.Duration
+
+	mx     sync.Mutex
+	values map[string]json.RawMessage
+	dirty  bool
+	flush  *time.Timer
+	closed bool
+}
+
+// Open loads the store from path, a missing file is an empty store. Changes
+// are written to path at most once per flushInterval.
+func Open(path string, flushInterval time.Duration) (*Store, error) {
+	if flushInterval <= 0 {
+		flushInterval = DefaultFlushInterval
+	}
+	s := &Store{
+		path:     path,
+		interval: flushInterval,
+		values:   map[string]json.RawMessage{},
+	}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading preferences: %w", err)
+	}
+	err = json.Unmarshal(data, &s.values)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding preferences from %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *Store) load(key string, v any) (bool, error) {
+	s.mx.Lock()
+	data, ok := s.values[key]
+	s.mx.Unlock()
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+func (s *Store) save(key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if bytes.Equal(s.values[key], data) {
+		return nil
+	}
+	s.values[key] = data
+	s.dirty = true
+	if s.path != "" && s.flush == nil && !s.closed {
+		s.flush = time.AfterFunc(s.interval, func() {
+			err := s.Flush()
+			if err != nil {
+				slog.Error("Failed to write preferences", "path", s.path, "err", err)
+			}
+		})
+	}
+	return nil
+}
+
+// Flush writes the changed values to the file of the store. The file is
+// replaced atomically, it never holds a partial write.
+func (s *Store) Flush() error {
+	if s == nil {
+		return nil
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.flush != nil {
+		s.flush.Stop()
+		s.flush = nil
+	}
+	if !s.dirty || s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.values, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = writeFile(s.path, data)
+	if err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+func writeFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Close writes the pending changes, later changes are kept in memory only.
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mx.Lock()
+	s.closed = true
+	s.mx.Unlock()
+	return s.Flush()
+}
+
+// Pref is a typed value of a Store. A Pref of a nil Store loads nothing and
+// saves nothing.
+type Pref[T any] struct {
+	s   *Store
+	key string
+}
+
+// Make returns the value of s under key.
+func Make[T any](s *Store, key string) Pref[T] {
+	return Pref[T]{s: s, key: key}
+}
+
+// Load returns the saved value, ok is false if nothing was saved yet.
+func (p Pref[T]) Load() (v T, ok bool, err error) {
+	if p.s == nil {
+		return v, false, nil
+	}
+	ok, err = p.s.load(p.key, &v)
+	if err != nil {
+		return v, false, fmt.Errorf("error decoding preference %s: %w", p.key, err)
+	}
+	return v, ok, nil
+}
+
+// Save stores v, it is written to the file with the next flush.
+func (p Pref[T]) Save(v T) error {
+	if p.s == nil {
+		return nil
+	}
+	return p.s.save(p.key, v)
+}
+
+type storeCtxKey struct{}
+
+// Context returns a copy of ctx carrying s.
+func Context(ctx context.Context, s *Store) context.Context {
+	return context.WithValue(ctx, storeCtxKey{}, s)
+}
+
+// Get returns the store of the node, nil if there is none.
+func Get(ctx context.Context) *Store {
+	v := ctx.Value(storeCtxKey{})
+	if v == nil {
+		return nil
+	}
+	s, ok := v.(*Store)
+	if !ok {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package preferences
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	is := is.New(t)
+	path := filepath.Join(t.TempDir(), "node.preferences.json")
+	s, err := Open(path, time.Hour)
+	is.NoErr(err)
+	p := Make[float32](s, "number/a")
+	_, ok, err := p.Load()
+	is.NoErr(err)
+	is.True(!ok)
+	is.NoErr(p.Save(42.5))
+	_, err = os.Stat(path)
+	is.True(os.IsNotExist(err)) // not written before the flush
+
+	is.NoErr(s.Close())
+	s, err = Open(path, time.Hour)
+	is.NoErr(err)
+	v, ok, err := Make[float32](s, "number/a").Load()
+	is.NoErr(err)
+	is.True(ok)
+	is.Equal(v, float32(42.5))
+	_, _, err = Make[bool](s, "number/a").Load()
+	is.True(err != nil)
+}
+
+func TestStoreFlushInterval(t *testing.T) {
+	is := is.New(t)
+	path := filepath.Join(t.TempDir(), "node.preferences.json")
+	s, err := Open(path, 10*time.Millisecond)
+	is.NoErr(err)
+	defer s.Close()
+	is.NoErr(Make[bool](s, "switch/a").Save(true))
+	time.Sleep(100 * time.Millisecond)
+	data, err := os.ReadFile(path)
+	is.NoErr(err)
+	is.Equal(string(data), "{\n  \"switch/a\": true\n}")
+}
+
+func TestNilStore(t *testing.T) {
+	is := is.New(t)
+	p := Make[bool](nil, "switch/a")
+	is.NoErr(p.Save(true))
+	_, ok, err := p.Load()
+	is.NoErr(err)
+	is.True(!ok)
+	is.NoErr((*Store)(nil).Close())
+}
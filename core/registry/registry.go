@@ -3,28 +3,40 @@ package registry
 import (
 	"fmt"
 	"maps"
+	"reflect"
+	"slices"
 	"sync"
 
+	"github.com/gosthome/gosthome/core/automation"
 	"github.com/gosthome/gosthome/core/component"
 	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/core/lambda"
 )
 
 type componentDeclMap = map[string]component.Declaration
 type entityComponentMap = map[entity.DomainType]componentDeclMap
 
 type Registry struct {
-	reg   componentDeclMap
-	ecReg entityComponentMap
+	reg        componentDeclMap
+	ecReg      entityComponentMap
+	actions    map[string]func() automation.Action
+	conditions map[string]func() automation.Condition
+	objects    map[reflect.Type]lambda.Object
 }
 
+// NewRegistry returns a registry with the builtin actions and conditions of
+// automations only.
 func NewRegistry() *Registry {
 	ecReg := make(entityComponentMap)
 	for dt := range entity.DomainTypeEnd + 1 {
 		ecReg[dt] = make(componentDeclMap)
 	}
 	return &Registry{
-		reg:   make(componentDeclMap),
-		ecReg: ecReg,
+		reg:        make(componentDeclMap),
+		ecReg:      ecReg,
+		actions:    automation.BuiltinActions(),
+		conditions: automation.BuiltinConditions(),
+		objects:    map[reflect.Type]lambda.Object{},
 	}
 }
 
@@ -42,6 +54,47 @@ func (cr *Registry) GetEntityComponent(domain entity.DomainType, platform string
 	return
 }
 
+// Names returns the names of the registered components, sorted.
+func (cr *Registry) Names() []string {
+	return slices.Sorted(maps.Keys(cr.reg))
+}
+
+// Platforms returns the registered platforms of domain, sorted.
+func (cr *Registry) Platforms(domain entity.DomainType) []string {
+	return slices.Sorted(maps.Keys(cr.ecReg[domain]))
+}
+
+// Action implements automation.Registry.
+func (cr *Registry) Action(name string) (func() automation.Action, bool) {
+	a, ok := cr.actions[name]
+	return a, ok
+}
+
+// Condition implements automation.Registry.
+func (cr *Registry) Condition(name string) (func() automation.Condition, bool) {
+	c, ok := cr.conditions[name]
+	return c, ok
+}
+
+// ActionNames returns the names of the registered actions, sorted.
+func (cr *Registry) ActionNames() []string {
+	return slices.Sorted(maps.Keys(cr.actions))
+}
+
+// ConditionNames returns the names of the registered conditions, sorted.
+func (cr *Registry) ConditionNames() []string {
+	return slices.Sorted(maps.Keys(cr.conditions))
+}
+
+// Object implements lambda.Registry.
+func (cr *Registry) Object(t reflect.Type) (lambda.Object, bool) {
+	o, ok := cr.objects[t]
+	return o, ok
+}
+
+var _ automation.Registry = (*Registry)(nil)
+var _ lambda.Registry = (*Registry)(nil)
+
 func (cr *Registry) Register(name string, cd component.Declaration) error {
 	_, ok := cr.reg[name]
 	if ok {
@@ -68,6 +121,36 @@ func (cr *Registry) RegisterEntityComponent(domain entity.DomainType, platform s
 	return nil
 }
 
+// RegisterAction registers the action name of automations, e.g.
+// switch.turn_on.
+func (cr *Registry) RegisterAction(name string, factory func() automation.Action) error {
+	if _, ok := cr.actions[name]; ok {
+		return fmt.Errorf("action %s already registered", name)
+	}
+	cr.actions[name] = factory
+	return nil
+}
+
+// RegisterCondition registers the condition name of automations, e.g.
+// binary_sensor.is_on.
+func (cr *Registry) RegisterCondition(name string, factory func() automation.Condition) error {
+	if _, ok := cr.conditions[name]; ok {
+		return fmt.Errorf("condition %s already registered", name)
+	}
+	cr.conditions[name] = factory
+	return nil
+}
+
+// RegisterObject registers what id() gives in lambdas for the ids declared
+// with the type t, e.g. entity.Sensor.
+func (cr *Registry) RegisterObject(t reflect.Type, o lambda.Object) error {
+	if _, ok := cr.objects[t]; ok {
+		return fmt.Errorf("lambda object of %v already registered", t)
+	}
+	cr.objects[t] = o
+	return nil
+}
+
 var (
 	defaultRegistry    = NewRegistry()
 	defaultRegistryMux = sync.Mutex{}
@@ -79,6 +162,9 @@ func DefaultRegistry() *Registry {
 	defer defaultRegistryMux.Unlock()
 	ret.reg = maps.Clone(defaultRegistry.reg)
 	ret.ecReg = maps.Clone(defaultRegistry.ecReg)
+	ret.actions = maps.Clone(defaultRegistry.actions)
+	ret.conditions = maps.Clone(defaultRegistry.conditions)
+	ret.objects = maps.Clone(defaultRegistry.objects)
 	return ret
 }
 
@@ -101,3 +187,33 @@ func RegisterDefaultEntityComponent(domain entity.DomainType, platform string, c
 	}
 	return 0
 }
+
+func RegisterDefaultAction(name string, factory func() automation.Action) byte {
+	defaultRegistryMux.Lock()
+	defer defaultRegistryMux.Unlock()
+	err := defaultRegistry.RegisterAction(name, factory)
+	if err != nil {
+		panic(err)
+	}
+	return 0
+}
+
+func RegisterDefaultCondition(name string, factory func() automation.Condition) byte {
+	defaultRegistryMux.Lock()
+	defer defaultRegistryMux.Unlock()
+	err := defaultRegistry.RegisterCondition(name, factory)
+	if err != nil {
+		panic(err)
+	}
+	return 0
+}
+
+func RegisterDefaultObject[T any](o lambda.Object) byte {
+	defaultRegistryMux.Lock()
+	defer defaultRegistryMux.Unlock()
+	err := defaultRegistry.RegisterObject(reflect.TypeFor[T](), o)
+	if err != nil {
+		panic(err)
+	}
+	return 0
+}
@@ -0,0 +1,228 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/core/entity"
+)
+
+var ErrRestartRequired = errors.New("restart required")
+
+// ReloadEvent is emitted after Reload replaced the components of Keys.
+type ReloadEvent struct {
+	Keys []string
+	// EntitiesChanged is set when entities were removed or added.
+	EntitiesChanged bool
+}
+
+// EventType implements bus.EventData.
+func (r *ReloadEvent) EventType() string {
+	return "node_reload"
+}
+
+var _ bus.EventData = (*ReloadEvent)(nil)
+
+// changedKeys returns the config keys which differ between the running
+// config and cfg, along with the keys auto loading any of them.
+func (n *Node) changedKeys(cfg *config.Config) []string {
+	changed := map[string]struct{}{}
+	for key, cd := range n.Config.Components {
+		if !cd.Equal(cfg.Components[key]) {
+			changed[key] = struct{}{}
+		}
+	}
+	for key := range cfg.Components {
+		if _, ok := n.Config.Components[key]; !ok {
+			changed[key] = struct{}{}
+		}
+	}
+	autoLoads := func(c *config.Config, key string, deps map[string]struct{}) bool {
+		cd, ok := c.Components[key]
+		if !ok {
+			return false
+		}
+		al, ok := cd.Config.(component.AutoLoader)
+		if !ok {
+			return false
+		}
+		for dep := range al.AutoLoad() {
+			if _, ok := deps[dep]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	for grown := true; grown; {
+		grown = false
+		for _, c := range []*config.Config{n.Config, cfg} {
+			for key := range c.Components {
+				if _, ok := changed[key]; ok {
+					continue
+				}
+				if autoLoads(c, key, changed) {
+					changed[key] = struct{}{}
+					grown = true
+				}
+			}
+		}
+	}
+	ret := make([]string, 0, len(changed))
+	for key := range changed {
+		ret = append(ret, key)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// detached are the domains and entities of components taken out of the
+// registry.
+type detached struct {
+	domains  []entity.DomainTyper
+	entities map[entity.Entity]entity.DomainType
+}
+
+// detach takes the domains and entities of cmps out of the registry, it
+// reports whether any entity was registered.
+func (n *Node) detach(cmps []component.Component) (d detached, entitiesChanged bool) {
+	registered := map[entity.Entity]entity.DomainType{}
+	for dt, ent := range entity.IterateRegistry(n.Registry) {
+		registered[ent] = dt
+	}
+	for _, c := range cmps {
+		if e, ok := c.(entity.Entity); ok {
+			if _, ok := registered[e]; ok {
+				entitiesChanged = true
+			}
+		}
+	}
+	for _, c := range cmps {
+		if dm, ok := c.(entity.DomainTyper); ok {
+			if _, isEntity := c.(entity.Entity); !isEntity {
+				err := n.RemoveDomain(dm)
+				if err != nil {
+					slog.Warn("Failed to remove domain", "domain", dm.DomainType(), "err", err)
+					continue
+				}
+				d.domains = append(d.domains, dm)
+			}
+		}
+	}
+	d.entities = map[entity.Entity]entity.DomainType{}
+	for _, c := range cmps {
+		if e, ok := c.(entity.Entity); ok && n.Unregister(e) {
+			d.entities[e] = registered[e]
+		}
+	}
+	return d, entitiesChanged
+}
+
+// attach puts detached domains and entities back in the registry.
+func (n *Node) attach(d detached) {
+	for _, dm := range d.domains {
+		if err := n.RestoreDomain(dm); err != nil {
+			slog.Error("Failed to restore domain", "domain", dm.DomainType(), "err", err)
+		}
+	}
+	for e, dt := range d.entities {
+		if err := n.Register(dt, e); err != nil {
+			slog.Error("Failed to restore entity", "id", e.ID(), "err", err)
+		}
+	}
+}
+
+// Reload applies cfg to the running node. The components of the changed
+// config keys are created again from cfg and replace the running ones, the
+// rest keep running. When a component can not be created the running
+// components are left as they were. The gosthome section can not be
+// reloaded. It returns the reloaded keys.
+func (n *Node) Reload(cfg *config.Config) ([]string, error) {
+	n.lifecycle.Lock()
+	defer n.lifecycle.Unlock()
+	// DeepEqual can not compare the actions of on_boot: and on_shutdown:
+	if !component.ConfigEqual(n.Config.Gosthome, cfg.Gosthome) {
+		return nil, fmt.Errorf("%w: gosthome section changed", ErrRestartRequired)
+	}
+	keys, err := componentKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+	changed := n.changedKeys(cfg)
+	if len(changed) == 0 {
+		n.Config = cfg
+		return nil, nil
+	}
+	slog.Info("Reloading components", "keys", changed)
+
+	health := n.Health().Components
+	old := []component.Component{}
+	kept := []component.Component{}
+	keptKeys := []string{}
+	running := []ComponentHealth{}
+	for i, c := range n.cmp {
+		if slices.Contains(changed, n.keys[i]) {
+			old = append(old, c)
+			continue
+		}
+		kept = append(kept, c)
+		keptKeys = append(keptKeys, n.keys[i])
+		if i < len(health) {
+			running = append(running, health[i])
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), n.shutdownTimeout())
+	defer cancel()
+
+	// the old components keep running while the new ones are created, they
+	// only leave the registry for the new ones to take their place
+	oldDetached, entitiesChanged := n.detach(old)
+	oldConfig, oldCmp, oldKeys := n.Config, n.cmp, n.keys
+	n.Config = cfg
+	n.cmp, n.keys = slices.Clone(kept), slices.Clone(keptKeys)
+	errs := []error{}
+	for _, key := range keys {
+		if !slices.Contains(changed, key) {
+			continue
+		}
+		err := n.create(key)
+		if err != nil {
+			slog.Error("Failed to create component", "key", key, "err", err)
+			errs = append(errs, fmt.Errorf("error creating %s: %w", key, err))
+		}
+	}
+	created := n.cmp[len(kept):]
+	if len(errs) > 0 {
+		n.detach(created)
+		if err := closeComponents(ctx, n.closeOrder(created)); err != nil {
+			errs = append(errs, err)
+		}
+		n.Config, n.cmp, n.keys = oldConfig, oldCmp, oldKeys
+		n.attach(oldDetached)
+		return nil, errors.Join(errs...)
+	}
+
+	err = closeComponents(ctx, n.closeOrder(old))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	n.started = slices.DeleteFunc(n.started, func(c component.Component) bool {
+		return slices.Contains(old, c)
+	})
+	for _, c := range created {
+		if _, ok := c.(entity.Entity); ok {
+			entitiesChanged = true
+		}
+	}
+	n.publish(append(running, n.setup(created, running)...))
+	bus.MakeEventEmitter[ReloadEvent](n.Bus).Emit(&ReloadEvent{
+		Keys:            changed,
+		EntitiesChanged: entitiesChanged,
+	})
+	return changed, errors.Join(errs...)
+}
@@ -3,42 +3,219 @@ package state
 import (
 	"context"
 	"log/slog"
+	"maps"
+	"reflect"
+	"sync"
+	"time"
 
 	"github.com/gosthome/gosthome/core/bus"
 	"github.com/gosthome/gosthome/core/entity"
 )
 
+// Option changes how a State_ publishes its changes.
+type Option func(*options)
+
+type options struct {
+	forceUpdate  bool
+	availability entity.AvailabilityWatcher
+}
+
+// WithForceUpdate publishes every update, even when the value is unchanged.
+func WithForceUpdate(force bool) Option {
+	return func(o *options) {
+		o.forceUpdate = force
+	}
+}
+
+// FollowAvailability publishes the availability of a with the state, it is
+// usually the entity.BaseEntity of the entity.
+func FollowAvailability(a entity.AvailabilityWatcher) Option {
+	return func(o *options) {
+		o.availability = a
+	}
+}
+
+// Snapshot is the state of an entity at one point in time.
+type Snapshot[T comparable] struct {
+	Value T
+	// LastChanged is when Value last became different.
+	LastChanged time.Time
+	// LastUpdated is when Value was last set, even to the same value.
+	LastUpdated time.Time
+	Available   bool
+	Attributes  map[string]any
+}
+
+type stateData[T comparable] struct {
+	mx sync.RWMutex
+	Snapshot[T]
+	// pending are the events of the changes waiting to be emitted, in the
+	// order of the changes. The goroutine that finds emitting unset emits
+	// them, no lock is held meanwhile so that a subscriber may change the
+	// state again while its queue is full.
+	pending  []*bus.StateChangeEvent
+	emitting bool
+}
+
+// State_ keeps the state of an entity and publishes its changes on the bus
+// with a bus.StateChangeEvent. It is safe for concurrent use, copies share
+// the same state.
 type State_[T comparable] struct {
 	emitter bus.Emitter[bus.StateChangeEvent, *bus.StateChangeEvent]
 
-	e     entity.Entity
-	value T
+	e    entity.Entity
+	opts options
+	data *stateData[T]
 }
 
-func NewState[T comparable](ctx context.Context, e entity.Entity, initial T) (State_[T], error) {
+func NewState[T comparable](ctx context.Context, e entity.Entity, initial T, opts ...Option) (State_[T], error) {
 	b := bus.Get(ctx)
 
-	return State_[T]{
+	ret := State_[T]{
 		emitter: bus.MakeEventEmitter[bus.StateChangeEvent](b),
 		e:       e,
-		value:   initial,
-	}, nil
+		data: &stateData[T]{
+			Snapshot: Snapshot[T]{
+				Value:     initial,
+				Available: true,
+			},
+		},
+	}
+	for _, o := range opts {
+		o(&ret.opts)
+	}
+	if a := ret.opts.availability; a != nil {
+		ret.data.Available = a.Available()
+		a.WatchAvailability(ret.setAvailable)
+	}
+	return ret, nil
 }
 
 // State implements entity.WithState.
 func (s *State_[T]) State() T {
-	return s.value
+	s.data.mx.RLock()
+	defer s.data.mx.RUnlock()
+	return s.data.Value
 }
 
-func (s *State_[T]) SetState(nv T) {
-	if s.value == nv {
+// Snapshot returns the value with its timestamps, availability and
+// attributes.
+func (s *State_[T]) Snapshot() Snapshot[T] {
+	s.data.mx.RLock()
+	defer s.data.mx.RUnlock()
+	ret := s.data.Snapshot
+	ret.Attributes = maps.Clone(ret.Attributes)
+	return ret
+}
+
+// LastChanged returns when the value last became different.
+func (s *State_[T]) LastChanged() time.Time {
+	s.data.mx.RLock()
+	defer s.data.mx.RUnlock()
+	return s.data.LastChanged
+}
+
+// LastUpdated returns when the value was last set.
+func (s *State_[T]) LastUpdated() time.Time {
+	s.data.mx.RLock()
+	defer s.data.mx.RUnlock()
+	return s.data.LastUpdated
+}
+
+// update applies f under the lock and publishes the result when f reports a
+// change or force is set. The event may be emitted by the goroutine already
+// emitting the previous changes, after update returned.
+func (s *State_[T]) update(force bool, f func(d *Snapshot[T], now time.Time) bool) {
+	s.data.mx.Lock()
+	now := time.Now()
+	old := s.data.Value
+	changed := f(&s.data.Snapshot, now)
+	if !changed && !force {
+		s.data.mx.Unlock()
 		return
 	}
-	s.value = nv
-	ns := s.value
-	slog.Info("Sending state", "id", s.e.ID(), "state", ns)
-	s.emitter.Emit(&bus.StateChangeEvent{
-		Key:      s.e.HashID(),
-		NewState: &ns,
+	ns := s.data.Value
+	ev := &bus.StateChangeEvent{
+		Key:         s.e.HashID(),
+		OldState:    &old,
+		NewState:    &ns,
+		Changed:     changed,
+		LastChanged: s.data.LastChanged,
+		LastUpdated: s.data.LastUpdated,
+		Available:   s.data.Available,
+		Attributes:  maps.Clone(s.data.Attributes),
+	}
+	if d, ok := s.e.(interface{ Domain() entity.DomainType }); ok {
+		ev.Domain = d.Domain().String()
+	}
+	s.data.pending = append(s.data.pending, ev)
+	if s.data.emitting {
+		s.data.mx.Unlock()
+		return
+	}
+	s.data.emitting = true
+	for len(s.data.pending) > 0 {
+		events := s.data.pending
+		s.data.pending = nil
+		s.data.mx.Unlock()
+		for _, ev := range events {
+			slog.Info("Sending state", "id", s.e.ID(), "state", *ev.NewState.(*T), "available", ev.Available)
+			s.emitter.Emit(ev)
+		}
+		s.data.mx.Lock()
+	}
+	s.data.emitting = false
+	s.data.mx.Unlock()
+}
+
+// SetState stores nv and publishes it if it differs from the current value,
+// or always with WithForceUpdate.
+func (s *State_[T]) SetState(nv T) {
+	s.update(s.opts.forceUpdate, func(d *Snapshot[T], now time.Time) bool {
+		d.LastUpdated = now
+		if d.Value == nv {
+			return false
+		}
+		d.Value = nv
+		d.LastChanged = now
+		return true
+	})
+}
+
+func (s *State_[T]) setAvailable(available bool) {
+	s.update(false, func(d *Snapshot[T], now time.Time) bool {
+		if d.Available == available {
+			return false
+		}
+		d.Available = available
+		d.LastChanged = now
+		return true
+	})
+}
+
+// SetAttributes merges attrs into the attributes of the state, a nil value
+// removes the attribute.
+func (s *State_[T]) SetAttributes(attrs map[string]any) {
+	s.update(false, func(d *Snapshot[T], now time.Time) bool {
+		changed := false
+		for k, v := range attrs {
+			old, ok := d.Attributes[k]
+			switch {
+			case v == nil && ok:
+				delete(d.Attributes, k)
+			case v != nil && (!ok || !reflect.DeepEqual(old, v)):
+				if d.Attributes == nil {
+					d.Attributes = map[string]any{}
+				}
+				d.Attributes[k] = v
+			default:
+				continue
+			}
+			changed = true
+		}
+		if changed {
+			d.LastUpdated = now
+		}
+		return changed
 	})
 }
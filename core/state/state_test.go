@@ -1,9 +1,173 @@
 package state
 
-import "github.com/gosthome/gosthome/core/entity"
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/matryer/is"
+)
 
 type test struct {
 	A int
 }
 
 var _ entity.WithState[test] = (*State_[test])(nil)
+
+func newTestState(t *testing.T, opts ...Option) (*State_[test], *entity.BaseEntity, <-chan *bus.StateChangeEvent) {
+	t.Helper()
+	b := bus.New()
+	events := make(chan *bus.StateChangeEvent, 100)
+	sub := b.HandleEvents(bus.EventHandler(func(e *bus.StateChangeEvent) {
+		events <- e
+	}))
+	t.Cleanup(sub.Close)
+	ent := entity.NewBaseEntity(entity.DomainTypeSensor, &entity.EntityConfig{ID: "test"})
+	opts = append(opts, FollowAvailability(&ent))
+	s, err := NewState(bus.Context(context.Background(), b), &ent, test{}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &s, &ent, events
+}
+
+func next(t *testing.T, events <-chan *bus.StateChangeEvent) *bus.StateChangeEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no state change")
+		return nil
+	}
+}
+
+func none(t *testing.T, events <-chan *bus.StateChangeEvent) {
+	t.Helper()
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected state change %#v", e)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestSetState(t *testing.T) {
+	is := is.New(t)
+	s, ent, events := newTestState(t)
+	is.True(s.Snapshot().Available)
+	is.True(s.LastChanged().IsZero())
+
+	s.SetState(test{A: 1})
+	e := next(t, events)
+	is.Equal(e.OldState, &test{})
+	is.Equal(e.NewState, &test{A: 1})
+	is.Equal(e.Domain, "sensor")
+	is.True(e.Changed)
+	is.True(e.Available)
+	is.Equal(e.LastChanged, e.LastUpdated)
+	is.Equal(s.LastChanged(), e.LastChanged)
+
+	s.SetState(test{A: 1})
+	none(t, events)
+	is.True(s.LastUpdated().After(s.LastChanged()))
+
+	ent.SetAvailable(false)
+	e = next(t, events)
+	is.True(!e.Available)
+	is.Equal(e.NewState, &test{A: 1})
+	is.True(!s.Snapshot().Available)
+	ent.SetAvailable(false)
+	none(t, events)
+
+	s.SetAttributes(map[string]any{"unit": "V"})
+	e = next(t, events)
+	is.Equal(e.Attributes, map[string]any{"unit": "V"})
+	s.SetAttributes(map[string]any{"unit": "V"})
+	none(t, events)
+	s.SetAttributes(map[string]any{"unit": nil})
+	e = next(t, events)
+	is.Equal(len(e.Attributes), 0)
+	is.Equal(len(s.Snapshot().Attributes), 0)
+}
+
+func TestForceUpdate(t *testing.T) {
+	is := is.New(t)
+	s, _, events := newTestState(t, WithForceUpdate(true))
+	s.SetState(test{A: 1})
+	is.True(next(t, events).Changed)
+	s.SetState(test{A: 1})
+	e := next(t, events)
+	is.True(!e.Changed)
+	is.Equal(e.NewState, &test{A: 1})
+	is.True(e.LastUpdated.After(e.LastChanged))
+}
+
+func TestConcurrentSetState(t *testing.T) {
+	is := is.New(t)
+	s, _, events := newTestState(t)
+	wg := sync.WaitGroup{}
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 10 {
+				s.SetState(test{A: i*10 + j + 1})
+				_ = s.State()
+			}
+		}()
+	}
+	wg.Wait()
+	// the events chain up in the order of the changes
+	prev := &test{}
+	for range 100 {
+		e := next(t, events)
+		is.Equal(e.OldState, prev)
+		prev = e.NewState.(*test)
+	}
+	is.Equal(*prev, s.State())
+}
+
+func TestSetStateFromFullSubscriber(t *testing.T) {
+	is := is.New(t)
+	b := bus.New()
+	ent := entity.NewBaseEntity(entity.DomainTypeSensor, &entity.EntityConfig{ID: "test"})
+	s, err := NewState(bus.Context(context.Background(), b), &ent, test{})
+	is.NoErr(err)
+	seen := make(chan int, 100)
+	// the handler changes the state while the emitter waits for its queue
+	sub := b.HandleEvents(bus.EventHandler(func(e *bus.StateChangeEvent) {
+		a := e.NewState.(*test).A
+		if a == 1 {
+			time.Sleep(20 * time.Millisecond)
+			s.SetState(test{A: 101})
+		}
+		seen <- a
+	}), bus.WithQueueSize(1))
+	defer sub.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for a := range 3 {
+			s.SetState(test{A: a + 1})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("SetState deadlocked")
+	}
+	got := map[int]bool{}
+	for len(got) < 4 {
+		select {
+		case a := <-seen:
+			got[a] = true
+		case <-time.After(time.Second):
+			t.Fatalf("missing state changes, got %v", got)
+		}
+	}
+	is.Equal(got, map[int]bool{1: true, 2: true, 3: true, 101: true})
+}
@@ -0,0 +1,158 @@
+package tests_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/components/api"
+	"github.com/gosthome/gosthome/components/api/client"
+	ehp "github.com/gosthome/gosthome/components/api/esphomeproto"
+	"github.com/gosthome/gosthome/components/api/recorder"
+	"github.com/gosthome/gosthome/components/audit"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/tests"
+	"github.com/matryer/is"
+)
+
+func readAudit(t *testing.T, path string) (ret []audit.Record) {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var r audit.Record
+		if err := json.Unmarshal(s.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		ret = append(ret, r)
+	}
+	return
+}
+
+func TestAuditRateLimit(t *testing.T) {
+	is := is.New(t)
+	port := tests.GetFreePort(t)
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+api:
+    address: "127.0.0.1"
+    port: %d
+    rate_limits:
+      - entity: button.demo_regenerate_seed
+        command: button.press
+        interval: 1h
+
+audit:
+    path: %s
+
+demo:
+`, port, auditPath)))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	n.Start()
+
+	c := client.New(context.Background(), "127.0.0.1", uint16(port))
+	is.NoErr(c.Connect())
+	defer c.Close()
+	is.NoErr(c.ListEntities(5 * time.Second))
+	b, ok := c.ButtonByKey(258008683)
+	is.True(ok)
+	is.NoErr(b.Press(context.Background()))
+	is.NoErr(b.Press(context.Background()))
+
+	var commands []audit.Record
+	deadline := time.Now().Add(5 * time.Second)
+	for len(commands) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		commands = commands[:0]
+		for _, r := range readAudit(t, auditPath) {
+			if r.Event == "command" {
+				commands = append(commands, r)
+			}
+		}
+	}
+	is.Equal(len(commands), 2)
+	// commands are logged as they complete, not as they were sent
+	slices.SortStableFunc(commands, func(a, b audit.Record) int { return a.Time.Compare(b.Time) })
+	is.Equal(commands[0].Entity, "demo_regenerate_seed")
+	is.Equal(commands[0].Domain, "button")
+	is.Equal(commands[0].Command, "button.press")
+	is.Equal(commands[0].Client, "gosthome client")
+	is.True(commands[0].Remote != "")
+	is.Equal(commands[0].Result, bus.CommandResultOK)
+	is.True(commands[0].RequestID != "")
+	is.Equal(commands[1].Result, bus.CommandResultRateLimited)
+	is.Equal(commands[1].RequestID, "")
+}
+
+func TestUnsupportedCommand(t *testing.T) {
+	is := is.New(t)
+	cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+api:
+    address: "127.0.0.1"
+    port: %d
+
+demo:
+`, tests.GetFreePort(t))))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	n.Start()
+	commands := make(chan *bus.CommandEvent, 10)
+	sub := n.HandleEvents(bus.EventHandler(func(e *bus.CommandEvent) { commands <- e }))
+	defer sub.Close()
+
+	// no component handles light commands, not even for a switch
+	recording := &bytes.Buffer{}
+	enc := json.NewEncoder(recording)
+	for _, msg := range []ehp.EsphomeMessageTyper{
+		&ehp.HelloRequest{ClientInfo: "test", ApiVersionMajor: 1, ApiVersionMinor: 10},
+		&ehp.LightCommandRequest{Key: demoSwitch1Key, HasState: true, State: true},
+	} {
+		e, err := recorder.NewEntry(time.Now(), recorder.PeerClient, msg)
+		is.NoErr(err)
+		is.NoErr(enc.Encode(e))
+	}
+	rp, err := recorder.NewReplayer(recording)
+	is.NoErr(err)
+	c, ok := n.GetComponent(func(c component.Component) bool {
+		_, ok := c.(*api.Server)
+		return ok
+	})
+	is.True(ok)
+	_, err = c.(*api.Server).Replay(rp)
+	is.NoErr(err)
+	select {
+	case e := <-commands:
+		is.Equal(e.Command, "light.command")
+		is.Equal(e.Entity, "demo_switch_1")
+		is.Equal(e.Result, bus.CommandResultUnsupported)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the command was not reported")
+	}
+}
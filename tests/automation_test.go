@@ -0,0 +1,113 @@
+package tests_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/components/button"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/matryer/is"
+)
+
+func TestAutomations(t *testing.T) {
+	is := is.New(t)
+	cfg, err := config.LoadConfig(strings.NewReader(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+    on_boot:
+      - switch.turn_on: lamp
+
+switch:
+  - platform: template
+    id: lamp
+    name: Lamp
+    optimistic: true
+  - platform: template
+    id: relay
+    name: Relay
+    optimistic: true
+    on_turn_on:
+      - number.set: {id: level, value: 5}
+    on_turn_off:
+      - number.set: {id: level, value: 0}
+
+number:
+  - platform: template
+    id: level
+    name: Level
+    optimistic: true
+    min_value: 0
+    max_value: 10
+    step: 1
+
+button:
+  - platform: template
+    id: toggle
+    name: Toggle
+    on_press:
+      - if:
+          condition:
+            switch.is_on: lamp
+          then:
+            - switch.toggle: relay
+`))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	n.Start()
+
+	lamp, ok := n.SwitchByKey(cid.HashID("lamp"))
+	is.True(ok)
+	relay, ok := n.SwitchByKey(cid.HashID("relay"))
+	is.True(ok)
+	level, ok := n.NumberByKey(cid.HashID("level"))
+	is.True(ok)
+	eventually := func(what string, f func() bool) {
+		t.Helper()
+		for range 100 {
+			if f() {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal(what)
+	}
+	eventually("on_boot did not turn the lamp on", func() bool { return lamp.State().State })
+
+	press := func() {
+		_, err := bus.Call[*button.ButtonPress, any](context.Background(), n.Bus, &button.ButtonPress{Key: cid.HashID("toggle")})
+		is.NoErr(err)
+	}
+	press()
+	eventually("on_press did not turn the relay on", func() bool { return relay.State().State })
+	eventually("on_turn_on did not set the level", func() bool { return level.State().State == 5 })
+
+	press()
+	eventually("on_turn_off did not reset the level", func() bool { return level.State().State == 0 })
+	is.Equal(relay.State().State, false)
+}
+
+func TestAutomationUnknownID(t *testing.T) {
+	is := is.New(t)
+	_, err := config.LoadConfig(strings.NewReader(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+    on_boot:
+      - switch.turn_on: nope
+
+switch:
+  - platform: template
+    id: lamp
+    name: Lamp
+`))
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "nope")) // the unknown id is reported
+}
@@ -0,0 +1,90 @@
+package tests_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/components/api/client"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/tests"
+	"github.com/majfault/signal/dispatcher"
+	"github.com/matryer/is"
+)
+
+func TestAvailability(t *testing.T) {
+	is := is.New(t)
+	port := tests.GetFreePort(t)
+	cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+api:
+    address: "127.0.0.1"
+    port: %d
+
+demo:
+`, port)))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	n.Start()
+
+	c := client.New(context.Background(), "127.0.0.1", uint16(port))
+	disconnected := make(chan struct{}, 1)
+	c.OnDisconnect = func() {
+		select {
+		case disconnected <- struct{}{}:
+		default:
+		}
+	}
+	is.NoErr(c.Connect())
+	defer c.Close()
+	is.NoErr(c.ListEntities(5 * time.Second))
+
+	bs := n.BinarySensors()[0]
+	states := make(chan *client.BinarySensorComponent, 10)
+	c.States().Connect(dispatcher.Direct(), func(dt entity.DomainType, e entity.Entity) {
+		if cbs, ok := e.(*client.BinarySensorComponent); ok && cbs.HashID() == bs.HashID() {
+			states <- cbs
+		}
+	})
+	waitAvailable := func(available bool) {
+		t.Helper()
+		for {
+			select {
+			case cbs := <-states:
+				if cbs.Available() != available {
+					continue
+				}
+				is.Equal(cbs.State().Missing, !available)
+				return
+			case <-time.After(5 * time.Second):
+				t.Fatalf("binary sensor did not become available=%v", available)
+			}
+		}
+	}
+	is.NoErr(c.SubscribeStates())
+	waitAvailable(true)
+
+	bs.(entity.WithAvailability).SetAvailable(false)
+	waitAvailable(false)
+	bs.(entity.WithAvailability).SetAvailable(true)
+	waitAvailable(true)
+
+	cbs, ok := c.BinarySensorByKey(bs.HashID())
+	is.True(ok)
+	n.Close()
+	select {
+	case <-disconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client was not disconnected")
+	}
+	is.True(!cbs.Available())
+}
@@ -0,0 +1,102 @@
+package tests_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/matryer/is"
+	"github.com/oklog/ulid/v2"
+)
+
+func TestJournalReplay(t *testing.T) {
+	is := is.New(t)
+	cfg, err := config.LoadConfig(strings.NewReader(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+    journal_size: 100
+
+demo:
+`))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	n.Start()
+
+	sw, ok := n.SwitchByKey(demoSwitch1Key)
+	is.True(ok)
+	is.NoErr(sw.SetState(context.Background(), true))
+
+	// a late subscriber still sees the state change
+	events := make(chan *bus.StateChangeEvent, 10)
+	sub := n.HandleEvents(bus.AnyEventHandler(func(e *bus.Event) {
+		events <- e.EventData.(*bus.StateChangeEvent)
+	}).Filter(bus.OfType("state_change"), bus.ForDomain("switch")).ReplayFrom(ulid.ULID{}))
+	defer sub.Close()
+	select {
+	case e := <-events:
+		is.Equal(e.Key, uint32(demoSwitch1Key))
+		is.Equal(e.Domain, "switch")
+	case <-time.After(time.Second):
+		t.Fatal("state change was not replayed")
+	}
+}
+
+type testService struct{}
+
+func (*testService) ServiceType() string {
+	return "test.service"
+}
+
+func TestQueueConfig(t *testing.T) {
+	is := is.New(t)
+	cfg, err := config.LoadConfig(strings.NewReader(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+    queue_size: 5
+    queue_overflow: drop_newest
+`))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	sub := n.HandleEvents(bus.EventHandler(func(e *bus.StateChangeEvent) {}), bus.WithName("test"))
+	defer sub.Close()
+	found := false
+	for _, s := range n.Bus.Stats().Subscribers {
+		if s.Name == "test" {
+			found = true
+			is.Equal(s.Size, 5)
+			is.Equal(s.Overflow, bus.OverflowPolicyDropNewest)
+		}
+	}
+	is.True(found)
+
+	// service calls are never dropped
+	ssub := n.HandleServiceCalls(bus.ServiceHandler(func(e *testService) {}), bus.WithName("test_service"))
+	defer ssub.Close()
+	found = false
+	for _, s := range n.Bus.Stats().Subscribers {
+		if s.Name == "test_service" {
+			found = true
+			is.Equal(s.Size, 5)
+			is.Equal(s.Overflow, bus.OverflowPolicyBlock)
+		}
+	}
+	is.True(found)
+
+	_, err = config.LoadConfig(strings.NewReader(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+    queue_overflow: drop_all
+`))
+	is.True(err != nil) // unknown policy
+}
@@ -0,0 +1,69 @@
+package tests_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/matryer/is"
+)
+
+func TestESPHomeCompat(t *testing.T) {
+	is := is.New(t)
+	_, err := config.LoadConfigFile("exampleConfigs/esphome.yaml")
+	is.True(err != nil) // esphome: is not gosthome: without the compatibility mode
+
+	cfg, err := config.LoadConfigFile("exampleConfigs/esphome.yaml", config.WithESPHomeCompat(true))
+	is.NoErr(err)
+	is.Equal(cfg.Gosthome.Name, "living-room")
+	is.Equal(cfg.Gosthome.FriendlyName, "Living room")
+	is.Equal(cfg.Gosthome.Area, "Living room")
+	is.Equal(cfg.Gosthome.Project.Name, "gosthome.example")
+	ignored := []string{}
+	for _, k := range cfg.Ignored {
+		is.Equal(k.File, "exampleConfigs/esphome.yaml")
+		ignored = append(ignored, k.Path)
+	}
+	is.Equal(ignored, []string{
+		"$.esp32",
+		"$.wifi",
+		"$.logger",
+		"$.ota",
+		"$.api.reboot_timeout",
+		"$.switch[0].turn_on_action",
+		"$.button[0].on_press[1].homeassistant.event",
+	})
+
+	// the mac is derived from the name
+	again, err := config.LoadConfigFile("exampleConfigs/esphome.yaml", config.WithESPHomeCompat(true))
+	is.NoErr(err)
+	is.True(cfg.Gosthome.MAC.Equal(again.Gosthome.MAC))
+
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	n.Start()
+	is.Equal(len(n.Switches()), 1)
+	fan := n.Switches()[0].(entity.Switch)
+	is.NoErr(fan.SetState(context.Background(), true))
+	is.Equal(fan.State().State, true) // optimistic
+	energy := n.Sensors()[0].(entity.Sensor)
+	is.Equal(energy.StateClass(), entity.SensorStateClassTotalIncreasing)
+	is.Equal(energy.AccuracyDecimals(), int32(2))
+	is.Equal(energy.State().State, float32(1)) // the lambda runs on setup
+}
+
+func TestESPHomeCompatBothBlocks(t *testing.T) {
+	is := is.New(t)
+	_, err := config.LoadConfig(strings.NewReader(`
+gosthome:
+    name: a
+esphome:
+    name: b
+`), config.WithESPHomeCompat(true))
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "esphome is gosthome in gosthome, the config has both"))
+}
@@ -0,0 +1,67 @@
+package tests_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/components/api/client"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/tests"
+	"github.com/majfault/signal/dispatcher"
+	"github.com/matryer/is"
+)
+
+func TestEventTrigger(t *testing.T) {
+	is := is.New(t)
+	port := tests.GetFreePort(t)
+	cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+api:
+    address: "127.0.0.1"
+    port: %d
+
+demo:
+`, port)))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	n.Start()
+
+	c := client.New(context.Background(), "127.0.0.1", uint16(port))
+	is.NoErr(c.Connect())
+	defer c.Close()
+	is.NoErr(c.ListEntities(5 * time.Second))
+
+	type fired struct {
+		id, eventType string
+	}
+	events := make(chan fired, 1)
+	c.EventTriggers().Connect(dispatcher.Direct(), func(e *client.EventComponent, eventType string) {
+		events <- fired{e.ID(), eventType}
+	})
+	is.NoErr(c.SubscribeStates())
+
+	var ring *client.ButtonComponent
+	for _, b := range c.Buttons() {
+		if b.ID() == "demo_ring_doorbell" {
+			ring = b
+		}
+	}
+	is.True(ring != nil)
+	is.NoErr(ring.Press(context.Background()))
+
+	select {
+	case f := <-events:
+		is.Equal(f, fired{"demo_doorbell", "ring"})
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+}
@@ -0,0 +1,72 @@
+package tests_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/components/api"
+	"github.com/gosthome/gosthome/components/api/client"
+	"github.com/gosthome/gosthome/components/api/frameshakers"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/tests"
+	"github.com/matryer/is"
+)
+
+func gatewayNode(t *testing.T, ctx context.Context, name string, key *frameshakers.ConfigNoisePSK, extra string) *core.Node {
+	t.Helper()
+	is := is.New(t)
+	cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(`
+gosthome:
+    name: %s
+    mac: 22:a8:cb:28:fd:7f
+
+api:
+    encryption:
+        key: "%s"
+%s`, name, key, extra)))
+	is.NoErr(err)
+	n, err := core.NewNode(ctx, cfg)
+	is.NoErr(err)
+	t.Cleanup(func() { n.Close() })
+	return n
+}
+
+func TestGatewayUsesTheKeyOfTheNodes(t *testing.T) {
+	is := is.New(t)
+	port := tests.GetFreePort(t)
+	g := api.NewGateway(context.Background(), fmt.Sprintf("127.0.0.1:%d", port),
+		api.WithRoutes(map[string]string{"gosthome*": "second"}))
+	is.NoErr(g.Start())
+	defer g.Close()
+	ctx := api.GatewayContext(context.Background(), g)
+	key, err := frameshakers.GenerateEncryptionKey()
+	is.NoErr(err)
+	first := gatewayNode(t, ctx, "first", key, "")
+	is.Equal(first.Start().Status, component.StatusOk)
+	second := gatewayNode(t, ctx, "second", key, "demo:\n")
+	is.Equal(second.Start().Status, component.StatusOk)
+
+	// a node with another key can not be served with the same key
+	otherKey, err := frameshakers.GenerateEncryptionKey()
+	is.NoErr(err)
+	other := gatewayNode(t, ctx, "other", otherKey, "")
+	is.Equal(other.Start().Status, component.StatusFailed)
+
+	// the client is routed to the second node by its client info
+	c := client.New(context.Background(), "127.0.0.1", uint16(port), client.WithNoisePSK(key))
+	is.NoErr(c.Connect())
+	defer c.Close()
+	is.NoErr(c.ListEntities(5 * time.Second))
+	_, ok := c.ButtonByKey(258008683)
+	is.True(ok)
+
+	// the gateway does not serve plaintext clients of encrypted nodes
+	plain := client.New(context.Background(), "127.0.0.1", uint16(port))
+	defer plain.Close()
+	is.True(plain.Connect() != nil)
+}
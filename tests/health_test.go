@@ -0,0 +1,76 @@
+package tests_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/components/api/client"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/tests"
+	"github.com/majfault/signal/dispatcher"
+	"github.com/matryer/is"
+)
+
+func TestHealthFailedWebserver(t *testing.T) {
+	is := is.New(t)
+	port := tests.GetFreePort(t)
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	is.NoErr(err)
+	defer taken.Close()
+	cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+api:
+    address: "127.0.0.1"
+    port: %d
+
+webserver:
+    address: "127.0.0.1"
+    port: %d
+
+health:
+`, port, taken.Addr().(*net.TCPAddr).Port)))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	h := n.Start()
+	is.Equal(h.Status, component.StatusFailed)
+	status := map[string]component.Status{}
+	for _, ch := range h.Components {
+		status[ch.ID] = ch.Status
+	}
+	is.Equal(status["webserver"], component.StatusFailed)
+	is.Equal(status["api"], component.StatusOk)
+
+	c := client.New(context.Background(), "127.0.0.1", uint16(port))
+	is.NoErr(c.Connect())
+	defer c.Close()
+	is.NoErr(c.ListEntities(5 * time.Second))
+	states := make(chan string, 1)
+	c.States().Connect(dispatcher.Direct(), func(dt entity.DomainType, e entity.Entity) {
+		if ts, ok := e.(*client.TextSensorComponent); ok && ts.ID() == "health" {
+			select {
+			case states <- ts.State().State:
+			default:
+			}
+		}
+	})
+	is.NoErr(c.SubscribeStates())
+	select {
+	case s := <-states:
+		is.True(strings.HasPrefix(s, "failed ("))
+		is.True(strings.Contains(s, "webserver failed"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("health state was not delivered")
+	}
+}
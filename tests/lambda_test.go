@@ -0,0 +1,187 @@
+package tests_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/gosthome/gosthome/components/button"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/matryer/is"
+)
+
+const lambdaConfig = `
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+number:
+  - platform: template
+    id: celsius
+    name: Celsius
+    optimistic: true
+    min_value: -50
+    max_value: 50
+    step: 0.5
+    on_value:
+      - number.set:
+          id: doubled
+          value: !lambda return x * 2;
+
+  - platform: template
+    id: doubled
+    name: Doubled
+    optimistic: true
+    min_value: -100
+    max_value: 100
+    step: 1
+
+sensor:
+  - platform: template
+    id: fahrenheit
+    name: Fahrenheit
+    update_interval: 10ms
+    lambda: "return id(celsius).has_state() ? id(celsius).state : NAN;"
+    filters:
+      - multiply: 1.8
+      - lambda: return x + 32;
+
+binary_sensor:
+  - platform: template
+    id: warm
+    name: Warm
+    lambda: id(celsius).state > 20
+
+text_sensor:
+  - platform: template
+    id: summary
+    name: Summary
+    update_interval: 10ms
+    lambda: |-
+      str_sprintf("%.1f °F, %s", id(fahrenheit).state, id(warm).state ? "warm" : "cold")
+
+switch:
+  - platform: template
+    id: heating
+    name: Heating
+    optimistic: true
+
+button:
+  - platform: template
+    id: check
+    name: Check
+    on_press:
+      - if:
+          condition:
+            lambda: return !id(warm).state && id(fahrenheit).state < 60;
+          then:
+            - switch.turn_on: heating
+          else:
+            - switch.turn_off: heating
+`
+
+func TestLambdas(t *testing.T) {
+	is := is.New(t)
+	cfg, err := config.LoadConfig(strings.NewReader(lambdaConfig))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	n.Start()
+
+	celsius, ok := n.NumberByKey(cid.HashID("celsius"))
+	is.True(ok)
+	doubled, ok := n.NumberByKey(cid.HashID("doubled"))
+	is.True(ok)
+	fahrenheit, ok := n.SensorByKey(cid.HashID("fahrenheit"))
+	is.True(ok)
+	warm, ok := n.BinarySensorByKey(cid.HashID("warm"))
+	is.True(ok)
+	summary, ok := n.TextSensorByKey(cid.HashID("summary"))
+	is.True(ok)
+	heating, ok := n.SwitchByKey(cid.HashID("heating"))
+	is.True(ok)
+	eventually := func(what string, f func() bool) {
+		t.Helper()
+		for range 100 {
+			if f() {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal(what)
+	}
+	press := func() {
+		_, err := bus.Call[*button.ButtonPress, any](context.Background(), n.Bus, &button.ButtonPress{Key: cid.HashID("check")})
+		is.NoErr(err)
+	}
+
+	is.NoErr(celsius.SetValue(context.Background(), 10))
+	eventually("the filters did not convert the reading", func() bool { return fahrenheit.State().State == 50 })
+	eventually("the value lambda did not set the number", func() bool { return doubled.State().State == 20 })
+	is.Equal(warm.State().State, false)
+	eventually("the text lambda did not run", func() bool { return summary.State().State == "50.0 °F, cold" })
+	press()
+	eventually("the lambda condition did not turn the heating on", func() bool { return heating.State().State })
+
+	is.NoErr(celsius.SetValue(context.Background(), 25))
+	eventually("the binary sensor lambda did not follow the number", func() bool { return warm.State().State })
+	eventually("the text lambda did not follow", func() bool { return summary.State().State == "77.0 °F, warm" })
+	press()
+	eventually("the lambda condition did not turn the heating off", func() bool { return !heating.State().State })
+}
+
+func TestLambdasShowAndLoadAgain(t *testing.T) {
+	is := is.New(t)
+	cfg, err := config.LoadConfig(strings.NewReader(lambdaConfig))
+	is.NoErr(err)
+	data, err := yaml.MarshalContext(context.Background(), cfg)
+	is.NoErr(err)
+	is.True(strings.Contains(string(data), `value: !lambda "return x * 2;"`)) // the tag is kept
+	_, err = config.LoadConfig(strings.NewReader(string(data)))
+	is.NoErr(err)
+}
+
+func TestLambdaErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, lambda, msg string
+	}{
+		{"syntax", "return id(celsius).state >;", "col 27: unexpected end of lambda"},
+		{"unknown id", "return id(nope).state > 1;", `there is no id "nope"`},
+		{"unknown field", "return id(celsius).value > 1;", "id(celsius) has no .value, it has .has_state, .state"},
+		{"type", `return id(celsius).state + "a";`, "+ is not defined for number and string"},
+		{"result", "return id(celsius).state;", "the lambda returns a number, not a bool"},
+		{"no x", "return x > 1;", "unknown name x"},
+		{"statements", "if (x) return true; return false;", "a lambda is a single expression"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			_, err := config.LoadConfig(strings.NewReader(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+number:
+  - platform: template
+    id: celsius
+    name: Celsius
+    min_value: -50
+    max_value: 50
+    step: 1
+
+binary_sensor:
+  - platform: template
+    name: Warm
+    lambda: "` + strings.ReplaceAll(tc.lambda, `"`, `\"`) + `"
+`))
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tc.msg)) // the error tells what is wrong
+			is.True(strings.Contains(err.Error(), "lambda"))
+		})
+	}
+}
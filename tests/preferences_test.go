@@ -0,0 +1,65 @@
+package tests_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/matryer/is"
+)
+
+const (
+	demoSwitch1Key = 1372848023
+	demoSwitch2Key = 1372848020
+	demoNumber1Key = 1316747944
+)
+
+func TestRestoreState(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	start := func() *core.Node {
+		cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+    data_dir: %s
+
+demo:
+`, dir)))
+		is.NoErr(err)
+		n, err := core.NewNode(context.Background(), cfg)
+		is.NoErr(err)
+		n.Start()
+		return n
+	}
+	entities := func(n *core.Node) (entity.Switch, entity.Switch, entity.Number) {
+		sw1, ok := n.SwitchByKey(demoSwitch1Key)
+		is.True(ok)
+		sw2, ok := n.SwitchByKey(demoSwitch2Key)
+		is.True(ok)
+		num1, ok := n.NumberByKey(demoNumber1Key)
+		is.True(ok)
+		return sw1, sw2, num1
+	}
+
+	n := start()
+	sw1, sw2, num1 := entities(n)
+	is.Equal(sw1.State().State, false) // restore_default_off without a saved state
+	is.Equal(sw2.State().State, true)  // always_on
+	is.Equal(num1.State().State, float32(0))
+	is.NoErr(sw1.SetState(context.Background(), true))
+	is.NoErr(sw2.SetState(context.Background(), false))
+	is.NoErr(num1.SetValue(context.Background(), 42))
+	is.NoErr(n.Close())
+
+	n = start()
+	defer n.Close()
+	sw1, sw2, num1 = entities(n)
+	is.Equal(sw1.State().State, true)
+	is.Equal(sw2.State().State, true)
+	is.Equal(num1.State().State, float32(42))
+}
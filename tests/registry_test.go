@@ -0,0 +1,128 @@
+package tests_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/components/api/client"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/tests"
+	"github.com/majfault/signal/dispatcher"
+	"github.com/matryer/is"
+)
+
+func TestDynamicEntities(t *testing.T) {
+	is := is.New(t)
+	port := tests.GetFreePort(t)
+	cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+api:
+    address: "127.0.0.1"
+    port: %d
+
+demo:
+`, port)))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	n.Start()
+
+	c := client.New(context.Background(), "127.0.0.1", uint16(port))
+	disconnected := make(chan struct{}, 1)
+	c.OnDisconnect = func() {
+		select {
+		case disconnected <- struct{}{}:
+		default:
+		}
+	}
+	added := map[string]entity.DomainType{}
+	removed := map[string]entity.DomainType{}
+	c.EntitiesAdded().Connect(dispatcher.Direct(), func(dt entity.DomainType, e entity.Entity) {
+		added[e.ID()] = dt
+	})
+	c.EntitiesRemoved().Connect(dispatcher.Direct(), func(dt entity.DomainType, e entity.Entity) {
+		removed[e.ID()] = dt
+	})
+	is.NoErr(c.Connect())
+	defer c.Close()
+	is.NoErr(c.ListEntities(5 * time.Second))
+	is.Equal(len(added), len(n.Buttons())+len(n.BinarySensors())+len(n.Events())+len(n.Switches())+len(n.Numbers()))
+	is.Equal(len(removed), 0)
+
+	relist := func() {
+		select {
+		case <-disconnected:
+		case <-time.After(5 * time.Second):
+			t.Fatal("client was not disconnected")
+		}
+		clear(added)
+		clear(removed)
+		is.NoErr(c.Connect())
+		is.NoErr(c.ListEntities(5 * time.Second))
+	}
+
+	btn := n.Buttons()[0]
+	is.True(n.Unregister(btn))
+	relist()
+	is.Equal(added, map[string]entity.DomainType{})
+	is.Equal(removed, map[string]entity.DomainType{btn.ID(): entity.DomainTypeButton})
+	_, ok := c.ButtonByKey(btn.HashID())
+	is.True(!ok)
+
+	is.NoErr(n.RegisterButton(btn.(entity.Button)))
+	relist()
+	is.Equal(added, map[string]entity.DomainType{btn.ID(): entity.DomainTypeButton})
+	is.Equal(removed, map[string]entity.DomainType{})
+	_, ok = c.ButtonByKey(btn.HashID())
+	is.True(ok)
+}
+
+func TestRegistryChangedEvent(t *testing.T) {
+	is := is.New(t)
+	cfg, err := config.LoadConfig(strings.NewReader(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+demo:
+`))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	n.Start()
+
+	events := make(chan bus.RegistryChangedEvent, 2)
+	sub := n.HandleEvents(bus.EventHandler(func(e *bus.RegistryChangedEvent) {
+		events <- *e
+	}))
+	defer sub.Close()
+
+	btn := n.Buttons()[0]
+	is.True(n.Unregister(btn))
+	is.True(!n.Unregister(btn)) // already gone
+	is.NoErr(n.RegisterButton(btn.(entity.Button)))
+	for _, removed := range []bool{true, false} {
+		select {
+		case e := <-events:
+			is.Equal(e, bus.RegistryChangedEvent{
+				Domain:  "button",
+				Key:     btn.HashID(),
+				ID:      btn.ID(),
+				Removed: removed,
+			})
+		case <-time.After(5 * time.Second):
+			t.Fatal("no registry changed event")
+		}
+	}
+}
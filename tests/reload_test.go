@@ -0,0 +1,141 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/components/api"
+	"github.com/gosthome/gosthome/components/api/client"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/tests"
+	"github.com/matryer/is"
+)
+
+func isAPI(c component.Component) bool {
+	_, ok := c.(*api.Server)
+	return ok
+}
+
+func TestReload(t *testing.T) {
+	is := is.New(t)
+	port := tests.GetFreePort(t)
+	load := func(name string, extra string) *config.Config {
+		cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(`
+gosthome:
+    name: %s
+    mac: 22:a8:cb:28:fd:7f
+
+api:
+    address: "127.0.0.1"
+    port: %d
+
+demo:
+%s`, name, port, extra)))
+		is.NoErr(err)
+		return cfg
+	}
+	n, err := core.NewNode(context.Background(), load("testABC", ""))
+	is.NoErr(err)
+	defer n.Close()
+	n.Start()
+	api, ok := n.GetComponent(isAPI)
+	is.True(ok)
+
+	c := client.New(context.Background(), "127.0.0.1", uint16(port))
+	is.NoErr(c.Connect())
+	defer c.Close()
+	is.NoErr(c.ListEntities(5 * time.Second))
+
+	keys, err := n.Reload(load("testABC", ""))
+	is.NoErr(err)
+	is.Equal(len(keys), 0)
+
+	_, err = n.Reload(load("testXYZ", ""))
+	is.True(errors.Is(err, core.ErrRestartRequired))
+
+	keys, err = n.Reload(load("testABC", "\nhealth:\n"))
+	is.NoErr(err)
+	is.Equal(keys, []string{"health", "text_sensor"})
+	running, ok := n.GetComponent(isAPI)
+	is.True(ok)
+	is.Equal(running, api) // the api kept running
+	is.Equal(n.Health().Status, component.StatusOk)
+
+	keys, err = n.Reload(load("testABC", "    seeds: [3, 4]\nhealth:\n"))
+	is.NoErr(err)
+	is.Equal(keys, []string{"demo"})
+	is.Equal(n.Health().Status, component.StatusOk)
+	_, ok = n.ButtonByKey(258008683)
+	is.True(ok)
+
+	// the client was asked to disconnect to list the new entities
+	deadline := time.Now().Add(5 * time.Second)
+	for c.ListEntities(100*time.Millisecond) == nil && time.Now().Before(deadline) {
+	}
+	is.True(time.Now().Before(deadline))
+
+	c2 := client.New(context.Background(), "127.0.0.1", uint16(port))
+	is.NoErr(c2.Connect())
+	defer c2.Close()
+	is.NoErr(c2.ListEntities(5 * time.Second))
+	found := false
+	for _, ts := range c2.TextSensors() {
+		found = found || ts.ID() == "health"
+	}
+	is.True(found)
+}
+
+func TestReloadKeepsRunningOnError(t *testing.T) {
+	is := is.New(t)
+	port := tests.GetFreePort(t)
+	load := func(api, demo string) *config.Config {
+		cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+api:
+    address: "127.0.0.1"
+    port: %d
+%s
+demo:
+%s`, port, api, demo)))
+		is.NoErr(err)
+		return cfg
+	}
+	n, err := core.NewNode(context.Background(), load("", ""))
+	is.NoErr(err)
+	defer n.Close()
+	n.Start()
+	api, ok := n.GetComponent(isAPI)
+	is.True(ok)
+	button, ok := n.ButtonByKey(258008683)
+	is.True(ok)
+
+	// the api can not create its recording, the new demo entities are
+	// dropped and the old ones keep running
+	record := "    record: " + filepath.Join(t.TempDir(), "missing", "record.jsonl")
+	_, err = n.Reload(load(record, "    seeds: [3, 4]"))
+	is.True(err != nil)
+	running, ok := n.GetComponent(isAPI)
+	is.True(ok)
+	is.Equal(running, api)
+	b, ok := n.ButtonByKey(258008683)
+	is.True(ok)
+	is.Equal(b, button)
+	is.Equal(n.Health().Status, component.StatusOk)
+
+	c := client.New(context.Background(), "127.0.0.1", uint16(port))
+	is.NoErr(c.Connect())
+	defer c.Close()
+	is.NoErr(c.ListEntities(5 * time.Second))
+	_, ok = c.ButtonByKey(258008683)
+	is.True(ok)
+}
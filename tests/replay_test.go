@@ -0,0 +1,164 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gosthome/gosthome/components/api"
+	"github.com/gosthome/gosthome/components/api/client"
+	"github.com/gosthome/gosthome/components/api/common"
+	ehp "github.com/gosthome/gosthome/components/api/esphomeproto"
+	"github.com/gosthome/gosthome/components/api/frameshakers"
+	"github.com/gosthome/gosthome/components/api/recorder"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/core/entity"
+	"github.com/gosthome/gosthome/tests"
+	"github.com/majfault/signal/dispatcher"
+	"github.com/matryer/is"
+)
+
+var replayRecording = filepath.Join("testdata", "replay", "session.jsonl")
+
+func serverEntries(entries []recorder.Entry) (ret []recorder.Entry) {
+	for _, e := range entries {
+		if e.From == recorder.PeerServer {
+			ret = append(ret, e)
+		}
+	}
+	return
+}
+
+func TestReplayServer(t *testing.T) {
+	is := is.New(t)
+	configBytes := &bytes.Buffer{}
+	err := sampleConfig.Execute(configBytes, &struct {
+		Port     int
+		Password string
+		NoisePSK string
+		MAC      string
+	}{
+		Port: tests.GetFreePort(t),
+		MAC:  "22:a8:cb:28:fd:7f",
+	})
+	is.NoErr(err)
+	cfg, err := config.LoadConfig(configBytes)
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	n.Start()
+
+	c, ok := n.GetComponent(func(c component.Component) bool {
+		_, ok := c.(*api.Server)
+		return ok
+	})
+	is.True(ok)
+	rp, err := recorder.Open(replayRecording)
+	is.NoErr(err)
+
+	transcript, err := c.(*api.Server).Replay(rp)
+	is.NoErr(err)
+
+	got := serverEntries(transcript)
+	want := serverEntries(rp.Entries())
+	is.True(len(got) >= len(want))
+	for i, w := range want {
+		g := got[i]
+		is.Equal(g.Name, w.Name)
+		// demo states are random, only the responses of the handlers are stable
+		if strings.HasSuffix(w.Name, "StateResponse") || w.Name == "HelloResponse" {
+			continue
+		}
+		is.Equal(string(g.Body), string(w.Body))
+	}
+}
+
+func TestReplayClient(t *testing.T) {
+	is := is.New(t)
+	rp, err := recorder.Open(replayRecording)
+	is.NoErr(err)
+
+	// the state of the basement sensor in the recording
+	const wetKey = 2292024046
+	var recorded *ehp.BinarySensorStateResponse
+	for _, e := range rp.Entries() {
+		if e.Type != ehp.MessageTypeBinarySensorStateResponse {
+			continue
+		}
+		msg, err := e.Message()
+		is.NoErr(err)
+		if msg.(*ehp.BinarySensorStateResponse).Key == wetKey {
+			recorded = msg.(*ehp.BinarySensorStateResponse)
+		}
+	}
+	is.True(recorded != nil)
+
+	c := client.New(context.Background(), "127.0.0.1", 0)
+	// the recording lists the entities again after the states, the states
+	// are caught as they are replayed
+	var replayed *entity.BinarySensorState
+	c.States().Connect(dispatcher.Direct(), func(dt entity.DomainType, e entity.Entity) {
+		if cbs, ok := e.(*client.BinarySensorComponent); ok && cbs.HashID() == wetKey {
+			st := cbs.State()
+			replayed = &st
+		}
+	})
+	is.NoErr(c.Replay(rp))
+
+	is.Equal(len(c.BinarySensors()), 2)
+	is.Equal(len(c.Buttons()), 3)
+	is.Equal(len(c.Events()), 1)
+	is.Equal(len(c.Switches()), 2)
+	is.Equal(len(c.Numbers()), 2)
+	is.Equal(c.Events()[0].EventTypes(), []string{"ring"})
+	bs, ok := c.BinarySensorByKey(wetKey)
+	is.True(ok)
+	is.Equal(bs.Name(), "Demo Basement Floor Wet")
+	is.True(replayed != nil)
+	is.Equal(replayed.State, recorded.State)
+	is.Equal(replayed.Missing, recorded.MissingState)
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestRecordingErrorKeepsConnection(t *testing.T) {
+	is := is.New(t)
+	frames, err := common.EncodeFrames([]ehp.EsphomeMessageTyper{&ehp.PingRequest{}})
+	is.NoErr(err)
+	r := recorder.New(failingWriter{}, recorder.PeerClient)
+	sent := 0
+	send := r.Sender(func(f []frameshakers.Frame) error {
+		sent += len(f)
+		return nil
+	})
+	is.NoErr(send(frames))
+	is.NoErr(send(frames))
+	is.Equal(sent, 2) // the frames are sent without being recorded
+
+	// frames that are not messages are passed on too, and the recording
+	// goes on with the next ones
+	received := 0
+	out := &bytes.Buffer{}
+	receive := recorder.New(out, recorder.PeerClient).Receiver(func(f []frameshakers.Frame) error {
+		received += len(f)
+		return nil
+	})
+	is.NoErr(receive([]frameshakers.Frame{{Type: 9999}}))
+	is.NoErr(receive(frames))
+	is.Equal(received, 2)
+	rp, err := recorder.NewReplayer(out)
+	is.NoErr(err)
+	is.Equal(len(rp.Entries()), 1)
+	is.Equal(rp.Entries()[0].Type, ehp.MessageTypePingRequest)
+}
@@ -0,0 +1,288 @@
+package tests_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/gosthome/gosthome/components/api/client"
+	"github.com/gosthome/gosthome/components/button"
+	"github.com/gosthome/gosthome/components/globals"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/bus"
+	"github.com/gosthome/gosthome/core/component"
+	"github.com/gosthome/gosthome/core/component/cid"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/core/entity/info"
+	"github.com/gosthome/gosthome/tests"
+	"github.com/matryer/is"
+)
+
+const scriptConfig = `
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+    data_dir: %s
+
+api:
+    address: "127.0.0.1"
+    port: %d
+    services:
+      - service: add
+        variables:
+          amount: int
+          note: string
+        then:
+          - globals.set:
+              id: total
+              value: !lambda return id(total) + amount;
+          - globals.set:
+              id: note
+              value: note
+          - script.execute: count
+
+globals:
+  - id: total
+    type: int
+    restore_value: true
+    initial_value: 10
+  - id: note
+    type: std::string
+    initial_value: '"none"'
+  - id: counted
+    type: int
+  - id: ticks
+    type: uint32_t
+  - id: was_running
+    type: bool
+  - id: stopped
+    type: bool
+
+interval:
+  - interval: 10ms
+    then:
+      - globals.set:
+          id: ticks
+          value: id(ticks) + 1
+
+script:
+  - id: count
+    mode: queued
+    then:
+      - delay: 20ms
+      - globals.set:
+          id: counted
+          value: id(counted) + 1
+  - id: long
+    then:
+      - delay: 1h
+
+button:
+  - platform: template
+    id: check
+    name: Check
+    on_press:
+      - script.execute: long
+      - globals.set:
+          id: was_running
+          value: id(long).is_running
+      - script.stop: long
+      - script.wait: long
+      - if:
+          condition:
+            not:
+              script.is_running: long
+          then:
+            - globals.set:
+                id: stopped
+                value: true
+`
+
+func global[T bool | float64 | string](t *testing.T, n *core.Node, id string) *globals.Global[T] {
+	t.Helper()
+	c, ok := n.GetComponent(func(c component.Component) bool { return c.ID() == id })
+	if !ok {
+		t.Fatalf("there is no global %s", id)
+	}
+	return c.(*globals.Global[T])
+}
+
+func eventually(t *testing.T, what string, f func() bool) {
+	t.Helper()
+	for range 300 {
+		if f() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal(what)
+}
+
+func TestScripts(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	port := tests.GetFreePort(t)
+	start := func() *core.Node {
+		cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(scriptConfig, dir, port)))
+		is.NoErr(err)
+		n, err := core.NewNode(context.Background(), cfg)
+		is.NoErr(err)
+		n.Start()
+		return n
+	}
+	n := start()
+	total := global[float64](t, n, "total")
+	is.Equal(total.Value(), 10.0)
+	is.Equal(global[string](t, n, "note").Value(), "none")
+	ticks := global[float64](t, n, "ticks")
+	eventually(t, "the interval did not run", func() bool { return ticks.Value() >= 3 })
+
+	c := client.New(context.Background(), "127.0.0.1", uint16(port))
+	is.NoErr(c.Connect())
+	is.NoErr(c.ListEntities(5 * time.Second))
+	var add *client.ServiceComponent
+	for _, s := range c.Services() {
+		if s.Name() == "add" {
+			add = s
+		}
+	}
+	is.True(add != nil)
+	is.Equal(add.Args(), []info.ServicesArgument{{Name: "amount", Type: "int"}, {Name: "note", Type: "string"}})
+	for i := range 3 {
+		is.NoErr(add.Execute(context.Background(), map[string]any{"amount": 5, "note": "hello"}))
+		want := float64(15 + 5*i)
+		eventually(t, "the service did not set the global", func() bool { return total.Value() == want })
+	}
+	is.Equal(global[string](t, n, "note").Value(), "hello")
+	counted := global[float64](t, n, "counted")
+	eventually(t, "the queued script did not run for every call", func() bool { return counted.Value() == 3 })
+	is.NoErr(c.Close())
+
+	_, err := bus.Call[*button.ButtonPress, any](context.Background(), n.Bus, &button.ButtonPress{Key: cid.HashID("check")})
+	is.NoErr(err)
+	stopped := global[bool](t, n, "stopped")
+	eventually(t, "script.wait did not return after script.stop", stopped.Value)
+	is.True(global[bool](t, n, "was_running").Value())
+	is.NoErr(n.Close())
+
+	n = start()
+	defer n.Close()
+	is.Equal(global[float64](t, n, "total").Value(), 25.0) // restore_value
+	is.Equal(global[string](t, n, "note").Value(), "none")
+}
+
+func TestScriptConfigErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, config, msg string
+	}{
+		{"global type", "globals:\n  - id: g\n    type: long\n", "should be one of"},
+		{"initial value", "globals:\n  - id: g\n    type: int\n    initial_value: '\"a\"'\n", "the value is a string, not a number"},
+		{"integer range", "globals:\n  - id: g\n    type: uint8_t\n    initial_value: '300'\n", "300 is out of the range 0 to 255"},
+		{"negative unsigned", "globals:\n  - id: g\n    type: uint32_t\n    initial_value: '-1'\n", "-1 is out of the range 0 to 4294967295"},
+		{"global id", "globals:\n  - id: g\n    type: int\nbinary_sensor:\n  - platform: template\n    name: B\n    lambda: id(g)\n", "the lambda returns a number, not a bool"},
+		{"max_runs", "script:\n  - id: s\n    max_runs: 2\n    then:\n      - delay: 1s\n", "max_runs is only for the queued and parallel modes"},
+		{"script id", "script:\n  - id: s\n    then:\n      - script.execute: nope\n", "nope"},
+		{"service variable", "api:\n  services:\n    - service: s\n      variables:\n        x: double\n      then:\n        - delay: 1s\n", "should be one of"},
+		{"service lambda", "api:\n  services:\n    - service: s\n      variables:\n        x: string\n      then:\n        - if:\n            condition:\n              lambda: x > 1\n            then:\n              - delay: 1s\n", "is not defined for string and number"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			is := is.New(t)
+			_, err := config.LoadConfig(strings.NewReader(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+` + tc.config))
+			is.True(err != nil)
+			is.True(strings.Contains(err.Error(), tc.msg)) // the error tells what is wrong
+		})
+	}
+}
+
+func TestScriptsShowAndLoadAgain(t *testing.T) {
+	is := is.New(t)
+	cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(scriptConfig, t.TempDir(), 6053)))
+	is.NoErr(err)
+	data, err := yaml.MarshalContext(context.Background(), cfg)
+	is.NoErr(err)
+	_, err = config.LoadConfig(strings.NewReader(string(data)))
+	is.NoErr(err)
+}
+
+func TestIntervalRunsDoNotOverlap(t *testing.T) {
+	is := is.New(t)
+	cfg, err := config.LoadConfig(strings.NewReader(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+globals:
+  - id: active
+    type: int
+  - id: runs
+    type: int
+  - id: overlapped
+    type: bool
+
+interval:
+  - interval: 10ms
+    then:
+      - if:
+          condition:
+            lambda: return id(active) > 0;
+          then:
+            - globals.set:
+                id: overlapped
+                value: true
+      - globals.set:
+          id: active
+          value: id(active) + 1
+      - delay: 30ms
+      - globals.set:
+          id: active
+          value: id(active) - 1
+      - globals.set:
+          id: runs
+          value: id(runs) + 1
+`))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	n.Start()
+	runs := global[float64](t, n, "runs")
+	eventually(t, "the interval did not run", func() bool { return runs.Value() >= 3 })
+	is.True(!global[bool](t, n, "overlapped").Value()) // a run is skipped while the last one runs
+	is.NoErr(n.Close())
+}
+
+func TestGlobalIntegerRange(t *testing.T) {
+	is := is.New(t)
+	cfg, err := config.LoadConfig(strings.NewReader(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+
+globals:
+  - id: small
+    type: int8_t
+    initial_value: '-128.7'
+  - id: big
+    type: int64_t
+`))
+	is.NoErr(err)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	defer n.Close()
+	small := global[float64](t, n, "small")
+	is.Equal(small.Value(), -128.0) // truncated to the range
+	is.True(small.Set(128.0) != nil)
+	is.Equal(small.Value(), -128.0) // out of range values are not set
+	is.NoErr(small.Set(127.9))
+	is.Equal(small.Value(), 127.0)
+	big := global[float64](t, n, "big")
+	is.NoErr(big.Set(float64(1 << 53)))
+	is.True(big.Set(float64(1<<53)+2) != nil) // not exact as a float64
+}
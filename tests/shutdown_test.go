@@ -0,0 +1,49 @@
+package tests_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosthome/gosthome/components/api/client"
+	"github.com/gosthome/gosthome/core"
+	"github.com/gosthome/gosthome/core/config"
+	"github.com/gosthome/gosthome/tests"
+	"github.com/matryer/is"
+)
+
+func TestShutdownDisconnectsClients(t *testing.T) {
+	is := is.New(t)
+	port := tests.GetFreePort(t)
+	cfg, err := config.LoadConfig(strings.NewReader(fmt.Sprintf(`
+gosthome:
+    name: testABC
+    mac: 22:a8:cb:28:fd:7f
+    shutdown_timeout: 10s
+
+api:
+    address: "127.0.0.1"
+    port: %d
+
+demo:
+`, port)))
+	is.NoErr(err)
+	is.Equal(cfg.Gosthome.ShutdownTimeout, 10*time.Second)
+	n, err := core.NewNode(context.Background(), cfg)
+	is.NoErr(err)
+	n.Start()
+
+	c := client.New(context.Background(), "127.0.0.1", uint16(port))
+	is.NoErr(c.Connect())
+	defer c.Close()
+	is.NoErr(c.ListEntities(5 * time.Second))
+	is.NoErr(c.SubscribeStates())
+
+	// the client acknowledges the DisconnectRequest, so the api does not
+	// have to wait for the deadline to drop it
+	start := time.Now()
+	is.NoErr(n.Close())
+	is.True(time.Since(start) < 5*time.Second)
+}